@@ -0,0 +1,1076 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Baehry/chirpy/internal/activitypub"
+	"github.com/Baehry/chirpy/internal/database"
+	"github.com/Baehry/chirpy/internal/netguard"
+	"github.com/Baehry/chirpy/internal/pagination"
+	"github.com/Baehry/chirpy/internal/webhooks"
+	"github.com/google/uuid"
+)
+
+const (
+	maxInboxSize = 1 << 20
+	// signatureSkew is how far a signed request's Date may be from our
+	// clock, either way.
+	signatureSkew = time.Hour
+
+	maxActivityAttempts = 8
+	activityBatchSize = 20
+	activityTimeout = 10 * time.Second
+	activityDeliveryInterval = 5 * time.Second
+	activityPurgeInterval = time.Hour
+)
+
+// federationHTTPClient fetches and delivers to URLs that other servers, or
+// anyone posting to an inbox, choose: actor documents, signing keys and
+// inboxes. It won't connect to internal addresses.
+var federationHTTPClient = &http.Client{
+	Transport: netguard.Transport(),
+	Timeout: activityTimeout,
+	CheckRedirect: func(request *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// activityError is something wrong with an incoming activity rather than
+// with us, so the sender gets a 400 instead of retrying.
+type activityError string
+
+func (err activityError) Error() string {
+	return string(err)
+}
+
+// federationBase is the origin of our actor and object IDs. Other servers
+// keep those IDs for good, so they come from PUBLIC_URL rather than
+// whatever Host a request came in on, and federation is off without it.
+func (cfg *apiConfig) federationBase() (string, bool) {
+	if cfg.publicURL == "" {
+		return "", false
+	}
+	return strings.TrimSuffix(cfg.publicURL, "/"), true
+}
+
+func (cfg *apiConfig) federated(writer http.ResponseWriter) (string, bool) {
+	base, ok := cfg.federationBase()
+	if !ok {
+		respondWithError(writer, 404, "federation is disabled")
+	}
+	return base, ok
+}
+
+func actorURI(base string, userID uuid.UUID) string {
+	return base + "/ap/users/" + userID.String()
+}
+
+func noteURI(base string, chirpID uuid.UUID) string {
+	return base + "/ap/chirps/" + chirpID.String()
+}
+
+func followURI(base string, followID uuid.UUID) string {
+	return base + "/ap/follows/" + followID.String()
+}
+
+// localID pulls the ID out of one of our own URIs, base + prefix + ID.
+func localID(base string, prefix string, uri string) (uuid.UUID, bool) {
+	rest, ok := strings.CutPrefix(uri, base+prefix)
+	if !ok {
+		return uuid.Nil, false
+	}
+	id, err := uuid.Parse(rest)
+	return id, err == nil
+}
+
+func sameHost(a string, b string) bool {
+	parsedA, errA := url.Parse(a)
+	parsedB, errB := url.Parse(b)
+	return errA == nil && errB == nil && parsedA.Host != "" && strings.EqualFold(parsedA.Host, parsedB.Host)
+}
+
+func respondWithActivity(writer http.ResponseWriter, code int, payload any) {
+	dat, err := json.Marshal(payload)
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	writer.Header().Set("Content-Type", activitypub.ContentType)
+	writer.WriteHeader(code)
+	writer.Write(dat)
+}
+
+// actorKey returns the key userID signs activities with, making it the
+// first time it's needed.
+func (cfg *apiConfig) actorKey(ctx context.Context, userID uuid.UUID) (database.ActorKey, error) {
+	key, err := cfg.dbQueries.GetActorKey(ctx, userID)
+	if !errors.Is(err, sql.ErrNoRows) {
+		return key, err
+	}
+	privatePEM, publicPEM, err := activitypub.GenerateKey()
+	if err != nil {
+		return database.ActorKey{}, err
+	}
+	// If another request got there first, its key wins.
+	if err := cfg.dbQueries.CreateActorKey(ctx, database.CreateActorKeyParams{
+		UserID: userID,
+		PublicKeyPem: publicPEM,
+		PrivateKeyPem: privatePEM,
+	}); err != nil {
+		return database.ActorKey{}, err
+	}
+	return cfg.dbQueries.GetActorKey(ctx, userID)
+}
+
+func newActor(base string, user database.User, key database.ActorKey) activitypub.Actor {
+	id := actorURI(base, user.ID)
+	return activitypub.Actor{
+		Context: activitypub.Context,
+		ID: id,
+		Type: "Person",
+		PreferredUsername: user.Handle,
+		Name: user.DisplayName,
+		Summary: activitypub.HTML(user.Bio),
+		URL: base + "/api/users/" + url.PathEscape(user.Handle),
+		Inbox: id + "/inbox",
+		Outbox: id + "/outbox",
+		Followers: id + "/followers",
+		Following: id + "/following",
+		Endpoints: &activitypub.Endpoints{SharedInbox: base + "/ap/inbox"},
+		PublicKey: activitypub.PublicKey{
+			ID: id + "#main-key",
+			Owner: id,
+			PublicKeyPem: key.PublicKeyPem,
+		},
+	}
+}
+
+func newNote(base string, chirp database.Chirp) activitypub.Note {
+	actor := actorURI(base, chirp.UserID)
+	return activitypub.Note{
+		ID: noteURI(base, chirp.ID),
+		Type: "Note",
+		AttributedTo: actor,
+		Content: activitypub.HTML(chirp.Body),
+		URL: base + "/api/chirps/" + chirp.ID.String(),
+		Published: chirp.CreatedAt,
+		To: []string{activitypub.Public},
+		Cc: []string{actor + "/followers"},
+	}
+}
+
+func newCreateActivity(base string, chirp database.Chirp) (activitypub.Activity, error) {
+	note := newNote(base, chirp)
+	activity, err := activitypub.NewActivity(note.ID+"/activity", "Create", note.AttributedTo, note)
+	if err != nil {
+		return activitypub.Activity{}, err
+	}
+	activity.Published = &note.Published
+	activity.To = note.To
+	activity.Cc = note.Cc
+	return activity, nil
+}
+
+// enqueueActivity queues activity, sent as userID, for inboxes, or for the
+// servers of all of userID's remote followers when inboxes is nil. Run
+// inside a transaction, nothing is sent unless it commits.
+func enqueueActivity(ctx context.Context, q *database.Queries, userID uuid.UUID, activity activitypub.Activity, inboxes []string) error {
+	if inboxes == nil {
+		var err error
+		inboxes, err = q.GetFollowerInboxes(ctx, userID)
+		if err != nil {
+			return err
+		}
+	}
+	if len(inboxes) == 0 {
+		return nil
+	}
+	payload, err := json.Marshal(activity)
+	if err != nil {
+		return err
+	}
+	return q.EnqueueActivity(ctx, database.EnqueueActivityParams{
+		UserID: userID,
+		Activity: string(payload),
+		Inboxes: inboxes,
+	})
+}
+
+// federateChirp sends a newly published chirp to its author's remote
+// followers.
+func (cfg *apiConfig) federateChirp(ctx context.Context, q *database.Queries, chirp database.Chirp) error {
+	base, ok := cfg.federationBase()
+	if !ok {
+		return nil
+	}
+	activity, err := newCreateActivity(base, chirp)
+	if err != nil {
+		return err
+	}
+	return enqueueActivity(ctx, q, chirp.UserID, activity, nil)
+}
+
+// federateChirpDeleted tells the author's remote followers a chirp is gone.
+func (cfg *apiConfig) federateChirpDeleted(ctx context.Context, q *database.Queries, chirp database.Chirp) error {
+	base, ok := cfg.federationBase()
+	if !ok {
+		return nil
+	}
+	id := noteURI(base, chirp.ID)
+	activity, err := activitypub.NewActivity(id+"#delete", "Delete", actorURI(base, chirp.UserID), activitypub.Tombstone{
+		ID: id,
+		Type: "Tombstone",
+	})
+	if err != nil {
+		return err
+	}
+	activity.To = []string{activitypub.Public}
+	return enqueueActivity(ctx, q, chirp.UserID, activity, nil)
+}
+
+// deliverActivities works through the outbound activity queue, the same
+// way deliverWebhooks does.
+func (cfg *apiConfig) deliverActivities(ctx context.Context) {
+	base, ok := cfg.federationBase()
+	if !ok {
+		return
+	}
+	ticker := time.NewTicker(activityDeliveryInterval)
+	defer ticker.Stop()
+	var lastPurge time.Time
+	for {
+		for {
+			delivered, err := cfg.deliverActivityBatch(ctx, base)
+			if err != nil {
+				fmt.Printf("delivering activities: %v\n", err)
+			}
+			if err != nil || delivered < activityBatchSize {
+				break
+			}
+		}
+		if time.Since(lastPurge) > activityPurgeInterval {
+			if _, err := cfg.dbQueries.DeleteFinishedActivityDeliveries(ctx); err != nil {
+				fmt.Printf("purging activity deliveries: %v\n", err)
+			}
+			lastPurge = time.Now()
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (cfg *apiConfig) deliverActivityBatch(ctx context.Context, base string) (int, error) {
+	deliveries, err := cfg.dbQueries.ClaimActivityDeliveries(ctx, activityBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	keys := map[uuid.UUID]database.ActorKey{}
+	for _, delivery := range deliveries {
+		if _, ok := keys[delivery.UserID]; ok {
+			continue
+		}
+		key, err := cfg.actorKey(ctx, delivery.UserID)
+		if err != nil {
+			return 0, err
+		}
+		keys[delivery.UserID] = key
+	}
+	var wg sync.WaitGroup
+	for _, delivery := range deliveries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := cfg.attemptActivity(ctx, base, keys[delivery.UserID], delivery); err != nil {
+				fmt.Printf("recording activity delivery %s: %v\n", delivery.ID, err)
+			}
+		}()
+	}
+	wg.Wait()
+	return len(deliveries), nil
+}
+
+// attemptActivity makes one delivery attempt. Failures are retried with
+// the webhook backoff, except for client errors other than 429: the inbox
+// is gone or will never take the activity, so the delivery is dead.
+func (cfg *apiConfig) attemptActivity(ctx context.Context, base string, key database.ActorKey, delivery database.ApDelivery) error {
+	privateKey, err := activitypub.ParsePrivateKey(key.PrivateKeyPem)
+	if err == nil {
+		err = cfg.federation.Deliver(ctx, delivery.Inbox, actorURI(base, delivery.UserID)+"#main-key", privateKey, []byte(delivery.Activity))
+	}
+	status := webhookSucceeded
+	message := ""
+	var retryAfter sql.NullInt32
+	if err != nil {
+		message = err.Error()
+		status = webhookDead
+		var statusErr *activitypub.StatusError
+		permanent := errors.As(err, &statusErr) && statusErr.StatusCode >= 400 && statusErr.StatusCode < 500 && statusErr.StatusCode != 429
+		attempts := int(delivery.Attempts) + 1
+		if !permanent && attempts < maxActivityAttempts {
+			status = webhookFailed
+			retryAfter = sql.NullInt32{Int32: int32(webhooks.Backoff(attempts).Seconds()), Valid: true}
+		}
+	}
+	return cfg.dbQueries.FinishActivityDelivery(ctx, database.FinishActivityDeliveryParams{
+		Status: status,
+		RetryAfterSeconds: retryAfter,
+		Error: message,
+		ID: delivery.ID,
+	})
+}
+
+// refreshRemoteActor fetches the actor at uri and stores it.
+func (cfg *apiConfig) refreshRemoteActor(ctx context.Context, uri string) (database.RemoteActor, error) {
+	actor, err := cfg.federation.FetchActor(ctx, uri)
+	if err != nil {
+		return database.RemoteActor{}, err
+	}
+	if !sameHost(actor.PublicKey.ID, actor.ID) {
+		return database.RemoteActor{}, fmt.Errorf("actor %s has a key on another server", actor.ID)
+	}
+	parsed, err := url.Parse(actor.ID)
+	if err != nil {
+		return database.RemoteActor{}, err
+	}
+	sharedInbox := ""
+	if actor.Endpoints != nil {
+		sharedInbox = actor.Endpoints.SharedInbox
+	}
+	return cfg.dbQueries.UpsertRemoteActor(ctx, database.UpsertRemoteActorParams{
+		Uri: actor.ID,
+		Username: actor.PreferredUsername,
+		Domain: strings.ToLower(parsed.Host),
+		DisplayName: actor.Name,
+		Summary: activitypub.PlainText(actor.Summary),
+		Url: actor.URL,
+		Inbox: actor.Inbox,
+		SharedInbox: sharedInbox,
+		PublicKeyID: actor.PublicKey.ID,
+		PublicKeyPem: actor.PublicKey.PublicKeyPem,
+	})
+}
+
+// verifySignature checks the HTTP signature on an inbox request and
+// returns the actor that made it. An unknown key, or one that no longer
+// verifies because the actor rotated it, sends us to fetch the actor again.
+func (cfg *apiConfig) verifySignature(ctx context.Context, request *http.Request, body []byte) (database.RemoteActor, error) {
+	signature, err := activitypub.ParseSignature(request.Header.Get("Signature"))
+	if err != nil {
+		return database.RemoteActor{}, err
+	}
+	verify := func(actor database.RemoteActor) error {
+		key, err := activitypub.ParsePublicKey(actor.PublicKeyPem)
+		if err != nil {
+			return err
+		}
+		return activitypub.VerifyRequest(request, body, signature, key, signatureSkew, time.Now())
+	}
+	actor, err := cfg.dbQueries.GetRemoteActorByKeyID(ctx, signature.KeyID)
+	if err == nil && verify(actor) == nil {
+		return actor, nil
+	}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return database.RemoteActor{}, err
+	}
+	actor, err = cfg.refreshRemoteActor(ctx, signature.Owner())
+	if err != nil {
+		return database.RemoteActor{}, err
+	}
+	if actor.PublicKeyID != signature.KeyID {
+		return database.RemoteActor{}, activitypub.ErrInvalidSignature
+	}
+	if err := verify(actor); err != nil {
+		return database.RemoteActor{}, err
+	}
+	return actor, nil
+}
+
+func remoteAccount(actor database.RemoteActor) string {
+	return actor.Username + "@" + actor.Domain
+}
+
+type remoteActorResponse struct {
+	ID uuid.UUID `json:"id"`
+	URI string `json:"uri"`
+	Account string `json:"account"`
+	DisplayName string `json:"display_name"`
+	Summary string `json:"summary"`
+	URL string `json:"url"`
+}
+
+func newRemoteActorResponse(actor database.RemoteActor) remoteActorResponse {
+	return remoteActorResponse{
+		ID: actor.ID,
+		URI: actor.Uri,
+		Account: remoteAccount(actor),
+		DisplayName: actor.DisplayName,
+		Summary: actor.Summary,
+		URL: actor.Url,
+	}
+}
+
+// WebFingerHandler resolves acct:handle@domain, or one of our actor URIs,
+// to the user's actor.
+func (cfg *apiConfig) WebFingerHandler(writer http.ResponseWriter, request *http.Request) {
+	base, ok := cfg.federated(writer)
+	if !ok {
+		return
+	}
+	parsed, err := url.Parse(base)
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	domain := strings.ToLower(parsed.Host)
+	resource := request.URL.Query().Get("resource")
+	var user database.User
+	if userID, ok := localID(base, "/ap/users/", resource); ok {
+		user, err = cfg.dbQueries.GetUser(request.Context(), userID)
+	} else {
+		handle, resourceDomain, parseErr := activitypub.ParseAccount(resource)
+		if parseErr != nil {
+			respondWithError(writer, 400, parseErr.Error())
+			return
+		}
+		if resourceDomain != domain {
+			respondWithError(writer, 404, "user not found")
+			return
+		}
+		user, err = cfg.dbQueries.GetUserByHandle(request.Context(), handle)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(writer, 404, "user not found")
+		return
+	}
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	profileURL := base + "/api/users/" + url.PathEscape(user.Handle)
+	writer.Header().Set("Access-Control-Allow-Origin", "*")
+	dat, err := json.Marshal(activitypub.WebFinger{
+		Subject: "acct:" + user.Handle + "@" + domain,
+		Aliases: []string{actorURI(base, user.ID), profileURL},
+		Links: []activitypub.Link{
+			{Rel: "self", Type: activitypub.ContentType, Href: actorURI(base, user.ID)},
+			{Rel: "http://webfinger.net/rel/profile-page", Type: "application/json", Href: profileURL},
+		},
+	})
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	writer.Header().Set("Content-Type", activitypub.JRDContentType)
+	writer.WriteHeader(200)
+	writer.Write(dat)
+}
+
+// apUser looks up the local user an /ap/users/{userID} route is for.
+func (cfg *apiConfig) apUser(writer http.ResponseWriter, request *http.Request) (database.User, bool) {
+	userID, err := uuid.Parse(request.PathValue("userID"))
+	if err != nil {
+		respondWithError(writer, 404, "user not found")
+		return database.User{}, false
+	}
+	user, err := cfg.dbQueries.GetUser(request.Context(), userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(writer, 404, "user not found")
+		return database.User{}, false
+	}
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return database.User{}, false
+	}
+	return user, true
+}
+
+// ActorHandler serves a user's actor document. Browsers and other clients
+// that don't ask for ActivityPub are sent to the profile instead.
+func (cfg *apiConfig) ActorHandler(writer http.ResponseWriter, request *http.Request) {
+	base, ok := cfg.federated(writer)
+	if !ok {
+		return
+	}
+	user, ok := cfg.apUser(writer, request)
+	if !ok {
+		return
+	}
+	if !activitypub.IsActivityContentType(request.Header.Get("Accept")) {
+		http.Redirect(writer, request, "/api/users/"+url.PathEscape(user.Handle), http.StatusSeeOther)
+		return
+	}
+	key, err := cfg.actorKey(request.Context(), user.ID)
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	respondWithActivity(writer, 200, newActor(base, user, key))
+}
+
+// OutboxHandler serves a user's Create activities, newest first. The bare
+// collection only has the count; ?page=true pages through it.
+func (cfg *apiConfig) OutboxHandler(writer http.ResponseWriter, request *http.Request) {
+	base, ok := cfg.federated(writer)
+	if !ok {
+		return
+	}
+	user, ok := cfg.apUser(writer, request)
+	if !ok {
+		return
+	}
+	outbox := actorURI(base, user.ID) + "/outbox"
+	query := request.URL.Query()
+	if query.Get("page") != "true" {
+		profile, err := cfg.dbQueries.GetUserProfile(request.Context(), user.ID)
+		if err != nil {
+			respondWithError(writer, 500, err.Error())
+			return
+		}
+		respondWithActivity(writer, 200, activitypub.OrderedCollection{
+			Context: activitypub.ActivityStreamsContext,
+			ID: outbox,
+			Type: "OrderedCollection",
+			TotalItems: profile.ChirpCount,
+			First: outbox + "?page=true",
+		})
+		return
+	}
+	page, err := parsePageParams(query)
+	if err != nil {
+		respondWithError(writer, 400, err.Error())
+		return
+	}
+	chirps, err := cfg.getChirpsByUser(request.Context(), user.ID, uuid.NullUUID{}, true, false, page)
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	collectionPage := activitypub.OrderedCollectionPage{
+		Context: activitypub.ActivityStreamsContext,
+		ID: base + request.URL.RequestURI(),
+		Type: "OrderedCollectionPage",
+		PartOf: outbox,
+		OrderedItems: []any{},
+	}
+	if len(chirps) > page.Limit {
+		chirps = chirps[:page.Limit]
+		last := chirps[len(chirps)-1]
+		collectionPage.Next = outbox + "?" + url.Values{
+			"page": {"true"},
+			"cursor": {pagination.EncodeCursor(last.CreatedAt, last.ID)},
+		}.Encode()
+	}
+	for _, chirp := range chirps {
+		activity, err := newCreateActivity(base, chirp)
+		if err != nil {
+			respondWithError(writer, 500, err.Error())
+			return
+		}
+		activity.Context = nil
+		collectionPage.OrderedItems = append(collectionPage.OrderedItems, activity)
+	}
+	respondWithActivity(writer, 200, collectionPage)
+}
+
+// FollowersHandler and FollowingHandler serve counts only; who follows
+// whom isn't published.
+func (cfg *apiConfig) FollowersHandler(writer http.ResponseWriter, request *http.Request) {
+	cfg.serveFollowCollection(writer, request, "followers")
+}
+
+func (cfg *apiConfig) FollowingHandler(writer http.ResponseWriter, request *http.Request) {
+	cfg.serveFollowCollection(writer, request, "following")
+}
+
+func (cfg *apiConfig) serveFollowCollection(writer http.ResponseWriter, request *http.Request, name string) {
+	base, ok := cfg.federated(writer)
+	if !ok {
+		return
+	}
+	user, ok := cfg.apUser(writer, request)
+	if !ok {
+		return
+	}
+	profile, err := cfg.dbQueries.GetUserProfile(request.Context(), user.ID)
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	total := profile.FollowingCount
+	var remote int64
+	if name == "followers" {
+		total = profile.FollowerCount
+		remote, err = cfg.dbQueries.CountRemoteFollowers(request.Context(), user.ID)
+	} else {
+		remote, err = cfg.dbQueries.CountRemoteFollows(request.Context(), user.ID)
+	}
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	respondWithActivity(writer, 200, activitypub.OrderedCollection{
+		Context: activitypub.ActivityStreamsContext,
+		ID: actorURI(base, user.ID) + "/" + name,
+		Type: "OrderedCollection",
+		TotalItems: total + remote,
+	})
+}
+
+// NoteHandler serves a published chirp as a Note.
+func (cfg *apiConfig) NoteHandler(writer http.ResponseWriter, request *http.Request) {
+	base, ok := cfg.federated(writer)
+	if !ok {
+		return
+	}
+	chirpID, err := uuid.Parse(request.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(writer, 404, "chirp not found")
+		return
+	}
+	chirp, err := cfg.dbQueries.GetChirp(request.Context(), chirpID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && chirp.Status != statusPublished) {
+		respondWithError(writer, 404, "chirp not found")
+		return
+	}
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	if !activitypub.IsActivityContentType(request.Header.Get("Accept")) {
+		http.Redirect(writer, request, "/api/chirps/"+chirp.ID.String(), http.StatusSeeOther)
+		return
+	}
+	note := newNote(base, chirp)
+	note.Context = activitypub.ActivityStreamsContext
+	respondWithActivity(writer, 200, note)
+}
+
+// InboxHandler takes activities from other servers, at the shared inbox
+// and each user's. Only signed activities from the actor that signed them
+// are accepted; types we don't handle are accepted and dropped.
+func (cfg *apiConfig) InboxHandler(writer http.ResponseWriter, request *http.Request) {
+	base, ok := cfg.federated(writer)
+	if !ok {
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(request.Body, maxInboxSize+1))
+	if err != nil {
+		respondWithError(writer, 400, err.Error())
+		return
+	}
+	if len(body) > maxInboxSize {
+		respondWithError(writer, 413, "activity is too large")
+		return
+	}
+	actor, err := cfg.verifySignature(request.Context(), request, body)
+	if err != nil {
+		respondWithError(writer, 401, err.Error())
+		return
+	}
+	var activity activitypub.Activity
+	if err := json.Unmarshal(body, &activity); err != nil {
+		respondWithError(writer, 400, err.Error())
+		return
+	}
+	if activity.Actor != actor.Uri {
+		respondWithError(writer, 401, "activity isn't from the actor that signed it")
+		return
+	}
+	if err := cfg.receiveActivity(request.Context(), base, actor, activity); err != nil {
+		var badActivity activityError
+		if errors.As(err, &badActivity) {
+			respondWithError(writer, 400, err.Error())
+			return
+		}
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	writer.WriteHeader(202)
+}
+
+func (cfg *apiConfig) receiveActivity(ctx context.Context, base string, actor database.RemoteActor, activity activitypub.Activity) error {
+	switch activity.Type {
+	case "Follow":
+		return cfg.receiveFollow(ctx, base, actor, activity)
+	case "Undo":
+		return cfg.receiveUndo(ctx, base, actor, activity)
+	case "Accept":
+		followID, ok := localID(base, "/ap/follows/", activity.ObjectID())
+		if !ok {
+			return nil
+		}
+		_, err := cfg.dbQueries.AcceptRemoteFollow(ctx, database.AcceptRemoteFollowParams{
+			ID: followID,
+			RemoteActorID: actor.ID,
+		})
+		return err
+	case "Reject":
+		followID, ok := localID(base, "/ap/follows/", activity.ObjectID())
+		if !ok {
+			return nil
+		}
+		_, err := cfg.dbQueries.RejectRemoteFollow(ctx, database.RejectRemoteFollowParams{
+			ID: followID,
+			RemoteActorID: actor.ID,
+		})
+		return err
+	case "Create", "Update":
+		switch activity.ObjectType() {
+		case "Note":
+			return cfg.receiveNote(ctx, actor, activity)
+		case "Person", "Service", "Application", "Group", "Organization":
+			if activity.Type == "Update" && activity.ObjectID() == actor.Uri {
+				_, err := cfg.refreshRemoteActor(ctx, actor.Uri)
+				return err
+			}
+		}
+		return nil
+	case "Delete":
+		if activity.ObjectID() == actor.Uri {
+			_, err := cfg.dbQueries.DeleteRemoteActor(ctx, actor.Uri)
+			return err
+		}
+		_, err := cfg.dbQueries.DeleteRemotePost(ctx, database.DeleteRemotePostParams{
+			Uri: activity.ObjectID(),
+			RemoteActorID: actor.ID,
+		})
+		return err
+	case "Like":
+		return cfg.receiveLike(ctx, base, actor, activity)
+	}
+	return nil
+}
+
+// receiveFollow records a remote follower and accepts the follow straight
+// away; there are no locked accounts.
+func (cfg *apiConfig) receiveFollow(ctx context.Context, base string, actor database.RemoteActor, activity activitypub.Activity) error {
+	userID, ok := localID(base, "/ap/users/", activity.ObjectID())
+	if !ok {
+		return activityError("only local users can be followed")
+	}
+	if _, err := cfg.dbQueries.GetUser(ctx, userID); errors.Is(err, sql.ErrNoRows) {
+		return activityError("user not found")
+	} else if err != nil {
+		return err
+	}
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+	if _, err := qtx.AddRemoteFollower(ctx, database.AddRemoteFollowerParams{
+		UserID: userID,
+		RemoteActorID: actor.ID,
+		FollowUri: activity.ID,
+	}); err != nil {
+		return err
+	}
+	if err := notify(ctx, qtx, notificationEvent{
+		UserID: userID,
+		Type: notificationFollow,
+		Detail: remoteAccount(actor),
+	}); err != nil {
+		return err
+	}
+	activity.Context = nil
+	accept, err := activitypub.NewActivity(actorURI(base, userID)+"#accepts/"+uuid.NewString(), "Accept", actorURI(base, userID), activity)
+	if err != nil {
+		return err
+	}
+	if err := enqueueActivity(ctx, qtx, userID, accept, []string{actor.Inbox}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (cfg *apiConfig) receiveUndo(ctx context.Context, base string, actor database.RemoteActor, activity activitypub.Activity) error {
+	switch activity.ObjectType() {
+	case "Follow":
+		var userID uuid.NullUUID
+		if inner, ok := activity.InnerActivity(); ok {
+			if id, ok := localID(base, "/ap/users/", inner.ObjectID()); ok {
+				userID = uuid.NullUUID{UUID: id, Valid: true}
+			}
+		}
+		_, err := cfg.dbQueries.RemoveRemoteFollower(ctx, database.RemoveRemoteFollowerParams{
+			RemoteActorID: actor.ID,
+			FollowUri: activity.ObjectID(),
+			UserID: userID,
+		})
+		return err
+	case "Like", "":
+		// A bare ID can only be matched against likes; follows are undone
+		// with the Follow embedded.
+		_, err := cfg.dbQueries.RemoveRemoteLike(ctx, database.RemoveRemoteLikeParams{
+			RemoteActorID: actor.ID,
+			LikeUri: activity.ObjectID(),
+		})
+		return err
+	}
+	return nil
+}
+
+// receiveNote stores a remote post, but only from actors someone here
+// follows; nothing else would show it.
+func (cfg *apiConfig) receiveNote(ctx context.Context, actor database.RemoteActor, activity activitypub.Activity) error {
+	var note activitypub.Note
+	if err := json.Unmarshal(activity.Object, &note); err != nil {
+		return activityError(err.Error())
+	}
+	if note.AttributedTo != actor.Uri || !sameHost(note.ID, actor.Uri) {
+		return activityError("note isn't by the actor that sent it")
+	}
+	followed, err := cfg.dbQueries.IsRemoteActorFollowed(ctx, actor.ID)
+	if err != nil || !followed {
+		return err
+	}
+	// A date in the future would pin the post to the top of timelines.
+	published := note.Published
+	if now := time.Now(); published.IsZero() || published.After(now) {
+		published = now
+	}
+	return cfg.dbQueries.UpsertRemotePost(ctx, database.UpsertRemotePostParams{
+		Uri: note.ID,
+		RemoteActorID: actor.ID,
+		Content: activitypub.PlainText(note.Content),
+		Url: note.URL,
+		PublishedAt: published.UTC(),
+	})
+}
+
+func (cfg *apiConfig) receiveLike(ctx context.Context, base string, actor database.RemoteActor, activity activitypub.Activity) error {
+	chirpID, ok := localID(base, "/ap/chirps/", activity.ObjectID())
+	if !ok {
+		return nil
+	}
+	chirp, err := cfg.dbQueries.GetChirp(ctx, chirpID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && chirp.Status != statusPublished) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+	added, err := qtx.AddRemoteLike(ctx, database.AddRemoteLikeParams{
+		ChirpID: chirp.ID,
+		RemoteActorID: actor.ID,
+		LikeUri: activity.ID,
+	})
+	if err != nil {
+		return err
+	}
+	if added > 0 {
+		if err := notify(ctx, qtx, notificationEvent{
+			UserID: chirp.UserID,
+			Type: notificationLike,
+			ChirpID: uuid.NullUUID{UUID: chirp.ID, Valid: true},
+			Detail: remoteAccount(actor),
+		}); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+type remoteFollowResponse struct {
+	Actor remoteActorResponse `json:"actor"`
+	Accepted bool `json:"accepted"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// RemoteFollowHandler follows an account on another server, given as
+// user@domain. The follow is pending until that server accepts it.
+func (cfg *apiConfig) RemoteFollowHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, err := cfg.authenticatedUserID(request)
+	if err != nil {
+		respondWithError(writer, 401, err.Error())
+		return
+	}
+	base, ok := cfg.federated(writer)
+	if !ok {
+		return
+	}
+	type parameters struct {
+		Account string `json:"account"`
+	}
+	decoder := json.NewDecoder(request.Body)
+	var params parameters
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(writer, 400, err.Error())
+		return
+	}
+	if _, _, err := activitypub.ParseAccount(params.Account); err != nil {
+		respondWithError(writer, 400, err.Error())
+		return
+	}
+	uri, err := cfg.federation.Lookup(request.Context(), params.Account)
+	if err != nil {
+		respondWithError(writer, 502, err.Error())
+		return
+	}
+	if strings.HasPrefix(uri, base+"/") {
+		respondWithError(writer, 400, "that account is on this server")
+		return
+	}
+	actor, err := cfg.refreshRemoteActor(request.Context(), uri)
+	if err != nil {
+		respondWithError(writer, 502, err.Error())
+		return
+	}
+	tx, err := cfg.db.BeginTx(request.Context(), nil)
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+	follow, err := qtx.CreateRemoteFollow(request.Context(), database.CreateRemoteFollowParams{
+		UserID: userID,
+		RemoteActorID: actor.ID,
+	})
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	if !follow.Accepted {
+		activity, err := activitypub.NewActivity(followURI(base, follow.ID), "Follow", actorURI(base, userID), actor.Uri)
+		if err != nil {
+			respondWithError(writer, 500, err.Error())
+			return
+		}
+		if err := enqueueActivity(request.Context(), qtx, userID, activity, []string{actor.Inbox}); err != nil {
+			respondWithError(writer, 500, err.Error())
+			return
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	respondWithJSON(writer, 202, remoteFollowResponse{
+		Actor: newRemoteActorResponse(actor),
+		Accepted: follow.Accepted,
+		CreatedAt: follow.CreatedAt,
+	})
+}
+
+func (cfg *apiConfig) RemoteFollowsHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, err := cfg.authenticatedUserID(request)
+	if err != nil {
+		respondWithError(writer, 401, err.Error())
+		return
+	}
+	follows, err := cfg.dbQueries.GetRemoteFollows(request.Context(), userID)
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	actorIDs := make([]uuid.UUID, len(follows))
+	for i, follow := range follows {
+		actorIDs[i] = follow.RemoteActorID
+	}
+	actors, err := cfg.dbQueries.GetRemoteActorsByIDs(request.Context(), actorIDs)
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	actorsByID := map[uuid.UUID]database.RemoteActor{}
+	for _, actor := range actors {
+		actorsByID[actor.ID] = actor
+	}
+	result := make([]remoteFollowResponse, len(follows))
+	for i, follow := range follows {
+		result[i] = remoteFollowResponse{
+			Actor: newRemoteActorResponse(actorsByID[follow.RemoteActorID]),
+			Accepted: follow.Accepted,
+			CreatedAt: follow.CreatedAt,
+		}
+	}
+	respondWithJSON(writer, 200, result)
+}
+
+// RemoteUnfollowHandler stops following a remote account and tells its
+// server with an Undo.
+func (cfg *apiConfig) RemoteUnfollowHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, err := cfg.authenticatedUserID(request)
+	if err != nil {
+		respondWithError(writer, 401, err.Error())
+		return
+	}
+	base, ok := cfg.federated(writer)
+	if !ok {
+		return
+	}
+	actorID, err := uuid.Parse(request.PathValue("remoteActorID"))
+	if err != nil {
+		respondWithError(writer, 404, "follow not found")
+		return
+	}
+	actor, err := cfg.dbQueries.GetRemoteActor(request.Context(), actorID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(writer, 404, "follow not found")
+		return
+	}
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	tx, err := cfg.db.BeginTx(request.Context(), nil)
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+	follow, err := qtx.DeleteRemoteFollow(request.Context(), database.DeleteRemoteFollowParams{
+		UserID: userID,
+		RemoteActorID: actor.ID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(writer, 404, "follow not found")
+		return
+	}
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	followActivity, err := activitypub.NewActivity(followURI(base, follow.ID), "Follow", actorURI(base, userID), actor.Uri)
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	followActivity.Context = nil
+	undo, err := activitypub.NewActivity(followURI(base, follow.ID)+"#undo", "Undo", actorURI(base, userID), followActivity)
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	if err := enqueueActivity(request.Context(), qtx, userID, undo, []string{actor.Inbox}); err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	writer.WriteHeader(204)
+}
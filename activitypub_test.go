@@ -0,0 +1,28 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Baehry/chirpy/internal/activitypub"
+	"github.com/Baehry/chirpy/internal/netguard"
+)
+
+// An inbox POST names its own keyId, so fetching it mustn't reach
+// anything internal.
+func TestFederationClientRefusesInternalAddresses(t *testing.T) {
+	fetched := false
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		fetched = true
+	}))
+	defer server.Close()
+	federation := &activitypub.Client{HTTP: federationHTTPClient}
+	_, err := federation.FetchActor(context.Background(), server.URL+"/actor#main-key")
+	if !errors.Is(err, netguard.ErrForbiddenAddress) || fetched {
+		t.Logf("expected the loopback actor to be refused, got %v (fetched=%v)\n", err, fetched)
+		t.Fail()
+	}
+}
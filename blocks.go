@@ -0,0 +1,340 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Baehry/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	mutedWordKeyword = "keyword"
+	mutedWordHashtag = "hashtag"
+	maxMutedWordLength = 100
+	mutedWordReapInterval = time.Hour
+)
+
+// viewer returns the user a read is for, so their blocks, mutes and muted
+// words can be applied. Anonymous requests get a NULL viewer.
+func (cfg *apiConfig) viewer(request *http.Request) uuid.NullUUID {
+	userID, err := cfg.authenticatedUserID(request)
+	if err != nil {
+		return uuid.NullUUID{}
+	}
+	return uuid.NullUUID{UUID: userID, Valid: true}
+}
+
+// isBlocked reports whether either user has blocked the other. Blocks stop
+// mentions (see saveEntities) and follows; chirps have no reply
+// relation yet, so there are no replies to stop. Whatever adds one has to
+// check this too.
+func (cfg *apiConfig) isBlocked(ctx context.Context, userID, otherID uuid.UUID) (bool, error) {
+	if userID == uuid.Nil || otherID == uuid.Nil || userID == otherID {
+		return false, nil
+	}
+	return cfg.dbQueries.IsBlocked(ctx, database.IsBlockedParams{
+		UserID: userID,
+		OtherID: otherID,
+	})
+}
+
+type userSummaryResponse struct {
+	ID uuid.UUID `json:"id"`
+	Handle string `json:"handle"`
+	DisplayName string `json:"display_name"`
+	AvatarURL string `json:"avatar_url"`
+}
+
+func newUserSummaryResponses(users []database.User) []userSummaryResponse {
+	result := make([]userSummaryResponse, len(users))
+	for i, user := range users {
+		result[i] = userSummaryResponse{
+			ID: user.ID,
+			Handle: user.Handle,
+			DisplayName: user.DisplayName,
+			AvatarURL: user.AvatarUrl,
+		}
+	}
+	return result
+}
+
+// targetUser resolves the {handle} of a block or mute request and makes sure
+// it isn't the caller.
+func (cfg *apiConfig) targetUser(writer http.ResponseWriter, request *http.Request, userID uuid.UUID) (database.User, bool) {
+	target, err := cfg.dbQueries.GetUserByHandle(request.Context(), request.PathValue("handle"))
+	if err != nil {
+		respondWithError(writer, 404, "user not found")
+		return target, false
+	}
+	if target.ID == userID {
+		respondWithError(writer, 400, "you can't do that to yourself")
+		return target, false
+	}
+	return target, true
+}
+
+// BlockHandler blocks a user. Blocking also ends any follow between the two.
+func (cfg *apiConfig) BlockHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, err := cfg.authenticatedUserID(request)
+	if err != nil {
+		respondWithError(writer, 401, err.Error())
+		return
+	}
+	target, ok := cfg.targetUser(writer, request, userID)
+	if !ok {
+		return
+	}
+	tx, err := cfg.db.BeginTx(request.Context(), nil)
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+	if err := qtx.BlockUser(request.Context(), database.BlockUserParams{
+		BlockerID: userID,
+		BlockedID: target.ID,
+	}); err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	if err := qtx.RemoveFollowsBetween(request.Context(), database.RemoveFollowsBetweenParams{
+		UserID: userID,
+		OtherID: target.ID,
+	}); err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	writer.WriteHeader(204)
+}
+
+func (cfg *apiConfig) UnblockHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, err := cfg.authenticatedUserID(request)
+	if err != nil {
+		respondWithError(writer, 401, err.Error())
+		return
+	}
+	target, ok := cfg.targetUser(writer, request, userID)
+	if !ok {
+		return
+	}
+	if _, err := cfg.dbQueries.UnblockUser(request.Context(), database.UnblockUserParams{
+		BlockerID: userID,
+		BlockedID: target.ID,
+	}); err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	writer.WriteHeader(204)
+}
+
+func (cfg *apiConfig) BlocksHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, err := cfg.authenticatedUserID(request)
+	if err != nil {
+		respondWithError(writer, 401, err.Error())
+		return
+	}
+	users, err := cfg.dbQueries.ListBlockedUsers(request.Context(), userID)
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	respondWithJSON(writer, 200, newUserSummaryResponses(users))
+}
+
+func (cfg *apiConfig) MuteHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, err := cfg.authenticatedUserID(request)
+	if err != nil {
+		respondWithError(writer, 401, err.Error())
+		return
+	}
+	target, ok := cfg.targetUser(writer, request, userID)
+	if !ok {
+		return
+	}
+	if err := cfg.dbQueries.MuteUser(request.Context(), database.MuteUserParams{
+		MuterID: userID,
+		MutedID: target.ID,
+	}); err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	writer.WriteHeader(204)
+}
+
+func (cfg *apiConfig) UnmuteHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, err := cfg.authenticatedUserID(request)
+	if err != nil {
+		respondWithError(writer, 401, err.Error())
+		return
+	}
+	target, ok := cfg.targetUser(writer, request, userID)
+	if !ok {
+		return
+	}
+	if _, err := cfg.dbQueries.UnmuteUser(request.Context(), database.UnmuteUserParams{
+		MuterID: userID,
+		MutedID: target.ID,
+	}); err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	writer.WriteHeader(204)
+}
+
+func (cfg *apiConfig) MutesHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, err := cfg.authenticatedUserID(request)
+	if err != nil {
+		respondWithError(writer, 401, err.Error())
+		return
+	}
+	users, err := cfg.dbQueries.ListMutedUsers(request.Context(), userID)
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	respondWithJSON(writer, 200, newUserSummaryResponses(users))
+}
+
+type mutedWordResponse struct {
+	ID uuid.UUID `json:"id"`
+	Kind string `json:"kind"`
+	Word string `json:"word"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+func newMutedWordResponse(word database.MutedWord) mutedWordResponse {
+	result := mutedWordResponse{
+		ID: word.ID,
+		Kind: word.Kind,
+		Word: word.Word,
+		CreatedAt: word.CreatedAt,
+	}
+	if word.ExpiresAt.Valid {
+		result.ExpiresAt = &word.ExpiresAt.Time
+	}
+	return result
+}
+
+func (cfg *apiConfig) MutedWordsHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, err := cfg.authenticatedUserID(request)
+	if err != nil {
+		respondWithError(writer, 401, err.Error())
+		return
+	}
+	words, err := cfg.dbQueries.ListMutedWords(request.Context(), userID)
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	result := make([]mutedWordResponse, len(words))
+	for i, word := range words {
+		result[i] = newMutedWordResponse(word)
+	}
+	respondWithJSON(writer, 200, result)
+}
+
+// CreateMutedWordHandler mutes a keyword, or a hashtag when the word starts
+// with #. Keywords are matched the way search matches them, so muting "run"
+// also hides "running".
+func (cfg *apiConfig) CreateMutedWordHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, err := cfg.authenticatedUserID(request)
+	if err != nil {
+		respondWithError(writer, 401, err.Error())
+		return
+	}
+	type parameters struct {
+		Word string `json:"word"`
+		ExpiresIn *int `json:"expires_in"`
+	}
+	decoder := json.NewDecoder(request.Body)
+	var params parameters
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(writer, 400, err.Error())
+		return
+	}
+	word := strings.ToLower(strings.TrimSpace(params.Word))
+	kind := mutedWordKeyword
+	if strings.HasPrefix(word, "#") {
+		kind = mutedWordHashtag
+		word = strings.TrimPrefix(word, "#")
+	}
+	if word == "" || utf8.RuneCountInString(word) > maxMutedWordLength {
+		respondWithError(writer, 400, fmt.Sprintf("muted words are 1 to %d characters", maxMutedWordLength))
+		return
+	}
+	var expiresAt sql.NullTime
+	if params.ExpiresIn != nil {
+		if *params.ExpiresIn <= 0 {
+			respondWithError(writer, 400, "expires_in must be positive")
+			return
+		}
+		expiresAt = sql.NullTime{Time: time.Now().UTC().Add(time.Duration(*params.ExpiresIn) * time.Second), Valid: true}
+	}
+	muted, err := cfg.dbQueries.CreateMutedWord(request.Context(), database.CreateMutedWordParams{
+		UserID: userID,
+		Kind: kind,
+		Word: word,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	respondWithJSON(writer, 201, newMutedWordResponse(muted))
+}
+
+func (cfg *apiConfig) DeleteMutedWordHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, err := cfg.authenticatedUserID(request)
+	if err != nil {
+		respondWithError(writer, 401, err.Error())
+		return
+	}
+	id, err := uuid.Parse(request.PathValue("wordID"))
+	if err != nil {
+		respondWithError(writer, 404, "muted word not found")
+		return
+	}
+	deleted, err := cfg.dbQueries.DeleteMutedWord(request.Context(), database.DeleteMutedWordParams{
+		ID: id,
+		UserID: userID,
+	})
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	if deleted == 0 {
+		respondWithError(writer, 404, "muted word not found")
+		return
+	}
+	writer.WriteHeader(204)
+}
+
+// reapExpiredMutedWords clears out muted words whose time is up. Reads
+// already ignore them, so this only reclaims space.
+func (cfg *apiConfig) reapExpiredMutedWords(ctx context.Context) {
+	ticker := time.NewTicker(mutedWordReapInterval)
+	defer ticker.Stop()
+	for {
+		if err := cfg.dbQueries.DeleteExpiredMutedWords(ctx); err != nil {
+			fmt.Printf("deleting expired muted words: %v\n", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
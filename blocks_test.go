@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Baehry/chirpy/client"
+)
+
+// TestBlockedViewers checks that every way of reaching a chirp hides it
+// from someone its author has blocked. It needs TEST_DB_URL, like
+// TestClient.
+func TestBlockedViewers(t *testing.T) {
+	dbURL := os.Getenv("TEST_DB_URL")
+	if dbURL == "" {
+		t.Skip("TEST_DB_URL not set")
+	}
+	server := newTestServer(t, dbURL)
+	ctx := context.Background()
+	admin, _ := client.New(server.URL)
+	if err := admin.Reset(ctx); err != nil {
+		t.Fatal(err)
+	}
+	users := map[string]*client.Client{}
+	for _, handle := range []string{"alice", "bob"} {
+		c, _ := client.New(server.URL)
+		email := handle + "@example.com"
+		if _, err := c.CreateUser(ctx, email, "hunter2", handle); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := c.Login(ctx, email, "hunter2"); err != nil {
+			t.Fatal(err)
+		}
+		users[handle] = c
+	}
+	alice, bob := users["alice"], users["bob"]
+	chirp, err := alice.CreateChirp(ctx, client.NewChirp{
+		Body: "#lunch: soup or salad?",
+		Poll: &client.NewPoll{Options: []string{"soup", "salad"}, ClosesAt: time.Now().Add(time.Hour)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := alice.Block(ctx, "bob"); err != nil {
+		t.Fatal(err)
+	}
+	// listed turns a page into an error if it has the chirp in it.
+	listed := func(page client.Page[client.Chirp], err error) error {
+		if err != nil {
+			return err
+		}
+		if slices.ContainsFunc(page.Items, func(c client.Chirp) bool { return c.ID == chirp.ID }) {
+			return fmt.Errorf("listed %s", chirp.ID)
+		}
+		return nil
+	}
+	cases := []struct {
+		name string
+		call func() error
+		err error
+	}{
+		{"get", func() error { _, err := bob.Chirp(ctx, chirp.ID); return err }, client.ErrNotFound},
+		{"vote", func() error { _, err := bob.Vote(ctx, chirp.ID, chirp.Poll.Options[0].ID); return err }, client.ErrNotFound},
+		{"bookmark", func() error { _, err := bob.BookmarkChirp(ctx, chirp.ID, ""); return err }, client.ErrNotFound},
+		{"by author", func() error { return listed(bob.Chirps(ctx, client.ChirpQuery{AuthorID: chirp.UserID})) }, nil},
+		{"all chirps", func() error { return listed(bob.Chirps(ctx, client.ChirpQuery{})) }, nil},
+		{"hashtag", func() error { return listed(bob.HashtagChirps(ctx, "lunch", client.PageParams{})) }, nil},
+	}
+	for _, c := range cases {
+		if err := c.call(); !errors.Is(err, c.err) {
+			t.Logf("%s: expected %v, got %v\n", c.name, c.err, err)
+			t.Fail()
+		}
+	}
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Baehry/chirpy/internal/database"
+	"github.com/Baehry/chirpy/internal/pagination"
+	"github.com/google/uuid"
+)
+
+const maxCollectionNameLength = 50
+
+// bookmarkResponse stands in for a bookmarked chirp. Chirps that have since
+// been deleted, have expired or are hidden by a block or mute are kept as
+// tombstones with no chirp, until the chirp row is purged and the bookmark
+// goes with it.
+type bookmarkResponse struct {
+	ChirpID uuid.UUID `json:"chirp_id"`
+	CollectionID *uuid.UUID `json:"collection_id"`
+	CreatedAt time.Time `json:"created_at"`
+	Chirp *chirpResponse `json:"chirp"`
+	Tombstone bool `json:"tombstone"`
+}
+
+func newBookmarkResponse(bookmark database.Bookmark) bookmarkResponse {
+	result := bookmarkResponse{
+		ChirpID: bookmark.ChirpID,
+		CreatedAt: bookmark.CreatedAt,
+	}
+	if bookmark.CollectionID.Valid {
+		result.CollectionID = &bookmark.CollectionID.UUID
+	}
+	return result
+}
+
+func (cfg *apiConfig) BookmarkHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, err := cfg.authenticatedUserID(request)
+	if err != nil {
+		respondWithError(writer, 401, err.Error())
+		return
+	}
+	id, err := uuid.Parse(request.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(writer, 404, "chirp not found")
+		return
+	}
+	type parameters struct {
+		Collection string `json:"collection"`
+	}
+	decoder := json.NewDecoder(request.Body)
+	var params parameters
+	if err := decoder.Decode(&params); err != nil && !errors.Is(err, io.EOF) {
+		respondWithError(writer, 400, err.Error())
+		return
+	}
+	chirp, err := cfg.dbQueries.GetChirp(request.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !canView(chirp, userID)) {
+		respondWithError(writer, 404, "chirp not found")
+		return
+	}
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	blocked, err := cfg.isBlocked(request.Context(), userID, chirp.UserID)
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	if blocked {
+		respondWithError(writer, 404, "chirp not found")
+		return
+	}
+	var collectionID uuid.NullUUID
+	if name := strings.TrimSpace(params.Collection); name != "" {
+		if utf8.RuneCountInString(name) > maxCollectionNameLength {
+			respondWithError(writer, 400, fmt.Sprintf("collection names can be at most %d characters", maxCollectionNameLength))
+			return
+		}
+		collection, err := cfg.dbQueries.UpsertBookmarkCollection(request.Context(), database.UpsertBookmarkCollectionParams{
+			UserID: userID,
+			Name: name,
+		})
+		if err != nil {
+			respondWithError(writer, 500, err.Error())
+			return
+		}
+		collectionID = uuid.NullUUID{UUID: collection.ID, Valid: true}
+	}
+	bookmark, err := cfg.dbQueries.CreateBookmark(request.Context(), database.CreateBookmarkParams{
+		UserID: userID,
+		ChirpID: chirp.ID,
+		CollectionID: collectionID,
+	})
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	result, err := cfg.chirpResponseFor(request.Context(), userID, chirp)
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	response := newBookmarkResponse(bookmark)
+	response.Chirp = &result
+	respondWithJSON(writer, 201, response)
+}
+
+func (cfg *apiConfig) UnbookmarkHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, err := cfg.authenticatedUserID(request)
+	if err != nil {
+		respondWithError(writer, 401, err.Error())
+		return
+	}
+	id, err := uuid.Parse(request.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(writer, 404, "bookmark not found")
+		return
+	}
+	deleted, err := cfg.dbQueries.DeleteBookmark(request.Context(), database.DeleteBookmarkParams{
+		UserID: userID,
+		ChirpID: id,
+	})
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	if deleted == 0 {
+		respondWithError(writer, 404, "bookmark not found")
+		return
+	}
+	writer.WriteHeader(204)
+}
+
+func (cfg *apiConfig) BookmarksHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, err := cfg.authenticatedUserID(request)
+	if err != nil {
+		respondWithError(writer, 401, err.Error())
+		return
+	}
+	query := request.URL.Query()
+	page, err := parsePageParams(query)
+	if err != nil {
+		respondWithError(writer, 400, err.Error())
+		return
+	}
+	var collectionID uuid.NullUUID
+	if name := query.Get("collection"); name != "" {
+		collection, err := cfg.dbQueries.GetBookmarkCollectionByName(request.Context(), database.GetBookmarkCollectionByNameParams{
+			UserID: userID,
+			Name: name,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(writer, 404, "collection not found")
+			return
+		}
+		if err != nil {
+			respondWithError(writer, 500, err.Error())
+			return
+		}
+		collectionID = uuid.NullUUID{UUID: collection.ID, Valid: true}
+	}
+	bookmarks, err := cfg.dbQueries.GetBookmarks(request.Context(), database.GetBookmarksParams{
+		UserID: userID,
+		CollectionID: collectionID,
+		CursorCreatedAt: page.CursorCreatedAt,
+		CursorID: page.CursorID,
+		Limit: page.fetchLimit(),
+	})
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	var nextCursor *string
+	if len(bookmarks) > page.Limit {
+		bookmarks = bookmarks[:page.Limit]
+		last := bookmarks[len(bookmarks)-1]
+		cursor := pagination.EncodeCursor(last.CreatedAt, last.ChirpID)
+		nextCursor = &cursor
+	}
+	ids := make([]uuid.UUID, len(bookmarks))
+	for i, bookmark := range bookmarks {
+		ids[i] = bookmark.ChirpID
+	}
+	chirps, err := cfg.dbQueries.GetChirpsByIDs(request.Context(), database.GetChirpsByIDsParams{
+		Ids: ids,
+		ViewerID: uuid.NullUUID{UUID: userID, Valid: true},
+	})
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	responses, err := cfg.chirpResponses(request.Context(), userID, chirps)
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	live := map[uuid.UUID]*chirpResponse{}
+	for i := range responses {
+		live[responses[i].ID] = &responses[i]
+	}
+	type response struct {
+		Bookmarks []bookmarkResponse `json:"bookmarks"`
+		NextCursor *string `json:"next_cursor"`
+	}
+	result := make([]bookmarkResponse, len(bookmarks))
+	for i, bookmark := range bookmarks {
+		result[i] = newBookmarkResponse(bookmark)
+		result[i].Chirp = live[bookmark.ChirpID]
+		result[i].Tombstone = result[i].Chirp == nil
+	}
+	setNextLink(writer, request, nextCursor)
+	respondWithJSON(writer, 200, response{
+		Bookmarks: result,
+		NextCursor: nextCursor,
+	})
+}
+
+func (cfg *apiConfig) BookmarkCollectionsHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, err := cfg.authenticatedUserID(request)
+	if err != nil {
+		respondWithError(writer, 401, err.Error())
+		return
+	}
+	collections, err := cfg.dbQueries.ListBookmarkCollections(request.Context(), userID)
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	type collectionResponse struct {
+		ID uuid.UUID `json:"id"`
+		Name string `json:"name"`
+		CreatedAt time.Time `json:"created_at"`
+		BookmarkCount int64 `json:"bookmark_count"`
+	}
+	result := make([]collectionResponse, len(collections))
+	for i, collection := range collections {
+		result[i] = collectionResponse{
+			ID: collection.ID,
+			Name: collection.Name,
+			CreatedAt: collection.CreatedAt,
+			BookmarkCount: collection.BookmarkCount,
+		}
+	}
+	respondWithJSON(writer, 200, result)
+}
+
+// DeleteBookmarkCollectionHandler removes a collection. Its bookmarks are
+// kept, just no longer filed under it.
+func (cfg *apiConfig) DeleteBookmarkCollectionHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, err := cfg.authenticatedUserID(request)
+	if err != nil {
+		respondWithError(writer, 401, err.Error())
+		return
+	}
+	id, err := uuid.Parse(request.PathValue("collectionID"))
+	if err != nil {
+		respondWithError(writer, 404, "collection not found")
+		return
+	}
+	deleted, err := cfg.dbQueries.DeleteBookmarkCollection(request.Context(), database.DeleteBookmarkCollectionParams{
+		ID: id,
+		UserID: userID,
+	})
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	if deleted == 0 {
+		respondWithError(writer, 404, "collection not found")
+		return
+	}
+	writer.WriteHeader(204)
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/Baehry/chirpy/internal/database"
+	"github.com/Baehry/chirpy/internal/entities"
+	"github.com/Baehry/chirpy/internal/pagination"
+	"github.com/google/uuid"
+)
+
+type chirpResponse struct {
+	database.Chirp
+	Entities []entities.Entity `json:"entities"`
+	Media []mediaResponse `json:"media"`
+	PublishAt *time.Time `json:"publish_at,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	Pinned bool `json:"pinned"`
+	Poll *pollResponse `json:"poll,omitempty"`
+	Bookmarked bool `json:"bookmarked"`
+}
+
+// chirpResponses builds the JSON for chirps as seen by viewerID, which is
+// uuid.Nil for anonymous requests.
+func (cfg *apiConfig) chirpResponses(ctx context.Context, viewerID uuid.UUID, chirps []database.Chirp) ([]chirpResponse, error) {
+	ids := make([]uuid.UUID, len(chirps))
+	for i, chirp := range chirps {
+		ids[i] = chirp.ID
+	}
+	mentions, err := cfg.dbQueries.GetMentionsForChirps(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	mentioned := map[uuid.UUID]map[string]uuid.UUID{}
+	for _, mention := range mentions {
+		if mentioned[mention.ChirpID] == nil {
+			mentioned[mention.ChirpID] = map[string]uuid.UUID{}
+		}
+		mentioned[mention.ChirpID][mention.Handle] = mention.UserID
+	}
+	pinnedIDs, err := cfg.dbQueries.GetPinnedChirpIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	pinned := map[uuid.UUID]bool{}
+	for _, id := range pinnedIDs {
+		pinned[id] = true
+	}
+	attachments, err := cfg.dbQueries.GetMediaForChirps(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	chirpPolls, err := cfg.pollResponses(ctx, viewerID, ids)
+	if err != nil {
+		return nil, err
+	}
+	bookmarked := map[uuid.UUID]bool{}
+	if viewerID != uuid.Nil {
+		bookmarkedIDs, err := cfg.dbQueries.GetBookmarkedChirpIDs(ctx, database.GetBookmarkedChirpIDsParams{
+			UserID: viewerID,
+			ChirpIds: ids,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range bookmarkedIDs {
+			bookmarked[id] = true
+		}
+	}
+	chirpMedia := map[uuid.UUID][]mediaResponse{}
+	for _, medium := range attachments {
+		chirpMedia[medium.ChirpID.UUID] = append(chirpMedia[medium.ChirpID.UUID], newMediaResponse(medium))
+	}
+	result := make([]chirpResponse, len(chirps))
+	for i, chirp := range chirps {
+		result[i] = chirpResponse{
+			Chirp: chirp,
+			Entities: []entities.Entity{},
+			Media: chirpMedia[chirp.ID],
+			Pinned: pinned[chirp.ID],
+			Poll: chirpPolls[chirp.ID],
+			Bookmarked: bookmarked[chirp.ID],
+		}
+		if chirp.PublishAt.Valid {
+			result[i].PublishAt = &chirp.PublishAt.Time
+		}
+		if chirp.ExpiresAt.Valid {
+			result[i].ExpiresAt = &chirp.ExpiresAt.Time
+		}
+		if chirp.DeletedAt.Valid {
+			result[i].DeletedAt = &chirp.DeletedAt.Time
+		}
+		if result[i].Media == nil {
+			result[i].Media = []mediaResponse{}
+		}
+		for _, entity := range entities.Parse(chirp.Body) {
+			if entity.Type == entities.TypeMention {
+				userID, ok := mentioned[chirp.ID][entity.Value]
+				if !ok {
+					continue
+				}
+				entity.UserID = &userID
+			}
+			result[i].Entities = append(result[i].Entities, entity)
+		}
+	}
+	return result, nil
+}
+
+func (cfg *apiConfig) chirpResponseFor(ctx context.Context, viewerID uuid.UUID, chirp database.Chirp) (chirpResponse, error) {
+	result, err := cfg.chirpResponses(ctx, viewerID, []database.Chirp{chirp})
+	if err != nil {
+		return chirpResponse{}, err
+	}
+	return result[0], nil
+}
+
+// respondWithChirpPage writes one page of a chirp listing. chirps holds up to
+// page.fetchLimit() rows; the extra row only signals that a next page exists.
+func (cfg *apiConfig) respondWithChirpPage(writer http.ResponseWriter, request *http.Request, chirps []database.Chirp, page pageParams) {
+	cfg.respondWithPinnedChirpPage(writer, request, nil, chirps, page)
+}
+
+// respondWithPinnedChirpPage is respondWithChirpPage with pinned chirps
+// placed ahead of the page. They don't count towards the page limit.
+func (cfg *apiConfig) respondWithPinnedChirpPage(writer http.ResponseWriter, request *http.Request, pinned []database.Chirp, chirps []database.Chirp, page pageParams) {
+	type response struct {
+		Chirps []chirpResponse `json:"chirps"`
+		NextCursor *string `json:"next_cursor"`
+	}
+	var nextCursor *string
+	if len(chirps) > page.Limit {
+		chirps = chirps[:page.Limit]
+		last := chirps[len(chirps)-1]
+		cursor := pagination.EncodeCursor(last.CreatedAt, last.ID)
+		nextCursor = &cursor
+	}
+	viewerID, _ := cfg.authenticatedUserID(request)
+	result, err := cfg.chirpResponses(request.Context(), viewerID, append(pinned, chirps...))
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	setNextLink(writer, request, nextCursor)
+	respondWithJSON(writer, 200, response{
+		Chirps: result,
+		NextCursor: nextCursor,
+	})
+}
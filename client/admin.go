@@ -0,0 +1,74 @@
+package client
+
+import (
+	"context"
+	"iter"
+
+	"github.com/google/uuid"
+)
+
+// Moderation actions.
+const (
+	ActionMask = "mask"
+	ActionFlag = "flag"
+	ActionReject = "reject"
+)
+
+// The methods below need WithAdminKey.
+
+func (client *Client) ModerationRules(ctx context.Context) ([]ModerationRule, error) {
+	var rules []ModerationRule
+	err := client.do(ctx, "GET", "/admin/moderation/rules", authAdmin, nil, &rules)
+	return rules, err
+}
+
+// PutModerationRule creates the rule for word, or changes its action.
+func (client *Client) PutModerationRule(ctx context.Context, word, action string) (ModerationRule, error) {
+	type parameters struct {
+		Word string `json:"word"`
+		Action string `json:"action"`
+	}
+	var rule ModerationRule
+	err := client.do(ctx, "PUT", "/admin/moderation/rules", authAdmin, parameters{Word: word, Action: action}, &rule)
+	return rule, err
+}
+
+func (client *Client) DeleteModerationRule(ctx context.Context, id uuid.UUID) error {
+	return client.do(ctx, "DELETE", "/admin/moderation/rules/"+id.String(), authAdmin, nil, nil)
+}
+
+func (client *Client) ChirpFlags(ctx context.Context) ([]ChirpFlag, error) {
+	var flags []ChirpFlag
+	err := client.do(ctx, "GET", "/admin/moderation/flags", authAdmin, nil, &flags)
+	return flags, err
+}
+
+func (client *Client) ResolveChirpFlag(ctx context.Context, chirpID uuid.UUID) error {
+	return client.do(ctx, "POST", "/admin/moderation/flags/"+chirpID.String()+"/resolve", authAdmin, nil, nil)
+}
+
+// DeletedChirps lists every user's deleted chirps that haven't been purged.
+func (client *Client) DeletedChirps(ctx context.Context, params PageParams) (Page[Chirp], error) {
+	return client.chirpPage(ctx, "/admin/chirps/deleted", authAdmin, params.values())
+}
+
+func (client *Client) AllDeletedChirps(ctx context.Context, params PageParams) iter.Seq2[Chirp, error] {
+	return paginate(ctx, params, client.DeletedChirps)
+}
+
+// AdminChirp fetches a chirp whether or not it has been deleted.
+func (client *Client) AdminChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	var chirp Chirp
+	err := client.do(ctx, "GET", "/admin/chirps/"+id.String(), authAdmin, nil, &chirp)
+	return chirp, err
+}
+
+// AdminDeleteChirp moves anyone's chirp to the trash.
+func (client *Client) AdminDeleteChirp(ctx context.Context, id uuid.UUID) error {
+	return client.do(ctx, "DELETE", "/admin/chirps/"+id.String(), authAdmin, nil, nil)
+}
+
+// Reset deletes every user. The server only allows it on the dev platform.
+func (client *Client) Reset(ctx context.Context) error {
+	return client.do(ctx, "POST", "/admin/reset", authNone, nil, nil)
+}
@@ -0,0 +1,70 @@
+package client
+
+import (
+	"context"
+	"iter"
+
+	"github.com/google/uuid"
+)
+
+// BookmarkChirp bookmarks a chirp, into the named collection if collection
+// isn't empty. The collection is created on first use.
+func (client *Client) BookmarkChirp(ctx context.Context, chirpID uuid.UUID, collection string) (Bookmark, error) {
+	type parameters struct {
+		Collection string `json:"collection,omitempty"`
+	}
+	var bookmark Bookmark
+	err := client.do(ctx, "POST", "/api/chirps/"+chirpID.String()+"/bookmark", authUser, parameters{Collection: collection}, &bookmark)
+	return bookmark, err
+}
+
+func (client *Client) UnbookmarkChirp(ctx context.Context, chirpID uuid.UUID) error {
+	return client.do(ctx, "DELETE", "/api/chirps/"+chirpID.String()+"/bookmark", authUser, nil, nil)
+}
+
+// BookmarkQuery lists bookmarks, newest first, optionally from one
+// collection.
+type BookmarkQuery struct {
+	PageParams
+	Collection string
+}
+
+func (client *Client) Bookmarks(ctx context.Context, query BookmarkQuery) (Page[Bookmark], error) {
+	values := query.PageParams.values()
+	if query.Collection != "" {
+		values.Set("collection", query.Collection)
+	}
+	var result struct {
+		Bookmarks []Bookmark `json:"bookmarks"`
+		NextCursor *string `json:"next_cursor"`
+	}
+	err := client.call(ctx, request{
+		method: "GET",
+		path: "/api/bookmarks",
+		query: values,
+		auth: authUser,
+	}, &result)
+	if err != nil {
+		return Page[Bookmark]{}, err
+	}
+	return Page[Bookmark]{Items: result.Bookmarks, NextCursor: derefCursor(result.NextCursor)}, nil
+}
+
+func (client *Client) AllBookmarks(ctx context.Context, query BookmarkQuery) iter.Seq2[Bookmark, error] {
+	return paginate(ctx, query.PageParams, func(ctx context.Context, params PageParams) (Page[Bookmark], error) {
+		query.PageParams = params
+		return client.Bookmarks(ctx, query)
+	})
+}
+
+func (client *Client) BookmarkCollections(ctx context.Context) ([]BookmarkCollection, error) {
+	var collections []BookmarkCollection
+	err := client.do(ctx, "GET", "/api/bookmarks/collections", authUser, nil, &collections)
+	return collections, err
+}
+
+// DeleteBookmarkCollection deletes a collection. Its bookmarks are kept,
+// outside any collection.
+func (client *Client) DeleteBookmarkCollection(ctx context.Context, id uuid.UUID) error {
+	return client.do(ctx, "DELETE", "/api/bookmarks/collections/"+id.String(), authUser, nil, nil)
+}
@@ -0,0 +1,198 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// NewChirp is a chirp to post. Status defaults to published, or scheduled
+// when PublishAt is set.
+type NewChirp struct {
+	Body string `json:"body"`
+	MediaIDs []uuid.UUID `json:"media_ids,omitempty"`
+	Status string `json:"status,omitempty"`
+	PublishAt *time.Time `json:"publish_at,omitempty"`
+	// ExpiresIn deletes the chirp this many seconds after it is published.
+	ExpiresIn *int `json:"expires_in,omitempty"`
+	Poll *NewPoll `json:"poll,omitempty"`
+}
+
+type NewPoll struct {
+	Options []string `json:"options"`
+	ClosesAt time.Time `json:"closes_at"`
+}
+
+func (client *Client) CreateChirp(ctx context.Context, chirp NewChirp) (Chirp, error) {
+	var created Chirp
+	err := client.do(ctx, "POST", "/api/chirps", authUser, chirp, &created)
+	return created, err
+}
+
+// ChirpQuery filters GET /api/chirps.
+type ChirpQuery struct {
+	PageParams
+	AuthorID uuid.UUID
+	// Desc lists newest first.
+	Desc bool
+	// PinnedFirst puts the author's pinned chirps ahead of the first page.
+	// It needs AuthorID.
+	PinnedFirst bool
+}
+
+func (query ChirpQuery) values() url.Values {
+	values := query.PageParams.values()
+	if query.AuthorID != uuid.Nil {
+		values.Set("author_id", query.AuthorID.String())
+	}
+	if query.Desc {
+		values.Set("sort", "desc")
+	}
+	if query.PinnedFirst {
+		values.Set("pinned_first", "true")
+	}
+	return values
+}
+
+func (client *Client) Chirps(ctx context.Context, query ChirpQuery) (Page[Chirp], error) {
+	return client.chirpPage(ctx, "/api/chirps", authUser, query.values())
+}
+
+func (client *Client) AllChirps(ctx context.Context, query ChirpQuery) iter.Seq2[Chirp, error] {
+	return paginate(ctx, query.PageParams, func(ctx context.Context, params PageParams) (Page[Chirp], error) {
+		query.PageParams = params
+		// Pinned chirps come only with the first page.
+		if params.Cursor != "" {
+			query.PinnedFirst = false
+		}
+		return client.Chirps(ctx, query)
+	})
+}
+
+// SearchQuery is a full-text search. Results come best match first, and
+// Cursor must come from an earlier search.
+type SearchQuery struct {
+	PageParams
+	Query string
+	AuthorID uuid.UUID
+}
+
+func (client *Client) Search(ctx context.Context, query SearchQuery) (Page[SearchResult], error) {
+	values := query.PageParams.values()
+	values.Set("q", query.Query)
+	if query.AuthorID != uuid.Nil {
+		values.Set("author_id", query.AuthorID.String())
+	}
+	var result struct {
+		Results []SearchResult `json:"results"`
+		NextCursor *string `json:"next_cursor"`
+	}
+	err := client.call(ctx, request{
+		method: "GET",
+		path: "/api/chirps/search",
+		query: values,
+		auth: authUser,
+	}, &result)
+	if err != nil {
+		return Page[SearchResult]{}, err
+	}
+	return Page[SearchResult]{Items: result.Results, NextCursor: derefCursor(result.NextCursor)}, nil
+}
+
+func (client *Client) AllSearch(ctx context.Context, query SearchQuery) iter.Seq2[SearchResult, error] {
+	return paginate(ctx, query.PageParams, func(ctx context.Context, params PageParams) (Page[SearchResult], error) {
+		query.PageParams = params
+		return client.Search(ctx, query)
+	})
+}
+
+func (client *Client) Chirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	var chirp Chirp
+	err := client.do(ctx, "GET", "/api/chirps/"+id.String(), authUser, nil, &chirp)
+	return chirp, err
+}
+
+// DeleteChirp moves a chirp to the trash.
+func (client *Client) DeleteChirp(ctx context.Context, id uuid.UUID) error {
+	return client.do(ctx, "DELETE", "/api/chirps/"+id.String(), authUser, nil, nil)
+}
+
+// DraftUpdate replaces a draft or scheduled chirp. Status defaults to
+// draft, or scheduled when PublishAt is set.
+type DraftUpdate struct {
+	Body string `json:"body"`
+	Status string `json:"status,omitempty"`
+	PublishAt *time.Time `json:"publish_at,omitempty"`
+	ExpiresIn *int `json:"expires_in,omitempty"`
+}
+
+func (client *Client) UpdateDraft(ctx context.Context, id uuid.UUID, update DraftUpdate) (Chirp, error) {
+	var chirp Chirp
+	err := client.do(ctx, "PUT", "/api/chirps/"+id.String(), authUser, update, &chirp)
+	return chirp, err
+}
+
+func (client *Client) PublishChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	var chirp Chirp
+	err := client.do(ctx, "POST", "/api/chirps/"+id.String()+"/publish", authUser, nil, &chirp)
+	return chirp, err
+}
+
+// Drafts lists the signed-in user's drafts and scheduled chirps.
+func (client *Client) Drafts(ctx context.Context, params PageParams) (Page[Chirp], error) {
+	return client.chirpPage(ctx, "/api/drafts", authUser, params.values())
+}
+
+func (client *Client) AllDrafts(ctx context.Context, params PageParams) iter.Seq2[Chirp, error] {
+	return paginate(ctx, params, client.Drafts)
+}
+
+// Trash lists the signed-in user's deleted chirps that can still be
+// restored.
+func (client *Client) Trash(ctx context.Context, params PageParams) (Page[Chirp], error) {
+	return client.chirpPage(ctx, "/api/trash", authUser, params.values())
+}
+
+func (client *Client) AllTrash(ctx context.Context, params PageParams) iter.Seq2[Chirp, error] {
+	return paginate(ctx, params, client.Trash)
+}
+
+func (client *Client) RestoreChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	var chirp Chirp
+	err := client.do(ctx, "POST", "/api/chirps/"+id.String()+"/restore", authUser, nil, &chirp)
+	return chirp, err
+}
+
+func (client *Client) PinChirp(ctx context.Context, id uuid.UUID) error {
+	return client.do(ctx, "POST", "/api/chirps/"+id.String()+"/pin", authUser, nil, nil)
+}
+
+func (client *Client) UnpinChirp(ctx context.Context, id uuid.UUID) error {
+	return client.do(ctx, "DELETE", "/api/chirps/"+id.String()+"/pin", authUser, nil, nil)
+}
+
+// Vote votes in the chirp's poll and returns the poll with its counts.
+func (client *Client) Vote(ctx context.Context, chirpID, optionID uuid.UUID) (Poll, error) {
+	type parameters struct {
+		OptionID uuid.UUID `json:"option_id"`
+	}
+	var poll Poll
+	err := client.do(ctx, "POST", "/api/chirps/"+chirpID.String()+"/poll/votes", authUser, parameters{OptionID: optionID}, &poll)
+	return poll, err
+}
+
+// HashtagChirps lists chirps tagged with tag, with or without the '#'.
+func (client *Client) HashtagChirps(ctx context.Context, tag string, params PageParams) (Page[Chirp], error) {
+	tag = strings.TrimPrefix(tag, "#")
+	return client.chirpPage(ctx, "/api/hashtags/"+url.PathEscape(tag)+"/chirps", authUser, params.values())
+}
+
+func (client *Client) AllHashtagChirps(ctx context.Context, tag string, params PageParams) iter.Seq2[Chirp, error] {
+	return paginate(ctx, params, func(ctx context.Context, params PageParams) (Page[Chirp], error) {
+		return client.HashtagChirps(ctx, tag, params)
+	})
+}
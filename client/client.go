@@ -0,0 +1,264 @@
+// Package client is a Go client for the Chirpy HTTP API.
+//
+// A Client logs in once and then keeps its access token fresh on its own:
+// when a request comes back 401 it trades the refresh token for a new
+// access token through /api/refresh and tries again. Errors from the
+// server are returned as *Error, which matches the sentinel errors in this
+// package with errors.Is.
+//
+// Server-to-server endpoints (ActivityPub, Polka webhooks), feeds, the
+// event stream, the WebSocket gateway, GraphQL and the /admin/metrics page
+// are not covered here.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Client talks to one Chirpy server. It is safe for concurrent use.
+type Client struct {
+	baseURL *url.URL
+	http *http.Client
+	adminKey string
+
+	mu sync.Mutex
+	accessToken string
+	refreshToken string
+	// refreshing is held while a refresh is in flight, so concurrent 401s
+	// share one refresh instead of racing each other.
+	refreshing sync.Mutex
+}
+
+type Option func(*Client)
+
+// WithHTTPClient sends requests through httpClient instead of
+// http.DefaultClient.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(client *Client) {
+		client.http = httpClient
+	}
+}
+
+// WithTokens starts the client with tokens from an earlier login.
+func WithTokens(accessToken, refreshToken string) Option {
+	return func(client *Client) {
+		client.accessToken = accessToken
+		client.refreshToken = refreshToken
+	}
+}
+
+// WithAdminKey sets the key for the /admin endpoints.
+func WithAdminKey(key string) Option {
+	return func(client *Client) {
+		client.adminKey = key
+	}
+}
+
+// New returns a client for the server at baseURL, e.g.
+// "https://chirpy.example".
+func New(baseURL string, opts ...Option) (*Client, error) {
+	parsed, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil {
+		return nil, err
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return nil, fmt.Errorf("client: base URL must be http or https")
+	}
+	client := &Client{
+		baseURL: parsed,
+		http: http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(client)
+	}
+	return client, nil
+}
+
+// Tokens returns the current access and refresh tokens, so they can be
+// saved and handed to WithTokens later.
+func (client *Client) Tokens() (accessToken, refreshToken string) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	return client.accessToken, client.refreshToken
+}
+
+// SetTokens replaces the client's tokens.
+func (client *Client) SetTokens(accessToken, refreshToken string) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.accessToken = accessToken
+	client.refreshToken = refreshToken
+}
+
+type authMode int
+
+const (
+	// authNone sends no credentials.
+	authNone authMode = iota
+	// authUser sends the access token if there is one and refreshes it on
+	// a 401.
+	authUser
+	// authAdmin sends the admin key.
+	authAdmin
+)
+
+// request is one API call. The body is kept as bytes so the call can be
+// replayed after a token refresh.
+type request struct {
+	method string
+	path string
+	query url.Values
+	body []byte
+	contentType string
+	auth authMode
+}
+
+func jsonRequest(method, path string, auth authMode, body any) (request, error) {
+	req := request{
+		method: method,
+		path: path,
+		auth: auth,
+	}
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return request{}, err
+		}
+		req.body = data
+		req.contentType = "application/json"
+	}
+	return req, nil
+}
+
+// call sends req and decodes a JSON response into out, which may be nil.
+func (client *Client) call(ctx context.Context, req request, out any) error {
+	response, err := client.send(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if out == nil {
+		io.Copy(io.Discard, response.Body)
+		return nil
+	}
+	return json.NewDecoder(response.Body).Decode(out)
+}
+
+// do is call for JSON requests.
+func (client *Client) do(ctx context.Context, method, path string, auth authMode, body any, out any) error {
+	req, err := jsonRequest(method, path, auth, body)
+	if err != nil {
+		return err
+	}
+	return client.call(ctx, req, out)
+}
+
+// send performs req, refreshing the access token and retrying once if the
+// server says it has expired. Non-2xx responses are returned as *Error.
+func (client *Client) send(ctx context.Context, req request) (*http.Response, error) {
+	accessToken, _ := client.Tokens()
+	response, err := client.sendOnce(ctx, req, accessToken)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode == http.StatusUnauthorized && req.auth == authUser && accessToken != "" {
+		if refreshErr := client.refreshAfter(ctx, accessToken); refreshErr == nil {
+			response.Body.Close()
+			accessToken, _ = client.Tokens()
+			response, err = client.sendOnce(ctx, req, accessToken)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		defer response.Body.Close()
+		return nil, newError(response)
+	}
+	return response, nil
+}
+
+func (client *Client) sendOnce(ctx context.Context, req request, accessToken string) (*http.Response, error) {
+	target := client.baseURL.JoinPath(req.path)
+	if len(req.query) > 0 {
+		target.RawQuery = req.query.Encode()
+	}
+	var body io.Reader
+	if req.body != nil {
+		body = bytes.NewReader(req.body)
+	}
+	httpRequest, err := http.NewRequestWithContext(ctx, req.method, target.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	if req.contentType != "" {
+		httpRequest.Header.Set("Content-Type", req.contentType)
+	}
+	switch req.auth {
+	case authUser:
+		if accessToken != "" {
+			httpRequest.Header.Set("Authorization", "Bearer "+accessToken)
+		}
+	case authAdmin:
+		httpRequest.Header.Set("Authorization", "ApiKey "+client.adminKey)
+	}
+	return client.http.Do(httpRequest)
+}
+
+// refreshAfter gets a new access token to replace stale, unless another
+// request already has.
+func (client *Client) refreshAfter(ctx context.Context, stale string) error {
+	client.refreshing.Lock()
+	defer client.refreshing.Unlock()
+	accessToken, refreshToken := client.Tokens()
+	if accessToken != stale {
+		return nil
+	}
+	if refreshToken == "" {
+		return ErrUnauthorized
+	}
+	_, err := client.Refresh(ctx)
+	return err
+}
+
+// Refresh trades the refresh token for a new access token and starts
+// using it. The client calls it on its own when the access token expires.
+func (client *Client) Refresh(ctx context.Context) (string, error) {
+	_, refreshToken := client.Tokens()
+	if refreshToken == "" {
+		return "", errors.New("client: no refresh token")
+	}
+	// The refresh token goes where an access token would, and a 401 here
+	// must not trigger another refresh, so this skips send.
+	response, err := client.sendOnce(ctx, request{
+		method: http.MethodPost,
+		path: "/api/refresh",
+		auth: authUser,
+	}, refreshToken)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return "", newError(response)
+	}
+	var result struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	client.mu.Lock()
+	client.accessToken = result.Token
+	client.mu.Unlock()
+	return result.Token, nil
+}
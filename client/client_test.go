@@ -0,0 +1,269 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestErrorIs(t *testing.T) {
+	cases := []struct {
+		status int
+		body string
+		target error
+		message string
+	}{
+		{400, `{"error":"invalid limit"}`, ErrBadRequest, "invalid limit"},
+		{401, "token is expired", ErrUnauthorized, "token is expired"},
+		{401, "", ErrUnauthorized, ""},
+		{403, `{"error":"not your chirp"}`, ErrForbidden, "not your chirp"},
+		{404, `{"error":"chirp not found"}`, ErrNotFound, "chirp not found"},
+		{409, `{"error":"handle is taken"}`, ErrConflict, "handle is taken"},
+		{413, `{"error":"too big"}`, ErrTooLarge, "too big"},
+		{415, `{"error":"not an image"}`, ErrUnsupportedMediaType, "not an image"},
+		{429, "", ErrTooManyRequests, ""},
+		{500, `{"error":"boom"}`, ErrServer, "boom"},
+		{502, `{"error":"lookup failed"}`, ErrServer, "lookup failed"},
+	}
+	for _, c := range cases {
+		server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			writer.WriteHeader(c.status)
+			writer.Write([]byte(c.body))
+		}))
+		client, _ := New(server.URL)
+		err := client.Healthz(context.Background())
+		server.Close()
+		var apiErr *Error
+		if !errors.Is(err, c.target) || !errors.As(err, &apiErr) {
+			t.Logf("%d: expected %v, got %v\n", c.status, c.target, err)
+			t.Fail()
+			continue
+		}
+		if apiErr.Message != c.message {
+			t.Logf("%d: expected message %q, got %q\n", c.status, c.message, apiErr.Message)
+			t.Fail()
+		}
+		if errors.Is(err, ErrNotFound) != (c.status == 404) {
+			t.Logf("%d: matched the wrong sentinel\n", c.status)
+			t.Fail()
+		}
+	}
+}
+
+// tokenServer accepts "fresh-N" access tokens only for the latest N, and
+// hands out a new one for refresh token "r".
+type tokenServer struct {
+	mu sync.Mutex
+	generation int
+	refreshes atomic.Int32
+}
+
+func (s *tokenServer) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch request.URL.Path {
+	case "/api/refresh":
+		if request.Header.Get("Authorization") != "Bearer r" {
+			writer.WriteHeader(401)
+			return
+		}
+		s.refreshes.Add(1)
+		s.generation++
+		json.NewEncoder(writer).Encode(map[string]string{"token": fmt.Sprintf("fresh-%d", s.generation)})
+	default:
+		if request.Header.Get("Authorization") != fmt.Sprintf("Bearer fresh-%d", s.generation) {
+			writer.WriteHeader(401)
+			writer.Write([]byte("token is expired"))
+			return
+		}
+		json.NewEncoder(writer).Encode(map[string]string{"policy": "everyone"})
+	}
+}
+
+func TestRefreshOnUnauthorized(t *testing.T) {
+	tokens := &tokenServer{}
+	server := httptest.NewServer(tokens)
+	defer server.Close()
+	client, _ := New(server.URL, WithTokens("stale", "r"))
+	const callers = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, callers)
+	for range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.DMPolicy(context.Background())
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Logf("expected the request to succeed after a refresh, got %v\n", err)
+			t.Fail()
+		}
+	}
+	if n := tokens.refreshes.Load(); n != 1 {
+		t.Logf("expected concurrent 401s to share one refresh, got %d\n", n)
+		t.Fail()
+	}
+	if access, _ := client.Tokens(); access != "fresh-1" {
+		t.Logf("expected the client to keep the new token, got %q\n", access)
+		t.Fail()
+	}
+}
+
+func TestRefreshFails(t *testing.T) {
+	tokens := &tokenServer{}
+	server := httptest.NewServer(tokens)
+	defer server.Close()
+	cases := []struct {
+		name string
+		refreshToken string
+		refreshes int32
+	}{
+		{"revoked refresh token", "revoked", 0},
+		{"no refresh token", "", 0},
+	}
+	for _, c := range cases {
+		client, _ := New(server.URL, WithTokens("stale", c.refreshToken))
+		_, err := client.DMPolicy(context.Background())
+		if !errors.Is(err, ErrUnauthorized) {
+			t.Logf("%s: expected ErrUnauthorized, got %v\n", c.name, err)
+			t.Fail()
+		}
+	}
+	if n := tokens.refreshes.Load(); n != 0 {
+		t.Logf("expected no successful refreshes, got %d\n", n)
+		t.Fail()
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	const total = 7
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		requests.Add(1)
+		limit, _ := strconv.Atoi(request.URL.Query().Get("limit"))
+		start, _ := strconv.Atoi(request.URL.Query().Get("cursor"))
+		var page chirpPage
+		for i := start; i < start+limit && i < total; i++ {
+			page.Chirps = append(page.Chirps, Chirp{Body: strconv.Itoa(i)})
+		}
+		if start+limit < total {
+			next := strconv.Itoa(start + limit)
+			page.NextCursor = &next
+		}
+		json.NewEncoder(writer).Encode(page)
+	}))
+	defer server.Close()
+	client, _ := New(server.URL)
+	cases := []struct {
+		name string
+		stopAfter int
+		expected int
+		requests int32
+	}{
+		{"all pages", -1, total, 3},
+		{"stop early", 2, 2, 1},
+		{"stop on a page boundary", 3, 3, 1},
+	}
+	for _, c := range cases {
+		requests.Store(0)
+		var got []string
+		for chirp, err := range client.AllChirps(context.Background(), ChirpQuery{PageParams: PageParams{Limit: 3}}) {
+			if err != nil {
+				t.Logf("%s: unexpected error %v\n", c.name, err)
+				t.Fail()
+				break
+			}
+			got = append(got, chirp.Body)
+			if len(got) == c.stopAfter {
+				break
+			}
+		}
+		if len(got) != c.expected {
+			t.Logf("%s: expected %d chirps, got %v\n", c.name, c.expected, got)
+			t.Fail()
+		}
+		for i, body := range got {
+			if body != strconv.Itoa(i) {
+				t.Logf("%s: expected chirps in order, got %v\n", c.name, got)
+				t.Fail()
+				break
+			}
+		}
+		if n := requests.Load(); n != c.requests {
+			t.Logf("%s: expected %d requests, got %d\n", c.name, c.requests, n)
+			t.Fail()
+		}
+	}
+}
+
+func TestPaginateError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(400)
+		writer.Write([]byte(`{"error":"invalid cursor"}`))
+	}))
+	defer server.Close()
+	client, _ := New(server.URL)
+	var errs []error
+	for _, err := range client.AllTrash(context.Background(), PageParams{Cursor: "bogus"}) {
+		errs = append(errs, err)
+	}
+	if len(errs) != 1 || !errors.Is(errs[0], ErrBadRequest) {
+		t.Logf("expected a single ErrBadRequest, got %v\n", errs)
+		t.Fail()
+	}
+}
+
+type recordingTransport struct {
+	paths []string
+}
+
+func (transport *recordingTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	transport.paths = append(transport.paths, request.URL.EscapedPath())
+	return http.DefaultTransport.RoundTrip(request)
+}
+
+func TestCustomHTTPClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(204)
+	}))
+	defer server.Close()
+	transport := &recordingTransport{}
+	client, _ := New(server.URL+"/", WithHTTPClient(&http.Client{Transport: transport}))
+	client.Follow(context.Background(), "a b/c")
+	if len(transport.paths) != 1 || transport.paths[0] != "/api/users/a%20b%2Fc/follow" {
+		t.Logf("expected one escaped request through the custom client, got %v\n", transport.paths)
+		t.Fail()
+	}
+}
+
+func TestNew(t *testing.T) {
+	cases := []struct {
+		baseURL string
+		valid bool
+	}{
+		{"http://localhost:8080", true},
+		{"https://chirpy.example/", true},
+		{"chirpy.example", false},
+		{"ftp://chirpy.example", false},
+		{"://", false},
+	}
+	for _, c := range cases {
+		_, err := New(c.baseURL)
+		if (err == nil) != c.valid {
+			t.Logf("%q: expected valid=%v, got %v\n", c.baseURL, c.valid, err)
+			t.Fail()
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// The server's error codes. An *Error matches the one for its status code
+// with errors.Is.
+var (
+	ErrBadRequest = errors.New("bad request")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden = errors.New("forbidden")
+	ErrNotFound = errors.New("not found")
+	ErrConflict = errors.New("conflict")
+	ErrTooLarge = errors.New("request too large")
+	ErrUnsupportedMediaType = errors.New("unsupported media type")
+	ErrTooManyRequests = errors.New("too many requests")
+	ErrServer = errors.New("server error")
+)
+
+// Error is a non-2xx response from the server.
+type Error struct {
+	StatusCode int
+	// Message is the server's explanation, when it gave one.
+	Message string
+}
+
+func (err *Error) Error() string {
+	if err.Message == "" {
+		return fmt.Sprintf("chirpy: %d %s", err.StatusCode, http.StatusText(err.StatusCode))
+	}
+	return fmt.Sprintf("chirpy: %d %s: %s", err.StatusCode, http.StatusText(err.StatusCode), err.Message)
+}
+
+func (err *Error) Is(target error) bool {
+	switch target {
+	case ErrBadRequest:
+		return err.StatusCode == http.StatusBadRequest
+	case ErrUnauthorized:
+		return err.StatusCode == http.StatusUnauthorized
+	case ErrForbidden:
+		return err.StatusCode == http.StatusForbidden
+	case ErrNotFound:
+		return err.StatusCode == http.StatusNotFound
+	case ErrConflict:
+		return err.StatusCode == http.StatusConflict
+	case ErrTooLarge:
+		return err.StatusCode == http.StatusRequestEntityTooLarge
+	case ErrUnsupportedMediaType:
+		return err.StatusCode == http.StatusUnsupportedMediaType
+	case ErrTooManyRequests:
+		return err.StatusCode == http.StatusTooManyRequests
+	case ErrServer:
+		return err.StatusCode >= 500
+	}
+	return false
+}
+
+// maxErrorBody caps how much of an error response is read.
+const maxErrorBody = 64 << 10
+
+// newError reads an error response. Most handlers answer with
+// {"error": "..."}; some older ones with plain text or nothing at all.
+func newError(response *http.Response) *Error {
+	result := &Error{StatusCode: response.StatusCode}
+	body, _ := io.ReadAll(io.LimitReader(response.Body, maxErrorBody))
+	var payload struct {
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(body, &payload); err == nil && payload.Error != "" {
+		result.Message = payload.Error
+	} else {
+		result.Message = strings.TrimSpace(string(body))
+	}
+	return result
+}
@@ -0,0 +1,75 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime/multipart"
+
+	"github.com/google/uuid"
+)
+
+// UploadMedia uploads an image to attach to a chirp. The server works out
+// its type from the content, so name is only used as the form's file name.
+func (client *Client) UploadMedia(ctx context.Context, name string, file io.Reader, altText string) (Media, error) {
+	// The body is buffered rather than streamed so it can be sent again
+	// after a token refresh. Uploads are small enough for that.
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	if altText != "" {
+		if err := form.WriteField("alt_text", altText); err != nil {
+			return Media{}, err
+		}
+	}
+	part, err := form.CreateFormFile("file", name)
+	if err != nil {
+		return Media{}, err
+	}
+	if _, err := io.Copy(part, file); err != nil {
+		return Media{}, err
+	}
+	if err := form.Close(); err != nil {
+		return Media{}, err
+	}
+	var uploaded Media
+	err = client.call(ctx, request{
+		method: "POST",
+		path: "/api/media",
+		body: body.Bytes(),
+		contentType: form.FormDataContentType(),
+		auth: authUser,
+	}, &uploaded)
+	return uploaded, err
+}
+
+func (client *Client) SetMediaAltText(ctx context.Context, id uuid.UUID, altText string) (Media, error) {
+	type parameters struct {
+		AltText string `json:"alt_text"`
+	}
+	var updated Media
+	err := client.do(ctx, "PUT", "/api/media/"+id.String(), authUser, parameters{AltText: altText}, &updated)
+	return updated, err
+}
+
+// MediaFile opens an uploaded image. The caller must close it.
+func (client *Client) MediaFile(ctx context.Context, id uuid.UUID) (io.ReadCloser, string, error) {
+	return client.file(ctx, "/api/media/"+id.String())
+}
+
+// MediaThumbnail opens an image's thumbnail. The caller must close it.
+func (client *Client) MediaThumbnail(ctx context.Context, id uuid.UUID) (io.ReadCloser, string, error) {
+	return client.file(ctx, "/api/media/"+id.String()+"/thumbnail")
+}
+
+// file returns a response body and its content type.
+func (client *Client) file(ctx context.Context, path string) (io.ReadCloser, string, error) {
+	response, err := client.send(ctx, request{
+		method: "GET",
+		path: path,
+		auth: authUser,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return response.Body, response.Header.Get("Content-Type"), nil
+}
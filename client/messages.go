@@ -0,0 +1,150 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/url"
+
+	"github.com/google/uuid"
+)
+
+// StartConversation messages the users with the given handles. With one
+// handle it reuses the existing direct conversation if there is one; with
+// more it starts a group, which title names.
+func (client *Client) StartConversation(ctx context.Context, handles []string, title, body string) (Conversation, error) {
+	type parameters struct {
+		Handles []string `json:"handles"`
+		Title string `json:"title,omitempty"`
+		Body string `json:"body"`
+	}
+	var conversation Conversation
+	err := client.do(ctx, "POST", "/api/conversations", authUser, parameters{Handles: handles, Title: title, Body: body}, &conversation)
+	return conversation, err
+}
+
+// ConversationPage is a page of conversations along with how many unread
+// messages there are across all of them.
+type ConversationPage struct {
+	Page[Conversation]
+	UnreadCount int64
+}
+
+// Conversations lists the signed-in user's conversations, most recently
+// active first.
+func (client *Client) Conversations(ctx context.Context, params PageParams) (ConversationPage, error) {
+	var result struct {
+		Conversations []Conversation `json:"conversations"`
+		UnreadCount int64 `json:"unread_count"`
+		NextCursor *string `json:"next_cursor"`
+	}
+	err := client.call(ctx, request{
+		method: "GET",
+		path: "/api/conversations",
+		query: params.values(),
+		auth: authUser,
+	}, &result)
+	if err != nil {
+		return ConversationPage{}, err
+	}
+	return ConversationPage{
+		Page: Page[Conversation]{Items: result.Conversations, NextCursor: derefCursor(result.NextCursor)},
+		UnreadCount: result.UnreadCount,
+	}, nil
+}
+
+func (client *Client) AllConversations(ctx context.Context, params PageParams) iter.Seq2[Conversation, error] {
+	return paginate(ctx, params, func(ctx context.Context, params PageParams) (Page[Conversation], error) {
+		page, err := client.Conversations(ctx, params)
+		return page.Page, err
+	})
+}
+
+func (client *Client) Conversation(ctx context.Context, id uuid.UUID) (Conversation, error) {
+	var conversation Conversation
+	err := client.do(ctx, "GET", "/api/conversations/"+id.String(), authUser, nil, &conversation)
+	return conversation, err
+}
+
+// Messages lists a conversation's messages, newest first.
+func (client *Client) Messages(ctx context.Context, conversationID uuid.UUID, params PageParams) (Page[Message], error) {
+	var result struct {
+		Messages []Message `json:"messages"`
+		NextCursor *string `json:"next_cursor"`
+	}
+	err := client.call(ctx, request{
+		method: "GET",
+		path: "/api/conversations/" + conversationID.String() + "/messages",
+		query: params.values(),
+		auth: authUser,
+	}, &result)
+	if err != nil {
+		return Page[Message]{}, err
+	}
+	return Page[Message]{Items: result.Messages, NextCursor: derefCursor(result.NextCursor)}, nil
+}
+
+func (client *Client) AllMessages(ctx context.Context, conversationID uuid.UUID, params PageParams) iter.Seq2[Message, error] {
+	return paginate(ctx, params, func(ctx context.Context, params PageParams) (Page[Message], error) {
+		return client.Messages(ctx, conversationID, params)
+	})
+}
+
+func (client *Client) SendMessage(ctx context.Context, conversationID uuid.UUID, body string) (Message, error) {
+	type parameters struct {
+		Body string `json:"body"`
+	}
+	var message Message
+	err := client.do(ctx, "POST", "/api/conversations/"+conversationID.String()+"/messages", authUser, parameters{Body: body}, &message)
+	return message, err
+}
+
+// DeleteMessage hides a message from the signed-in user, or from every
+// member when forEveryone is set. Only the sender can do the latter.
+func (client *Client) DeleteMessage(ctx context.Context, conversationID, messageID uuid.UUID, forEveryone bool) error {
+	query := url.Values{}
+	if forEveryone {
+		query.Set("for", "everyone")
+	}
+	return client.call(ctx, request{
+		method: "DELETE",
+		path: "/api/conversations/" + conversationID.String() + "/messages/" + messageID.String(),
+		query: query,
+		auth: authUser,
+	}, nil)
+}
+
+// MarkConversationRead marks the conversation read up to messageID, or up
+// to its latest message when messageID is uuid.Nil, and returns how many
+// of its messages are still unread.
+func (client *Client) MarkConversationRead(ctx context.Context, conversationID, messageID uuid.UUID) (int64, error) {
+	type parameters struct {
+		MessageID uuid.UUID `json:"message_id"`
+	}
+	var body any
+	if messageID != uuid.Nil {
+		body = parameters{MessageID: messageID}
+	}
+	var result struct {
+		UnreadCount int64 `json:"unread_count"`
+	}
+	err := client.do(ctx, "POST", "/api/conversations/"+conversationID.String()+"/read", authUser, body, &result)
+	return result.UnreadCount, err
+}
+
+// DMPolicy returns who may start a conversation with the signed-in user.
+func (client *Client) DMPolicy(ctx context.Context) (string, error) {
+	var result struct {
+		Policy string `json:"policy"`
+	}
+	err := client.do(ctx, "GET", "/api/dm_settings", authUser, nil, &result)
+	return result.Policy, err
+}
+
+func (client *Client) SetDMPolicy(ctx context.Context, policy string) (string, error) {
+	type parameters struct {
+		Policy string `json:"policy"`
+	}
+	var result parameters
+	err := client.do(ctx, "PUT", "/api/dm_settings", authUser, parameters{Policy: policy}, &result)
+	return result.Policy, err
+}
@@ -0,0 +1,81 @@
+package client
+
+import (
+	"context"
+	"iter"
+
+	"github.com/google/uuid"
+)
+
+type NotificationQuery struct {
+	PageParams
+	UnreadOnly bool
+}
+
+// NotificationPage is a page of notifications along with how many are
+// unread in total.
+type NotificationPage struct {
+	Page[Notification]
+	UnreadCount int64
+}
+
+func (client *Client) Notifications(ctx context.Context, query NotificationQuery) (NotificationPage, error) {
+	values := query.PageParams.values()
+	if query.UnreadOnly {
+		values.Set("unread", "true")
+	}
+	var result struct {
+		Notifications []Notification `json:"notifications"`
+		UnreadCount int64 `json:"unread_count"`
+		NextCursor *string `json:"next_cursor"`
+	}
+	err := client.call(ctx, request{
+		method: "GET",
+		path: "/api/notifications",
+		query: values,
+		auth: authUser,
+	}, &result)
+	if err != nil {
+		return NotificationPage{}, err
+	}
+	return NotificationPage{
+		Page: Page[Notification]{Items: result.Notifications, NextCursor: derefCursor(result.NextCursor)},
+		UnreadCount: result.UnreadCount,
+	}, nil
+}
+
+func (client *Client) AllNotifications(ctx context.Context, query NotificationQuery) iter.Seq2[Notification, error] {
+	return paginate(ctx, query.PageParams, func(ctx context.Context, params PageParams) (Page[Notification], error) {
+		query.PageParams = params
+		page, err := client.Notifications(ctx, query)
+		return page.Page, err
+	})
+}
+
+// MarkNotificationsRead marks the given notifications as read, or all of
+// them when ids is empty, and returns how many are still unread.
+func (client *Client) MarkNotificationsRead(ctx context.Context, ids ...uuid.UUID) (int64, error) {
+	type parameters struct {
+		IDs []uuid.UUID `json:"ids"`
+	}
+	var result struct {
+		UnreadCount int64 `json:"unread_count"`
+	}
+	err := client.do(ctx, "POST", "/api/notifications/read", authUser, parameters{IDs: ids}, &result)
+	return result.UnreadCount, err
+}
+
+// NotificationPreferences maps each notification type to whether it is on.
+func (client *Client) NotificationPreferences(ctx context.Context) (map[string]bool, error) {
+	var preferences map[string]bool
+	err := client.do(ctx, "GET", "/api/notifications/preferences", authUser, nil, &preferences)
+	return preferences, err
+}
+
+// SetNotificationPreferences turns notification types on or off. Types left
+// out keep their current setting.
+func (client *Client) SetNotificationPreferences(ctx context.Context, preferences map[string]bool) (map[string]bool, error) {
+	var result map[string]bool
+	err := client.do(ctx, "PUT", "/api/notifications/preferences", authUser, preferences, &result)
+	return result, err
+}
@@ -0,0 +1,94 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// PageParams selects one page of a listing. The zero value asks for the
+// first page at the server's default size.
+type PageParams struct {
+	Limit int
+	// Since and Until bound the listing by creation time when set.
+	Since time.Time
+	Until time.Time
+	// Cursor is the NextCursor of the previous page.
+	Cursor string
+}
+
+func (params PageParams) values() url.Values {
+	query := url.Values{}
+	if params.Limit > 0 {
+		query.Set("limit", strconv.Itoa(params.Limit))
+	}
+	if !params.Since.IsZero() {
+		query.Set("since", params.Since.Format(time.RFC3339))
+	}
+	if !params.Until.IsZero() {
+		query.Set("until", params.Until.Format(time.RFC3339))
+	}
+	if params.Cursor != "" {
+		query.Set("cursor", params.Cursor)
+	}
+	return query
+}
+
+// Page is one page of a listing. NextCursor is empty on the last page.
+type Page[T any] struct {
+	Items []T
+	NextCursor string
+}
+
+// paginate walks every page from params on, yielding items one at a time.
+// It stops after the first error, which it yields with a zero item.
+func paginate[T any](ctx context.Context, params PageParams, fetch func(context.Context, PageParams) (Page[T], error)) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for {
+			page, err := fetch(ctx, params)
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+			for _, item := range page.Items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+			if page.NextCursor == "" {
+				return
+			}
+			params.Cursor = page.NextCursor
+		}
+	}
+}
+
+func derefCursor(cursor *string) string {
+	if cursor == nil {
+		return ""
+	}
+	return *cursor
+}
+
+// chirpPage is the shape shared by every chirp listing.
+type chirpPage struct {
+	Chirps []Chirp `json:"chirps"`
+	NextCursor *string `json:"next_cursor"`
+}
+
+func (client *Client) chirpPage(ctx context.Context, path string, auth authMode, query url.Values) (Page[Chirp], error) {
+	var result chirpPage
+	err := client.call(ctx, request{
+		method: "GET",
+		path: path,
+		query: query,
+		auth: auth,
+	}, &result)
+	if err != nil {
+		return Page[Chirp]{}, err
+	}
+	return Page[Chirp]{Items: result.Chirps, NextCursor: derefCursor(result.NextCursor)}, nil
+}
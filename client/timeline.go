@@ -0,0 +1,53 @@
+package client
+
+import (
+	"context"
+	"iter"
+
+	"github.com/google/uuid"
+)
+
+// Timeline lists chirps and remote posts from accounts the signed-in user
+// follows, newest first.
+func (client *Client) Timeline(ctx context.Context, params PageParams) (Page[TimelineItem], error) {
+	var result struct {
+		Items []TimelineItem `json:"items"`
+		NextCursor *string `json:"next_cursor"`
+	}
+	err := client.call(ctx, request{
+		method: "GET",
+		path: "/api/timeline",
+		query: params.values(),
+		auth: authUser,
+	}, &result)
+	if err != nil {
+		return Page[TimelineItem]{}, err
+	}
+	return Page[TimelineItem]{Items: result.Items, NextCursor: derefCursor(result.NextCursor)}, nil
+}
+
+func (client *Client) AllTimeline(ctx context.Context, params PageParams) iter.Seq2[TimelineItem, error] {
+	return paginate(ctx, params, client.Timeline)
+}
+
+// FollowRemote follows an account on another server, given as
+// "user@host".
+func (client *Client) FollowRemote(ctx context.Context, account string) (RemoteFollow, error) {
+	type parameters struct {
+		Account string `json:"account"`
+	}
+	var follow RemoteFollow
+	err := client.do(ctx, "POST", "/api/remote_follows", authUser, parameters{Account: account}, &follow)
+	return follow, err
+}
+
+func (client *Client) RemoteFollows(ctx context.Context) ([]RemoteFollow, error) {
+	var follows []RemoteFollow
+	err := client.do(ctx, "GET", "/api/remote_follows", authUser, nil, &follows)
+	return follows, err
+}
+
+// UnfollowRemote takes the ID of the remote actor, not their account name.
+func (client *Client) UnfollowRemote(ctx context.Context, actorID uuid.UUID) error {
+	return client.do(ctx, "DELETE", "/api/remote_follows/"+actorID.String(), authUser, nil, nil)
+}
@@ -0,0 +1,296 @@
+package client
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// User is an account as its owner sees it.
+type User struct {
+	ID uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Email string `json:"email"`
+	IsChirpyRed bool `json:"is_chirpy_red"`
+	Handle string `json:"handle"`
+	DisplayName string `json:"display_name"`
+	Bio string `json:"bio"`
+	AvatarURL string `json:"avatar_url"`
+	Location string `json:"location"`
+}
+
+// Session is what logging in returns.
+type Session struct {
+	ID uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Email string `json:"email"`
+	Handle string `json:"handle"`
+	Token string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	IsChirpyRed bool `json:"is_chirpy_red"`
+}
+
+type UserSummary struct {
+	ID uuid.UUID `json:"id"`
+	Handle string `json:"handle"`
+	DisplayName string `json:"display_name"`
+	AvatarURL string `json:"avatar_url"`
+}
+
+type Profile struct {
+	ID uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Handle string `json:"handle"`
+	DisplayName string `json:"display_name"`
+	Bio string `json:"bio"`
+	AvatarURL string `json:"avatar_url"`
+	Location string `json:"location"`
+	IsChirpyRed bool `json:"is_chirpy_red"`
+	ChirpCount int64 `json:"chirp_count"`
+	FollowerCount int64 `json:"follower_count"`
+	FollowingCount int64 `json:"following_count"`
+	PinnedChirps []Chirp `json:"pinned_chirps"`
+}
+
+// Chirp statuses.
+const (
+	StatusDraft = "draft"
+	StatusScheduled = "scheduled"
+	StatusPublished = "published"
+)
+
+type Chirp struct {
+	ID uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Body string `json:"body"`
+	UserID uuid.UUID `json:"user_id"`
+	Status string `json:"status"`
+	Entities []Entity `json:"entities"`
+	Media []Media `json:"media"`
+	PublishAt *time.Time `json:"publish_at,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	Pinned bool `json:"pinned"`
+	Poll *Poll `json:"poll,omitempty"`
+	Bookmarked bool `json:"bookmarked"`
+}
+
+// Entity types.
+const (
+	EntityHashtag = "hashtag"
+	EntityMention = "mention"
+)
+
+// Entity is a hashtag or mention in a chirp body. Start and End count
+// characters, not bytes.
+type Entity struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+	Value string `json:"value"`
+	Start int `json:"start"`
+	End int `json:"end"`
+	UserID *uuid.UUID `json:"user_id,omitempty"`
+}
+
+type Media struct {
+	ID uuid.UUID `json:"id"`
+	URL string `json:"url"`
+	ThumbnailURL string `json:"thumbnail_url"`
+	ContentType string `json:"content_type"`
+	Width int32 `json:"width"`
+	Height int32 `json:"height"`
+	AltText string `json:"alt_text"`
+}
+
+// Poll only carries vote counts once the viewer has voted or the poll has
+// closed.
+type Poll struct {
+	ClosesAt time.Time `json:"closes_at"`
+	Closed bool `json:"closed"`
+	Options []PollOption `json:"options"`
+	TotalVotes *int64 `json:"total_votes,omitempty"`
+	VotedOptionID *uuid.UUID `json:"voted_option_id,omitempty"`
+}
+
+type PollOption struct {
+	ID uuid.UUID `json:"id"`
+	Text string `json:"text"`
+	Votes *int64 `json:"votes,omitempty"`
+}
+
+type SearchResult struct {
+	ID uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Body string `json:"body"`
+	UserID uuid.UUID `json:"user_id"`
+	Rank float32 `json:"rank"`
+	// Snippet is HTML: the escaped body with matches in <mark> tags.
+	Snippet string `json:"snippet"`
+}
+
+// Bookmark stands in for a bookmarked chirp. Chirp is nil, and Tombstone
+// set, once the chirp has been deleted or has expired.
+type Bookmark struct {
+	ChirpID uuid.UUID `json:"chirp_id"`
+	CollectionID *uuid.UUID `json:"collection_id"`
+	CreatedAt time.Time `json:"created_at"`
+	Chirp *Chirp `json:"chirp"`
+	Tombstone bool `json:"tombstone"`
+}
+
+type BookmarkCollection struct {
+	ID uuid.UUID `json:"id"`
+	Name string `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	BookmarkCount int64 `json:"bookmark_count"`
+}
+
+type MutedWord struct {
+	ID uuid.UUID `json:"id"`
+	Kind string `json:"kind"`
+	Word string `json:"word"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// Timeline item types.
+const (
+	TimelineChirp = "chirp"
+	TimelineRemotePost = "remote_post"
+)
+
+// TimelineItem is a chirp or a post from another server; Type says which.
+type TimelineItem struct {
+	Type string `json:"type"`
+	Chirp *Chirp `json:"chirp,omitempty"`
+	RemotePost *RemotePost `json:"remote_post,omitempty"`
+}
+
+type RemotePost struct {
+	ID uuid.UUID `json:"id"`
+	URI string `json:"uri"`
+	URL string `json:"url"`
+	Content string `json:"content"`
+	Author RemoteActor `json:"author"`
+	PublishedAt time.Time `json:"published_at"`
+}
+
+type RemoteActor struct {
+	ID uuid.UUID `json:"id"`
+	URI string `json:"uri"`
+	Account string `json:"account"`
+	DisplayName string `json:"display_name"`
+	Summary string `json:"summary"`
+	URL string `json:"url"`
+}
+
+// RemoteFollow is a follow of an account on another server. It stays
+// pending until that server accepts it.
+type RemoteFollow struct {
+	Actor RemoteActor `json:"actor"`
+	Accepted bool `json:"accepted"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Notification struct {
+	ID uuid.UUID `json:"id"`
+	Type string `json:"type"`
+	ChirpID *uuid.UUID `json:"chirp_id"`
+	Detail string `json:"detail,omitempty"`
+	Actors []UserSummary `json:"actors"`
+	ActorCount int64 `json:"actor_count"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Read bool `json:"read"`
+}
+
+type Conversation struct {
+	ID uuid.UUID `json:"id"`
+	IsGroup bool `json:"is_group"`
+	Title string `json:"title"`
+	Members []UserSummary `json:"members"`
+	LastMessage *Message `json:"last_message"`
+	UnreadCount int64 `json:"unread_count"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type Message struct {
+	ID uuid.UUID `json:"id"`
+	ConversationID uuid.UUID `json:"conversation_id"`
+	SenderID uuid.UUID `json:"sender_id"`
+	Body string `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+	Deleted bool `json:"deleted"`
+}
+
+// DM policies.
+const (
+	DMPolicyEveryone = "everyone"
+	DMPolicyFollowing = "following"
+	DMPolicyNobody = "nobody"
+)
+
+// WebhookEndpoint is a URL events are delivered to. Secret is only set
+// when the endpoint is created.
+type WebhookEndpoint struct {
+	ID uuid.UUID `json:"id"`
+	URL string `json:"url"`
+	Events []string `json:"events"`
+	Active bool `json:"active"`
+	Secret string `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Webhook delivery statuses.
+const (
+	WebhookPending = "pending"
+	WebhookFailed = "failed"
+	WebhookSucceeded = "succeeded"
+	WebhookDead = "dead"
+)
+
+type WebhookDelivery struct {
+	ID uuid.UUID `json:"id"`
+	EventID uuid.UUID `json:"event_id"`
+	EventType string `json:"event_type"`
+	Status string `json:"status"`
+	Attempts int32 `json:"attempts"`
+	NextAttemptAt *time.Time `json:"next_attempt_at"`
+	LastStatusCode *int32 `json:"last_status_code"`
+	LastError string `json:"last_error,omitempty"`
+	Payload json.RawMessage `json:"payload"`
+	AttemptLog []WebhookAttempt `json:"attempt_log"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type WebhookAttempt struct {
+	AttemptedAt time.Time `json:"attempted_at"`
+	StatusCode *int32 `json:"status_code"`
+	Error string `json:"error,omitempty"`
+	DurationMs int32 `json:"duration_ms"`
+}
+
+type ModerationRule struct {
+	ID uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Word string `json:"word"`
+	Action string `json:"action"`
+}
+
+// ChirpFlag is a chirp held for review by a moderation rule.
+type ChirpFlag struct {
+	ChirpID uuid.UUID `json:"chirp_id"`
+	CreatedAt time.Time `json:"created_at"`
+	Words []string `json:"words"`
+	Body string `json:"body"`
+	UserID uuid.UUID `json:"user_id"`
+}
@@ -0,0 +1,176 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/http"
+	"net/url"
+
+	"github.com/google/uuid"
+)
+
+// Healthz reports whether the server is up.
+func (client *Client) Healthz(ctx context.Context) error {
+	return client.do(ctx, "GET", "/api/healthz", authNone, nil, nil)
+}
+
+// CreateUser signs up a new account. handle may be empty, in which case the
+// server picks one.
+func (client *Client) CreateUser(ctx context.Context, email, password, handle string) (User, error) {
+	type parameters struct {
+		Email string `json:"email"`
+		Password string `json:"password"`
+		Handle *string `json:"handle,omitempty"`
+	}
+	params := parameters{Email: email, Password: password}
+	if handle != "" {
+		params.Handle = &handle
+	}
+	var user User
+	err := client.do(ctx, "POST", "/api/users", authNone, params, &user)
+	return user, err
+}
+
+// Login signs in and keeps the tokens for later requests.
+func (client *Client) Login(ctx context.Context, email, password string) (Session, error) {
+	type parameters struct {
+		Email string `json:"email"`
+		Password string `json:"password"`
+	}
+	var session Session
+	if err := client.do(ctx, "POST", "/api/login", authNone, parameters{Email: email, Password: password}, &session); err != nil {
+		return Session{}, err
+	}
+	client.SetTokens(session.Token, session.RefreshToken)
+	return session, nil
+}
+
+// Revoke revokes the refresh token and forgets both tokens.
+func (client *Client) Revoke(ctx context.Context) error {
+	_, refreshToken := client.Tokens()
+	response, err := client.sendOnce(ctx, request{
+		method: http.MethodPost,
+		path: "/api/revoke",
+		auth: authUser,
+	}, refreshToken)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusNoContent {
+		return newError(response)
+	}
+	client.SetTokens("", "")
+	return nil
+}
+
+// UpdateUser changes the signed-in user's email and password, and their
+// handle too unless it is empty.
+func (client *Client) UpdateUser(ctx context.Context, email, password, handle string) (User, error) {
+	type parameters struct {
+		Email string `json:"email"`
+		Password string `json:"password"`
+		Handle *string `json:"handle,omitempty"`
+	}
+	params := parameters{Email: email, Password: password}
+	if handle != "" {
+		params.Handle = &handle
+	}
+	var user User
+	err := client.do(ctx, "PUT", "/api/users", authUser, params, &user)
+	return user, err
+}
+
+// ProfileUpdate changes the fields that are set and leaves the rest alone.
+type ProfileUpdate struct {
+	Handle *string `json:"handle,omitempty"`
+	DisplayName *string `json:"display_name,omitempty"`
+	Bio *string `json:"bio,omitempty"`
+	AvatarURL *string `json:"avatar_url,omitempty"`
+	Location *string `json:"location,omitempty"`
+}
+
+func (client *Client) UpdateProfile(ctx context.Context, update ProfileUpdate) (User, error) {
+	var user User
+	err := client.do(ctx, "PUT", "/api/users/profile", authUser, update, &user)
+	return user, err
+}
+
+func (client *Client) Profile(ctx context.Context, handle string) (Profile, error) {
+	var profile Profile
+	err := client.do(ctx, "GET", "/api/users/"+url.PathEscape(handle), authUser, nil, &profile)
+	return profile, err
+}
+
+func (client *Client) Follow(ctx context.Context, handle string) error {
+	return client.do(ctx, "POST", "/api/users/"+url.PathEscape(handle)+"/follow", authUser, nil, nil)
+}
+
+func (client *Client) Unfollow(ctx context.Context, handle string) error {
+	return client.do(ctx, "DELETE", "/api/users/"+url.PathEscape(handle)+"/follow", authUser, nil, nil)
+}
+
+func (client *Client) Block(ctx context.Context, handle string) error {
+	return client.do(ctx, "POST", "/api/users/"+url.PathEscape(handle)+"/block", authUser, nil, nil)
+}
+
+func (client *Client) Unblock(ctx context.Context, handle string) error {
+	return client.do(ctx, "DELETE", "/api/users/"+url.PathEscape(handle)+"/block", authUser, nil, nil)
+}
+
+func (client *Client) Mute(ctx context.Context, handle string) error {
+	return client.do(ctx, "POST", "/api/users/"+url.PathEscape(handle)+"/mute", authUser, nil, nil)
+}
+
+func (client *Client) Unmute(ctx context.Context, handle string) error {
+	return client.do(ctx, "DELETE", "/api/users/"+url.PathEscape(handle)+"/mute", authUser, nil, nil)
+}
+
+func (client *Client) Blocks(ctx context.Context) ([]UserSummary, error) {
+	var users []UserSummary
+	err := client.do(ctx, "GET", "/api/blocks", authUser, nil, &users)
+	return users, err
+}
+
+func (client *Client) Mutes(ctx context.Context) ([]UserSummary, error) {
+	var users []UserSummary
+	err := client.do(ctx, "GET", "/api/mutes", authUser, nil, &users)
+	return users, err
+}
+
+func (client *Client) MutedWords(ctx context.Context) ([]MutedWord, error) {
+	var words []MutedWord
+	err := client.do(ctx, "GET", "/api/muted_words", authUser, nil, &words)
+	return words, err
+}
+
+// MuteWord hides chirps containing word. expiresIn is in seconds; zero
+// mutes it for good.
+func (client *Client) MuteWord(ctx context.Context, word string, expiresIn int) (MutedWord, error) {
+	type parameters struct {
+		Word string `json:"word"`
+		ExpiresIn *int `json:"expires_in,omitempty"`
+	}
+	params := parameters{Word: word}
+	if expiresIn > 0 {
+		params.ExpiresIn = &expiresIn
+	}
+	var muted MutedWord
+	err := client.do(ctx, "POST", "/api/muted_words", authUser, params, &muted)
+	return muted, err
+}
+
+func (client *Client) UnmuteWord(ctx context.Context, id uuid.UUID) error {
+	return client.do(ctx, "DELETE", "/api/muted_words/"+id.String(), authUser, nil, nil)
+}
+
+// Mentions lists chirps that mention the user.
+func (client *Client) Mentions(ctx context.Context, userID uuid.UUID, params PageParams) (Page[Chirp], error) {
+	return client.chirpPage(ctx, "/api/users/"+userID.String()+"/mentions", authUser, params.values())
+}
+
+func (client *Client) AllMentions(ctx context.Context, userID uuid.UUID, params PageParams) iter.Seq2[Chirp, error] {
+	return paginate(ctx, params, func(ctx context.Context, params PageParams) (Page[Chirp], error) {
+		return client.Mentions(ctx, userID, params)
+	})
+}
@@ -0,0 +1,83 @@
+package client
+
+import (
+	"context"
+	"iter"
+
+	"github.com/google/uuid"
+)
+
+// CreateWebhook registers url to receive the given event types. The
+// returned endpoint's Secret signs every delivery and is not shown again.
+func (client *Client) CreateWebhook(ctx context.Context, url string, events []string) (WebhookEndpoint, error) {
+	type parameters struct {
+		URL string `json:"url"`
+		Events []string `json:"events"`
+	}
+	var endpoint WebhookEndpoint
+	err := client.do(ctx, "POST", "/api/webhooks", authUser, parameters{URL: url, Events: events}, &endpoint)
+	return endpoint, err
+}
+
+func (client *Client) Webhooks(ctx context.Context) ([]WebhookEndpoint, error) {
+	var endpoints []WebhookEndpoint
+	err := client.do(ctx, "GET", "/api/webhooks", authUser, nil, &endpoints)
+	return endpoints, err
+}
+
+func (client *Client) UpdateWebhook(ctx context.Context, id uuid.UUID, url string, events []string, active bool) (WebhookEndpoint, error) {
+	type parameters struct {
+		URL string `json:"url"`
+		Events []string `json:"events"`
+		Active bool `json:"active"`
+	}
+	var endpoint WebhookEndpoint
+	err := client.do(ctx, "PUT", "/api/webhooks/"+id.String(), authUser, parameters{URL: url, Events: events, Active: active}, &endpoint)
+	return endpoint, err
+}
+
+func (client *Client) DeleteWebhook(ctx context.Context, id uuid.UUID) error {
+	return client.do(ctx, "DELETE", "/api/webhooks/"+id.String(), authUser, nil, nil)
+}
+
+// DeliveryQuery lists an endpoint's deliveries, newest first, optionally
+// only those with the given status.
+type DeliveryQuery struct {
+	PageParams
+	Status string
+}
+
+func (client *Client) WebhookDeliveries(ctx context.Context, webhookID uuid.UUID, query DeliveryQuery) (Page[WebhookDelivery], error) {
+	values := query.PageParams.values()
+	if query.Status != "" {
+		values.Set("status", query.Status)
+	}
+	var result struct {
+		Deliveries []WebhookDelivery `json:"deliveries"`
+		NextCursor *string `json:"next_cursor"`
+	}
+	err := client.call(ctx, request{
+		method: "GET",
+		path: "/api/webhooks/" + webhookID.String() + "/deliveries",
+		query: values,
+		auth: authUser,
+	}, &result)
+	if err != nil {
+		return Page[WebhookDelivery]{}, err
+	}
+	return Page[WebhookDelivery]{Items: result.Deliveries, NextCursor: derefCursor(result.NextCursor)}, nil
+}
+
+func (client *Client) AllWebhookDeliveries(ctx context.Context, webhookID uuid.UUID, query DeliveryQuery) iter.Seq2[WebhookDelivery, error] {
+	return paginate(ctx, query.PageParams, func(ctx context.Context, params PageParams) (Page[WebhookDelivery], error) {
+		query.PageParams = params
+		return client.WebhookDeliveries(ctx, webhookID, query)
+	})
+}
+
+// Redeliver queues a delivery to be sent again.
+func (client *Client) Redeliver(ctx context.Context, webhookID, deliveryID uuid.UUID) (WebhookDelivery, error) {
+	var delivery WebhookDelivery
+	err := client.do(ctx, "POST", "/api/webhooks/"+webhookID.String()+"/deliveries/"+deliveryID.String()+"/redeliver", authUser, nil, &delivery)
+	return delivery, err
+}
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Baehry/chirpy/client"
+	"github.com/Baehry/chirpy/internal/activitypub"
+	"github.com/Baehry/chirpy/internal/auth"
+	"github.com/Baehry/chirpy/internal/database"
+	"github.com/Baehry/chirpy/internal/gateway"
+	"github.com/Baehry/chirpy/internal/moderation"
+	"github.com/Baehry/chirpy/internal/storage"
+	"github.com/Baehry/chirpy/internal/stream"
+	"github.com/google/uuid"
+)
+
+const (
+	testSecret = "test-secret"
+	testAdminKey = "test-admin-key"
+)
+
+// newTestServer serves the real handlers on top of the database at dbURL.
+func newTestServer(t *testing.T, dbURL string) *httptest.Server {
+	db, err := sql.Open("postgres", dbURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	blobStore, err := storage.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &apiConfig{
+		db: db,
+		dbQueries: database.New(db),
+		platform: "dev",
+		tokenSecret: testSecret,
+		adminKey: testAdminKey,
+		pinLimit: defaultPinLimit,
+		pinLimitRed: defaultPinLimitRed,
+		blobStore: blobStore,
+		hub: stream.NewHub(),
+		gateway: gateway.NewRegistry(),
+		draining: make(chan struct{}),
+		federation: &activitypub.Client{HTTP: federationHTTPClient},
+	}
+	cfg.moderationRules.Set(moderation.NewWordFilter(nil))
+	cfg.contentFilter = moderation.Pipeline{&cfg.moderationRules}
+	cfg.graphqlSchema = newGraphQLSchema(cfg)
+	server := httptest.NewServer(cfg.routes())
+	t.Cleanup(server.Close)
+	return server
+}
+
+// countingTransport counts requests by path.
+type countingTransport struct {
+	mu sync.Mutex
+	paths map[string]int
+}
+
+func (transport *countingTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	transport.mu.Lock()
+	transport.paths[request.URL.Path]++
+	transport.mu.Unlock()
+	return http.DefaultTransport.RoundTrip(request)
+}
+
+func (transport *countingTransport) count(path string) int {
+	transport.mu.Lock()
+	defer transport.mu.Unlock()
+	return transport.paths[path]
+}
+
+// TestClientErrors checks the client against handlers that answer before
+// touching the database, so it runs without one.
+func TestClientErrors(t *testing.T) {
+	server := newTestServer(t, "postgres://chirpy@127.0.0.1:1/chirpy?sslmode=disable")
+	ctx := context.Background()
+	anonymous, _ := client.New(server.URL)
+	admin, _ := client.New(server.URL, client.WithAdminKey(testAdminKey))
+	wrongKey, _ := client.New(server.URL, client.WithAdminKey("wrong"))
+	if err := anonymous.Healthz(ctx); err != nil {
+		t.Logf("healthz: unexpected error %v\n", err)
+		t.Fail()
+	}
+	cases := []struct {
+		name string
+		call func() error
+		target error
+	}{
+		{"post without a token", func() error {
+			_, err := anonymous.CreateChirp(ctx, client.NewChirp{Body: "hi"})
+			return err
+		}, client.ErrUnauthorized},
+		{"admin with the wrong key", func() error {
+			_, err := wrongKey.ModerationRules(ctx)
+			return err
+		}, client.ErrUnauthorized},
+		{"pinned_first without author", func() error {
+			_, err := anonymous.Chirps(ctx, client.ChirpQuery{PinnedFirst: true})
+			return err
+		}, client.ErrBadRequest},
+		{"bad cursor", func() error {
+			_, err := anonymous.Chirps(ctx, client.ChirpQuery{PageParams: client.PageParams{Cursor: "bogus"}})
+			return err
+		}, client.ErrBadRequest},
+		{"database down", func() error {
+			_, err := admin.ModerationRules(ctx)
+			return err
+		}, client.ErrServer},
+	}
+	for _, c := range cases {
+		if err := c.call(); !errors.Is(err, c.target) {
+			t.Logf("%s: expected %v, got %v\n", c.name, c.target, err)
+			t.Fail()
+		}
+	}
+}
+
+// TestClientRefreshesExpiredToken checks that an expired access token sends
+// the client to /api/refresh before giving up.
+func TestClientRefreshesExpiredToken(t *testing.T) {
+	server := newTestServer(t, "postgres://chirpy@127.0.0.1:1/chirpy?sslmode=disable")
+	expired, err := auth.MakeJWT(uuid.New(), testSecret, -time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transport := &countingTransport{paths: map[string]int{}}
+	c, _ := client.New(server.URL,
+		client.WithHTTPClient(&http.Client{Transport: transport}),
+		client.WithTokens(expired, "not-a-refresh-token"),
+	)
+	// The refresh token isn't in the database, so the refresh fails and the
+	// original 401 comes back.
+	_, err = c.Drafts(context.Background(), client.PageParams{})
+	if !errors.Is(err, client.ErrUnauthorized) {
+		t.Logf("expected ErrUnauthorized, got %v\n", err)
+		t.Fail()
+	}
+	if transport.count("/api/drafts") != 1 || transport.count("/api/refresh") != 1 {
+		t.Logf("expected one drafts request and one refresh, got %v\n", transport.paths)
+		t.Fail()
+	}
+}
+
+// TestClient runs the client through the real handlers and a real
+// database. TEST_DB_URL must point at a migrated database that the test
+// may wipe.
+func TestClient(t *testing.T) {
+	dbURL := os.Getenv("TEST_DB_URL")
+	if dbURL == "" {
+		t.Skip("TEST_DB_URL not set")
+	}
+	server := newTestServer(t, dbURL)
+	ctx := context.Background()
+	c, _ := client.New(server.URL)
+	if err := c.Reset(ctx); err != nil {
+		t.Fatal(err)
+	}
+	user, err := c.CreateUser(ctx, "sdk@example.com", "hunter2", "sdk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.CreateUser(ctx, "sdk@example.com", "hunter2", "sdk2"); !errors.Is(err, client.ErrConflict) {
+		t.Logf("taken email: expected ErrConflict, got %v\n", err)
+		t.Fail()
+	}
+	if _, err := c.CreateUser(ctx, "sdk2@example.com", "hunter2", "SDK"); !errors.Is(err, client.ErrConflict) {
+		t.Logf("taken handle: expected ErrConflict, got %v\n", err)
+		t.Fail()
+	}
+	if _, err := c.CreateUser(ctx, "sdk2@example.com", "hunter2", "not a handle"); !errors.Is(err, client.ErrBadRequest) {
+		t.Logf("invalid handle: expected ErrBadRequest, got %v\n", err)
+		t.Fail()
+	}
+	if _, err := c.Login(ctx, "sdk@example.com", "wrong"); !errors.Is(err, client.ErrUnauthorized) {
+		t.Logf("wrong password: expected ErrUnauthorized, got %v\n", err)
+		t.Fail()
+	}
+	if _, err := c.Login(ctx, "sdk@example.com", "hunter2"); err != nil {
+		t.Fatal(err)
+	}
+
+	bodies := []string{"one #sdk", "two #sdk", "three #sdk", "four #sdk", "five #sdk"}
+	for _, body := range bodies {
+		if _, err := c.CreateChirp(ctx, client.NewChirp{Body: body}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var got []string
+	for chirp, err := range c.AllChirps(ctx, client.ChirpQuery{PageParams: client.PageParams{Limit: 2}, AuthorID: user.ID}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, chirp.Body)
+	}
+	if len(got) != len(bodies) {
+		t.Logf("expected %d chirps across pages, got %v\n", len(bodies), got)
+		t.Fail()
+	}
+	for i := range got {
+		if i < len(bodies) && got[i] != bodies[i] {
+			t.Logf("expected chirps oldest first, got %v\n", got)
+			t.Fail()
+			break
+		}
+	}
+	var tagged int
+	for _, err := range c.AllHashtagChirps(ctx, "#sdk", client.PageParams{Limit: 3}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		tagged++
+	}
+	if tagged != len(bodies) {
+		t.Logf("expected %d chirps tagged #sdk, got %d\n", len(bodies), tagged)
+		t.Fail()
+	}
+	if _, err := c.Chirp(ctx, uuid.New()); !errors.Is(err, client.ErrNotFound) {
+		t.Logf("missing chirp: expected ErrNotFound, got %v\n", err)
+		t.Fail()
+	}
+
+	var picture bytes.Buffer
+	png.Encode(&picture, image.NewGray(image.Rect(0, 0, 8, 8)))
+	upload, err := c.UploadMedia(ctx, "dot.png", &picture, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, _, err := c.MediaFile(ctx, upload.ID)
+	if err != nil {
+		t.Logf("own unattached upload: expected the file, got %v\n", err)
+		t.Fail()
+	} else {
+		file.Close()
+	}
+	anonymous, _ := client.New(server.URL)
+	if _, _, err := anonymous.MediaFile(ctx, upload.ID); !errors.Is(err, client.ErrNotFound) {
+		t.Logf("someone else's unattached upload: expected ErrNotFound, got %v\n", err)
+		t.Fail()
+	}
+
+	// Swap in an expired access token; the next call should refresh it.
+	expired, _ := auth.MakeJWT(user.ID, testSecret, -time.Minute)
+	_, refreshToken := c.Tokens()
+	c.SetTokens(expired, refreshToken)
+	if _, err := c.Drafts(ctx, client.PageParams{}); err != nil {
+		t.Logf("expected the expired token to be refreshed, got %v\n", err)
+		t.Fail()
+	}
+	if access, _ := c.Tokens(); access == expired {
+		t.Log("expected a new access token after the refresh")
+		t.Fail()
+	}
+
+	if err := c.Revoke(ctx); err != nil {
+		t.Fatal(err)
+	}
+	c.SetTokens(expired, refreshToken)
+	if _, err := c.Drafts(ctx, client.PageParams{}); !errors.Is(err, client.ErrUnauthorized) {
+		t.Logf("revoked refresh token: expected ErrUnauthorized, got %v\n", err)
+		t.Fail()
+	}
+}
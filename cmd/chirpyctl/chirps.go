@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Baehry/chirpy/client"
+	"github.com/google/uuid"
+)
+
+// chirpsDelete goes through the admin API rather than the database so that
+// webhooks fire and remote servers hear about the deletion.
+func chirpsDelete(ctx context.Context, app *app, args []string) error {
+	positional, err := app.parse(app.flagSet(), args, 1)
+	if err != nil {
+		return err
+	}
+	id, err := uuid.Parse(positional[0])
+	if err != nil {
+		return fmt.Errorf("invalid chirp ID %q", positional[0])
+	}
+	api, err := app.client()
+	if err != nil {
+		return err
+	}
+	chirp, err := api.AdminChirp(ctx, id)
+	if errors.Is(err, client.ErrUnauthorized) {
+		return errors.New("the server refused the admin key; set ADMIN_KEY or pass -admin-key")
+	}
+	if err != nil {
+		return err
+	}
+	if chirp.DeletedAt != nil {
+		return fmt.Errorf("chirp %s is already deleted", id)
+	}
+	if app.dryRun {
+		return app.printDryRun("delete-chirp", chirp, "delete chirp %s by %s: %q", chirp.ID, chirp.UserID, chirp.Body)
+	}
+	if err := api.AdminDeleteChirp(ctx, id); err != nil {
+		return err
+	}
+	chirp, err = api.AdminChirp(ctx, id)
+	if err != nil {
+		return err
+	}
+	return app.print(chirp, "deleted chirp %s by %s", chirp.ID, chirp.UserID)
+}
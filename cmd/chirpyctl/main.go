@@ -0,0 +1,235 @@
+// Command chirpyctl is an operations tool for Chirpy.
+//
+// Account and session commands talk to the database directly; deleting
+// chirps and seeding go through the HTTP API so the server's side effects
+// (webhooks, federation, hashtag indexing) still happen. Every command can
+// print JSON with -json, and commands that destroy or overwrite data accept
+// -dry-run to show what they would do.
+//
+//	chirpyctl users create -email a@example.com -password hunter2
+//	chirpyctl users set-password @alice -dry-run
+//	chirpyctl tokens revoke alice@example.com -json
+//	chirpyctl chirps delete 6c1f... -admin-key $ADMIN_KEY
+//	chirpyctl migrate status
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Baehry/chirpy/client"
+	"github.com/Baehry/chirpy/internal/database"
+	"github.com/joho/godotenv"
+	_ "github.com/lib/pq"
+)
+
+// errUsage means the command line was wrong; the usage has already been
+// printed.
+var errUsage = errors.New("usage")
+
+type command struct {
+	name string
+	args string
+	summary string
+	// destructive commands accept -dry-run.
+	destructive bool
+	run func(ctx context.Context, app *app, args []string) error
+}
+
+var commands = []command{
+	{"users create", "-email EMAIL -password PASSWORD [-handle HANDLE]", "create a user", false, usersCreate},
+	{"users show", "USER", "show a user", false, usersShow},
+	{"users set-password", "USER [-password PASSWORD] [-keep-sessions]", "reset a user's password", true, usersSetPassword},
+	{"users grant-red", "USER", "give a user Chirpy Red", false, usersGrantRed},
+	{"users revoke-red", "USER", "take Chirpy Red away from a user", true, usersRevokeRed},
+	{"tokens list", "USER [-all]", "list a user's refresh tokens", false, tokensList},
+	{"tokens revoke", "USER [-token PREFIX]", "revoke a user's refresh tokens", true, tokensRevoke},
+	{"chirps delete", "CHIRP_ID", "move a chirp to the trash", true, chirpsDelete},
+	{"migrate up", "[-dir DIR]", "apply pending migrations", true, migrateUp},
+	{"migrate down", "[-dir DIR]", "roll back the latest migration", true, migrateDown},
+	{"migrate status", "[-dir DIR]", "list migrations and whether they are applied", false, migrateStatus},
+	{"seed", "[-users N] [-chirps N] [-prefix PREFIX] [-password PASSWORD]", "fill a server with sample users and chirps", false, seed},
+}
+
+// app holds the global options and the connections commands share.
+type app struct {
+	dbURL string
+	serverURL string
+	adminKey string
+	json bool
+	dryRun bool
+	stdout io.Writer
+	stderr io.Writer
+
+	db *sql.DB
+	// current is the command being run.
+	current command
+}
+
+func newApp(stdout, stderr io.Writer) *app {
+	return &app{
+		dbURL: os.Getenv("DB_URL"),
+		serverURL: envOr("CHIRPY_URL", "http://localhost:8080"),
+		adminKey: os.Getenv("ADMIN_KEY"),
+		stdout: stdout,
+		stderr: stderr,
+	}
+}
+
+func main() {
+	godotenv.Load()
+	app := newApp(os.Stdout, os.Stderr)
+	err := app.run(context.Background(), os.Args[1:])
+	if app.db != nil {
+		app.db.Close()
+	}
+	if errors.Is(err, errUsage) {
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "chirpyctl: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func (app *app) run(ctx context.Context, args []string) error {
+	global := flag.NewFlagSet("chirpyctl", flag.ContinueOnError)
+	global.SetOutput(app.stderr)
+	app.globalFlags(global)
+	global.BoolVar(&app.dryRun, "dry-run", false, "show what would change without changing it")
+	global.Usage = app.usage
+	if err := global.Parse(args); err != nil {
+		return errUsage
+	}
+	args = global.Args()
+	for _, cmd := range commands {
+		words := strings.Fields(cmd.name)
+		if len(args) < len(words) || strings.Join(args[:len(words)], " ") != cmd.name {
+			continue
+		}
+		if app.dryRun && !cmd.destructive {
+			return fmt.Errorf("%s doesn't take -dry-run", cmd.name)
+		}
+		app.current = cmd
+		return cmd.run(ctx, app, args[len(words):])
+	}
+	app.usage()
+	return errUsage
+}
+
+func (app *app) globalFlags(flags *flag.FlagSet) {
+	flags.Var(secretFlag{&app.dbURL}, "db", "database `URL` (default $DB_URL)")
+	flags.StringVar(&app.serverURL, "server", app.serverURL, "server `URL` for commands that use the API; $CHIRPY_URL if set")
+	flags.Var(secretFlag{&app.adminKey}, "admin-key", "admin API `key` (default $ADMIN_KEY)")
+	flags.BoolVar(&app.json, "json", app.json, "print JSON")
+}
+
+func (app *app) usage() {
+	fmt.Fprintf(app.stderr, "usage: chirpyctl [-db URL] [-server URL] [-admin-key KEY] [-json] [-dry-run] COMMAND\n\ncommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(app.stderr, "  %s %s\n    \t%s", cmd.name, cmd.args, cmd.summary)
+		if cmd.destructive {
+			fmt.Fprintf(app.stderr, " (accepts -dry-run)")
+		}
+		fmt.Fprintln(app.stderr)
+	}
+}
+
+func (app *app) flagSet() *flag.FlagSet {
+	return flag.NewFlagSet("chirpyctl "+app.current.name, flag.ContinueOnError)
+}
+
+// parse parses a command's flags, which may come before, after or between
+// its positional arguments, and checks it got want positional arguments.
+func (app *app) parse(flags *flag.FlagSet, args []string, want int) ([]string, error) {
+	flags.SetOutput(app.stderr)
+	app.globalFlags(flags)
+	if app.current.destructive {
+		flags.BoolVar(&app.dryRun, "dry-run", app.dryRun, "show what would change without changing it")
+	}
+	var positional []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, errUsage
+		}
+		if flags.NArg() == 0 {
+			break
+		}
+		positional = append(positional, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+	if len(positional) != want {
+		fmt.Fprintf(app.stderr, "%s takes %d argument(s), got %d\n", flags.Name(), want, len(positional))
+		return nil, errUsage
+	}
+	return positional, nil
+}
+
+func (app *app) queries() (*database.Queries, error) {
+	if app.db == nil {
+		if app.dbURL == "" {
+			return nil, errors.New("no database: set DB_URL or pass -db")
+		}
+		db, err := sql.Open("postgres", app.dbURL)
+		if err != nil {
+			return nil, err
+		}
+		app.db = db
+	}
+	return database.New(app.db), nil
+}
+
+func (app *app) client() (*client.Client, error) {
+	return client.New(app.serverURL, client.WithAdminKey(app.adminKey))
+}
+
+// print writes v as JSON with -json, and text otherwise.
+func (app *app) print(v any, text string, args ...any) error {
+	if app.json {
+		encoder := json.NewEncoder(app.stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(v)
+	}
+	_, err := fmt.Fprintf(app.stdout, text+"\n", args...)
+	return err
+}
+
+// dryRunResult is printed instead of the usual result by -dry-run.
+type dryRunResult struct {
+	DryRun bool `json:"dry_run"`
+	Action string `json:"action"`
+	Target any `json:"target"`
+}
+
+func (app *app) printDryRun(action string, target any, text string, args ...any) error {
+	return app.print(dryRunResult{DryRun: true, Action: action, Target: target}, "dry run: would "+text, args...)
+}
+
+// secretFlag is a string flag whose value, which may hold a password, is
+// never printed as its default.
+type secretFlag struct {
+	value *string
+}
+
+func (flag secretFlag) String() string {
+	return ""
+}
+
+func (flag secretFlag) Set(value string) error {
+	*flag.value = value
+	return nil
+}
+
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Baehry/chirpy/client"
+	"github.com/google/uuid"
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		name string
+		args []string
+		want int
+		positional []string
+		json bool
+		dryRun bool
+		ok bool
+	}{
+		{"flags after", []string{"alice", "-json", "-dry-run"}, 1, []string{"alice"}, true, true, true},
+		{"flags before", []string{"-json", "alice"}, 1, []string{"alice"}, true, false, true},
+		{"flags between", []string{"a", "-dry-run", "b"}, 2, []string{"a", "b"}, false, true, true},
+		{"too many", []string{"alice", "bob"}, 1, nil, false, false, false},
+		{"too few", []string{"-json"}, 1, nil, true, false, false},
+		{"unknown flag", []string{"alice", "-force"}, 1, nil, false, false, false},
+	}
+	for _, c := range cases {
+		app := newApp(io.Discard, io.Discard)
+		app.current = command{name: "test", destructive: true}
+		positional, err := app.parse(app.flagSet(), c.args, c.want)
+		if (err == nil) != c.ok {
+			t.Logf("%s: expected ok=%v, got %v\n", c.name, c.ok, err)
+			t.Fail()
+			continue
+		}
+		if !c.ok {
+			continue
+		}
+		if strings.Join(positional, " ") != strings.Join(c.positional, " ") || app.json != c.json || app.dryRun != c.dryRun {
+			t.Logf("%s: expected %v json=%v dry-run=%v, got %v json=%v dry-run=%v\n", c.name, c.positional, c.json, c.dryRun, positional, app.json, app.dryRun)
+			t.Fail()
+		}
+	}
+}
+
+func TestRunRejectsDryRun(t *testing.T) {
+	cases := []struct {
+		args []string
+	}{
+		{[]string{"-dry-run", "users", "show", "alice"}},
+		{[]string{"users", "grant-red", "alice", "-dry-run"}},
+		{[]string{"seed", "-dry-run"}},
+	}
+	for _, c := range cases {
+		app := newApp(io.Discard, io.Discard)
+		if err := app.run(context.Background(), c.args); err == nil {
+			t.Logf("%v: expected -dry-run to be refused\n", c.args)
+			t.Fail()
+		}
+		if app.db != nil {
+			t.Logf("%v: expected no database connection\n", c.args)
+			t.Fail()
+		}
+	}
+}
+
+func TestRunUnknownCommand(t *testing.T) {
+	var stderr bytes.Buffer
+	app := newApp(io.Discard, &stderr)
+	err := app.run(context.Background(), []string{"users", "frobnicate"})
+	if !errors.Is(err, errUsage) || !strings.Contains(stderr.String(), "users set-password") {
+		t.Logf("expected usage, got %v: %s\n", err, stderr.String())
+		t.Fail()
+	}
+}
+
+// adminServer fakes the two admin endpoints chirps delete uses.
+type adminServer struct {
+	mu sync.Mutex
+	chirp client.Chirp
+	deletes int
+}
+
+func (s *adminServer) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if request.Header.Get("Authorization") != "ApiKey key" {
+		writer.WriteHeader(401)
+		writer.Write([]byte(`{"error":"admin key required"}`))
+		return
+	}
+	if request.URL.Path != "/admin/chirps/"+s.chirp.ID.String() {
+		writer.WriteHeader(404)
+		writer.Write([]byte(`{"error":"chirp not found"}`))
+		return
+	}
+	switch request.Method {
+	case "GET":
+		json.NewEncoder(writer).Encode(s.chirp)
+	case "DELETE":
+		s.deletes++
+		now := time.Now()
+		s.chirp.DeletedAt = &now
+		writer.WriteHeader(204)
+	}
+}
+
+func TestChirpsDelete(t *testing.T) {
+	cases := []struct {
+		name string
+		args []string
+		deletes int
+		dryRun bool
+		ok bool
+	}{
+		{"dry run", []string{"-dry-run"}, 0, true, true},
+		{"delete", nil, 1, false, true},
+		{"wrong key", []string{"-admin-key", "nope"}, 0, false, false},
+		{"already deleted", nil, 0, false, false},
+	}
+	fake := &adminServer{chirp: client.Chirp{ID: uuid.New(), UserID: uuid.New(), Body: "spam"}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	for _, c := range cases {
+		fake.deletes = 0
+		var stdout bytes.Buffer
+		app := newApp(&stdout, io.Discard)
+		args := append([]string{"-server", server.URL, "-admin-key", "key", "-json", "chirps", "delete", fake.chirp.ID.String()}, c.args...)
+		err := app.run(context.Background(), args)
+		if (err == nil) != c.ok {
+			t.Logf("%s: expected ok=%v, got %v\n", c.name, c.ok, err)
+			t.Fail()
+			continue
+		}
+		if fake.deletes != c.deletes {
+			t.Logf("%s: expected %d deletes, got %d\n", c.name, c.deletes, fake.deletes)
+			t.Fail()
+		}
+		if !c.ok {
+			continue
+		}
+		var out map[string]any
+		if err := json.Unmarshal(stdout.Bytes(), &out); err != nil {
+			t.Logf("%s: expected JSON output, got %q\n", c.name, stdout.String())
+			t.Fail()
+			continue
+		}
+		if _, isDryRun := out["dry_run"]; isDryRun != c.dryRun {
+			t.Logf("%s: expected dry_run=%v in %v\n", c.name, c.dryRun, out)
+			t.Fail()
+		}
+	}
+}
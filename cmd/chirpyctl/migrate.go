@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Baehry/chirpy/internal/database"
+	"github.com/Baehry/chirpy/internal/migrations"
+	"github.com/pressly/goose/v3"
+)
+
+type migrationResponse struct {
+	Version int64 `json:"version"`
+	File string `json:"file"`
+	State string `json:"state,omitempty"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+	DurationMs int64 `json:"duration_ms,omitempty"`
+}
+
+// migrations opens a goose provider for the migrations built into
+// chirpyctl, or for the ones in the -dir flag when it is set.
+func (app *app) migrations(args []string) (*goose.Provider, error) {
+	flags := app.flagSet()
+	dir := flags.String("dir", "", "directory holding the migrations (default: the ones built in)")
+	if _, err := app.parse(flags, args, 0); err != nil {
+		return nil, err
+	}
+	if _, err := app.queries(); err != nil {
+		return nil, err
+	}
+	if *dir != "" {
+		return migrations.NewFS(app.db, os.DirFS(*dir))
+	}
+	return migrations.New(app.db)
+}
+
+func pendingMigrations(ctx context.Context, provider *goose.Provider) ([]migrationResponse, error) {
+	statuses, err := provider.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pending := []migrationResponse{}
+	for _, status := range statuses {
+		if status.State == goose.StatePending {
+			pending = append(pending, migrationResponse{Version: status.Source.Version, File: filepath.Base(status.Source.Path)})
+		}
+	}
+	return pending, nil
+}
+
+func migrationResponses(results []*goose.MigrationResult) ([]migrationResponse, string) {
+	responses := make([]migrationResponse, len(results))
+	var text strings.Builder
+	for i, result := range results {
+		responses[i] = migrationResponse{
+			Version: result.Source.Version,
+			File: filepath.Base(result.Source.Path),
+			DurationMs: result.Duration.Milliseconds(),
+		}
+		fmt.Fprintf(&text, "\n  %s (%s)", responses[i].File, result.Duration.Round(time.Millisecond))
+	}
+	return responses, text.String()
+}
+
+func migrateUp(ctx context.Context, app *app, args []string) error {
+	provider, err := app.migrations(args)
+	if err != nil {
+		return err
+	}
+	if app.dryRun {
+		pending, err := pendingMigrations(ctx, provider)
+		if err != nil {
+			return err
+		}
+		var text strings.Builder
+		for _, migration := range pending {
+			fmt.Fprintf(&text, "\n  %s", migration.File)
+		}
+		return app.printDryRun("migrate-up", pending, "apply %d migration(s)%s", len(pending), text.String())
+	}
+	results, err := provider.Up(ctx)
+	if err != nil {
+		return err
+	}
+	responses, text := migrationResponses(results)
+	return app.print(responses, "applied %d migration(s)%s", len(results), text)
+}
+
+func migrateDown(ctx context.Context, app *app, args []string) error {
+	provider, err := app.migrations(args)
+	if err != nil {
+		return err
+	}
+	if app.dryRun {
+		version, err := provider.GetDBVersion(ctx)
+		if err != nil {
+			return err
+		}
+		if version == 0 {
+			return app.printDryRun("migrate-down", nil, "do nothing: no migrations are applied")
+		}
+		for _, source := range provider.ListSources() {
+			if source.Version == version {
+				migration := migrationResponse{Version: version, File: filepath.Base(source.Path)}
+				return app.printDryRun("migrate-down", migration, "roll back %s", migration.File)
+			}
+		}
+		return fmt.Errorf("the database is at version %d, which has no migration file", version)
+	}
+	result, err := provider.Down(ctx)
+	if err != nil {
+		return err
+	}
+	responses, text := migrationResponses([]*goose.MigrationResult{result})
+	return app.print(responses[0], "rolled back%s", text)
+}
+
+func migrateStatus(ctx context.Context, app *app, args []string) error {
+	provider, err := app.migrations(args)
+	if err != nil {
+		return err
+	}
+	statuses, err := provider.Status(ctx)
+	if err != nil {
+		return err
+	}
+	responses := make([]migrationResponse, len(statuses))
+	var text strings.Builder
+	for i, status := range statuses {
+		responses[i] = migrationResponse{
+			Version: status.Source.Version,
+			File: filepath.Base(status.Source.Path),
+			State: string(status.State),
+		}
+		applied := "pending"
+		if status.State == goose.StateApplied {
+			responses[i].AppliedAt = &status.AppliedAt
+			applied = status.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(&text, "\n  %-24s %s", responses[i].File, applied)
+	}
+	version, err := provider.GetDBVersion(ctx)
+	if err != nil {
+		return err
+	}
+	return app.print(responses, "database is at version %d; this build needs %d%s", version, database.SchemaVersion, text.String())
+}
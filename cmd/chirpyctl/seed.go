@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Baehry/chirpy/client"
+	"github.com/Baehry/chirpy/internal/handles"
+	"github.com/google/uuid"
+)
+
+var seedBodies = []string{
+	"Hello, Chirpy! #introductions",
+	"Anyone else up this early? #coffee",
+	"Shipping something new today #buildinpublic",
+	"@%s have you seen this? #chirpy",
+	"Reading list for the weekend #books",
+	"Rainy day, good day for #coffee and #books",
+	"Thanks @%s, that was a great thread",
+}
+
+type seedUser struct {
+	ID uuid.UUID `json:"id"`
+	Handle string `json:"handle"`
+	Email string `json:"email"`
+	Created bool `json:"created"`
+}
+
+// seed fills a server with users who follow each other and chirp about a
+// few hashtags. It goes through the API so the data looks like real use,
+// and is safe to run twice: users that exist are logged into, not
+// recreated.
+func seed(ctx context.Context, app *app, args []string) error {
+	flags := app.flagSet()
+	users := flags.Int("users", 5, "number of users")
+	chirps := flags.Int("chirps", 3, "chirps per user")
+	prefix := flags.String("prefix", "seed", "handle prefix; users are PREFIX_1, PREFIX_2, ...")
+	password := flags.String("password", "password", "password for every seeded user")
+	if _, err := app.parse(flags, args, 0); err != nil {
+		return err
+	}
+	if *users < 1 || *chirps < 0 {
+		return errors.New("-users must be at least 1 and -chirps not negative")
+	}
+	if err := handles.Validate(fmt.Sprintf("%s_%d", *prefix, *users)); err != nil {
+		return fmt.Errorf("invalid -prefix: %w", err)
+	}
+	accounts := make([]*client.Client, *users)
+	result := struct {
+		Users []seedUser `json:"users"`
+		Chirps int `json:"chirps"`
+	}{}
+	for i := range accounts {
+		api, err := app.client()
+		if err != nil {
+			return err
+		}
+		user := seedUser{
+			Handle: fmt.Sprintf("%s_%d", *prefix, i+1),
+			Email: fmt.Sprintf("%s_%d@example.com", *prefix, i+1),
+		}
+		if _, err := api.Profile(ctx, user.Handle); errors.Is(err, client.ErrNotFound) {
+			if _, err := api.CreateUser(ctx, user.Email, *password, user.Handle); err != nil {
+				return fmt.Errorf("creating @%s: %w", user.Handle, err)
+			}
+			user.Created = true
+		} else if err != nil {
+			return err
+		}
+		session, err := api.Login(ctx, user.Email, *password)
+		if err != nil {
+			return fmt.Errorf("logging in as @%s: %w", user.Handle, err)
+		}
+		user.ID = session.ID
+		accounts[i] = api
+		result.Users = append(result.Users, user)
+	}
+	for i, api := range accounts {
+		next := result.Users[(i+1)%len(accounts)]
+		if len(accounts) > 1 {
+			if err := api.Follow(ctx, next.Handle); err != nil {
+				return fmt.Errorf("@%s following @%s: %w", result.Users[i].Handle, next.Handle, err)
+			}
+		}
+		for j := range *chirps {
+			body := seedBodies[(i+j)%len(seedBodies)]
+			if strings.Contains(body, "%s") {
+				body = fmt.Sprintf(body, next.Handle)
+			}
+			if _, err := api.CreateChirp(ctx, client.NewChirp{Body: body}); err != nil {
+				return fmt.Errorf("chirping as @%s: %w", result.Users[i].Handle, err)
+			}
+			result.Chirps++
+		}
+	}
+	created := 0
+	for _, user := range result.Users {
+		if user.Created {
+			created++
+		}
+	}
+	return app.print(result, "seeded %d user(s) (%d new) and %d chirp(s) on %s", len(result.Users), created, result.Chirps, app.serverURL)
+}
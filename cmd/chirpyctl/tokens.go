@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Baehry/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// tokenPrefixLength is how much of a refresh token is shown. It is enough
+// to tell tokens apart without printing a usable credential.
+const tokenPrefixLength = 12
+
+type tokenResponse struct {
+	Prefix string `json:"prefix"`
+	UserID uuid.UUID `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+	Active bool `json:"active"`
+}
+
+func newTokenResponse(token database.RefreshToken, now time.Time) tokenResponse {
+	result := tokenResponse{
+		Prefix: token.Token[:min(len(token.Token), tokenPrefixLength)],
+		UserID: token.UserID,
+		CreatedAt: token.CreatedAt,
+		ExpiresAt: token.ExpiresAt,
+		Active: !token.RevokedAt.Valid && token.ExpiresAt.After(now),
+	}
+	if token.RevokedAt.Valid {
+		result.RevokedAt = &token.RevokedAt.Time
+	}
+	return result
+}
+
+// userTokens returns the user's refresh tokens, only the usable ones unless
+// all is set.
+func userTokens(ctx context.Context, queries *database.Queries, userID uuid.UUID, all bool) ([]database.RefreshToken, error) {
+	tokens, err := queries.GetRefreshTokensByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	result := []database.RefreshToken{}
+	for _, token := range tokens {
+		if all || newTokenResponse(token, now).Active {
+			result = append(result, token)
+		}
+	}
+	return result, nil
+}
+
+// describeTokens returns tokens as they are printed: as JSON, and as one
+// line of text each.
+func describeTokens(tokens []database.RefreshToken) ([]tokenResponse, string) {
+	now := time.Now().UTC()
+	result := make([]tokenResponse, len(tokens))
+	var text strings.Builder
+	for i, token := range tokens {
+		result[i] = newTokenResponse(token, now)
+		state := "active"
+		if result[i].RevokedAt != nil {
+			state = "revoked " + result[i].RevokedAt.Format(time.RFC3339)
+		} else if !result[i].Active {
+			state = "expired"
+		}
+		fmt.Fprintf(&text, "\n  %s…  created %s  expires %s  %s", result[i].Prefix, token.CreatedAt.Format(time.RFC3339), token.ExpiresAt.Format(time.RFC3339), state)
+	}
+	return result, text.String()
+}
+
+func tokensList(ctx context.Context, app *app, args []string) error {
+	flags := app.flagSet()
+	all := flags.Bool("all", false, "include revoked and expired tokens")
+	positional, err := app.parse(flags, args, 1)
+	if err != nil {
+		return err
+	}
+	queries, err := app.queries()
+	if err != nil {
+		return err
+	}
+	user, err := findUser(ctx, queries, positional[0])
+	if err != nil {
+		return err
+	}
+	tokens, err := userTokens(ctx, queries, user.ID, *all)
+	if err != nil {
+		return err
+	}
+	result, text := describeTokens(tokens)
+	return app.print(result, "@%s has %d refresh token(s)%s", user.Handle, len(tokens), text)
+}
+
+func tokensRevoke(ctx context.Context, app *app, args []string) error {
+	flags := app.flagSet()
+	prefix := flags.String("token", "", "revoke only the token starting with this prefix")
+	positional, err := app.parse(flags, args, 1)
+	if err != nil {
+		return err
+	}
+	queries, err := app.queries()
+	if err != nil {
+		return err
+	}
+	user, err := findUser(ctx, queries, positional[0])
+	if err != nil {
+		return err
+	}
+	tokens, err := userTokens(ctx, queries, user.ID, false)
+	if err != nil {
+		return err
+	}
+	if *prefix != "" {
+		var matches []database.RefreshToken
+		for _, token := range tokens {
+			if strings.HasPrefix(token.Token, *prefix) {
+				matches = append(matches, token)
+			}
+		}
+		if len(matches) == 0 {
+			return fmt.Errorf("@%s has no active token starting with %q", user.Handle, *prefix)
+		}
+		if len(matches) > 1 {
+			return fmt.Errorf("%d tokens start with %q; give more of it", len(matches), *prefix)
+		}
+		tokens = matches
+	}
+	if app.dryRun {
+		result, text := describeTokens(tokens)
+		return app.printDryRun("revoke-tokens", result, "revoke %d refresh token(s) of @%s%s", len(tokens), user.Handle, text)
+	}
+	if *prefix != "" {
+		err = queries.RevokeToken(ctx, tokens[0].Token)
+	} else {
+		_, err = queries.RevokeUserRefreshTokens(ctx, user.ID)
+	}
+	if err != nil {
+		return err
+	}
+	revokedAt := sql.NullTime{Time: time.Now().UTC(), Valid: true}
+	for i := range tokens {
+		tokens[i].RevokedAt = revokedAt
+	}
+	result, text := describeTokens(tokens)
+	return app.print(result, "revoked %d refresh token(s) of @%s%s", len(tokens), user.Handle, text)
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Baehry/chirpy/internal/auth"
+	"github.com/Baehry/chirpy/internal/database"
+	"github.com/Baehry/chirpy/internal/handles"
+	"github.com/google/uuid"
+)
+
+// findUser looks a user up by ID, email or handle, with or without the '@'.
+func findUser(ctx context.Context, queries *database.Queries, ref string) (database.User, error) {
+	var user database.User
+	var err error
+	if id, parseErr := uuid.Parse(ref); parseErr == nil {
+		user, err = queries.GetUser(ctx, id)
+	} else if strings.Contains(strings.TrimPrefix(ref, "@"), "@") {
+		user, err = queries.GetUserByEmail(ctx, ref)
+	} else {
+		user, err = queries.GetUserByHandle(ctx, strings.TrimPrefix(ref, "@"))
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.User{}, fmt.Errorf("no user %q", ref)
+	}
+	return user, err
+}
+
+// userArg parses a command that takes a single USER argument and looks the
+// user up.
+func (app *app) userArg(ctx context.Context, args []string) (*database.Queries, database.User, error) {
+	positional, err := app.parse(app.flagSet(), args, 1)
+	if err != nil {
+		return nil, database.User{}, err
+	}
+	queries, err := app.queries()
+	if err != nil {
+		return nil, database.User{}, err
+	}
+	user, err := findUser(ctx, queries, positional[0])
+	return queries, user, err
+}
+
+func (app *app) printUser(user database.User) error {
+	red := ""
+	if user.IsChirpyRed {
+		red = " (Chirpy Red)"
+	}
+	return app.print(user, "%s @%s <%s>%s", user.ID, user.Handle, user.Email, red)
+}
+
+func usersCreate(ctx context.Context, app *app, args []string) error {
+	flags := app.flagSet()
+	email := flags.String("email", "", "email address")
+	password := flags.String("password", "", "password")
+	handle := flags.String("handle", "", "handle; generated when empty")
+	if _, err := app.parse(flags, args, 0); err != nil {
+		return err
+	}
+	if *email == "" || *password == "" {
+		return errors.New("-email and -password are required")
+	}
+	var handleArg sql.NullString
+	if *handle != "" {
+		if err := handles.Validate(*handle); err != nil {
+			return err
+		}
+		handleArg = sql.NullString{String: *handle, Valid: true}
+	}
+	queries, err := app.queries()
+	if err != nil {
+		return err
+	}
+	hashedPassword, err := auth.HashPassword(*password)
+	if err != nil {
+		return err
+	}
+	user, err := queries.CreateUser(ctx, database.CreateUserParams{
+		Email: *email,
+		HashedPassword: hashedPassword,
+		Handle: handleArg,
+	})
+	if err != nil {
+		return err
+	}
+	return app.printUser(user)
+}
+
+func usersShow(ctx context.Context, app *app, args []string) error {
+	_, user, err := app.userArg(ctx, args)
+	if err != nil {
+		return err
+	}
+	return app.printUser(user)
+}
+
+func usersSetPassword(ctx context.Context, app *app, args []string) error {
+	flags := app.flagSet()
+	password := flags.String("password", "", "new password; a random one is generated and printed when empty")
+	keepSessions := flags.Bool("keep-sessions", false, "leave the user's refresh tokens alone")
+	positional, err := app.parse(flags, args, 1)
+	if err != nil {
+		return err
+	}
+	queries, err := app.queries()
+	if err != nil {
+		return err
+	}
+	user, err := findUser(ctx, queries, positional[0])
+	if err != nil {
+		return err
+	}
+	if app.dryRun {
+		return app.printDryRun("set-password", user, "reset the password of @%s", user.Handle)
+	}
+	generated := *password == ""
+	if generated {
+		*password = randomPassword()
+	}
+	hashedPassword, err := auth.HashPassword(*password)
+	if err != nil {
+		return err
+	}
+	type result struct {
+		User database.User `json:"user"`
+		Password string `json:"password,omitempty"`
+		RevokedTokens int64 `json:"revoked_tokens"`
+	}
+	var out result
+	// The password and the sessions change together, so a reset can't
+	// leave a stolen session behind.
+	tx, err := app.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := queries.WithTx(tx)
+	out.User, err = qtx.SetUserPassword(ctx, database.SetUserPasswordParams{
+		ID: user.ID,
+		HashedPassword: hashedPassword,
+	})
+	if err != nil {
+		return err
+	}
+	if !*keepSessions {
+		out.RevokedTokens, err = qtx.RevokeUserRefreshTokens(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	if generated {
+		out.Password = *password
+		return app.print(out, "reset the password of @%s to %s and revoked %d refresh token(s)", user.Handle, *password, out.RevokedTokens)
+	}
+	return app.print(out, "reset the password of @%s and revoked %d refresh token(s)", user.Handle, out.RevokedTokens)
+}
+
+func randomPassword() string {
+	var b [18]byte
+	rand.Read(b[:])
+	return base64.RawURLEncoding.EncodeToString(b[:])
+}
+
+func usersGrantRed(ctx context.Context, app *app, args []string) error {
+	queries, user, err := app.userArg(ctx, args)
+	if err != nil {
+		return err
+	}
+	if err := queries.UpgradeUser(ctx, user.ID); err != nil {
+		return err
+	}
+	user.IsChirpyRed = true
+	return app.printUser(user)
+}
+
+func usersRevokeRed(ctx context.Context, app *app, args []string) error {
+	queries, user, err := app.userArg(ctx, args)
+	if err != nil {
+		return err
+	}
+	if app.dryRun {
+		return app.printDryRun("revoke-red", user, "take Chirpy Red away from @%s", user.Handle)
+	}
+	if err := queries.DowngradeUser(ctx, user.ID); err != nil {
+		return err
+	}
+	user.IsChirpyRed = false
+	return app.printUser(user)
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Baehry/chirpy/internal/database"
+	"github.com/Baehry/chirpy/internal/moderation"
+	"github.com/google/uuid"
+)
+
+const (
+	statusDraft = "draft"
+	statusScheduled = "scheduled"
+	statusPublished = "published"
+
+	maxScheduleAhead = 365 * 24 * time.Hour
+	publishInterval = 10 * time.Second
+	publishBatchSize = 100
+)
+
+// moderateChirp checks a chirp body and runs it through the content filter.
+func (cfg *apiConfig) moderateChirp(body string) (moderation.Result, error) {
+	if len(body) > 140 {
+		return moderation.Result{}, fmt.Errorf("Chirp is too long")
+	}
+	moderated := cfg.contentFilter.Apply(body)
+	if moderated.Rejected {
+		return moderation.Result{}, fmt.Errorf("Chirp contains prohibited words")
+	}
+	return moderated, nil
+}
+
+// chirpSchedule works out the status of a new or edited chirp from the
+// requested status and publish time.
+func chirpSchedule(status string, publishAt *time.Time) (string, sql.NullTime, error) {
+	if publishAt != nil {
+		if status != "" && status != statusScheduled {
+			return "", sql.NullTime{}, fmt.Errorf("publish_at can only be set on scheduled chirps")
+		}
+		if !publishAt.After(time.Now()) {
+			return "", sql.NullTime{}, fmt.Errorf("publish_at must be in the future")
+		}
+		if publishAt.After(time.Now().Add(maxScheduleAhead)) {
+			return "", sql.NullTime{}, fmt.Errorf("chirps can be scheduled at most %v ahead", maxScheduleAhead)
+		}
+		return statusScheduled, sql.NullTime{Time: publishAt.UTC(), Valid: true}, nil
+	}
+	switch status {
+	case "", statusPublished:
+		return statusPublished, sql.NullTime{}, nil
+	case statusDraft:
+		return statusDraft, sql.NullTime{}, nil
+	}
+	return "", sql.NullTime{}, fmt.Errorf("status must be draft or published, or set publish_at to schedule")
+}
+
+// saveChirpDetails stores what the body of a new or edited chirp implies:
+// its moderation flag, hashtags and mentions.
+func saveChirpDetails(ctx context.Context, q *database.Queries, chirp database.Chirp, moderated moderation.Result) error {
+	if moderated.Flagged {
+		if err := q.FlagChirp(ctx, database.FlagChirpParams{
+			ChirpID: chirp.ID,
+			Words: flaggedWords(moderated.Matches),
+		}); err != nil {
+			return err
+		}
+	}
+	return saveEntities(ctx, q, chirp)
+}
+
+// canView reports whether viewerID may see chirp. Drafts and scheduled
+// chirps are private to their author until they are published.
+func canView(chirp database.Chirp, viewerID uuid.UUID) bool {
+	return chirp.Status == statusPublished || chirp.UserID == viewerID
+}
+
+func (cfg *apiConfig) DraftsHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, err := cfg.authenticatedUserID(request)
+	if err != nil {
+		respondWithError(writer, 401, err.Error())
+		return
+	}
+	page, err := parsePageParams(request.URL.Query())
+	if err != nil {
+		respondWithError(writer, 400, err.Error())
+		return
+	}
+	chirps, err := cfg.dbQueries.GetDraftsByUser(request.Context(), database.GetDraftsByUserParams{
+		UserID: userID,
+		CursorCreatedAt: page.CursorCreatedAt,
+		CursorID: page.CursorID,
+		Limit: page.fetchLimit(),
+	})
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	cfg.respondWithChirpPage(writer, request, chirps, page)
+}
+
+func (cfg *apiConfig) PutChirpHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, err := cfg.authenticatedUserID(request)
+	if err != nil {
+		respondWithError(writer, 401, err.Error())
+		return
+	}
+	id, err := uuid.Parse(request.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(writer, 404, "chirp not found")
+		return
+	}
+	type parameters struct {
+		Body string `json:"body"`
+		Status string `json:"status"`
+		PublishAt *time.Time `json:"publish_at"`
+		ExpiresIn *int `json:"expires_in"`
+	}
+	decoder := json.NewDecoder(request.Body)
+	var params parameters
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(writer, 400, err.Error())
+		return
+	}
+	if params.Status == "" && params.PublishAt == nil {
+		params.Status = statusDraft
+	}
+	status, publishAt, err := chirpSchedule(params.Status, params.PublishAt)
+	if err != nil {
+		respondWithError(writer, 400, err.Error())
+		return
+	}
+	if status == statusPublished {
+		respondWithError(writer, 400, "use POST /api/chirps/{chirpID}/publish to publish a draft")
+		return
+	}
+	expiresAt, err := cfg.chirpExpiry(request.Context(), userID, params.ExpiresIn, status, publishAt)
+	if err != nil {
+		respondWithError(writer, 400, err.Error())
+		return
+	}
+	moderated, err := cfg.moderateChirp(params.Body)
+	if err != nil {
+		respondWithError(writer, 400, err.Error())
+		return
+	}
+	tx, err := cfg.db.BeginTx(request.Context(), nil)
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+	chirp, err := qtx.UpdateDraft(request.Context(), database.UpdateDraftParams{
+		ID: id,
+		UserID: userID,
+		Body: moderated.Body,
+		Status: status,
+		PublishAt: publishAt,
+		ExpiresAt: expiresAt,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(writer, 404, "draft not found")
+		return
+	}
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	if err := qtx.DeleteChirpHashtags(request.Context(), chirp.ID); err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	if err := qtx.DeleteChirpMentions(request.Context(), chirp.ID); err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	if err := saveChirpDetails(request.Context(), qtx, chirp, moderated); err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	result, err := cfg.chirpResponseFor(request.Context(), userID, chirp)
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	respondWithJSON(writer, 200, result)
+}
+
+func (cfg *apiConfig) PublishChirpHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, err := cfg.authenticatedUserID(request)
+	if err != nil {
+		respondWithError(writer, 401, err.Error())
+		return
+	}
+	id, err := uuid.Parse(request.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(writer, 404, "chirp not found")
+		return
+	}
+	tx, err := cfg.db.BeginTx(request.Context(), nil)
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+	chirp, err := qtx.PublishDraft(request.Context(), database.PublishDraftParams{
+		ID: id,
+		UserID: userID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(writer, 404, "draft not found")
+		return
+	}
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	if err := cfg.chirpPublished(request.Context(), qtx, chirp); err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	result, err := cfg.chirpResponseFor(request.Context(), userID, chirp)
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	respondWithJSON(writer, 200, result)
+}
+
+// chirpPublished runs inside the transaction that makes a chirp visible, so
+// whatever it records happens exactly when the chirp is published.
+func (cfg *apiConfig) chirpPublished(ctx context.Context, q *database.Queries, chirp database.Chirp) error {
+	if err := notifyMentions(ctx, q, chirp); err != nil {
+		return err
+	}
+	if err := chirpWebhooks(ctx, q, chirp); err != nil {
+		return err
+	}
+	if err := cfg.federateChirp(ctx, q, chirp); err != nil {
+		return err
+	}
+	return q.NotifyChirpPublished(ctx, chirp.ID)
+}
+
+// publishScheduledChirps promotes scheduled chirps once their publish time
+// has passed. Rows are claimed with FOR UPDATE SKIP LOCKED, so several
+// instances can run this at once without publishing a chirp twice, and a
+// chirp stays scheduled until the transaction publishing it commits.
+func (cfg *apiConfig) publishScheduledChirps(ctx context.Context) {
+	ticker := time.NewTicker(publishInterval)
+	defer ticker.Stop()
+	for {
+		for {
+			published, err := cfg.publishDueChirps(ctx)
+			if err != nil {
+				fmt.Printf("publishing scheduled chirps: %v\n", err)
+			}
+			if err != nil || published < publishBatchSize {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (cfg *apiConfig) publishDueChirps(ctx context.Context) (int, error) {
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+	due, err := qtx.ClaimDueChirps(ctx, publishBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	for _, chirp := range due {
+		published, err := qtx.MarkChirpPublished(ctx, chirp.ID)
+		if err != nil {
+			return 0, err
+		}
+		if err := cfg.chirpPublished(ctx, qtx, published); err != nil {
+			return 0, err
+		}
+	}
+	return len(due), tx.Commit()
+}
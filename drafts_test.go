@@ -0,0 +1,32 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/Baehry/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+func TestCanView(t *testing.T) {
+	authorID := uuid.New()
+	cases := []struct {
+		status string
+		viewerID uuid.UUID
+		visible bool
+	}{
+		{statusPublished, uuid.New(), true},
+		{statusPublished, uuid.Nil, true},
+		{statusDraft, authorID, true},
+		{statusDraft, uuid.New(), false},
+		{statusDraft, uuid.Nil, false},
+		{statusScheduled, authorID, true},
+		{statusScheduled, uuid.New(), false},
+	}
+	for _, c := range cases {
+		chirp := database.Chirp{UserID: authorID, Status: c.status}
+		if visible := canView(chirp, c.viewerID); visible != c.visible {
+			t.Logf("%s seen by %v: expected %v, got %v\n", c.status, c.viewerID, c.visible, visible)
+			t.Fail()
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/Baehry/chirpy/internal/database"
+	"github.com/Baehry/chirpy/internal/entities"
+	"github.com/google/uuid"
+)
+
+// saveEntities stores the hashtags and mentions of a freshly created chirp.
+// Mentions of handles that don't belong to anyone are dropped.
+func saveEntities(ctx context.Context, q *database.Queries, chirp database.Chirp) error {
+	list := entities.Parse(chirp.Body)
+	for _, tag := range entities.Values(list, entities.TypeHashtag) {
+		hashtag, err := q.UpsertHashtag(ctx, tag)
+		if err != nil {
+			return err
+		}
+		if err := q.AddChirpHashtag(ctx, database.AddChirpHashtagParams{
+			ChirpID: chirp.ID,
+			HashtagID: hashtag.ID,
+		}); err != nil {
+			return err
+		}
+	}
+	handles := entities.Values(list, entities.TypeMention)
+	if len(handles) == 0 {
+		return nil
+	}
+	users, err := q.GetUsersByHandles(ctx, handles)
+	if err != nil {
+		return err
+	}
+	for _, user := range users {
+		// Users who block the author, or whom the author blocks, can't be
+		// mentioned by them.
+		blocked, err := q.IsBlocked(ctx, database.IsBlockedParams{
+			UserID: chirp.UserID,
+			OtherID: user.ID,
+		})
+		if err != nil {
+			return err
+		}
+		if blocked {
+			continue
+		}
+		if err := q.CreateMention(ctx, database.CreateMentionParams{
+			ChirpID: chirp.ID,
+			UserID: user.ID,
+			Handle: strings.ToLower(user.Handle),
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (cfg *apiConfig) HashtagChirpsHandler(writer http.ResponseWriter, request *http.Request) {
+	tag := strings.ToLower(strings.TrimPrefix(request.PathValue("tag"), "#"))
+	page, err := parsePageParams(request.URL.Query())
+	if err != nil {
+		respondWithError(writer, 400, err.Error())
+		return
+	}
+	chirps, err := cfg.dbQueries.GetChirpsByHashtag(request.Context(), database.GetChirpsByHashtagParams{
+		Tag: tag,
+		Since: page.Since,
+		Until: page.Until,
+		CursorCreatedAt: page.CursorCreatedAt,
+		CursorID: page.CursorID,
+		ViewerID: cfg.viewer(request),
+		Limit: page.fetchLimit(),
+	})
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	cfg.respondWithChirpPage(writer, request, chirps, page)
+}
+
+func (cfg *apiConfig) MentionsHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, err := uuid.Parse(request.PathValue("id"))
+	if err != nil {
+		respondWithError(writer, 404, "user not found")
+		return
+	}
+	page, err := parsePageParams(request.URL.Query())
+	if err != nil {
+		respondWithError(writer, 400, err.Error())
+		return
+	}
+	chirps, err := cfg.dbQueries.GetChirpsMentioningUser(request.Context(), database.GetChirpsMentioningUserParams{
+		UserID: userID,
+		Since: page.Since,
+		Until: page.Until,
+		CursorCreatedAt: page.CursorCreatedAt,
+		CursorID: page.CursorID,
+		ViewerID: cfg.viewer(request),
+		Limit: page.fetchLimit(),
+	})
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	cfg.respondWithChirpPage(writer, request, chirps, page)
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	minChirpTTL = time.Minute
+	maxChirpTTL = 24 * time.Hour
+	maxChirpTTLRed = 7 * 24 * time.Hour
+
+	expiryReapInterval = time.Minute
+	expiryReapBatchSize = 100
+)
+
+// chirpExpiry turns an expires_in (in seconds) into the time a chirp
+// should disappear. The clock starts when the chirp is published, so a
+// scheduled chirp lives for its full TTL. Drafts can't expire.
+func (cfg *apiConfig) chirpExpiry(ctx context.Context, userID uuid.UUID, expiresIn *int, status string, publishAt sql.NullTime) (sql.NullTime, error) {
+	if expiresIn == nil {
+		return sql.NullTime{}, nil
+	}
+	if status == statusDraft {
+		return sql.NullTime{}, fmt.Errorf("drafts can't have expires_in")
+	}
+	user, err := cfg.dbQueries.GetUser(ctx, userID)
+	if err != nil {
+		return sql.NullTime{}, err
+	}
+	limit := maxChirpTTL
+	if user.IsChirpyRed {
+		limit = maxChirpTTLRed
+	}
+	ttl, err := chirpTTL(*expiresIn, limit)
+	if err != nil {
+		return sql.NullTime{}, err
+	}
+	start := time.Now().UTC()
+	if publishAt.Valid {
+		start = publishAt.Time
+	}
+	return sql.NullTime{Time: start.Add(ttl), Valid: true}, nil
+}
+
+// chirpTTL checks expires_in against the allowed range. The check is on
+// whole seconds, before converting to a Duration, which a huge expires_in
+// would overflow.
+func chirpTTL(expiresIn int, limit time.Duration) (time.Duration, error) {
+	minSeconds := int(minChirpTTL / time.Second)
+	maxSeconds := int(limit / time.Second)
+	if expiresIn < minSeconds || expiresIn > maxSeconds {
+		return 0, fmt.Errorf("expires_in must be between %d and %d seconds", minSeconds, maxSeconds)
+	}
+	return time.Duration(expiresIn) * time.Second, nil
+}
+
+// reapExpiredChirps hard-deletes chirps whose TTL has passed. Reads already
+// hide them, so this only reclaims space. Attachments are removed from the
+// blob store first; hashtags, mentions, flags and the search index go with
+// the chirp row.
+func (cfg *apiConfig) reapExpiredChirps(ctx context.Context) {
+	ticker := time.NewTicker(expiryReapInterval)
+	defer ticker.Stop()
+	for {
+		for {
+			reaped, err := cfg.reapExpiredBatch(ctx)
+			if err != nil {
+				fmt.Printf("reaping expired chirps: %v\n", err)
+			}
+			if err != nil || reaped < expiryReapBatchSize {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// reapExpiredBatch deletes one batch of expired chirps. The rows go in the
+// same transaction that queues their chirp.deleted webhooks and Delete
+// activities, so a chirp can't disappear without anyone hearing about it.
+func (cfg *apiConfig) reapExpiredBatch(ctx context.Context) (int, error) {
+	expired, err := cfg.dbQueries.ListExpiredChirps(ctx, expiryReapBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	ids, err := cfg.deleteChirpMedia(ctx, expired)
+	if err != nil {
+		return 0, err
+	}
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+	reaped, err := qtx.DeleteExpiredChirps(ctx, ids)
+	if err != nil {
+		return 0, err
+	}
+	// Webhook subscribers and other servers don't know about TTLs, so
+	// they're told the chirp was deleted. Trashed chirps already were.
+	authorIDs := []uuid.UUID{}
+	for _, chirp := range reaped {
+		if chirp.Status != statusPublished || chirp.DeletedAt.Valid {
+			continue
+		}
+		authorIDs = append(authorIDs, chirp.UserID)
+		if err := chirpDeletedWebhook(ctx, qtx, chirp); err != nil {
+			return 0, err
+		}
+		if err := cfg.federateChirpDeleted(ctx, qtx, chirp); err != nil {
+			return 0, err
+		}
+	}
+	if err := qtx.TouchChirpsChangedAt(ctx, authorIDs); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return len(reaped), nil
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestChirpTTL(t *testing.T) {
+	cases := []struct {
+		expiresIn int
+		limit time.Duration
+		ttl time.Duration
+		ok bool
+	}{
+		{60, maxChirpTTL, time.Minute, true},
+		{86400, maxChirpTTL, 24 * time.Hour, true},
+		{59, maxChirpTTL, 0, false},
+		{0, maxChirpTTL, 0, false},
+		{-60, maxChirpTTL, 0, false},
+		{86401, maxChirpTTL, 0, false},
+		{7 * 86400, maxChirpTTLRed, 7 * 24 * time.Hour, true},
+		{7*86400 + 1, maxChirpTTLRed, 0, false},
+		// Wraps to about a minute as a Duration.
+		{18446744134, maxChirpTTL, 0, false},
+	}
+	for _, c := range cases {
+		ttl, err := chirpTTL(c.expiresIn, c.limit)
+		if (err == nil) != c.ok || ttl != c.ttl {
+			t.Logf("%d with limit %v: expected %v (ok=%v), got %v, %v\n", c.expiresIn, c.limit, c.ttl, c.ok, ttl, err)
+			t.Fail()
+		}
+	}
+}
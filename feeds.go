@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/Baehry/chirpy/internal/database"
+	"github.com/Baehry/chirpy/internal/feeds"
+	"github.com/Baehry/chirpy/internal/handles"
+	"github.com/google/uuid"
+)
+
+const (
+	feedSize = 50
+	feedMaxAge = 5 * time.Minute
+)
+
+// baseURL is where this server is reachable from outside, for links that
+// leave the API: PUBLIC_URL if it's set, otherwise worked out from the
+// request.
+func (cfg *apiConfig) baseURL(request *http.Request) string {
+	if cfg.publicURL != "" {
+		return strings.TrimSuffix(cfg.publicURL, "/")
+	}
+	scheme := "http"
+	if request.TLS != nil || request.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + request.Host
+}
+
+// feedItems turns chirps into feed items linking to each chirp.
+func (cfg *apiConfig) feedItems(ctx context.Context, base string, chirps []database.Chirp) ([]feeds.Item, error) {
+	authorIDs := []uuid.UUID{}
+	for _, chirp := range chirps {
+		authorIDs = append(authorIDs, chirp.UserID)
+	}
+	authors, err := cfg.dbQueries.GetUsersByIDs(ctx, authorIDs)
+	if err != nil {
+		return nil, err
+	}
+	authorsByID := map[uuid.UUID]database.User{}
+	for _, author := range authors {
+		authorsByID[author.ID] = author
+	}
+	items := make([]feeds.Item, len(chirps))
+	for i, chirp := range chirps {
+		author := authorsByID[chirp.UserID]
+		chirpURL := base + "/api/chirps/" + chirp.ID.String()
+		items[i] = feeds.Item{
+			ID: chirpURL,
+			URL: chirpURL,
+			Content: chirp.Body,
+			AuthorName: "@" + author.Handle,
+			AuthorURL: base + "/api/users/" + url.PathEscape(author.Handle),
+			Published: chirp.CreatedAt,
+			Updated: chirp.UpdatedAt,
+		}
+	}
+	return items, nil
+}
+
+// latest is t, or changed if that's later.
+func latest(t time.Time, changed sql.NullTime) time.Time {
+	if changed.Valid && changed.Time.After(t) {
+		return changed.Time
+	}
+	return t
+}
+
+// serveFeed renders feed in the format named by the request's extension.
+// http.ServeContent takes care of ETag, If-None-Match and
+// If-Modified-Since, so readers polling an unchanged feed get a 304.
+// Last-Modified is the latest of feed.Updated and the items' times, so
+// feed.Updated has to cover chirps leaving the feed, which the items can't.
+func serveFeed(writer http.ResponseWriter, request *http.Request, feed feeds.Feed) {
+	for _, item := range feed.Items {
+		if item.Updated.After(feed.Updated) {
+			feed.Updated = item.Updated
+		}
+	}
+	var body []byte
+	var err error
+	var contentType string
+	switch path.Ext(request.URL.Path) {
+	case ".atom":
+		body, err = feeds.Atom(feed)
+		contentType = feeds.AtomContentType
+	case ".rss":
+		body, err = feeds.RSS(feed)
+		contentType = feeds.RSSContentType
+	case ".json":
+		body, err = feeds.JSON(feed)
+		contentType = feeds.JSONContentType
+	default:
+		respondWithError(writer, 404, "unknown feed format")
+		return
+	}
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	writer.Header().Set("Content-Type", contentType)
+	writer.Header().Set("ETag", feeds.ETag(body))
+	writer.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(feedMaxAge.Seconds())))
+	http.ServeContent(writer, request, "", feed.Updated, bytes.NewReader(body))
+}
+
+// UserFeedHandler serves a user's latest chirps at
+// /users/{handle}/feed.atom, feed.rss and feed.json.
+func (cfg *apiConfig) UserFeedHandler(writer http.ResponseWriter, request *http.Request) {
+	handle := request.PathValue("handle")
+	user, err := cfg.dbQueries.GetUserByHandle(request.Context(), handle)
+	if errors.Is(err, sql.ErrNoRows) {
+		redirect, err := cfg.dbQueries.GetHandleRedirect(request.Context(), handles.Normalize(handle))
+		if err != nil {
+			respondWithError(writer, 404, "user not found")
+			return
+		}
+		user, err := cfg.dbQueries.GetUser(request.Context(), redirect.UserID)
+		if err != nil {
+			respondWithError(writer, 404, "user not found")
+			return
+		}
+		// Permanent, so feed readers update the subscription.
+		http.Redirect(writer, request, "/users/"+url.PathEscape(user.Handle)+"/feed"+path.Ext(request.URL.Path), http.StatusMovedPermanently)
+		return
+	}
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	chirps, err := cfg.getChirpsByUser(request.Context(), user.ID, uuid.NullUUID{}, true, false, pageParams{Limit: feedSize})
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	if len(chirps) > feedSize {
+		chirps = chirps[:feedSize]
+	}
+	base := cfg.baseURL(request)
+	items, err := cfg.feedItems(request.Context(), base, chirps)
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	name := user.DisplayName
+	if name == "" {
+		name = user.Handle
+	}
+	profileURL := base + "/api/users/" + url.PathEscape(user.Handle)
+	serveFeed(writer, request, feeds.Feed{
+		ID: profileURL,
+		Title: fmt.Sprintf("%s (@%s) on Chirpy", name, user.Handle),
+		Description: user.Bio,
+		URL: profileURL,
+		FeedURL: base + request.URL.Path,
+		Updated: latest(user.CreatedAt, user.ChirpsChangedAt),
+		Items: items,
+	})
+}
+
+// HashtagFeedHandler serves the latest chirps with a hashtag at
+// /hashtags/{tag}/feed.atom, feed.rss and feed.json.
+func (cfg *apiConfig) HashtagFeedHandler(writer http.ResponseWriter, request *http.Request) {
+	tag := strings.ToLower(strings.TrimPrefix(request.PathValue("tag"), "#"))
+	chirps, err := cfg.dbQueries.GetChirpsByHashtag(request.Context(), database.GetChirpsByHashtagParams{
+		Tag: tag,
+		Limit: feedSize,
+	})
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	// Any author's deletion might have taken a chirp out of this feed.
+	changedAt, err := cfg.dbQueries.GetLatestChirpsChangedAt(request.Context())
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	base := cfg.baseURL(request)
+	items, err := cfg.feedItems(request.Context(), base, chirps)
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	tagURL := base + "/api/hashtags/" + url.PathEscape(tag) + "/chirps"
+	serveFeed(writer, request, feeds.Feed{
+		ID: tagURL,
+		Title: fmt.Sprintf("#%s on Chirpy", tag),
+		URL: tagURL,
+		FeedURL: base + request.URL.Path,
+		// An empty feed with nothing deleted has nothing to date it by;
+		// the epoch keeps its body, and so its ETag, stable.
+		Updated: latest(time.Unix(0, 0), changedAt),
+		Items: items,
+	})
+}
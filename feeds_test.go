@@ -0,0 +1,63 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Baehry/chirpy/internal/feeds"
+)
+
+func TestServeFeedConditionalGET(t *testing.T) {
+	older := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	newer := older.Add(time.Hour)
+	feed := feeds.Feed{
+		ID: "https://chirpy.test/api/users/alice",
+		Title: "alice on Chirpy",
+		Updated: older,
+		Items: []feeds.Item{
+			{ID: "https://chirpy.test/api/chirps/2", Content: "newer", Published: newer, Updated: newer},
+			{ID: "https://chirpy.test/api/chirps/1", Content: "older", Published: older, Updated: older},
+		},
+	}
+	serve := func(feed feeds.Feed, header http.Header) *httptest.ResponseRecorder {
+		request := httptest.NewRequest("GET", "/users/alice/feed.rss", nil)
+		for key, values := range header {
+			request.Header[key] = values
+		}
+		recorder := httptest.NewRecorder()
+		serveFeed(recorder, request, feed)
+		return recorder
+	}
+
+	first := serve(feed, nil)
+	etag := first.Header().Get("ETag")
+	lastModified := first.Header().Get("Last-Modified")
+	if first.Code != 200 || etag == "" || lastModified != newer.Format(http.TimeFormat) {
+		t.Fatalf("expected a 200 with an ETag and the newest item's Last-Modified, got %d %v", first.Code, first.Header())
+	}
+
+	// Deleting the newest chirp leaves nothing newer among the items, but
+	// bumps the author's chirps_changed_at, which is what Updated carries.
+	deleted := feed
+	deleted.Items = feed.Items[1:]
+	deleted.Updated = newer.Add(time.Minute)
+	cases := []struct {
+		name string
+		feed feeds.Feed
+		header http.Header
+		code int
+	}{
+		{"unchanged, If-None-Match", feed, http.Header{"If-None-Match": {etag}}, 304},
+		{"unchanged, If-Modified-Since", feed, http.Header{"If-Modified-Since": {lastModified}}, 304},
+		{"deleted, If-None-Match", deleted, http.Header{"If-None-Match": {etag}}, 200},
+		{"deleted, If-Modified-Since", deleted, http.Header{"If-Modified-Since": {lastModified}}, 200},
+	}
+	for _, c := range cases {
+		if response := serve(c.feed, c.header); response.Code != c.code {
+			t.Logf("%s: expected %d, got %d\n", c.name, c.code, response.Code)
+			t.Fail()
+		}
+	}
+}
@@ -0,0 +1,562 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Baehry/chirpy/internal/auth"
+	"github.com/Baehry/chirpy/internal/database"
+	"github.com/Baehry/chirpy/internal/stream"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"golang.org/x/time/rate"
+)
+
+const (
+	notificationsChangedChannel = "notifications_changed"
+	gatewaySignalChannel = "gateway_signal"
+
+	gatewayAuthTimeout = 10 * time.Second
+	gatewayWriteTimeout = 10 * time.Second
+	gatewayPongTimeout = 60 * time.Second
+	gatewayPingInterval = 25 * time.Second
+	gatewayMaxMessageSize = 4096
+	gatewayMaxTopics = 100
+	gatewayBufferSize = 256
+	gatewayReplyBufferSize = 16
+
+	// Clients may send gatewayMessageRate messages a second, in bursts of
+	// up to gatewayMessageBurst. Messages over the limit are rejected, and
+	// after gatewayMaxViolations of those the connection is closed. The
+	// count starts over whenever the client lets its burst refill.
+	gatewayMessageRate = 10
+	gatewayMessageBurst = 20
+	gatewayMaxViolations = 20
+)
+
+var gatewayUpgrader = websocket.Upgrader{
+	ReadBufferSize: 1024,
+	WriteBufferSize: 1024,
+	// Connections authenticate with a bearer token, not cookies, so another
+	// origin can't ride on someone's session.
+	CheckOrigin: func(request *http.Request) bool {
+		return true
+	},
+}
+
+// gatewayMessage is a frame in either direction. Clients send auth,
+// subscribe, unsubscribe, typing and ping; the server answers with ready,
+// subscribed, unsubscribed, pong and error, and pushes event. Ref is picked
+// by the client and echoed on the reply to its message.
+type gatewayMessage struct {
+	Type string `json:"type"`
+	Ref string `json:"ref,omitempty"`
+	Token string `json:"token,omitempty"`
+	Topics []string `json:"topics,omitempty"`
+	Topic string `json:"topic,omitempty"`
+	ID string `json:"id,omitempty"`
+	Event string `json:"event,omitempty"`
+	Data json.RawMessage `json:"data,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// gatewayTopic is a topic as a client names it, and the hub topic behind
+// it. Members of a topic with signals can send typing signals to it.
+type gatewayTopic struct {
+	name string
+	hub string
+	signals bool
+}
+
+var errUnknownTopic = errors.New("unknown topic")
+
+// parseGatewayTopic resolves a topic named by userID's client. The topics
+// are:
+//
+//	chirps             every published chirp
+//	author:<id>        chirps by one user
+//	hashtag:<tag>      chirps with a hashtag
+//	notifications      the user's unread notification count
+//	presence:<id>      whether someone the user shares a conversation with is connected
+//	conversation:<id>  messages and typing in a conversation the user is in
+func parseGatewayTopic(userID uuid.UUID, name string) (gatewayTopic, error) {
+	switch name {
+	case "chirps":
+		return gatewayTopic{name: name, hub: name}, nil
+	case "notifications":
+		return gatewayTopic{name: name, hub: notificationsTopic(userID)}, nil
+	}
+	kind, value, ok := strings.Cut(name, ":")
+	if !ok {
+		return gatewayTopic{}, errUnknownTopic
+	}
+	switch kind {
+	case "hashtag":
+		tag := strings.ToLower(strings.TrimPrefix(value, "#"))
+		if tag == "" {
+			return gatewayTopic{}, errors.New("hashtag topics need a tag")
+		}
+		return gatewayTopic{name: "hashtag:" + tag, hub: "hashtag:" + tag}, nil
+	case "author", "presence":
+		id, err := uuid.Parse(value)
+		if err != nil {
+			return gatewayTopic{}, fmt.Errorf("invalid user id in %s topic", kind)
+		}
+		name = kind + ":" + id.String()
+		return gatewayTopic{name: name, hub: name}, nil
+	case "conversation":
+		id, err := uuid.Parse(value)
+		if err != nil {
+			return gatewayTopic{}, errors.New("invalid conversation id")
+		}
+		name = conversationTopic(id)
+		return gatewayTopic{name: name, hub: name, signals: true}, nil
+	}
+	return gatewayTopic{}, errUnknownTopic
+}
+
+// authorizeGatewayTopic checks that userID may subscribe to topic. Presence
+// is only shared between people who have a conversation together and
+// haven't blocked each other.
+func (cfg *apiConfig) authorizeGatewayTopic(ctx context.Context, userID uuid.UUID, topic gatewayTopic) error {
+	if other, ok := strings.CutPrefix(topic.hub, "presence:"); ok {
+		otherID, err := uuid.Parse(other)
+		if err != nil {
+			return err
+		}
+		if otherID == userID {
+			return nil
+		}
+		shared, err := cfg.dbQueries.SharesConversation(ctx, database.SharesConversationParams{
+			UserID: userID,
+			OtherID: otherID,
+		})
+		if err != nil {
+			return err
+		}
+		blocked, err := cfg.dbQueries.IsBlocked(ctx, database.IsBlockedParams{
+			UserID: userID,
+			OtherID: otherID,
+		})
+		if err != nil {
+			return err
+		}
+		if !shared || blocked {
+			return errors.New("you can't follow this user's presence")
+		}
+	}
+	if conversation, ok := strings.CutPrefix(topic.hub, "conversation:"); ok {
+		conversationID, err := uuid.Parse(conversation)
+		if err != nil {
+			return err
+		}
+		member, err := cfg.dbQueries.IsConversationMember(ctx, database.IsConversationMemberParams{
+			ConversationID: conversationID,
+			UserID: userID,
+		})
+		if err != nil {
+			return err
+		}
+		if !member {
+			return errors.New("conversation not found")
+		}
+	}
+	return nil
+}
+
+type gatewaySignal struct {
+	Topic string `json:"topic"`
+	Type string `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// sendSignal broadcasts a short-lived event, like typing or presence, to
+// every instance. Signals aren't stored anywhere; sent through a
+// transaction, they go out when it commits.
+func (cfg *apiConfig) sendSignal(ctx context.Context, q *database.Queries, topic string, eventType string, data any) error {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(gatewaySignal{
+		Topic: topic,
+		Type: eventType,
+		Data: encoded,
+	})
+	if err != nil {
+		return err
+	}
+	return q.NotifyGatewaySignal(ctx, string(payload))
+}
+
+// publishSignal hands a signal heard from Postgres to local subscribers.
+func (cfg *apiConfig) publishSignal(payload string) error {
+	var signal gatewaySignal
+	if err := json.Unmarshal([]byte(payload), &signal); err != nil {
+		return err
+	}
+	cfg.hub.Publish(stream.Event{
+		Type: signal.Type,
+		Data: signal.Data,
+	}, signal.Topic)
+	return nil
+}
+
+// sendPresence announces userID coming online or going offline. Presence
+// is counted per instance, so a user connected to two instances shows as
+// offline for a moment when either connection drops.
+func (cfg *apiConfig) sendPresence(userID uuid.UUID, online bool) {
+	type presence struct {
+		UserID uuid.UUID `json:"user_id"`
+		Online bool `json:"online"`
+	}
+	err := cfg.sendSignal(context.Background(), cfg.dbQueries, "presence:"+userID.String(), "presence", presence{
+		UserID: userID,
+		Online: online,
+	})
+	if err != nil {
+		fmt.Printf("sending presence: %v\n", err)
+	}
+}
+
+type gatewayConn struct {
+	cfg *apiConfig
+	ws *websocket.Conn
+	userID uuid.UUID
+	sub *stream.Subscription
+	limiter *rate.Limiter
+	violations int
+	replies chan gatewayMessage
+	drain chan struct{}
+	drainOnce sync.Once
+
+	mu sync.Mutex
+	// topics is keyed by hub topic.
+	topics map[string]gatewayTopic
+}
+
+// Drain asks the connection to close for shutdown.
+func (conn *gatewayConn) Drain() {
+	conn.drainOnce.Do(func() {
+		close(conn.drain)
+	})
+}
+
+// GatewayHandler upgrades to a WebSocket that carries live events for one
+// user. Clients authenticate once, with a bearer token on the upgrade
+// request or with an auth message as the first frame.
+func (cfg *apiConfig) GatewayHandler(writer http.ResponseWriter, request *http.Request) {
+	var userID uuid.UUID
+	hasToken := request.Header.Get("Authorization") != ""
+	if hasToken {
+		id, err := cfg.authenticatedUserID(request)
+		if err != nil {
+			respondWithError(writer, 401, err.Error())
+			return
+		}
+		userID = id
+	}
+	ws, err := gatewayUpgrader.Upgrade(writer, request, nil)
+	if err != nil {
+		// Upgrade has already replied.
+		return
+	}
+	defer ws.Close()
+	ws.SetReadLimit(gatewayMaxMessageSize)
+	if !hasToken {
+		id, err := cfg.gatewayAuth(ws)
+		if err != nil {
+			closeGateway(ws, websocket.ClosePolicyViolation, err.Error())
+			return
+		}
+		userID = id
+	}
+
+	conn := &gatewayConn{
+		cfg: cfg,
+		ws: ws,
+		userID: userID,
+		sub: cfg.hub.Subscribe(gatewayBufferSize),
+		limiter: rate.NewLimiter(gatewayMessageRate, gatewayMessageBurst),
+		replies: make(chan gatewayMessage, gatewayReplyBufferSize),
+		drain: make(chan struct{}),
+		topics: map[string]gatewayTopic{},
+	}
+	defer conn.sub.Close()
+	first, err := cfg.gateway.Add(conn, userID)
+	if err != nil {
+		closeGateway(ws, websocket.CloseTryAgainLater, err.Error())
+		return
+	}
+	defer func() {
+		if cfg.gateway.Remove(conn) {
+			cfg.sendPresence(userID, false)
+		}
+	}()
+	if first {
+		cfg.sendPresence(userID, true)
+	}
+	conn.run()
+}
+
+func (cfg *apiConfig) gatewayAuth(ws *websocket.Conn) (uuid.UUID, error) {
+	ws.SetReadDeadline(time.Now().Add(gatewayAuthTimeout))
+	var message gatewayMessage
+	if err := ws.ReadJSON(&message); err != nil || message.Type != "auth" {
+		return uuid.Nil, errors.New("expected an auth message")
+	}
+	return auth.ValidateJWT(message.Token, cfg.tokenSecret)
+}
+
+func closeGateway(ws *websocket.Conn, code int, reason string) {
+	message := websocket.FormatCloseMessage(code, reason)
+	ws.WriteControl(websocket.CloseMessage, message, time.Now().Add(gatewayWriteTimeout))
+}
+
+// run pumps messages until either side goes away. Only the write loop
+// writes frames other than close, as gorilla/websocket allows one writer.
+func (conn *gatewayConn) run() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go conn.readLoop(ctx, cancel)
+	conn.writeLoop(ctx)
+}
+
+func (conn *gatewayConn) writeLoop(ctx context.Context) {
+	ping := time.NewTicker(gatewayPingInterval)
+	defer ping.Stop()
+	ready, err := json.Marshal(map[string]uuid.UUID{"user_id": conn.userID})
+	if err != nil || conn.write(gatewayMessage{Type: "ready", Data: ready}) != nil {
+		return
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-conn.drain:
+			closeGateway(conn.ws, websocket.CloseGoingAway, "server is shutting down")
+			return
+		case <-ping.C:
+			if err := conn.ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(gatewayWriteTimeout)); err != nil {
+				return
+			}
+		case reply := <-conn.replies:
+			if err := conn.write(reply); err != nil {
+				return
+			}
+		case event, ok := <-conn.sub.Events():
+			// The hub drops subscribers that can't keep up; so does a client
+			// that can't take writes within gatewayWriteTimeout.
+			if !ok {
+				if errors.Is(conn.sub.Err(), stream.ErrSlowConsumer) {
+					closeGateway(conn.ws, websocket.CloseTryAgainLater, "connection fell behind")
+				}
+				return
+			}
+			if err := conn.deliver(ctx, event); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (conn *gatewayConn) write(message gatewayMessage) error {
+	conn.ws.SetWriteDeadline(time.Now().Add(gatewayWriteTimeout))
+	return conn.ws.WriteJSON(message)
+}
+
+func (conn *gatewayConn) deliver(ctx context.Context, event stream.Event) error {
+	conn.mu.Lock()
+	var topic gatewayTopic
+	ok := false
+	for _, hubTopic := range event.Topics {
+		if topic, ok = conn.topics[hubTopic]; ok {
+			break
+		}
+	}
+	conn.mu.Unlock()
+	// The client may have unsubscribed while the event was queued.
+	if !ok {
+		return nil
+	}
+	viewer := uuid.NullUUID{UUID: conn.userID, Valid: true}
+	if event.Type == "chirp" && conn.cfg.chirpEventHidden(ctx, event, viewer) {
+		return nil
+	}
+	if event.Type == "message" && conn.cfg.messageEventHidden(ctx, event, conn.userID) {
+		return nil
+	}
+	return conn.write(gatewayMessage{
+		Type: "event",
+		Topic: topic.name,
+		ID: event.ID,
+		Event: event.Type,
+		Data: event.Data,
+	})
+}
+
+func (conn *gatewayConn) readLoop(ctx context.Context, cancel context.CancelFunc) {
+	defer cancel()
+	conn.ws.SetReadDeadline(time.Now().Add(gatewayPongTimeout))
+	conn.ws.SetPongHandler(func(string) error {
+		return conn.ws.SetReadDeadline(time.Now().Add(gatewayPongTimeout))
+	})
+	for {
+		_, data, err := conn.ws.ReadMessage()
+		if err != nil {
+			return
+		}
+		conn.ws.SetReadDeadline(time.Now().Add(gatewayPongTimeout))
+		if allowed, exceeded := conn.allow(time.Now()); !allowed {
+			if exceeded {
+				closeGateway(conn.ws, websocket.ClosePolicyViolation, "rate limit exceeded")
+				return
+			}
+			conn.reply(ctx, gatewayMessage{Type: "error", Error: "rate limit exceeded"})
+			continue
+		}
+		var message gatewayMessage
+		if err := json.Unmarshal(data, &message); err != nil {
+			conn.reply(ctx, gatewayMessage{Type: "error", Error: "invalid message"})
+			continue
+		}
+		conn.handle(ctx, message)
+	}
+}
+
+// allow applies the rate limit to a message read at now. It reports
+// whether to handle the message, and whether the client has gone over the
+// limit often enough to be disconnected.
+func (conn *gatewayConn) allow(now time.Time) (allowed bool, exceeded bool) {
+	if conn.limiter.TokensAt(now) >= gatewayMessageBurst {
+		conn.violations = 0
+	}
+	if conn.limiter.AllowN(now, 1) {
+		return true, false
+	}
+	conn.violations++
+	return false, conn.violations > gatewayMaxViolations
+}
+
+// reply queues message for the write loop. Blocking here while the client
+// isn't reading keeps it from piling up requests.
+func (conn *gatewayConn) reply(ctx context.Context, message gatewayMessage) {
+	select {
+	case conn.replies <- message:
+	case <-ctx.Done():
+	}
+}
+
+func (conn *gatewayConn) replyError(ctx context.Context, request gatewayMessage, topic string, err error) {
+	conn.reply(ctx, gatewayMessage{
+		Type: "error",
+		Ref: request.Ref,
+		Topic: topic,
+		Error: err.Error(),
+	})
+}
+
+func (conn *gatewayConn) handle(ctx context.Context, message gatewayMessage) {
+	switch message.Type {
+	case "subscribe":
+		conn.subscribe(ctx, message)
+	case "unsubscribe":
+		conn.unsubscribe(ctx, message)
+	case "typing":
+		conn.typing(ctx, message)
+	case "ping":
+		conn.reply(ctx, gatewayMessage{Type: "pong", Ref: message.Ref})
+	case "auth":
+		conn.replyError(ctx, message, "", errors.New("already authenticated"))
+	default:
+		conn.replyError(ctx, message, "", fmt.Errorf("unknown message type %q", message.Type))
+	}
+}
+
+func (conn *gatewayConn) subscribe(ctx context.Context, message gatewayMessage) {
+	added := []string{}
+	for _, name := range message.Topics {
+		topic, err := parseGatewayTopic(conn.userID, name)
+		if err == nil {
+			err = conn.cfg.authorizeGatewayTopic(ctx, conn.userID, topic)
+		}
+		if err != nil {
+			conn.replyError(ctx, message, name, err)
+			continue
+		}
+		conn.mu.Lock()
+		_, subscribed := conn.topics[topic.hub]
+		full := !subscribed && len(conn.topics) >= gatewayMaxTopics
+		if !full {
+			conn.topics[topic.hub] = topic
+		}
+		conn.mu.Unlock()
+		if full {
+			conn.replyError(ctx, message, name, fmt.Errorf("a connection can subscribe to at most %d topics", gatewayMaxTopics))
+			continue
+		}
+		conn.sub.Add(topic.hub)
+		added = append(added, topic.name)
+	}
+	conn.reply(ctx, gatewayMessage{Type: "subscribed", Ref: message.Ref, Topics: added})
+}
+
+func (conn *gatewayConn) unsubscribe(ctx context.Context, message gatewayMessage) {
+	removed := []string{}
+	for _, name := range message.Topics {
+		topic, err := parseGatewayTopic(conn.userID, name)
+		if err != nil {
+			conn.replyError(ctx, message, name, err)
+			continue
+		}
+		conn.mu.Lock()
+		_, subscribed := conn.topics[topic.hub]
+		delete(conn.topics, topic.hub)
+		conn.mu.Unlock()
+		if subscribed {
+			conn.sub.Remove(topic.hub)
+			removed = append(removed, topic.name)
+		}
+	}
+	conn.reply(ctx, gatewayMessage{Type: "unsubscribed", Ref: message.Ref, Topics: removed})
+}
+
+// typing tells the other members of a topic that the user is typing. It
+// only works on topics the connection is subscribed to that allow signals.
+func (conn *gatewayConn) typing(ctx context.Context, message gatewayMessage) {
+	topic, err := parseGatewayTopic(conn.userID, message.Topic)
+	if err != nil {
+		conn.replyError(ctx, message, message.Topic, err)
+		return
+	}
+	conn.mu.Lock()
+	subscribed, ok := conn.topics[topic.hub]
+	conn.mu.Unlock()
+	if !ok || !subscribed.signals {
+		conn.replyError(ctx, message, message.Topic, errors.New("can't send typing signals to this topic"))
+		return
+	}
+	type typing struct {
+		UserID uuid.UUID `json:"user_id"`
+	}
+	if err := conn.cfg.sendSignal(ctx, conn.cfg.dbQueries, topic.hub, "typing", typing{UserID: conn.userID}); err != nil {
+		conn.replyError(ctx, message, message.Topic, err)
+	}
+}
+
+// messageEventHidden reports whether userID and the sender of a message
+// event have blocked one another, which can happen in group conversations.
+func (cfg *apiConfig) messageEventHidden(ctx context.Context, event stream.Event, userID uuid.UUID) bool {
+	var message messageResponse
+	if err := json.Unmarshal(event.Data, &message); err != nil {
+		return true
+	}
+	blocked, err := cfg.isBlocked(ctx, userID, message.SenderID)
+	return err != nil || blocked
+}
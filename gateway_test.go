@@ -0,0 +1,161 @@
+package main
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Baehry/chirpy/internal/auth"
+	"github.com/Baehry/chirpy/internal/database"
+	"github.com/Baehry/chirpy/internal/gateway"
+	"github.com/Baehry/chirpy/internal/stream"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"golang.org/x/time/rate"
+)
+
+func TestGatewayAllow(t *testing.T) {
+	cases := []struct {
+		name string
+		// bursts messages are sent back to back, gap apart.
+		bursts int
+		size int
+		gap time.Duration
+		exceeded bool
+	}{
+		{"within the limit", 10, gatewayMessageBurst, 3 * time.Second, false},
+		{"occasional overruns", 100, gatewayMessageBurst + 5, time.Hour, false},
+		{"constant flooding", 1, gatewayMessageBurst + gatewayMaxViolations + 1, 0, true},
+		{"overruns without a break", 10, gatewayMessageBurst + 5, time.Second / gatewayMessageRate, true},
+	}
+	for _, c := range cases {
+		conn := &gatewayConn{limiter: rate.NewLimiter(gatewayMessageRate, gatewayMessageBurst)}
+		now := time.Now()
+		exceeded := false
+		for range c.bursts {
+			for range c.size {
+				if _, over := conn.allow(now); over {
+					exceeded = true
+				}
+			}
+			now = now.Add(c.gap)
+		}
+		if exceeded != c.exceeded {
+			t.Logf("%s: expected exceeded=%v, got %v after %d violations\n", c.name, c.exceeded, exceeded, conn.violations)
+			t.Fail()
+		}
+	}
+}
+
+// newGatewayServer serves GatewayHandler with no working database, which
+// is enough for everything but presence and conversations.
+func newGatewayServer(t *testing.T) (*apiConfig, *httptest.Server) {
+	db, err := sql.Open("postgres", "postgres://chirpy@127.0.0.1:1/chirpy?sslmode=disable")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	cfg := &apiConfig{
+		db: db,
+		dbQueries: database.New(db),
+		tokenSecret: testSecret,
+		hub: stream.NewHub(),
+		gateway: gateway.NewRegistry(),
+	}
+	server := httptest.NewServer(http.HandlerFunc(cfg.GatewayHandler))
+	t.Cleanup(server.Close)
+	return cfg, server
+}
+
+func dialGateway(t *testing.T, server *httptest.Server, header http.Header) (*websocket.Conn, *http.Response, error) {
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+	ws, response, err := websocket.DefaultDialer.Dial(url, header)
+	if err == nil {
+		t.Cleanup(func() { ws.Close() })
+		ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+	}
+	return ws, response, err
+}
+
+func readGateway(t *testing.T, ws *websocket.Conn) gatewayMessage {
+	var message gatewayMessage
+	if err := ws.ReadJSON(&message); err != nil {
+		t.Fatal(err)
+	}
+	return message
+}
+
+func TestGatewayHandler(t *testing.T) {
+	cfg, server := newGatewayServer(t)
+	userID := uuid.New()
+	token, err := auth.MakeJWT(userID, testSecret, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, response, err := dialGateway(t, server, http.Header{"Authorization": {"Bearer nonsense"}}); err == nil || response == nil || response.StatusCode != 401 {
+		t.Logf("bad token: expected 401, got %v\n", err)
+		t.Fail()
+	}
+
+	ws, _, err := dialGateway(t, server, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ws.WriteJSON(gatewayMessage{Type: "subscribe", Topics: []string{"chirps"}})
+	if _, _, err := ws.ReadMessage(); !websocket.IsCloseError(err, websocket.ClosePolicyViolation) {
+		t.Logf("no auth message: expected a policy violation close, got %v\n", err)
+		t.Fail()
+	}
+
+	ws, _, err = dialGateway(t, server, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ws.WriteJSON(gatewayMessage{Type: "auth", Token: token})
+	if message := readGateway(t, ws); message.Type != "ready" {
+		t.Fatalf("expected ready, got %+v", message)
+	}
+	cases := []struct {
+		name string
+		send gatewayMessage
+		reply string
+		topics int
+	}{
+		{"ping", gatewayMessage{Type: "ping", Ref: "1"}, "pong", 0},
+		{"auth twice", gatewayMessage{Type: "auth", Ref: "2", Token: token}, "error", 0},
+		{"unknown type", gatewayMessage{Type: "shout", Ref: "3"}, "error", 0},
+		{"subscribe", gatewayMessage{Type: "subscribe", Ref: "4", Topics: []string{"notifications"}}, "subscribed", 1},
+		{"unknown topic", gatewayMessage{Type: "subscribe", Ref: "5", Topics: []string{"weather"}}, "error", 0},
+		// Presence needs a shared conversation, which needs the database.
+		{"someone's presence", gatewayMessage{Type: "subscribe", Ref: "6", Topics: []string{"presence:" + uuid.NewString()}}, "error", 0},
+		{"own presence", gatewayMessage{Type: "subscribe", Ref: "7", Topics: []string{"presence:" + userID.String()}}, "subscribed", 1},
+	}
+	for _, c := range cases {
+		if err := ws.WriteJSON(c.send); err != nil {
+			t.Fatal(err)
+		}
+		message := readGateway(t, ws)
+		if message.Type != c.reply || message.Ref != c.send.Ref {
+			t.Logf("%s: expected %s, got %+v\n", c.name, c.reply, message)
+			t.Fail()
+		}
+		if message.Type == "error" && c.send.Type == "subscribe" {
+			// Failed topics are reported one by one, then the summary.
+			message = readGateway(t, ws)
+		}
+		if c.send.Type == "subscribe" && len(message.Topics) != c.topics {
+			t.Logf("%s: expected %d topics subscribed, got %v\n", c.name, c.topics, message.Topics)
+			t.Fail()
+		}
+	}
+
+	cfg.hub.Publish(stream.Event{Type: "notifications", Data: []byte(`{"unread":1}`)}, notificationsTopic(userID))
+	if message := readGateway(t, ws); message.Type != "event" || message.Topic != "notifications" || message.Event != "notifications" {
+		t.Logf("expected the notification event, got %+v\n", message)
+		t.Fail()
+	}
+}
@@ -0,0 +1,723 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/Baehry/chirpy/internal/database"
+	"github.com/Baehry/chirpy/internal/entities"
+	"github.com/Baehry/chirpy/internal/pagination"
+	"github.com/Baehry/chirpy/internal/querycost"
+	"github.com/google/uuid"
+	graphql "github.com/graph-gophers/graphql-go"
+	gqlerrors "github.com/graph-gophers/graphql-go/errors"
+)
+
+const (
+	graphqlMaxDepth = 10
+	graphqlMaxComplexity = 5000
+	graphqlMaxQueryLength = 10000
+	graphqlMaxParallelism = 10
+)
+
+// graphqlPaginated lists the fields that return a page of results, with the
+// page size assumed when a query doesn't ask for one. The complexity of a
+// query multiplies everything selected under them by the page size.
+var graphqlPaginated = map[string]int{
+	"chirps": defaultPageLimit,
+	"timeline": defaultPageLimit,
+	"notifications": defaultPageLimit,
+}
+
+const graphqlSchema = `
+scalar Time
+
+schema {
+	query: Query
+}
+
+type Query {
+	me: User
+	user(handle: String!): User
+	chirp(id: ID!): Chirp
+	chirps(first: Int, after: String): ChirpConnection!
+	timeline(first: Int, after: String): Timeline!
+	notifications(first: Int, after: String, unreadOnly: Boolean): NotificationConnection!
+}
+
+type User {
+	id: ID!
+	handle: String!
+	displayName: String!
+	bio: String!
+	avatarUrl: String!
+	location: String!
+	isChirpyRed: Boolean!
+	createdAt: Time!
+	chirpCount: Int!
+	followerCount: Int!
+	followingCount: Int!
+	chirps(first: Int, after: String): ChirpConnection!
+}
+
+# Chirps have no replies yet, so there's no thread to fetch.
+type Chirp {
+	id: ID!
+	body: String!
+	createdAt: Time!
+	updatedAt: Time!
+	expiresAt: Time
+	author: User
+	pinned: Boolean!
+	bookmarked: Boolean!
+	hashtags: [String!]!
+	mentions: [User!]!
+	media: [Media!]!
+	# likeCount counts likes from other servers; there's no liking here yet.
+	likeCount: Int!
+}
+
+type ChirpConnection {
+	nodes: [Chirp!]!
+	nextCursor: String
+}
+
+type Media {
+	id: ID!
+	url: String!
+	thumbnailUrl: String!
+	contentType: String!
+	width: Int!
+	height: Int!
+	altText: String!
+}
+
+union TimelineItem = Chirp | RemotePost
+
+type Timeline {
+	items: [TimelineItem!]!
+	nextCursor: String
+}
+
+type RemotePost {
+	id: ID!
+	uri: String!
+	url: String!
+	content: String!
+	author: RemoteActor!
+	publishedAt: Time!
+}
+
+type RemoteActor {
+	id: ID!
+	uri: String!
+	account: String!
+	displayName: String!
+	summary: String!
+	url: String!
+}
+
+type Notification {
+	id: ID!
+	type: String!
+	chirp: Chirp
+	detail: String!
+	actors: [User!]!
+	actorCount: Int!
+	createdAt: Time!
+	updatedAt: Time!
+	read: Boolean!
+}
+
+type NotificationConnection {
+	nodes: [Notification!]!
+	unreadCount: Int!
+	nextCursor: String
+}
+`
+
+func newGraphQLSchema(cfg *apiConfig) *graphql.Schema {
+	return graphql.MustParseSchema(graphqlSchema, &graphqlResolver{cfg: cfg},
+		graphql.MaxDepth(graphqlMaxDepth),
+		graphql.MaxQueryLength(graphqlMaxQueryLength),
+		graphql.MaxParallelism(graphqlMaxParallelism),
+	)
+}
+
+func (cfg *apiConfig) GraphQLHandler(writer http.ResponseWriter, request *http.Request) {
+	type parameters struct {
+		Query string `json:"query"`
+		OperationName string `json:"operationName"`
+		Variables map[string]any `json:"variables"`
+	}
+	decoder := json.NewDecoder(request.Body)
+	params := parameters{}
+	if err := decoder.Decode(&params); err != nil {
+		respondWithGraphQLError(writer, 400, "invalid request body")
+		return
+	}
+	// Anonymous queries are fine, but a token that doesn't validate is an
+	// error rather than a silent downgrade to anonymous.
+	viewerID := uuid.Nil
+	if request.Header.Get("Authorization") != "" {
+		userID, err := cfg.authenticatedUserID(request)
+		if err != nil {
+			respondWithGraphQLError(writer, 401, err.Error())
+			return
+		}
+		viewerID = userID
+	}
+	if len(params.Query) > graphqlMaxQueryLength {
+		respondWithGraphQLError(writer, 400, "query is too long")
+		return
+	}
+	_, err := querycost.Estimate(params.Query, params.OperationName, params.Variables, graphqlPaginated, graphqlMaxComplexity)
+	if errors.Is(err, querycost.ErrTooComplex) {
+		respondWithGraphQLError(writer, 400, "query is too complex: cost exceeds "+strconv.Itoa(graphqlMaxComplexity))
+		return
+	}
+	if err != nil {
+		respondWithGraphQLError(writer, 400, err.Error())
+		return
+	}
+	ctx := withGraphQLRequest(request.Context(), cfg.newGraphQLRequest(viewerID))
+	respondWithJSON(writer, 200, cfg.graphqlSchema.Exec(ctx, params.Query, params.OperationName, params.Variables))
+}
+
+func respondWithGraphQLError(writer http.ResponseWriter, code int, msg string) {
+	respondWithJSON(writer, code, graphql.Response{
+		Errors: []*gqlerrors.QueryError{gqlerrors.Errorf("%s", msg)},
+	})
+}
+
+type graphqlResolver struct {
+	cfg *apiConfig
+}
+
+type graphqlPageArgs struct {
+	First *int32
+	After *string
+}
+
+// page reads the arguments the way parsePageParams reads a query string,
+// so the two APIs agree on defaults and limits.
+func (args graphqlPageArgs) page() (pageParams, error) {
+	query := url.Values{}
+	if args.First != nil {
+		query.Set("limit", strconv.Itoa(int(*args.First)))
+	}
+	if args.After != nil {
+		query.Set("cursor", *args.After)
+	}
+	return parsePageParams(query)
+}
+
+func viewerFrom(ctx context.Context) uuid.NullUUID {
+	viewerID := graphqlRequestFrom(ctx).viewerID
+	return uuid.NullUUID{UUID: viewerID, Valid: viewerID != uuid.Nil}
+}
+
+func (resolver *graphqlResolver) Me(ctx context.Context) (*userResolver, error) {
+	viewerID := graphqlRequestFrom(ctx).viewerID
+	if viewerID == uuid.Nil {
+		return nil, nil
+	}
+	user, err := graphqlRequestFrom(ctx).loadUser(ctx, viewerID)
+	if err != nil || user == nil {
+		return nil, err
+	}
+	return &userResolver{cfg: resolver.cfg, user: *user}, nil
+}
+
+func (resolver *graphqlResolver) User(ctx context.Context, args struct{ Handle string }) (*userResolver, error) {
+	user, err := resolver.cfg.dbQueries.GetUserByHandle(ctx, args.Handle)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	blocked, err := resolver.cfg.isBlocked(ctx, graphqlRequestFrom(ctx).viewerID, user.ID)
+	if err != nil || blocked {
+		return nil, err
+	}
+	return &userResolver{cfg: resolver.cfg, user: user}, nil
+}
+
+func (resolver *graphqlResolver) Chirp(ctx context.Context, args struct{ ID graphql.ID }) (*chirpResolver, error) {
+	id, err := uuid.Parse(string(args.ID))
+	if err != nil {
+		return nil, nil
+	}
+	chirp, err := resolver.cfg.dbQueries.GetChirp(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	viewerID := graphqlRequestFrom(ctx).viewerID
+	if !canView(chirp, viewerID) {
+		return nil, nil
+	}
+	blocked, err := resolver.cfg.isBlocked(ctx, viewerID, chirp.UserID)
+	if err != nil || blocked {
+		return nil, err
+	}
+	result, err := resolver.cfg.chirpResponseFor(ctx, viewerID, chirp)
+	if err != nil {
+		return nil, err
+	}
+	return &chirpResolver{cfg: resolver.cfg, chirp: result}, nil
+}
+
+func (resolver *graphqlResolver) Chirps(ctx context.Context, args graphqlPageArgs) (*chirpConnectionResolver, error) {
+	page, err := args.page()
+	if err != nil {
+		return nil, err
+	}
+	chirps, err := resolver.cfg.getAllChirps(ctx, viewerFrom(ctx), true, page)
+	if err != nil {
+		return nil, err
+	}
+	return resolver.cfg.chirpConnection(ctx, chirps, page)
+}
+
+func (resolver *graphqlResolver) Timeline(ctx context.Context, args graphqlPageArgs) (*timelineResolver, error) {
+	viewerID := graphqlRequestFrom(ctx).viewerID
+	if viewerID == uuid.Nil {
+		return nil, errGraphQLUnauthenticated
+	}
+	page, err := args.page()
+	if err != nil {
+		return nil, err
+	}
+	items, nextCursor, err := resolver.cfg.homeTimeline(ctx, viewerID, page)
+	if err != nil {
+		return nil, err
+	}
+	result := &timelineResolver{nextCursor: nextCursor}
+	for _, item := range items {
+		result.items = append(result.items, &timelineItemResolver{cfg: resolver.cfg, item: item})
+	}
+	return result, nil
+}
+
+func (resolver *graphqlResolver) Notifications(ctx context.Context, args struct {
+	First *int32
+	After *string
+	UnreadOnly *bool
+}) (*notificationConnectionResolver, error) {
+	viewerID := graphqlRequestFrom(ctx).viewerID
+	if viewerID == uuid.Nil {
+		return nil, errGraphQLUnauthenticated
+	}
+	page, err := graphqlPageArgs{First: args.First, After: args.After}.page()
+	if err != nil {
+		return nil, err
+	}
+	notifications, nextCursor, err := resolver.cfg.notificationPage(ctx, viewerID, args.UnreadOnly != nil && *args.UnreadOnly, page)
+	if err != nil {
+		return nil, err
+	}
+	result := &notificationConnectionResolver{cfg: resolver.cfg, nextCursor: nextCursor}
+	for _, notification := range notifications {
+		result.nodes = append(result.nodes, &notificationResolver{cfg: resolver.cfg, notification: notification})
+	}
+	return result, nil
+}
+
+// chirpConnection trims a page read with page.fetchLimit() down to size.
+func (cfg *apiConfig) chirpConnection(ctx context.Context, chirps []database.Chirp, page pageParams) (*chirpConnectionResolver, error) {
+	result := &chirpConnectionResolver{}
+	if len(chirps) > page.Limit {
+		chirps = chirps[:page.Limit]
+		last := chirps[len(chirps)-1]
+		cursor := pagination.EncodeCursor(last.CreatedAt, last.ID)
+		result.nextCursor = &cursor
+	}
+	responses, err := cfg.chirpResponses(ctx, graphqlRequestFrom(ctx).viewerID, chirps)
+	if err != nil {
+		return nil, err
+	}
+	for _, chirp := range responses {
+		result.nodes = append(result.nodes, &chirpResolver{cfg: cfg, chirp: chirp})
+	}
+	return result, nil
+}
+
+type userResolver struct {
+	cfg *apiConfig
+	user database.User
+}
+
+func (resolver *userResolver) ID() graphql.ID {
+	return graphql.ID(resolver.user.ID.String())
+}
+
+func (resolver *userResolver) Handle() string {
+	return resolver.user.Handle
+}
+
+func (resolver *userResolver) DisplayName() string {
+	return resolver.user.DisplayName
+}
+
+func (resolver *userResolver) Bio() string {
+	return resolver.user.Bio
+}
+
+func (resolver *userResolver) AvatarURL() string {
+	return resolver.user.AvatarUrl
+}
+
+func (resolver *userResolver) Location() string {
+	return resolver.user.Location
+}
+
+func (resolver *userResolver) IsChirpyRed() bool {
+	return resolver.user.IsChirpyRed
+}
+
+func (resolver *userResolver) CreatedAt() graphql.Time {
+	return graphql.Time{Time: resolver.user.CreatedAt}
+}
+
+func (resolver *userResolver) ChirpCount(ctx context.Context) (int32, error) {
+	counts, err := graphqlRequestFrom(ctx).loadUserCounts(ctx, resolver.user.ID)
+	return int32(counts.ChirpCount), err
+}
+
+func (resolver *userResolver) FollowerCount(ctx context.Context) (int32, error) {
+	counts, err := graphqlRequestFrom(ctx).loadUserCounts(ctx, resolver.user.ID)
+	return int32(counts.FollowerCount), err
+}
+
+func (resolver *userResolver) FollowingCount(ctx context.Context) (int32, error) {
+	counts, err := graphqlRequestFrom(ctx).loadUserCounts(ctx, resolver.user.ID)
+	return int32(counts.FollowingCount), err
+}
+
+func (resolver *userResolver) Chirps(ctx context.Context, args graphqlPageArgs) (*chirpConnectionResolver, error) {
+	page, err := args.page()
+	if err != nil {
+		return nil, err
+	}
+	chirps, err := resolver.cfg.getChirpsByUser(ctx, resolver.user.ID, viewerFrom(ctx), true, false, page)
+	if err != nil {
+		return nil, err
+	}
+	return resolver.cfg.chirpConnection(ctx, chirps, page)
+}
+
+type chirpResolver struct {
+	cfg *apiConfig
+	chirp chirpResponse
+}
+
+func (resolver *chirpResolver) ID() graphql.ID {
+	return graphql.ID(resolver.chirp.ID.String())
+}
+
+func (resolver *chirpResolver) Body() string {
+	return resolver.chirp.Body
+}
+
+func (resolver *chirpResolver) CreatedAt() graphql.Time {
+	return graphql.Time{Time: resolver.chirp.CreatedAt}
+}
+
+func (resolver *chirpResolver) UpdatedAt() graphql.Time {
+	return graphql.Time{Time: resolver.chirp.UpdatedAt}
+}
+
+func (resolver *chirpResolver) ExpiresAt() *graphql.Time {
+	if resolver.chirp.ExpiresAt == nil {
+		return nil
+	}
+	return &graphql.Time{Time: *resolver.chirp.ExpiresAt}
+}
+
+func (resolver *chirpResolver) Author(ctx context.Context) (*userResolver, error) {
+	user, err := graphqlRequestFrom(ctx).loadUser(ctx, resolver.chirp.UserID)
+	if err != nil || user == nil {
+		return nil, err
+	}
+	return &userResolver{cfg: resolver.cfg, user: *user}, nil
+}
+
+func (resolver *chirpResolver) Pinned() bool {
+	return resolver.chirp.Pinned
+}
+
+func (resolver *chirpResolver) Bookmarked() bool {
+	return resolver.chirp.Bookmarked
+}
+
+func (resolver *chirpResolver) Hashtags() []string {
+	hashtags := entities.Values(resolver.chirp.Entities, entities.TypeHashtag)
+	if hashtags == nil {
+		return []string{}
+	}
+	return hashtags
+}
+
+func (resolver *chirpResolver) Mentions(ctx context.Context) ([]*userResolver, error) {
+	ids := []uuid.UUID{}
+	seen := map[uuid.UUID]bool{}
+	for _, entity := range resolver.chirp.Entities {
+		if entity.Type != entities.TypeMention || entity.UserID == nil || seen[*entity.UserID] {
+			continue
+		}
+		seen[*entity.UserID] = true
+		ids = append(ids, *entity.UserID)
+	}
+	users, err := graphqlRequestFrom(ctx).loadUsers(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	result := []*userResolver{}
+	for _, user := range users {
+		result = append(result, &userResolver{cfg: resolver.cfg, user: user})
+	}
+	return result, nil
+}
+
+func (resolver *chirpResolver) LikeCount(ctx context.Context) (int32, error) {
+	count, err := graphqlRequestFrom(ctx).loadLikeCount(ctx, resolver.chirp.ID)
+	return int32(count), err
+}
+
+func (resolver *chirpResolver) Media() []*mediaResolver {
+	result := []*mediaResolver{}
+	for _, medium := range resolver.chirp.Media {
+		result = append(result, &mediaResolver{medium: medium})
+	}
+	return result
+}
+
+type chirpConnectionResolver struct {
+	nodes []*chirpResolver
+	nextCursor *string
+}
+
+func (resolver *chirpConnectionResolver) Nodes() []*chirpResolver {
+	if resolver.nodes == nil {
+		return []*chirpResolver{}
+	}
+	return resolver.nodes
+}
+
+func (resolver *chirpConnectionResolver) NextCursor() *string {
+	return resolver.nextCursor
+}
+
+type mediaResolver struct {
+	medium mediaResponse
+}
+
+func (resolver *mediaResolver) ID() graphql.ID {
+	return graphql.ID(resolver.medium.ID.String())
+}
+
+func (resolver *mediaResolver) URL() string {
+	return resolver.medium.URL
+}
+
+func (resolver *mediaResolver) ThumbnailURL() string {
+	return resolver.medium.ThumbnailURL
+}
+
+func (resolver *mediaResolver) ContentType() string {
+	return resolver.medium.ContentType
+}
+
+func (resolver *mediaResolver) Width() int32 {
+	return resolver.medium.Width
+}
+
+func (resolver *mediaResolver) Height() int32 {
+	return resolver.medium.Height
+}
+
+func (resolver *mediaResolver) AltText() string {
+	return resolver.medium.AltText
+}
+
+type timelineResolver struct {
+	items []*timelineItemResolver
+	nextCursor *string
+}
+
+func (resolver *timelineResolver) Items() []*timelineItemResolver {
+	if resolver.items == nil {
+		return []*timelineItemResolver{}
+	}
+	return resolver.items
+}
+
+func (resolver *timelineResolver) NextCursor() *string {
+	return resolver.nextCursor
+}
+
+type timelineItemResolver struct {
+	cfg *apiConfig
+	item timelineItem
+}
+
+func (resolver *timelineItemResolver) ToChirp() (*chirpResolver, bool) {
+	if resolver.item.Chirp == nil {
+		return nil, false
+	}
+	return &chirpResolver{cfg: resolver.cfg, chirp: *resolver.item.Chirp}, true
+}
+
+func (resolver *timelineItemResolver) ToRemotePost() (*remotePostResolver, bool) {
+	if resolver.item.RemotePost == nil {
+		return nil, false
+	}
+	return &remotePostResolver{post: *resolver.item.RemotePost}, true
+}
+
+type remotePostResolver struct {
+	post remotePostResponse
+}
+
+func (resolver *remotePostResolver) ID() graphql.ID {
+	return graphql.ID(resolver.post.ID.String())
+}
+
+func (resolver *remotePostResolver) URI() string {
+	return resolver.post.URI
+}
+
+func (resolver *remotePostResolver) URL() string {
+	return resolver.post.URL
+}
+
+func (resolver *remotePostResolver) Content() string {
+	return resolver.post.Content
+}
+
+func (resolver *remotePostResolver) Author() *remoteActorResolver {
+	return &remoteActorResolver{actor: resolver.post.Author}
+}
+
+func (resolver *remotePostResolver) PublishedAt() graphql.Time {
+	return graphql.Time{Time: resolver.post.PublishedAt}
+}
+
+type remoteActorResolver struct {
+	actor remoteActorResponse
+}
+
+func (resolver *remoteActorResolver) ID() graphql.ID {
+	return graphql.ID(resolver.actor.ID.String())
+}
+
+func (resolver *remoteActorResolver) URI() string {
+	return resolver.actor.URI
+}
+
+func (resolver *remoteActorResolver) Account() string {
+	return resolver.actor.Account
+}
+
+func (resolver *remoteActorResolver) DisplayName() string {
+	return resolver.actor.DisplayName
+}
+
+func (resolver *remoteActorResolver) Summary() string {
+	return resolver.actor.Summary
+}
+
+func (resolver *remoteActorResolver) URL() string {
+	return resolver.actor.URL
+}
+
+type notificationResolver struct {
+	cfg *apiConfig
+	notification notificationResponse
+}
+
+func (resolver *notificationResolver) ID() graphql.ID {
+	return graphql.ID(resolver.notification.ID.String())
+}
+
+func (resolver *notificationResolver) Type() string {
+	return resolver.notification.Type
+}
+
+func (resolver *notificationResolver) Chirp(ctx context.Context) (*chirpResolver, error) {
+	if resolver.notification.ChirpID == nil {
+		return nil, nil
+	}
+	chirp, err := graphqlRequestFrom(ctx).loadChirp(ctx, *resolver.notification.ChirpID)
+	if err != nil || chirp == nil {
+		return nil, err
+	}
+	return &chirpResolver{cfg: resolver.cfg, chirp: *chirp}, nil
+}
+
+func (resolver *notificationResolver) Detail() string {
+	return resolver.notification.Detail
+}
+
+func (resolver *notificationResolver) Actors(ctx context.Context) ([]*userResolver, error) {
+	ids := make([]uuid.UUID, len(resolver.notification.Actors))
+	for i, actor := range resolver.notification.Actors {
+		ids[i] = actor.ID
+	}
+	users, err := graphqlRequestFrom(ctx).loadUsers(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	result := []*userResolver{}
+	for _, user := range users {
+		result = append(result, &userResolver{cfg: resolver.cfg, user: user})
+	}
+	return result, nil
+}
+
+func (resolver *notificationResolver) ActorCount() int32 {
+	return int32(resolver.notification.ActorCount)
+}
+
+func (resolver *notificationResolver) CreatedAt() graphql.Time {
+	return graphql.Time{Time: resolver.notification.CreatedAt}
+}
+
+func (resolver *notificationResolver) UpdatedAt() graphql.Time {
+	return graphql.Time{Time: resolver.notification.UpdatedAt}
+}
+
+func (resolver *notificationResolver) Read() bool {
+	return resolver.notification.Read
+}
+
+type notificationConnectionResolver struct {
+	cfg *apiConfig
+	nodes []*notificationResolver
+	nextCursor *string
+}
+
+func (resolver *notificationConnectionResolver) Nodes() []*notificationResolver {
+	if resolver.nodes == nil {
+		return []*notificationResolver{}
+	}
+	return resolver.nodes
+}
+
+func (resolver *notificationConnectionResolver) UnreadCount(ctx context.Context) (int32, error) {
+	unread, err := resolver.cfg.dbQueries.CountUnreadNotifications(ctx, graphqlRequestFrom(ctx).viewerID)
+	return int32(unread), err
+}
+
+func (resolver *notificationConnectionResolver) NextCursor() *string {
+	return resolver.nextCursor
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Baehry/chirpy/internal/database"
+	"github.com/google/uuid"
+	"github.com/graph-gophers/dataloader"
+)
+
+type graphqlContextKey struct{}
+
+// graphqlRequest is what resolvers need to know about the request they are
+// answering. The loaders live for one request only, so nothing cached in
+// them outlives the viewer it was loaded for.
+type graphqlRequest struct {
+	viewerID uuid.UUID
+	users *dataloader.Loader
+	userCounts *dataloader.Loader
+	chirps *dataloader.Loader
+	likeCounts *dataloader.Loader
+}
+
+var errGraphQLUnauthenticated = errors.New("authentication required")
+
+func (cfg *apiConfig) newGraphQLRequest(viewerID uuid.UUID) *graphqlRequest {
+	return &graphqlRequest{
+		viewerID: viewerID,
+		users: dataloader.NewBatchedLoader(cfg.batchUsers),
+		userCounts: dataloader.NewBatchedLoader(cfg.batchUserCounts),
+		chirps: dataloader.NewBatchedLoader(func(ctx context.Context, keys dataloader.Keys) []*dataloader.Result {
+			return cfg.batchChirps(ctx, viewerID, keys)
+		}),
+		likeCounts: dataloader.NewBatchedLoader(cfg.batchLikeCounts),
+	}
+}
+
+func withGraphQLRequest(ctx context.Context, request *graphqlRequest) context.Context {
+	return context.WithValue(ctx, graphqlContextKey{}, request)
+}
+
+func graphqlRequestFrom(ctx context.Context) *graphqlRequest {
+	return ctx.Value(graphqlContextKey{}).(*graphqlRequest)
+}
+
+// batchIDs parses the loader keys. Keys are always uuids we put there
+// ourselves, so a bad one is a bug rather than bad input.
+func batchIDs(keys dataloader.Keys) []uuid.UUID {
+	ids := make([]uuid.UUID, len(keys))
+	for i, key := range keys {
+		ids[i] = uuid.MustParse(key.String())
+	}
+	return ids
+}
+
+// batchResults lines the loaded values up with the keys they were loaded
+// for. Keys with nothing behind them resolve to nil.
+func batchResults(keys dataloader.Keys, values map[string]any, err error) []*dataloader.Result {
+	results := make([]*dataloader.Result, len(keys))
+	for i, key := range keys {
+		results[i] = &dataloader.Result{Data: values[key.String()], Error: err}
+	}
+	return results
+}
+
+func (cfg *apiConfig) batchUsers(ctx context.Context, keys dataloader.Keys) []*dataloader.Result {
+	users, err := cfg.dbQueries.GetUsersByIDs(ctx, batchIDs(keys))
+	values := map[string]any{}
+	for _, user := range users {
+		values[user.ID.String()] = user
+	}
+	return batchResults(keys, values, err)
+}
+
+func (cfg *apiConfig) batchUserCounts(ctx context.Context, keys dataloader.Keys) []*dataloader.Result {
+	counts, err := cfg.dbQueries.GetUserCounts(ctx, batchIDs(keys))
+	values := map[string]any{}
+	for _, count := range counts {
+		values[count.ID.String()] = count
+	}
+	return batchResults(keys, values, err)
+}
+
+func (cfg *apiConfig) batchLikeCounts(ctx context.Context, keys dataloader.Keys) []*dataloader.Result {
+	counts, err := cfg.dbQueries.CountLikesForChirps(ctx, batchIDs(keys))
+	values := map[string]any{}
+	for _, count := range counts {
+		values[count.ChirpID.String()] = count.LikeCount
+	}
+	return batchResults(keys, values, err)
+}
+
+func (cfg *apiConfig) batchChirps(ctx context.Context, viewerID uuid.UUID, keys dataloader.Keys) []*dataloader.Result {
+	chirps, err := cfg.dbQueries.GetChirpsByIDs(ctx, database.GetChirpsByIDsParams{
+		Ids: batchIDs(keys),
+		ViewerID: uuid.NullUUID{UUID: viewerID, Valid: viewerID != uuid.Nil},
+	})
+	if err != nil {
+		return batchResults(keys, nil, err)
+	}
+	result, err := cfg.chirpResponses(ctx, viewerID, chirps)
+	values := map[string]any{}
+	for _, chirp := range result {
+		values[chirp.ID.String()] = chirp
+	}
+	return batchResults(keys, values, err)
+}
+
+func (request *graphqlRequest) loadUser(ctx context.Context, id uuid.UUID) (*database.User, error) {
+	data, err := request.users.Load(ctx, dataloader.StringKey(id.String()))()
+	if err != nil || data == nil {
+		return nil, err
+	}
+	user := data.(database.User)
+	return &user, nil
+}
+
+func (request *graphqlRequest) loadUsers(ctx context.Context, ids []uuid.UUID) ([]database.User, error) {
+	keys := make(dataloader.Keys, len(ids))
+	for i, id := range ids {
+		keys[i] = dataloader.StringKey(id.String())
+	}
+	data, errs := request.users.LoadMany(ctx, keys)()
+	users := []database.User{}
+	for i := range data {
+		if i < len(errs) && errs[i] != nil {
+			return nil, errs[i]
+		}
+		if data[i] != nil {
+			users = append(users, data[i].(database.User))
+		}
+	}
+	return users, nil
+}
+
+func (request *graphqlRequest) loadUserCounts(ctx context.Context, id uuid.UUID) (database.GetUserCountsRow, error) {
+	data, err := request.userCounts.Load(ctx, dataloader.StringKey(id.String()))()
+	if err != nil || data == nil {
+		return database.GetUserCountsRow{}, err
+	}
+	return data.(database.GetUserCountsRow), nil
+}
+
+func (request *graphqlRequest) loadChirp(ctx context.Context, id uuid.UUID) (*chirpResponse, error) {
+	data, err := request.chirps.Load(ctx, dataloader.StringKey(id.String()))()
+	if err != nil || data == nil {
+		return nil, err
+	}
+	chirp := data.(chirpResponse)
+	return &chirp, nil
+}
+
+// loadLikeCount counts a chirp's likes. Chirps nobody liked have no row,
+// so they come back as nil and count as 0.
+func (request *graphqlRequest) loadLikeCount(ctx context.Context, id uuid.UUID) (int64, error) {
+	data, err := request.likeCounts.Load(ctx, dataloader.StringKey(id.String()))()
+	if err != nil || data == nil {
+		return 0, err
+	}
+	return data.(int64), nil
+}
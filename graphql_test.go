@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestGraphQLSchema(t *testing.T) {
+	cfg := &apiConfig{}
+	schema := newGraphQLSchema(cfg)
+	ctx := withGraphQLRequest(context.Background(), cfg.newGraphQLRequest(uuid.Nil))
+	response := schema.Exec(ctx, `{ __type(name: "Chirp") { fields { name } } }`, "", nil)
+	if len(response.Errors) > 0 {
+		t.Fatal(response.Errors)
+	}
+	var data struct {
+		Type struct {
+			Fields []struct {
+				Name string `json:"name"`
+			} `json:"fields"`
+		} `json:"__type"`
+	}
+	if err := json.Unmarshal(response.Data, &data); err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, field := range data.Type.Fields {
+		names = append(names, field.Name)
+	}
+	for _, expected := range []string{"author", "mentions", "media", "likeCount"} {
+		if !slices.Contains(names, expected) {
+			t.Logf("expected Chirp to have %s, got %v\n", expected, names)
+			t.Fail()
+		}
+	}
+}
@@ -0,0 +1,404 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Baehry/chirpy/internal/auth"
+	"github.com/Baehry/chirpy/internal/chirpypb"
+	"github.com/Baehry/chirpy/internal/database"
+	"github.com/Baehry/chirpy/internal/entities"
+	"github.com/Baehry/chirpy/internal/grpcauth"
+	"github.com/Baehry/chirpy/internal/pagination"
+	"github.com/Baehry/chirpy/internal/stream"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (cfg *apiConfig) newGRPCServer() *grpc.Server {
+	authenticator := grpcauth.Authenticator{
+		Secret: cfg.tokenSecret,
+		Anonymous: map[string]bool{
+			chirpypb.AuthService_VerifyToken_FullMethodName: true,
+			chirpypb.UserService_GetUser_FullMethodName: true,
+			chirpypb.UserService_GetUsers_FullMethodName: true,
+			chirpypb.ChirpService_GetChirp_FullMethodName: true,
+			chirpypb.ChirpService_ListChirps_FullMethodName: true,
+			chirpypb.ChirpService_StreamChirps_FullMethodName: true,
+		},
+	}
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(authenticator.Unary()),
+		grpc.ChainStreamInterceptor(authenticator.Stream()),
+	)
+	chirpypb.RegisterAuthServiceServer(server, &authServer{cfg: cfg})
+	chirpypb.RegisterUserServiceServer(server, &userServer{cfg: cfg})
+	chirpypb.RegisterChirpServiceServer(server, &chirpServer{cfg: cfg})
+	return server
+}
+
+func grpcViewer(ctx context.Context) uuid.NullUUID {
+	viewerID := grpcauth.UserID(ctx)
+	return uuid.NullUUID{UUID: viewerID, Valid: viewerID != uuid.Nil}
+}
+
+func grpcInternal(err error) error {
+	return status.Error(codes.Internal, err.Error())
+}
+
+// grpcPage reads page_size and page_token the way parsePageParams reads
+// limit and cursor, so gRPC and HTTP pages agree on defaults and limits.
+func grpcPage(size int32, token string) (pageParams, error) {
+	query := url.Values{}
+	if size != 0 {
+		query.Set("limit", strconv.Itoa(int(size)))
+	}
+	if token != "" {
+		query.Set("cursor", token)
+	}
+	page, err := parsePageParams(query)
+	if err != nil {
+		return pageParams{}, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return page, nil
+}
+
+func timestampOrNil(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+type authServer struct {
+	chirpypb.UnimplementedAuthServiceServer
+	cfg *apiConfig
+}
+
+func (server *authServer) VerifyToken(ctx context.Context, request *chirpypb.VerifyTokenRequest) (*chirpypb.VerifyTokenResponse, error) {
+	userID, err := auth.ValidateJWT(request.Token, server.cfg.tokenSecret)
+	if err != nil {
+		return &chirpypb.VerifyTokenResponse{}, nil
+	}
+	return &chirpypb.VerifyTokenResponse{
+		Valid: true,
+		UserId: userID.String(),
+	}, nil
+}
+
+type userServer struct {
+	chirpypb.UnimplementedUserServiceServer
+	cfg *apiConfig
+}
+
+func (server *userServer) GetUser(ctx context.Context, request *chirpypb.GetUserRequest) (*chirpypb.User, error) {
+	var user database.User
+	var err error
+	switch {
+	case request.GetId() != "":
+		id, parseErr := uuid.Parse(request.GetId())
+		if parseErr != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid id")
+		}
+		user, err = server.cfg.dbQueries.GetUser(ctx, id)
+	case request.GetHandle() != "":
+		user, err = server.cfg.dbQueries.GetUserByHandle(ctx, request.GetHandle())
+	default:
+		return nil, status.Error(codes.InvalidArgument, "id or handle is required")
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+	if err != nil {
+		return nil, grpcInternal(err)
+	}
+	blocked, err := server.cfg.isBlocked(ctx, grpcauth.UserID(ctx), user.ID)
+	if err != nil {
+		return nil, grpcInternal(err)
+	}
+	if blocked {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+	users, err := server.cfg.userProtos(ctx, []database.User{user})
+	if err != nil {
+		return nil, grpcInternal(err)
+	}
+	return users[0], nil
+}
+
+func (server *userServer) GetUsers(ctx context.Context, request *chirpypb.GetUsersRequest) (*chirpypb.GetUsersResponse, error) {
+	if len(request.Ids) > maxPageLimit {
+		return nil, status.Errorf(codes.InvalidArgument, "at most %d ids", maxPageLimit)
+	}
+	ids := make([]uuid.UUID, len(request.Ids))
+	for i, id := range request.Ids {
+		parsed, err := uuid.Parse(id)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid id %q", id)
+		}
+		ids[i] = parsed
+	}
+	users, err := server.cfg.dbQueries.GetUsersByIDs(ctx, ids)
+	if err != nil {
+		return nil, grpcInternal(err)
+	}
+	visible := []database.User{}
+	for _, user := range users {
+		blocked, err := server.cfg.isBlocked(ctx, grpcauth.UserID(ctx), user.ID)
+		if err != nil {
+			return nil, grpcInternal(err)
+		}
+		if !blocked {
+			visible = append(visible, user)
+		}
+	}
+	result, err := server.cfg.userProtos(ctx, visible)
+	if err != nil {
+		return nil, grpcInternal(err)
+	}
+	return &chirpypb.GetUsersResponse{Users: result}, nil
+}
+
+func (server *userServer) GetCurrentUser(ctx context.Context, request *chirpypb.GetCurrentUserRequest) (*chirpypb.User, error) {
+	user, err := server.cfg.dbQueries.GetUser(ctx, grpcauth.UserID(ctx))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+	if err != nil {
+		return nil, grpcInternal(err)
+	}
+	users, err := server.cfg.userProtos(ctx, []database.User{user})
+	if err != nil {
+		return nil, grpcInternal(err)
+	}
+	return users[0], nil
+}
+
+// userProtos converts users along with their counts, which are read for
+// all of them at once.
+func (cfg *apiConfig) userProtos(ctx context.Context, users []database.User) ([]*chirpypb.User, error) {
+	ids := make([]uuid.UUID, len(users))
+	for i, user := range users {
+		ids[i] = user.ID
+	}
+	counts, err := cfg.dbQueries.GetUserCounts(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	countsByUser := map[uuid.UUID]database.GetUserCountsRow{}
+	for _, count := range counts {
+		countsByUser[count.ID] = count
+	}
+	result := make([]*chirpypb.User, len(users))
+	for i, user := range users {
+		count := countsByUser[user.ID]
+		result[i] = &chirpypb.User{
+			Id: user.ID.String(),
+			Handle: user.Handle,
+			DisplayName: user.DisplayName,
+			Bio: user.Bio,
+			AvatarUrl: user.AvatarUrl,
+			Location: user.Location,
+			IsChirpyRed: user.IsChirpyRed,
+			CreatedAt: timestamppb.New(user.CreatedAt),
+			ChirpCount: count.ChirpCount,
+			FollowerCount: count.FollowerCount,
+			FollowingCount: count.FollowingCount,
+		}
+	}
+	return result, nil
+}
+
+type chirpServer struct {
+	chirpypb.UnimplementedChirpServiceServer
+	cfg *apiConfig
+}
+
+func (server *chirpServer) GetChirp(ctx context.Context, request *chirpypb.GetChirpRequest) (*chirpypb.Chirp, error) {
+	id, err := uuid.Parse(request.Id)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid id")
+	}
+	chirp, err := server.cfg.dbQueries.GetChirp(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, status.Error(codes.NotFound, "chirp not found")
+	}
+	if err != nil {
+		return nil, grpcInternal(err)
+	}
+	viewerID := grpcauth.UserID(ctx)
+	if !canView(chirp, viewerID) {
+		return nil, status.Error(codes.NotFound, "chirp not found")
+	}
+	blocked, err := server.cfg.isBlocked(ctx, viewerID, chirp.UserID)
+	if err != nil {
+		return nil, grpcInternal(err)
+	}
+	if blocked {
+		return nil, status.Error(codes.NotFound, "chirp not found")
+	}
+	result, err := server.cfg.chirpResponseFor(ctx, viewerID, chirp)
+	if err != nil {
+		return nil, grpcInternal(err)
+	}
+	return chirpProto(result), nil
+}
+
+func (server *chirpServer) ListChirps(ctx context.Context, request *chirpypb.ListChirpsRequest) (*chirpypb.ListChirpsResponse, error) {
+	page, err := grpcPage(request.PageSize, request.PageToken)
+	if err != nil {
+		return nil, err
+	}
+	var chirps []database.Chirp
+	if request.AuthorId != "" {
+		authorID, err := uuid.Parse(request.AuthorId)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid author_id")
+		}
+		chirps, err = server.cfg.getChirpsByUser(ctx, authorID, grpcViewer(ctx), true, false, page)
+	} else {
+		chirps, err = server.cfg.getAllChirps(ctx, grpcViewer(ctx), true, page)
+	}
+	if err != nil {
+		return nil, grpcInternal(err)
+	}
+	response := &chirpypb.ListChirpsResponse{}
+	if len(chirps) > page.Limit {
+		chirps = chirps[:page.Limit]
+		last := chirps[len(chirps)-1]
+		response.NextPageToken = pagination.EncodeCursor(last.CreatedAt, last.ID)
+	}
+	result, err := server.cfg.chirpResponses(ctx, grpcauth.UserID(ctx), chirps)
+	if err != nil {
+		return nil, grpcInternal(err)
+	}
+	for _, chirp := range result {
+		response.Chirps = append(response.Chirps, chirpProto(chirp))
+	}
+	return response, nil
+}
+
+// StreamChirps is StreamChirpsHandler over gRPC: the same topics, replay
+// and filtering, with resume_token standing in for Last-Event-ID.
+func (server *chirpServer) StreamChirps(request *chirpypb.StreamChirpsRequest, feed grpc.ServerStreamingServer[chirpypb.StreamChirpsResponse]) error {
+	cfg := server.cfg
+	var authorID uuid.NullUUID
+	if request.AuthorId != "" {
+		id, err := uuid.Parse(request.AuthorId)
+		if err != nil {
+			return status.Error(codes.InvalidArgument, "invalid author_id")
+		}
+		authorID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+	hashtag := strings.ToLower(strings.TrimPrefix(request.Hashtag, "#"))
+	if authorID.Valid && hashtag != "" {
+		return status.Error(codes.InvalidArgument, "author_id and hashtag can't be combined")
+	}
+	var resumeFrom pagination.Cursor
+	if request.ResumeToken != "" {
+		cursor, err := pagination.DecodeCursor(request.ResumeToken)
+		if err != nil {
+			return status.Error(codes.InvalidArgument, "invalid resume_token")
+		}
+		resumeFrom = cursor
+	}
+	ctx := feed.Context()
+	viewer := grpcViewer(ctx)
+
+	topic := "chirps"
+	if authorID.Valid {
+		topic = "author:" + authorID.UUID.String()
+	} else if hashtag != "" {
+		topic = "hashtag:" + hashtag
+	}
+	// Subscribe before replaying so nothing published in between is lost.
+	sub := cfg.hub.Subscribe(streamBufferSize, topic)
+	defer sub.Close()
+
+	send := func(event stream.Event) error {
+		var chirp chirpResponse
+		if err := json.Unmarshal(event.Data, &chirp); err != nil {
+			return err
+		}
+		return feed.Send(&chirpypb.StreamChirpsResponse{
+			Chirp: chirpProto(chirp),
+			ResumeToken: event.ID,
+		})
+	}
+	replayed := map[uuid.UUID]bool{}
+	if request.ResumeToken != "" {
+		if err := cfg.replayChirps(ctx, send, resumeFrom, authorID, hashtag, viewer, replayed); err != nil {
+			return err
+		}
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-cfg.draining:
+			return status.Error(codes.Unavailable, "server is shutting down")
+		case event, ok := <-sub.Events():
+			// A dropped subscription ends the stream; the client
+			// reconnects with its last resume_token.
+			if !ok {
+				return status.Error(codes.Unavailable, sub.Err().Error())
+			}
+			if hashtag != "" && !event.Has("hashtag:"+hashtag) {
+				continue
+			}
+			cursor, err := pagination.DecodeCursor(event.ID)
+			if err != nil || replayed[cursor.ID] {
+				continue
+			}
+			if cfg.chirpEventHidden(ctx, event, viewer) {
+				continue
+			}
+			if err := send(event); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func chirpProto(chirp chirpResponse) *chirpypb.Chirp {
+	result := &chirpypb.Chirp{
+		Id: chirp.ID.String(),
+		UserId: chirp.UserID.String(),
+		Body: chirp.Body,
+		CreatedAt: timestamppb.New(chirp.CreatedAt),
+		UpdatedAt: timestamppb.New(chirp.UpdatedAt),
+		ExpiresAt: timestampOrNil(chirp.ExpiresAt),
+		Hashtags: entities.Values(chirp.Entities, entities.TypeHashtag),
+		Pinned: chirp.Pinned,
+		Bookmarked: chirp.Bookmarked,
+	}
+	seen := map[uuid.UUID]bool{}
+	for _, entity := range chirp.Entities {
+		if entity.Type != entities.TypeMention || entity.UserID == nil || seen[*entity.UserID] {
+			continue
+		}
+		seen[*entity.UserID] = true
+		result.MentionedUserIds = append(result.MentionedUserIds, entity.UserID.String())
+	}
+	for _, medium := range chirp.Media {
+		result.Media = append(result.Media, &chirpypb.Media{
+			Id: medium.ID.String(),
+			Url: medium.URL,
+			ThumbnailUrl: medium.ThumbnailURL,
+			ContentType: medium.ContentType,
+			Width: medium.Width,
+			Height: medium.Height,
+			AltText: medium.AltText,
+		})
+	}
+	return result
+}
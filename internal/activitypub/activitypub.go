@@ -0,0 +1,205 @@
+package activitypub
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+const (
+	ContentType = "application/activity+json"
+	// LDContentType is the other media type servers send and accept for
+	// activities.
+	LDContentType = `application/ld+json; profile="https://www.w3.org/ns/activitystreams"`
+	JRDContentType = "application/jrd+json"
+
+	ActivityStreamsContext = "https://www.w3.org/ns/activitystreams"
+	SecurityContext = "https://w3id.org/security/v1"
+	// Public is the audience that makes an object visible to everyone.
+	Public = "https://www.w3.org/ns/activitystreams#Public"
+)
+
+var ErrInvalidAccount = errors.New("invalid account")
+
+// Context is the @context of every document we serve.
+var Context = []string{ActivityStreamsContext, SecurityContext}
+
+type PublicKey struct {
+	ID string `json:"id"`
+	Owner string `json:"owner"`
+	PublicKeyPem string `json:"publicKeyPem"`
+}
+
+type Endpoints struct {
+	SharedInbox string `json:"sharedInbox,omitempty"`
+}
+
+type Actor struct {
+	Context any `json:"@context,omitempty"`
+	ID string `json:"id"`
+	Type string `json:"type"`
+	PreferredUsername string `json:"preferredUsername"`
+	Name string `json:"name,omitempty"`
+	Summary string `json:"summary,omitempty"`
+	URL string `json:"url,omitempty"`
+	Inbox string `json:"inbox"`
+	Outbox string `json:"outbox,omitempty"`
+	Followers string `json:"followers,omitempty"`
+	Following string `json:"following,omitempty"`
+	Endpoints *Endpoints `json:"endpoints,omitempty"`
+	PublicKey PublicKey `json:"publicKey"`
+}
+
+// SharedInbox is where activities for several of the actor's server's
+// users can be sent at once, or the actor's own inbox if there's none.
+func (actor Actor) SharedInbox() string {
+	if actor.Endpoints != nil && actor.Endpoints.SharedInbox != "" {
+		return actor.Endpoints.SharedInbox
+	}
+	return actor.Inbox
+}
+
+type Note struct {
+	Context any `json:"@context,omitempty"`
+	ID string `json:"id"`
+	Type string `json:"type"`
+	AttributedTo string `json:"attributedTo"`
+	Content string `json:"content"`
+	URL string `json:"url,omitempty"`
+	InReplyTo string `json:"inReplyTo,omitempty"`
+	Published time.Time `json:"published"`
+	Updated *time.Time `json:"updated,omitempty"`
+	To []string `json:"to,omitempty"`
+	Cc []string `json:"cc,omitempty"`
+}
+
+// Activity is any activity. Object is kept raw because it's a bare ID as
+// often as an embedded object; ObjectID and ObjectType read either form.
+type Activity struct {
+	Context any `json:"@context,omitempty"`
+	ID string `json:"id"`
+	Type string `json:"type"`
+	Actor string `json:"actor"`
+	Object json.RawMessage `json:"object"`
+	Published *time.Time `json:"published,omitempty"`
+	To []string `json:"to,omitempty"`
+	Cc []string `json:"cc,omitempty"`
+}
+
+// NewActivity wraps object, which can be an ID or anything that
+// marshals to an object, in an activity.
+func NewActivity(id string, activityType string, actor string, object any) (Activity, error) {
+	raw, err := json.Marshal(object)
+	if err != nil {
+		return Activity{}, err
+	}
+	return Activity{
+		Context: ActivityStreamsContext,
+		ID: id,
+		Type: activityType,
+		Actor: actor,
+		Object: raw,
+	}, nil
+}
+
+type objectHeader struct {
+	ID string `json:"id"`
+	Type string `json:"type"`
+}
+
+func (activity Activity) object() objectHeader {
+	var id string
+	if err := json.Unmarshal(activity.Object, &id); err == nil {
+		return objectHeader{ID: id}
+	}
+	var header objectHeader
+	json.Unmarshal(activity.Object, &header)
+	return header
+}
+
+// ObjectID is the ID of the activity's object.
+func (activity Activity) ObjectID() string {
+	return activity.object().ID
+}
+
+// ObjectType is the type of the activity's object, or "" when the object
+// is only referenced by ID.
+func (activity Activity) ObjectType() string {
+	return activity.object().Type
+}
+
+// InnerActivity decodes an object that is itself an activity, as in Undo
+// and Accept. ok is false when the object is only an ID.
+func (activity Activity) InnerActivity() (inner Activity, ok bool) {
+	if err := json.Unmarshal(activity.Object, &inner); err != nil {
+		return Activity{}, false
+	}
+	return inner, true
+}
+
+type OrderedCollection struct {
+	Context any `json:"@context,omitempty"`
+	ID string `json:"id"`
+	Type string `json:"type"`
+	TotalItems int64 `json:"totalItems"`
+	First string `json:"first,omitempty"`
+	OrderedItems []any `json:"orderedItems,omitempty"`
+}
+
+type OrderedCollectionPage struct {
+	Context any `json:"@context,omitempty"`
+	ID string `json:"id"`
+	Type string `json:"type"`
+	PartOf string `json:"partOf"`
+	Next string `json:"next,omitempty"`
+	OrderedItems []any `json:"orderedItems"`
+}
+
+// Tombstone stands in for a deleted object.
+type Tombstone struct {
+	ID string `json:"id"`
+	Type string `json:"type"`
+}
+
+// WebFinger is a WebFinger response (a JRD).
+type WebFinger struct {
+	Subject string `json:"subject"`
+	Aliases []string `json:"aliases,omitempty"`
+	Links []Link `json:"links"`
+}
+
+type Link struct {
+	Rel string `json:"rel"`
+	Type string `json:"type,omitempty"`
+	Href string `json:"href,omitempty"`
+}
+
+// ActorURI is the ActivityPub actor a WebFinger response points to.
+func (finger WebFinger) ActorURI() (string, bool) {
+	for _, link := range finger.Links {
+		if link.Rel == "self" && IsActivityContentType(link.Type) && link.Href != "" {
+			return link.Href, true
+		}
+	}
+	return "", false
+}
+
+// ParseAccount splits "user@domain", optionally written "@user@domain" or
+// "acct:user@domain", into its parts.
+func ParseAccount(account string) (user string, domain string, err error) {
+	account = strings.TrimPrefix(account, "acct:")
+	account = strings.TrimPrefix(account, "@")
+	user, domain, ok := strings.Cut(account, "@")
+	if !ok || user == "" || domain == "" || strings.ContainsAny(domain, "@/?#") {
+		return "", "", ErrInvalidAccount
+	}
+	return user, strings.ToLower(domain), nil
+}
+
+// IsActivityContentType reports whether a Content-Type or Accept value
+// names an ActivityPub document.
+func IsActivityContentType(value string) bool {
+	return strings.Contains(value, "application/activity+json") ||
+		(strings.Contains(value, "application/ld+json") && strings.Contains(value, "activitystreams"))
+}
@@ -0,0 +1,81 @@
+package activitypub
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseAccount(t *testing.T) {
+	cases := []struct {
+		account string
+		user string
+		domain string
+		valid bool
+	}{
+		{"alice@example.com", "alice", "example.com", true},
+		{"@alice@Example.com", "alice", "example.com", true},
+		{"acct:alice@example.com", "alice", "example.com", true},
+		{"alice", "", "", false},
+		{"@example.com", "", "", false},
+		{"alice@", "", "", false},
+		{"alice@example.com/evil", "", "", false},
+	}
+	for _, c := range cases {
+		user, domain, err := ParseAccount(c.account)
+		if (err == nil) != c.valid || user != c.user || domain != c.domain {
+			t.Logf("%q: expected %q %q valid=%v, got %q %q %v\n", c.account, c.user, c.domain, c.valid, user, domain, err)
+			t.Fail()
+		}
+	}
+}
+
+func TestActivityObject(t *testing.T) {
+	var byID Activity
+	json.Unmarshal([]byte(`{"type":"Like","object":"https://chirpy.test/ap/chirps/1"}`), &byID)
+	if byID.ObjectID() != "https://chirpy.test/ap/chirps/1" || byID.ObjectType() != "" {
+		t.Logf("unexpected object %q %q\n", byID.ObjectID(), byID.ObjectType())
+		t.Fail()
+	}
+	if _, ok := byID.InnerActivity(); ok {
+		t.Log("a bare ID shouldn't decode as an activity")
+		t.Fail()
+	}
+	var undo Activity
+	json.Unmarshal([]byte(`{"type":"Undo","object":{"id":"https://remote.test/follows/1","type":"Follow","actor":"https://remote.test/users/bob","object":"https://chirpy.test/ap/users/1"}}`), &undo)
+	inner, ok := undo.InnerActivity()
+	if !ok || undo.ObjectType() != "Follow" || inner.ObjectID() != "https://chirpy.test/ap/users/1" {
+		t.Logf("unexpected inner activity %+v\n", inner)
+		t.Fail()
+	}
+}
+
+func TestPlainText(t *testing.T) {
+	cases := []struct {
+		content string
+		expected string
+	}{
+		{"<p>hello</p>", "hello"},
+		{"<p>one<br>two</p><p>three</p>", "one\ntwo\n\nthree"},
+		{`<p><span class="h-card"><a href="https://remote.test/@bob">@<span>bob</span></a></span> hi &amp; bye</p>`, "@bob hi & bye"},
+		{"<script>alert(1)</script>", "alert(1)"},
+	}
+	for _, c := range cases {
+		if got := PlainText(c.content); got != c.expected {
+			t.Logf("%q: expected %q, got %q\n", c.content, c.expected, got)
+			t.Fail()
+		}
+	}
+}
+
+func TestHTML(t *testing.T) {
+	got := HTML("one <b>\ntwo\n\nthree")
+	expected := "<p>one &lt;b&gt;<br>two</p><p>three</p>"
+	if got != expected {
+		t.Logf("expected %q, got %q\n", expected, got)
+		t.Fail()
+	}
+	if PlainText(got) != "one <b>\ntwo\n\nthree" {
+		t.Logf("expected HTML to round-trip, got %q\n", PlainText(got))
+		t.Fail()
+	}
+}
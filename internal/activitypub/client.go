@@ -0,0 +1,117 @@
+package activitypub
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	maxDocumentSize = 1 << 20
+	userAgent = "Chirpy-ActivityPub/1.0"
+)
+
+// StatusError is a response from another server that wasn't 2xx.
+type StatusError struct {
+	URL string
+	StatusCode int
+}
+
+func (err *StatusError) Error() string {
+	return fmt.Sprintf("%s responded with %d", err.URL, err.StatusCode)
+}
+
+// Client talks to other servers.
+type Client struct {
+	HTTP *http.Client
+	// Scheme is used for WebFinger lookups, which only start from a
+	// domain. It's https unless set, so a plain-HTTP server can stand in
+	// for a remote one in development and tests.
+	Scheme string
+}
+
+func (client *Client) get(ctx context.Context, target string, accept string, into any) error {
+	request, err := http.NewRequestWithContext(ctx, "GET", target, nil)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Accept", accept)
+	request.Header.Set("User-Agent", userAgent)
+	response, err := client.HTTP.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return &StatusError{URL: target, StatusCode: response.StatusCode}
+	}
+	return json.NewDecoder(io.LimitReader(response.Body, maxDocumentSize)).Decode(into)
+}
+
+// Lookup finds the actor ID of an account ("user@domain") with WebFinger.
+func (client *Client) Lookup(ctx context.Context, account string) (string, error) {
+	user, domain, err := ParseAccount(account)
+	if err != nil {
+		return "", err
+	}
+	scheme := client.Scheme
+	if scheme == "" {
+		scheme = "https"
+	}
+	query := url.Values{"resource": {"acct:" + user + "@" + domain}}
+	var finger WebFinger
+	if err := client.get(ctx, scheme+"://"+domain+"/.well-known/webfinger?"+query.Encode(), JRDContentType, &finger); err != nil {
+		return "", err
+	}
+	actorURI, ok := finger.ActorURI()
+	if !ok {
+		return "", fmt.Errorf("%s has no ActivityPub actor", account)
+	}
+	return actorURI, nil
+}
+
+// FetchActor fetches the actor document at uri. The document has to
+// describe itself as living there, so a server can't hand out actors it
+// doesn't host.
+func (client *Client) FetchActor(ctx context.Context, uri string) (Actor, error) {
+	var actor Actor
+	if err := client.get(ctx, uri, ContentType+", "+LDContentType, &actor); err != nil {
+		return Actor{}, err
+	}
+	if actor.ID != uri {
+		return Actor{}, fmt.Errorf("actor at %s claims to be %s", uri, actor.ID)
+	}
+	if actor.Inbox == "" || actor.PublicKey.PublicKeyPem == "" || actor.PublicKey.Owner != actor.ID {
+		return Actor{}, fmt.Errorf("actor at %s is missing its inbox or key", uri)
+	}
+	return actor, nil
+}
+
+// Deliver POSTs activity to inbox, signed with the sending actor's key.
+func (client *Client) Deliver(ctx context.Context, inbox string, keyID string, key *rsa.PrivateKey, activity []byte) error {
+	request, err := http.NewRequestWithContext(ctx, "POST", inbox, bytes.NewReader(activity))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", ContentType)
+	request.Header.Set("User-Agent", userAgent)
+	if err := SignRequest(request, keyID, key, activity, time.Now()); err != nil {
+		return err
+	}
+	response, err := client.HTTP.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(response.Body, 64<<10))
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return &StatusError{URL: inbox, StatusCode: response.StatusCode}
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package activitypub
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// standIn is a minimal federated server: one actor, reachable through
+// WebFinger, whose inbox only takes activities signed by an actor it can
+// fetch.
+type standIn struct {
+	server *httptest.Server
+	client *Client
+	publicPEM string
+	received []Activity
+}
+
+func newStandIn(t *testing.T) *standIn {
+	_, publicPEM, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	remote := &standIn{publicPEM: publicPEM}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/webfinger", func(writer http.ResponseWriter, request *http.Request) {
+		if request.URL.Query().Get("resource") != "acct:bob@"+request.Host {
+			writer.WriteHeader(404)
+			return
+		}
+		writer.Header().Set("Content-Type", JRDContentType)
+		json.NewEncoder(writer).Encode(WebFinger{
+			Subject: request.URL.Query().Get("resource"),
+			Links: []Link{
+				{Rel: "http://webfinger.net/rel/profile-page", Type: "text/html", Href: remote.server.URL + "/@bob"},
+				{Rel: "self", Type: ContentType, Href: remote.server.URL + "/users/bob"},
+			},
+		})
+	})
+	mux.HandleFunc("GET /users/bob", func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", ContentType)
+		json.NewEncoder(writer).Encode(remote.actor())
+	})
+	mux.HandleFunc("POST /users/bob/inbox", func(writer http.ResponseWriter, request *http.Request) {
+		body, _ := io.ReadAll(request.Body)
+		signature, err := ParseSignature(request.Header.Get("Signature"))
+		if err != nil {
+			writer.WriteHeader(401)
+			return
+		}
+		sender, err := remote.client.FetchActor(request.Context(), signature.Owner())
+		if err != nil {
+			writer.WriteHeader(401)
+			return
+		}
+		key, err := ParsePublicKey(sender.PublicKey.PublicKeyPem)
+		if err != nil || VerifyRequest(request, body, signature, key, time.Minute, time.Now()) != nil {
+			writer.WriteHeader(401)
+			return
+		}
+		var activity Activity
+		if err := json.Unmarshal(body, &activity); err != nil || activity.Actor != sender.ID {
+			writer.WriteHeader(400)
+			return
+		}
+		remote.received = append(remote.received, activity)
+		writer.WriteHeader(202)
+	})
+	remote.server = httptest.NewServer(mux)
+	remote.client = &Client{HTTP: remote.server.Client(), Scheme: "http"}
+	t.Cleanup(remote.server.Close)
+	return remote
+}
+
+func (remote *standIn) actor() Actor {
+	id := remote.server.URL + "/users/bob"
+	return Actor{
+		Context: Context,
+		ID: id,
+		Type: "Person",
+		PreferredUsername: "bob",
+		Inbox: id + "/inbox",
+		Endpoints: &Endpoints{SharedInbox: remote.server.URL + "/inbox"},
+		PublicKey: PublicKey{
+			ID: id + "#main-key",
+			Owner: id,
+			PublicKeyPem: remote.publicPEM,
+		},
+	}
+}
+
+func (remote *standIn) account() string {
+	return "bob@" + strings.TrimPrefix(remote.server.URL, "http://")
+}
+
+func TestLookupAndFetchActor(t *testing.T) {
+	remote := newStandIn(t)
+	actorURI, err := remote.client.Lookup(context.Background(), remote.account())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actorURI != remote.server.URL+"/users/bob" {
+		t.Logf("expected bob's actor, got %q\n", actorURI)
+		t.Fail()
+	}
+	actor, err := remote.client.FetchActor(context.Background(), actorURI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actor.PreferredUsername != "bob" || actor.SharedInbox() != remote.server.URL+"/inbox" {
+		t.Logf("unexpected actor %+v\n", actor)
+		t.Fail()
+	}
+	if _, err := remote.client.Lookup(context.Background(), "alice@"+strings.TrimPrefix(remote.server.URL, "http://")); err == nil {
+		t.Log("expected an unknown account to fail")
+		t.Fail()
+	}
+}
+
+func TestFetchActorRejectsImpostors(t *testing.T) {
+	remote := newStandIn(t)
+	_, err := remote.client.FetchActor(context.Background(), remote.server.URL+"/users/bob?as=alice")
+	if err == nil {
+		t.Log("expected an actor served from another ID to be rejected")
+		t.Fail()
+	}
+}
+
+func TestDeliver(t *testing.T) {
+	remote := newStandIn(t)
+	// The sender is another actor the stand-in can fetch: a second
+	// stand-in, with a key we hold the private half of.
+	sender := newStandIn(t)
+	privatePEM, publicPEM, _ := GenerateKey()
+	sender.publicPEM = publicPEM
+	key, _ := ParsePrivateKey(privatePEM)
+	senderActor := sender.actor()
+	activity, err := NewActivity(sender.server.URL+"/follows/1", "Follow", senderActor.ID, remote.actor().ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := json.Marshal(activity)
+	if err := remote.client.Deliver(context.Background(), remote.actor().Inbox, senderActor.PublicKey.ID, key, body); err != nil {
+		t.Fatal(err)
+	}
+	if len(remote.received) != 1 || remote.received[0].Type != "Follow" || remote.received[0].ObjectID() != remote.actor().ID {
+		t.Logf("unexpected activities %+v\n", remote.received)
+		t.Fail()
+	}
+	// Signed with a key that isn't the sender's.
+	otherPEM, _, _ := GenerateKey()
+	other, _ := ParsePrivateKey(otherPEM)
+	err = remote.client.Deliver(context.Background(), remote.actor().Inbox, senderActor.PublicKey.ID, other, body)
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != 401 {
+		t.Logf("expected a 401, got %v\n", err)
+		t.Fail()
+	}
+}
@@ -0,0 +1,217 @@
+package activitypub
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+const keyBits = 2048
+
+var ErrInvalidSignature = errors.New("invalid http signature")
+
+// GenerateKey makes a key pair for an actor, PEM-encoded as PKCS#8 and
+// PKIX, the forms other servers expect in publicKeyPem.
+func GenerateKey() (privatePEM string, publicPEM string, err error) {
+	key, err := rsa.GenerateKey(rand.Reader, keyBits)
+	if err != nil {
+		return "", "", err
+	}
+	private, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return "", "", err
+	}
+	public, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		return "", "", err
+	}
+	privatePEM = string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: private}))
+	publicPEM = string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: public}))
+	return privatePEM, publicPEM, nil
+}
+
+func ParsePrivateKey(data string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		return nil, errors.New("no PEM block in private key")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("private key isn't RSA")
+	}
+	return key, nil
+}
+
+func ParsePublicKey(data string) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		return nil, errors.New("no PEM block in public key")
+	}
+	if key, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := parsed.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("public key isn't RSA")
+	}
+	return key, nil
+}
+
+// Digest is the Digest header value for body.
+func Digest(body []byte) string {
+	sum := sha256.Sum256(body)
+	return "SHA-256=" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// Signature is a parsed Signature header (draft-cavage-http-signatures,
+// the version the fediverse settled on).
+type Signature struct {
+	KeyID string
+	Algorithm string
+	Headers []string
+	Signature []byte
+}
+
+// Owner is the actor the signing key belongs to, by the usual convention
+// of key IDs being the actor's ID plus a fragment.
+func (signature Signature) Owner() string {
+	owner, _, _ := strings.Cut(signature.KeyID, "#")
+	return owner
+}
+
+func ParseSignature(header string) (Signature, error) {
+	var signature Signature
+	headers := "date"
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		value = strings.Trim(value, `"`)
+		switch key {
+		case "keyId":
+			signature.KeyID = value
+		case "algorithm":
+			signature.Algorithm = value
+		case "headers":
+			headers = value
+		case "signature":
+			decoded, err := base64.StdEncoding.DecodeString(value)
+			if err != nil {
+				return Signature{}, ErrInvalidSignature
+			}
+			signature.Signature = decoded
+		}
+	}
+	if signature.KeyID == "" || len(signature.Signature) == 0 {
+		return Signature{}, ErrInvalidSignature
+	}
+	signature.Headers = strings.Fields(strings.ToLower(headers))
+	return signature, nil
+}
+
+func signingString(request *http.Request, headers []string) (string, error) {
+	lines := make([]string, len(headers))
+	for i, name := range headers {
+		var value string
+		switch name {
+		case "(request-target)":
+			value = strings.ToLower(request.Method) + " " + request.URL.RequestURI()
+		case "host":
+			value = request.Host
+			if value == "" {
+				value = request.URL.Host
+			}
+		default:
+			values := request.Header.Values(name)
+			if len(values) == 0 {
+				return "", fmt.Errorf("signed header %q is missing", name)
+			}
+			value = strings.Join(values, ", ")
+		}
+		lines[i] = name + ": " + value
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// SignRequest signs request with key, covering the request target, Host
+// and Date, and for requests with a body its Digest. It sets Date and
+// Digest itself.
+func SignRequest(request *http.Request, keyID string, key *rsa.PrivateKey, body []byte, now time.Time) error {
+	request.Header.Set("Date", now.UTC().Format(http.TimeFormat))
+	headers := []string{"(request-target)", "host", "date"}
+	if body != nil {
+		request.Header.Set("Digest", Digest(body))
+		headers = append(headers, "digest")
+	}
+	toSign, err := signingString(request, headers)
+	if err != nil {
+		return err
+	}
+	hash := sha256.Sum256([]byte(toSign))
+	signed, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hash[:])
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Signature", fmt.Sprintf(`keyId="%s",algorithm="rsa-sha256",headers="%s",signature="%s"`,
+		keyID, strings.Join(headers, " "), base64.StdEncoding.EncodeToString(signed)))
+	return nil
+}
+
+// VerifyRequest checks signature, parsed from request's Signature header,
+// against key. The signature has to cover the request target, Host and
+// Date, and Digest too when there's a body, which must match it; Date must
+// be within maxSkew of now so old requests can't be replayed.
+func VerifyRequest(request *http.Request, body []byte, signature Signature, key *rsa.PublicKey, maxSkew time.Duration, now time.Time) error {
+	switch signature.Algorithm {
+	case "", "rsa-sha256", "hs2019":
+	default:
+		return ErrInvalidSignature
+	}
+	required := []string{"(request-target)", "host", "date"}
+	if len(body) > 0 {
+		required = append(required, "digest")
+		if request.Header.Get("Digest") != Digest(body) {
+			return ErrInvalidSignature
+		}
+	}
+	for _, name := range required {
+		if !slices.Contains(signature.Headers, name) {
+			return ErrInvalidSignature
+		}
+	}
+	date, err := http.ParseTime(request.Header.Get("Date"))
+	if err != nil || now.Sub(date).Abs() > maxSkew {
+		return ErrInvalidSignature
+	}
+	toSign, err := signingString(request, signature.Headers)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	hash := sha256.Sum256([]byte(toSign))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, hash[:], signature.Signature); err != nil {
+		return ErrInvalidSignature
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package activitypub
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSignAndVerifyRequest(t *testing.T) {
+	privatePEM, publicPEM, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	private, err := ParsePrivateKey(privatePEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	public, err := ParsePublicKey(publicPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, otherPEM, _ := GenerateKey()
+	other, _ := ParsePublicKey(otherPEM)
+	now := time.Unix(1700000000, 0)
+	body := []byte(`{"type":"Follow"}`)
+	signed := func() *http.Request {
+		request := httptest.NewRequest("POST", "https://chirpy.test/ap/inbox", bytes.NewReader(body))
+		if err := SignRequest(request, "https://remote.test/users/bob#main-key", private, body, now); err != nil {
+			t.Fatal(err)
+		}
+		return request
+	}
+	cases := []struct {
+		name string
+		tamper func(request *http.Request) []byte
+		key bool
+		now time.Time
+		valid bool
+	}{
+		{"valid", func(request *http.Request) []byte { return body }, true, now, true},
+		{"within skew", func(request *http.Request) []byte { return body }, true, now.Add(time.Minute), true},
+		{"stale", func(request *http.Request) []byte { return body }, true, now.Add(time.Hour), false},
+		{"wrong key", func(request *http.Request) []byte { return body }, false, now, false},
+		{"tampered body", func(request *http.Request) []byte { return []byte(`{"type":"Delete"}`) }, true, now, false},
+		{"tampered path", func(request *http.Request) []byte {
+			request.URL.Path = "/ap/users/1/inbox"
+			return body
+		}, true, now, false},
+		{"tampered host", func(request *http.Request) []byte {
+			request.Host = "other.test"
+			return body
+		}, true, now, false},
+	}
+	for _, c := range cases {
+		request := signed()
+		received := c.tamper(request)
+		signature, err := ParseSignature(request.Header.Get("Signature"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		key := public
+		if !c.key {
+			key = other
+		}
+		err = VerifyRequest(request, received, signature, key, 5*time.Minute, c.now)
+		if (err == nil) != c.valid {
+			t.Logf("%s: expected valid=%v, got %v\n", c.name, c.valid, err)
+			t.Fail()
+		}
+	}
+}
+
+func TestParseSignature(t *testing.T) {
+	signature, err := ParseSignature(`keyId="https://remote.test/users/bob#main-key",algorithm="rsa-sha256",headers="(request-target) host date digest",signature="c2lnbmF0dXJl"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signature.Owner() != "https://remote.test/users/bob" || len(signature.Headers) != 4 || string(signature.Signature) != "signature" {
+		t.Logf("unexpected signature %+v\n", signature)
+		t.Fail()
+	}
+	if _, err := ParseSignature(`algorithm="rsa-sha256"`); err == nil {
+		t.Log("expected a signature without a keyId to be rejected")
+		t.Fail()
+	}
+}
+
+func TestVerifyRequiresCoveredHeaders(t *testing.T) {
+	privatePEM, publicPEM, _ := GenerateKey()
+	private, _ := ParsePrivateKey(privatePEM)
+	public, _ := ParsePublicKey(publicPEM)
+	body := []byte(`{}`)
+	request := httptest.NewRequest("POST", "https://chirpy.test/ap/inbox", bytes.NewReader(body))
+	now := time.Now()
+	SignRequest(request, "https://remote.test/users/bob#main-key", private, body, now)
+	signature, _ := ParseSignature(request.Header.Get("Signature"))
+	signature.Headers = []string{"(request-target)", "host", "date"}
+	if err := VerifyRequest(request, body, signature, public, time.Minute, now); err == nil {
+		t.Log("expected a signature that doesn't cover the digest to be rejected")
+		t.Fail()
+	}
+}
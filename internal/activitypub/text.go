@@ -0,0 +1,41 @@
+package activitypub
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	lineBreak = regexp.MustCompile(`(?i)<br\s*/?>`)
+	paragraphEnd = regexp.MustCompile(`(?i)</p\s*>`)
+	tag = regexp.MustCompile(`<[^>]*>`)
+	blankLines = regexp.MustCompile(`\n{3,}`)
+)
+
+// PlainText turns the HTML content of a remote post into plain text. We
+// never render remote markup, so stripping it is all the sanitising needed.
+func PlainText(content string) string {
+	content = lineBreak.ReplaceAllString(content, "\n")
+	content = paragraphEnd.ReplaceAllString(content, "\n\n")
+	content = tag.ReplaceAllString(content, "")
+	content = html.UnescapeString(content)
+	content = blankLines.ReplaceAllString(content, "\n\n")
+	return strings.TrimSpace(content)
+}
+
+// HTML turns a chirp into the HTML content of a Note: escaped, with blank
+// lines starting new paragraphs and other newlines kept as line breaks.
+func HTML(text string) string {
+	var builder strings.Builder
+	for _, paragraph := range strings.Split(strings.TrimSpace(text), "\n\n") {
+		paragraph = strings.TrimSpace(paragraph)
+		if paragraph == "" {
+			continue
+		}
+		builder.WriteString("<p>")
+		builder.WriteString(strings.ReplaceAll(html.EscapeString(paragraph), "\n", "<br>"))
+		builder.WriteString("</p>")
+	}
+	return builder.String()
+}
@@ -0,0 +1,631 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: chirpy/v1/chirps.proto
+
+package chirpypb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Chirp struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Id               string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	UserId           string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Body             string                 `protobuf:"bytes,3,opt,name=body,proto3" json:"body,omitempty"`
+	CreatedAt        *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt        *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	ExpiresAt        *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	Hashtags         []string               `protobuf:"bytes,7,rep,name=hashtags,proto3" json:"hashtags,omitempty"`
+	MentionedUserIds []string               `protobuf:"bytes,8,rep,name=mentioned_user_ids,json=mentionedUserIds,proto3" json:"mentioned_user_ids,omitempty"`
+	Media            []*Media               `protobuf:"bytes,9,rep,name=media,proto3" json:"media,omitempty"`
+	Pinned           bool                   `protobuf:"varint,10,opt,name=pinned,proto3" json:"pinned,omitempty"`
+	Bookmarked       bool                   `protobuf:"varint,11,opt,name=bookmarked,proto3" json:"bookmarked,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *Chirp) Reset() {
+	*x = Chirp{}
+	mi := &file_chirpy_v1_chirps_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Chirp) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Chirp) ProtoMessage() {}
+
+func (x *Chirp) ProtoReflect() protoreflect.Message {
+	mi := &file_chirpy_v1_chirps_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Chirp.ProtoReflect.Descriptor instead.
+func (*Chirp) Descriptor() ([]byte, []int) {
+	return file_chirpy_v1_chirps_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Chirp) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Chirp) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *Chirp) GetBody() string {
+	if x != nil {
+		return x.Body
+	}
+	return ""
+}
+
+func (x *Chirp) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Chirp) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+func (x *Chirp) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+func (x *Chirp) GetHashtags() []string {
+	if x != nil {
+		return x.Hashtags
+	}
+	return nil
+}
+
+func (x *Chirp) GetMentionedUserIds() []string {
+	if x != nil {
+		return x.MentionedUserIds
+	}
+	return nil
+}
+
+func (x *Chirp) GetMedia() []*Media {
+	if x != nil {
+		return x.Media
+	}
+	return nil
+}
+
+func (x *Chirp) GetPinned() bool {
+	if x != nil {
+		return x.Pinned
+	}
+	return false
+}
+
+func (x *Chirp) GetBookmarked() bool {
+	if x != nil {
+		return x.Bookmarked
+	}
+	return false
+}
+
+type Media struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Url           string                 `protobuf:"bytes,2,opt,name=url,proto3" json:"url,omitempty"`
+	ThumbnailUrl  string                 `protobuf:"bytes,3,opt,name=thumbnail_url,json=thumbnailUrl,proto3" json:"thumbnail_url,omitempty"`
+	ContentType   string                 `protobuf:"bytes,4,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	Width         int32                  `protobuf:"varint,5,opt,name=width,proto3" json:"width,omitempty"`
+	Height        int32                  `protobuf:"varint,6,opt,name=height,proto3" json:"height,omitempty"`
+	AltText       string                 `protobuf:"bytes,7,opt,name=alt_text,json=altText,proto3" json:"alt_text,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Media) Reset() {
+	*x = Media{}
+	mi := &file_chirpy_v1_chirps_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Media) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Media) ProtoMessage() {}
+
+func (x *Media) ProtoReflect() protoreflect.Message {
+	mi := &file_chirpy_v1_chirps_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Media.ProtoReflect.Descriptor instead.
+func (*Media) Descriptor() ([]byte, []int) {
+	return file_chirpy_v1_chirps_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Media) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Media) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *Media) GetThumbnailUrl() string {
+	if x != nil {
+		return x.ThumbnailUrl
+	}
+	return ""
+}
+
+func (x *Media) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+func (x *Media) GetWidth() int32 {
+	if x != nil {
+		return x.Width
+	}
+	return 0
+}
+
+func (x *Media) GetHeight() int32 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *Media) GetAltText() string {
+	if x != nil {
+		return x.AltText
+	}
+	return ""
+}
+
+type GetChirpRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetChirpRequest) Reset() {
+	*x = GetChirpRequest{}
+	mi := &file_chirpy_v1_chirps_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetChirpRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetChirpRequest) ProtoMessage() {}
+
+func (x *GetChirpRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chirpy_v1_chirps_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetChirpRequest.ProtoReflect.Descriptor instead.
+func (*GetChirpRequest) Descriptor() ([]byte, []int) {
+	return file_chirpy_v1_chirps_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetChirpRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type ListChirpsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// author_id limits the list to one user's chirps.
+	AuthorId      string `protobuf:"bytes,1,opt,name=author_id,json=authorId,proto3" json:"author_id,omitempty"`
+	PageSize      int32  `protobuf:"varint,2,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	PageToken     string `protobuf:"bytes,3,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListChirpsRequest) Reset() {
+	*x = ListChirpsRequest{}
+	mi := &file_chirpy_v1_chirps_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListChirpsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListChirpsRequest) ProtoMessage() {}
+
+func (x *ListChirpsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chirpy_v1_chirps_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListChirpsRequest.ProtoReflect.Descriptor instead.
+func (*ListChirpsRequest) Descriptor() ([]byte, []int) {
+	return file_chirpy_v1_chirps_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ListChirpsRequest) GetAuthorId() string {
+	if x != nil {
+		return x.AuthorId
+	}
+	return ""
+}
+
+func (x *ListChirpsRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListChirpsRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+type ListChirpsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Chirps        []*Chirp               `protobuf:"bytes,1,rep,name=chirps,proto3" json:"chirps,omitempty"`
+	NextPageToken string                 `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListChirpsResponse) Reset() {
+	*x = ListChirpsResponse{}
+	mi := &file_chirpy_v1_chirps_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListChirpsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListChirpsResponse) ProtoMessage() {}
+
+func (x *ListChirpsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_chirpy_v1_chirps_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListChirpsResponse.ProtoReflect.Descriptor instead.
+func (*ListChirpsResponse) Descriptor() ([]byte, []int) {
+	return file_chirpy_v1_chirps_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ListChirpsResponse) GetChirps() []*Chirp {
+	if x != nil {
+		return x.Chirps
+	}
+	return nil
+}
+
+func (x *ListChirpsResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+type StreamChirpsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// At most one of author_id and hashtag may be set.
+	AuthorId      string `protobuf:"bytes,1,opt,name=author_id,json=authorId,proto3" json:"author_id,omitempty"`
+	Hashtag       string `protobuf:"bytes,2,opt,name=hashtag,proto3" json:"hashtag,omitempty"`
+	ResumeToken   string `protobuf:"bytes,3,opt,name=resume_token,json=resumeToken,proto3" json:"resume_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamChirpsRequest) Reset() {
+	*x = StreamChirpsRequest{}
+	mi := &file_chirpy_v1_chirps_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamChirpsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamChirpsRequest) ProtoMessage() {}
+
+func (x *StreamChirpsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chirpy_v1_chirps_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamChirpsRequest.ProtoReflect.Descriptor instead.
+func (*StreamChirpsRequest) Descriptor() ([]byte, []int) {
+	return file_chirpy_v1_chirps_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *StreamChirpsRequest) GetAuthorId() string {
+	if x != nil {
+		return x.AuthorId
+	}
+	return ""
+}
+
+func (x *StreamChirpsRequest) GetHashtag() string {
+	if x != nil {
+		return x.Hashtag
+	}
+	return ""
+}
+
+func (x *StreamChirpsRequest) GetResumeToken() string {
+	if x != nil {
+		return x.ResumeToken
+	}
+	return ""
+}
+
+type StreamChirpsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Chirp *Chirp                 `protobuf:"bytes,1,opt,name=chirp,proto3" json:"chirp,omitempty"`
+	// resume_token picks the stream up after this chirp.
+	ResumeToken   string `protobuf:"bytes,2,opt,name=resume_token,json=resumeToken,proto3" json:"resume_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamChirpsResponse) Reset() {
+	*x = StreamChirpsResponse{}
+	mi := &file_chirpy_v1_chirps_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamChirpsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamChirpsResponse) ProtoMessage() {}
+
+func (x *StreamChirpsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_chirpy_v1_chirps_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamChirpsResponse.ProtoReflect.Descriptor instead.
+func (*StreamChirpsResponse) Descriptor() ([]byte, []int) {
+	return file_chirpy_v1_chirps_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *StreamChirpsResponse) GetChirp() *Chirp {
+	if x != nil {
+		return x.Chirp
+	}
+	return nil
+}
+
+func (x *StreamChirpsResponse) GetResumeToken() string {
+	if x != nil {
+		return x.ResumeToken
+	}
+	return ""
+}
+
+var File_chirpy_v1_chirps_proto protoreflect.FileDescriptor
+
+const file_chirpy_v1_chirps_proto_rawDesc = "" +
+	"\n" +
+	"\x16chirpy/v1/chirps.proto\x12\tchirpy.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\x9f\x03\n" +
+	"\x05Chirp\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x12\n" +
+	"\x04body\x18\x03 \x01(\tR\x04body\x129\n" +
+	"\n" +
+	"created_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x129\n" +
+	"\n" +
+	"expires_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\x12\x1a\n" +
+	"\bhashtags\x18\a \x03(\tR\bhashtags\x12,\n" +
+	"\x12mentioned_user_ids\x18\b \x03(\tR\x10mentionedUserIds\x12&\n" +
+	"\x05media\x18\t \x03(\v2\x10.chirpy.v1.MediaR\x05media\x12\x16\n" +
+	"\x06pinned\x18\n" +
+	" \x01(\bR\x06pinned\x12\x1e\n" +
+	"\n" +
+	"bookmarked\x18\v \x01(\bR\n" +
+	"bookmarked\"\xba\x01\n" +
+	"\x05Media\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x10\n" +
+	"\x03url\x18\x02 \x01(\tR\x03url\x12#\n" +
+	"\rthumbnail_url\x18\x03 \x01(\tR\fthumbnailUrl\x12!\n" +
+	"\fcontent_type\x18\x04 \x01(\tR\vcontentType\x12\x14\n" +
+	"\x05width\x18\x05 \x01(\x05R\x05width\x12\x16\n" +
+	"\x06height\x18\x06 \x01(\x05R\x06height\x12\x19\n" +
+	"\balt_text\x18\a \x01(\tR\aaltText\"!\n" +
+	"\x0fGetChirpRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"l\n" +
+	"\x11ListChirpsRequest\x12\x1b\n" +
+	"\tauthor_id\x18\x01 \x01(\tR\bauthorId\x12\x1b\n" +
+	"\tpage_size\x18\x02 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x03 \x01(\tR\tpageToken\"f\n" +
+	"\x12ListChirpsResponse\x12(\n" +
+	"\x06chirps\x18\x01 \x03(\v2\x10.chirpy.v1.ChirpR\x06chirps\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\"o\n" +
+	"\x13StreamChirpsRequest\x12\x1b\n" +
+	"\tauthor_id\x18\x01 \x01(\tR\bauthorId\x12\x18\n" +
+	"\ahashtag\x18\x02 \x01(\tR\ahashtag\x12!\n" +
+	"\fresume_token\x18\x03 \x01(\tR\vresumeToken\"a\n" +
+	"\x14StreamChirpsResponse\x12&\n" +
+	"\x05chirp\x18\x01 \x01(\v2\x10.chirpy.v1.ChirpR\x05chirp\x12!\n" +
+	"\fresume_token\x18\x02 \x01(\tR\vresumeToken2\xe6\x01\n" +
+	"\fChirpService\x128\n" +
+	"\bGetChirp\x12\x1a.chirpy.v1.GetChirpRequest\x1a\x10.chirpy.v1.Chirp\x12I\n" +
+	"\n" +
+	"ListChirps\x12\x1c.chirpy.v1.ListChirpsRequest\x1a\x1d.chirpy.v1.ListChirpsResponse\x12Q\n" +
+	"\fStreamChirps\x12\x1e.chirpy.v1.StreamChirpsRequest\x1a\x1f.chirpy.v1.StreamChirpsResponse0\x01B,Z*github.com/Baehry/chirpy/internal/chirpypbb\x06proto3"
+
+var (
+	file_chirpy_v1_chirps_proto_rawDescOnce sync.Once
+	file_chirpy_v1_chirps_proto_rawDescData []byte
+)
+
+func file_chirpy_v1_chirps_proto_rawDescGZIP() []byte {
+	file_chirpy_v1_chirps_proto_rawDescOnce.Do(func() {
+		file_chirpy_v1_chirps_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_chirpy_v1_chirps_proto_rawDesc), len(file_chirpy_v1_chirps_proto_rawDesc)))
+	})
+	return file_chirpy_v1_chirps_proto_rawDescData
+}
+
+var file_chirpy_v1_chirps_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_chirpy_v1_chirps_proto_goTypes = []any{
+	(*Chirp)(nil),                 // 0: chirpy.v1.Chirp
+	(*Media)(nil),                 // 1: chirpy.v1.Media
+	(*GetChirpRequest)(nil),       // 2: chirpy.v1.GetChirpRequest
+	(*ListChirpsRequest)(nil),     // 3: chirpy.v1.ListChirpsRequest
+	(*ListChirpsResponse)(nil),    // 4: chirpy.v1.ListChirpsResponse
+	(*StreamChirpsRequest)(nil),   // 5: chirpy.v1.StreamChirpsRequest
+	(*StreamChirpsResponse)(nil),  // 6: chirpy.v1.StreamChirpsResponse
+	(*timestamppb.Timestamp)(nil), // 7: google.protobuf.Timestamp
+}
+var file_chirpy_v1_chirps_proto_depIdxs = []int32{
+	7, // 0: chirpy.v1.Chirp.created_at:type_name -> google.protobuf.Timestamp
+	7, // 1: chirpy.v1.Chirp.updated_at:type_name -> google.protobuf.Timestamp
+	7, // 2: chirpy.v1.Chirp.expires_at:type_name -> google.protobuf.Timestamp
+	1, // 3: chirpy.v1.Chirp.media:type_name -> chirpy.v1.Media
+	0, // 4: chirpy.v1.ListChirpsResponse.chirps:type_name -> chirpy.v1.Chirp
+	0, // 5: chirpy.v1.StreamChirpsResponse.chirp:type_name -> chirpy.v1.Chirp
+	2, // 6: chirpy.v1.ChirpService.GetChirp:input_type -> chirpy.v1.GetChirpRequest
+	3, // 7: chirpy.v1.ChirpService.ListChirps:input_type -> chirpy.v1.ListChirpsRequest
+	5, // 8: chirpy.v1.ChirpService.StreamChirps:input_type -> chirpy.v1.StreamChirpsRequest
+	0, // 9: chirpy.v1.ChirpService.GetChirp:output_type -> chirpy.v1.Chirp
+	4, // 10: chirpy.v1.ChirpService.ListChirps:output_type -> chirpy.v1.ListChirpsResponse
+	6, // 11: chirpy.v1.ChirpService.StreamChirps:output_type -> chirpy.v1.StreamChirpsResponse
+	9, // [9:12] is the sub-list for method output_type
+	6, // [6:9] is the sub-list for method input_type
+	6, // [6:6] is the sub-list for extension type_name
+	6, // [6:6] is the sub-list for extension extendee
+	0, // [0:6] is the sub-list for field type_name
+}
+
+func init() { file_chirpy_v1_chirps_proto_init() }
+func file_chirpy_v1_chirps_proto_init() {
+	if File_chirpy_v1_chirps_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_chirpy_v1_chirps_proto_rawDesc), len(file_chirpy_v1_chirps_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   7,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_chirpy_v1_chirps_proto_goTypes,
+		DependencyIndexes: file_chirpy_v1_chirps_proto_depIdxs,
+		MessageInfos:      file_chirpy_v1_chirps_proto_msgTypes,
+	}.Build()
+	File_chirpy_v1_chirps_proto = out.File
+	file_chirpy_v1_chirps_proto_goTypes = nil
+	file_chirpy_v1_chirps_proto_depIdxs = nil
+}
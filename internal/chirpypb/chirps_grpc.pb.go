@@ -0,0 +1,207 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: chirpy/v1/chirps.proto
+
+package chirpypb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	ChirpService_GetChirp_FullMethodName     = "/chirpy.v1.ChirpService/GetChirp"
+	ChirpService_ListChirps_FullMethodName   = "/chirpy.v1.ChirpService/ListChirps"
+	ChirpService_StreamChirps_FullMethodName = "/chirpy.v1.ChirpService/StreamChirps"
+)
+
+// ChirpServiceClient is the client API for ChirpService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ChirpServiceClient interface {
+	GetChirp(ctx context.Context, in *GetChirpRequest, opts ...grpc.CallOption) (*Chirp, error)
+	// ListChirps pages through published chirps, newest first.
+	ListChirps(ctx context.Context, in *ListChirpsRequest, opts ...grpc.CallOption) (*ListChirpsResponse, error)
+	// StreamChirps sends chirps as they are published. With a resume_token
+	// it first replays what was published after that chirp.
+	StreamChirps(ctx context.Context, in *StreamChirpsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[StreamChirpsResponse], error)
+}
+
+type chirpServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewChirpServiceClient(cc grpc.ClientConnInterface) ChirpServiceClient {
+	return &chirpServiceClient{cc}
+}
+
+func (c *chirpServiceClient) GetChirp(ctx context.Context, in *GetChirpRequest, opts ...grpc.CallOption) (*Chirp, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Chirp)
+	err := c.cc.Invoke(ctx, ChirpService_GetChirp_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chirpServiceClient) ListChirps(ctx context.Context, in *ListChirpsRequest, opts ...grpc.CallOption) (*ListChirpsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListChirpsResponse)
+	err := c.cc.Invoke(ctx, ChirpService_ListChirps_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chirpServiceClient) StreamChirps(ctx context.Context, in *StreamChirpsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[StreamChirpsResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ChirpService_ServiceDesc.Streams[0], ChirpService_StreamChirps_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamChirpsRequest, StreamChirpsResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ChirpService_StreamChirpsClient = grpc.ServerStreamingClient[StreamChirpsResponse]
+
+// ChirpServiceServer is the server API for ChirpService service.
+// All implementations must embed UnimplementedChirpServiceServer
+// for forward compatibility.
+type ChirpServiceServer interface {
+	GetChirp(context.Context, *GetChirpRequest) (*Chirp, error)
+	// ListChirps pages through published chirps, newest first.
+	ListChirps(context.Context, *ListChirpsRequest) (*ListChirpsResponse, error)
+	// StreamChirps sends chirps as they are published. With a resume_token
+	// it first replays what was published after that chirp.
+	StreamChirps(*StreamChirpsRequest, grpc.ServerStreamingServer[StreamChirpsResponse]) error
+	mustEmbedUnimplementedChirpServiceServer()
+}
+
+// UnimplementedChirpServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedChirpServiceServer struct{}
+
+func (UnimplementedChirpServiceServer) GetChirp(context.Context, *GetChirpRequest) (*Chirp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetChirp not implemented")
+}
+func (UnimplementedChirpServiceServer) ListChirps(context.Context, *ListChirpsRequest) (*ListChirpsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListChirps not implemented")
+}
+func (UnimplementedChirpServiceServer) StreamChirps(*StreamChirpsRequest, grpc.ServerStreamingServer[StreamChirpsResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamChirps not implemented")
+}
+func (UnimplementedChirpServiceServer) mustEmbedUnimplementedChirpServiceServer() {}
+func (UnimplementedChirpServiceServer) testEmbeddedByValue()                      {}
+
+// UnsafeChirpServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ChirpServiceServer will
+// result in compilation errors.
+type UnsafeChirpServiceServer interface {
+	mustEmbedUnimplementedChirpServiceServer()
+}
+
+func RegisterChirpServiceServer(s grpc.ServiceRegistrar, srv ChirpServiceServer) {
+	// If the following call pancis, it indicates UnimplementedChirpServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ChirpService_ServiceDesc, srv)
+}
+
+func _ChirpService_GetChirp_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetChirpRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChirpServiceServer).GetChirp(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ChirpService_GetChirp_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChirpServiceServer).GetChirp(ctx, req.(*GetChirpRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChirpService_ListChirps_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListChirpsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChirpServiceServer).ListChirps(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ChirpService_ListChirps_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChirpServiceServer).ListChirps(ctx, req.(*ListChirpsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChirpService_StreamChirps_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamChirpsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ChirpServiceServer).StreamChirps(m, &grpc.GenericServerStream[StreamChirpsRequest, StreamChirpsResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ChirpService_StreamChirpsServer = grpc.ServerStreamingServer[StreamChirpsResponse]
+
+// ChirpService_ServiceDesc is the grpc.ServiceDesc for ChirpService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ChirpService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "chirpy.v1.ChirpService",
+	HandlerType: (*ChirpServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetChirp",
+			Handler:    _ChirpService_GetChirp_Handler,
+		},
+		{
+			MethodName: "ListChirps",
+			Handler:    _ChirpService_ListChirps_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamChirps",
+			Handler:       _ChirpService_StreamChirps_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "chirpy/v1/chirps.proto",
+}
@@ -0,0 +1,5 @@
+// Package chirpypb holds the generated protobuf and gRPC code for the
+// services in proto/chirpy/v1.
+package chirpypb
+
+//go:generate protoc -I ../../proto --go_out=../.. --go_opt=module=github.com/Baehry/chirpy --go-grpc_out=../.. --go-grpc_opt=module=github.com/Baehry/chirpy chirpy/v1/auth.proto chirpy/v1/users.proto chirpy/v1/chirps.proto
@@ -0,0 +1,453 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: chirpy/v1/users.proto
+
+package chirpypb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type User struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Id             string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Handle         string                 `protobuf:"bytes,2,opt,name=handle,proto3" json:"handle,omitempty"`
+	DisplayName    string                 `protobuf:"bytes,3,opt,name=display_name,json=displayName,proto3" json:"display_name,omitempty"`
+	Bio            string                 `protobuf:"bytes,4,opt,name=bio,proto3" json:"bio,omitempty"`
+	AvatarUrl      string                 `protobuf:"bytes,5,opt,name=avatar_url,json=avatarUrl,proto3" json:"avatar_url,omitempty"`
+	Location       string                 `protobuf:"bytes,6,opt,name=location,proto3" json:"location,omitempty"`
+	IsChirpyRed    bool                   `protobuf:"varint,7,opt,name=is_chirpy_red,json=isChirpyRed,proto3" json:"is_chirpy_red,omitempty"`
+	CreatedAt      *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	ChirpCount     int64                  `protobuf:"varint,9,opt,name=chirp_count,json=chirpCount,proto3" json:"chirp_count,omitempty"`
+	FollowerCount  int64                  `protobuf:"varint,10,opt,name=follower_count,json=followerCount,proto3" json:"follower_count,omitempty"`
+	FollowingCount int64                  `protobuf:"varint,11,opt,name=following_count,json=followingCount,proto3" json:"following_count,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *User) Reset() {
+	*x = User{}
+	mi := &file_chirpy_v1_users_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *User) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*User) ProtoMessage() {}
+
+func (x *User) ProtoReflect() protoreflect.Message {
+	mi := &file_chirpy_v1_users_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use User.ProtoReflect.Descriptor instead.
+func (*User) Descriptor() ([]byte, []int) {
+	return file_chirpy_v1_users_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *User) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *User) GetHandle() string {
+	if x != nil {
+		return x.Handle
+	}
+	return ""
+}
+
+func (x *User) GetDisplayName() string {
+	if x != nil {
+		return x.DisplayName
+	}
+	return ""
+}
+
+func (x *User) GetBio() string {
+	if x != nil {
+		return x.Bio
+	}
+	return ""
+}
+
+func (x *User) GetAvatarUrl() string {
+	if x != nil {
+		return x.AvatarUrl
+	}
+	return ""
+}
+
+func (x *User) GetLocation() string {
+	if x != nil {
+		return x.Location
+	}
+	return ""
+}
+
+func (x *User) GetIsChirpyRed() bool {
+	if x != nil {
+		return x.IsChirpyRed
+	}
+	return false
+}
+
+func (x *User) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *User) GetChirpCount() int64 {
+	if x != nil {
+		return x.ChirpCount
+	}
+	return 0
+}
+
+func (x *User) GetFollowerCount() int64 {
+	if x != nil {
+		return x.FollowerCount
+	}
+	return 0
+}
+
+func (x *User) GetFollowingCount() int64 {
+	if x != nil {
+		return x.FollowingCount
+	}
+	return 0
+}
+
+type GetUserRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to User:
+	//
+	//	*GetUserRequest_Id
+	//	*GetUserRequest_Handle
+	User          isGetUserRequest_User `protobuf_oneof:"user"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetUserRequest) Reset() {
+	*x = GetUserRequest{}
+	mi := &file_chirpy_v1_users_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetUserRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetUserRequest) ProtoMessage() {}
+
+func (x *GetUserRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chirpy_v1_users_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetUserRequest.ProtoReflect.Descriptor instead.
+func (*GetUserRequest) Descriptor() ([]byte, []int) {
+	return file_chirpy_v1_users_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *GetUserRequest) GetUser() isGetUserRequest_User {
+	if x != nil {
+		return x.User
+	}
+	return nil
+}
+
+func (x *GetUserRequest) GetId() string {
+	if x != nil {
+		if x, ok := x.User.(*GetUserRequest_Id); ok {
+			return x.Id
+		}
+	}
+	return ""
+}
+
+func (x *GetUserRequest) GetHandle() string {
+	if x != nil {
+		if x, ok := x.User.(*GetUserRequest_Handle); ok {
+			return x.Handle
+		}
+	}
+	return ""
+}
+
+type isGetUserRequest_User interface {
+	isGetUserRequest_User()
+}
+
+type GetUserRequest_Id struct {
+	Id string `protobuf:"bytes,1,opt,name=id,proto3,oneof"`
+}
+
+type GetUserRequest_Handle struct {
+	Handle string `protobuf:"bytes,2,opt,name=handle,proto3,oneof"`
+}
+
+func (*GetUserRequest_Id) isGetUserRequest_User() {}
+
+func (*GetUserRequest_Handle) isGetUserRequest_User() {}
+
+type GetUsersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ids           []string               `protobuf:"bytes,1,rep,name=ids,proto3" json:"ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetUsersRequest) Reset() {
+	*x = GetUsersRequest{}
+	mi := &file_chirpy_v1_users_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetUsersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetUsersRequest) ProtoMessage() {}
+
+func (x *GetUsersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chirpy_v1_users_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetUsersRequest.ProtoReflect.Descriptor instead.
+func (*GetUsersRequest) Descriptor() ([]byte, []int) {
+	return file_chirpy_v1_users_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetUsersRequest) GetIds() []string {
+	if x != nil {
+		return x.Ids
+	}
+	return nil
+}
+
+type GetUsersResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Users         []*User                `protobuf:"bytes,1,rep,name=users,proto3" json:"users,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetUsersResponse) Reset() {
+	*x = GetUsersResponse{}
+	mi := &file_chirpy_v1_users_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetUsersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetUsersResponse) ProtoMessage() {}
+
+func (x *GetUsersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_chirpy_v1_users_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetUsersResponse.ProtoReflect.Descriptor instead.
+func (*GetUsersResponse) Descriptor() ([]byte, []int) {
+	return file_chirpy_v1_users_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetUsersResponse) GetUsers() []*User {
+	if x != nil {
+		return x.Users
+	}
+	return nil
+}
+
+type GetCurrentUserRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCurrentUserRequest) Reset() {
+	*x = GetCurrentUserRequest{}
+	mi := &file_chirpy_v1_users_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCurrentUserRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCurrentUserRequest) ProtoMessage() {}
+
+func (x *GetCurrentUserRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chirpy_v1_users_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCurrentUserRequest.ProtoReflect.Descriptor instead.
+func (*GetCurrentUserRequest) Descriptor() ([]byte, []int) {
+	return file_chirpy_v1_users_proto_rawDescGZIP(), []int{4}
+}
+
+var File_chirpy_v1_users_proto protoreflect.FileDescriptor
+
+const file_chirpy_v1_users_proto_rawDesc = "" +
+	"\n" +
+	"\x15chirpy/v1/users.proto\x12\tchirpy.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\xee\x02\n" +
+	"\x04User\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x16\n" +
+	"\x06handle\x18\x02 \x01(\tR\x06handle\x12!\n" +
+	"\fdisplay_name\x18\x03 \x01(\tR\vdisplayName\x12\x10\n" +
+	"\x03bio\x18\x04 \x01(\tR\x03bio\x12\x1d\n" +
+	"\n" +
+	"avatar_url\x18\x05 \x01(\tR\tavatarUrl\x12\x1a\n" +
+	"\blocation\x18\x06 \x01(\tR\blocation\x12\"\n" +
+	"\ris_chirpy_red\x18\a \x01(\bR\visChirpyRed\x129\n" +
+	"\n" +
+	"created_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x12\x1f\n" +
+	"\vchirp_count\x18\t \x01(\x03R\n" +
+	"chirpCount\x12%\n" +
+	"\x0efollower_count\x18\n" +
+	" \x01(\x03R\rfollowerCount\x12'\n" +
+	"\x0ffollowing_count\x18\v \x01(\x03R\x0efollowingCount\"D\n" +
+	"\x0eGetUserRequest\x12\x10\n" +
+	"\x02id\x18\x01 \x01(\tH\x00R\x02id\x12\x18\n" +
+	"\x06handle\x18\x02 \x01(\tH\x00R\x06handleB\x06\n" +
+	"\x04user\"#\n" +
+	"\x0fGetUsersRequest\x12\x10\n" +
+	"\x03ids\x18\x01 \x03(\tR\x03ids\"9\n" +
+	"\x10GetUsersResponse\x12%\n" +
+	"\x05users\x18\x01 \x03(\v2\x0f.chirpy.v1.UserR\x05users\"\x17\n" +
+	"\x15GetCurrentUserRequest2\xce\x01\n" +
+	"\vUserService\x125\n" +
+	"\aGetUser\x12\x19.chirpy.v1.GetUserRequest\x1a\x0f.chirpy.v1.User\x12C\n" +
+	"\bGetUsers\x12\x1a.chirpy.v1.GetUsersRequest\x1a\x1b.chirpy.v1.GetUsersResponse\x12C\n" +
+	"\x0eGetCurrentUser\x12 .chirpy.v1.GetCurrentUserRequest\x1a\x0f.chirpy.v1.UserB,Z*github.com/Baehry/chirpy/internal/chirpypbb\x06proto3"
+
+var (
+	file_chirpy_v1_users_proto_rawDescOnce sync.Once
+	file_chirpy_v1_users_proto_rawDescData []byte
+)
+
+func file_chirpy_v1_users_proto_rawDescGZIP() []byte {
+	file_chirpy_v1_users_proto_rawDescOnce.Do(func() {
+		file_chirpy_v1_users_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_chirpy_v1_users_proto_rawDesc), len(file_chirpy_v1_users_proto_rawDesc)))
+	})
+	return file_chirpy_v1_users_proto_rawDescData
+}
+
+var file_chirpy_v1_users_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_chirpy_v1_users_proto_goTypes = []any{
+	(*User)(nil),                  // 0: chirpy.v1.User
+	(*GetUserRequest)(nil),        // 1: chirpy.v1.GetUserRequest
+	(*GetUsersRequest)(nil),       // 2: chirpy.v1.GetUsersRequest
+	(*GetUsersResponse)(nil),      // 3: chirpy.v1.GetUsersResponse
+	(*GetCurrentUserRequest)(nil), // 4: chirpy.v1.GetCurrentUserRequest
+	(*timestamppb.Timestamp)(nil), // 5: google.protobuf.Timestamp
+}
+var file_chirpy_v1_users_proto_depIdxs = []int32{
+	5, // 0: chirpy.v1.User.created_at:type_name -> google.protobuf.Timestamp
+	0, // 1: chirpy.v1.GetUsersResponse.users:type_name -> chirpy.v1.User
+	1, // 2: chirpy.v1.UserService.GetUser:input_type -> chirpy.v1.GetUserRequest
+	2, // 3: chirpy.v1.UserService.GetUsers:input_type -> chirpy.v1.GetUsersRequest
+	4, // 4: chirpy.v1.UserService.GetCurrentUser:input_type -> chirpy.v1.GetCurrentUserRequest
+	0, // 5: chirpy.v1.UserService.GetUser:output_type -> chirpy.v1.User
+	3, // 6: chirpy.v1.UserService.GetUsers:output_type -> chirpy.v1.GetUsersResponse
+	0, // 7: chirpy.v1.UserService.GetCurrentUser:output_type -> chirpy.v1.User
+	5, // [5:8] is the sub-list for method output_type
+	2, // [2:5] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_chirpy_v1_users_proto_init() }
+func file_chirpy_v1_users_proto_init() {
+	if File_chirpy_v1_users_proto != nil {
+		return
+	}
+	file_chirpy_v1_users_proto_msgTypes[1].OneofWrappers = []any{
+		(*GetUserRequest_Id)(nil),
+		(*GetUserRequest_Handle)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_chirpy_v1_users_proto_rawDesc), len(file_chirpy_v1_users_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   5,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_chirpy_v1_users_proto_goTypes,
+		DependencyIndexes: file_chirpy_v1_users_proto_depIdxs,
+		MessageInfos:      file_chirpy_v1_users_proto_msgTypes,
+	}.Build()
+	File_chirpy_v1_users_proto = out.File
+	file_chirpy_v1_users_proto_goTypes = nil
+	file_chirpy_v1_users_proto_depIdxs = nil
+}
@@ -7,29 +7,88 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+const claimDueChirps = `-- name: ClaimDueChirps :many
+SELECT id, created_at, updated_at, body, user_id, search_vector, status, publish_at, expires_at, deleted_at FROM chirps
+WHERE status = 'scheduled'
+AND deleted_at IS NULL
+AND publish_at <= NOW()
+ORDER BY publish_at
+LIMIT $1
+FOR UPDATE SKIP LOCKED
+`
+
+func (q *Queries) ClaimDueChirps(ctx context.Context, limit int32) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, claimDueChirps, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.SearchVector,
+			&i.Status,
+			&i.PublishAt,
+			&i.ExpiresAt,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const createChirp = `-- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, status, publish_at, expires_at)
 VALUES (
     gen_random_uuid(),
     NOW(),
     NOW(),
     $1,
-    $2
+    $2,
+    $3,
+    $4,
+    $5
 )
-RETURNING id, created_at, updated_at, body, user_id
+RETURNING id, created_at, updated_at, body, user_id, search_vector, status, publish_at, expires_at, deleted_at
 `
 
 type CreateChirpParams struct {
-	Body   string
-	UserID uuid.UUID
+	Body      string
+	UserID    uuid.UUID
+	Status    string
+	PublishAt sql.NullTime
+	ExpiresAt sql.NullTime
 }
 
 func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp, error) {
-	row := q.db.QueryRowContext(ctx, createChirp, arg.Body, arg.UserID)
+	row := q.db.QueryRowContext(ctx, createChirp,
+		arg.Body,
+		arg.UserID,
+		arg.Status,
+		arg.PublishAt,
+		arg.ExpiresAt,
+	)
 	var i Chirp
 	err := row.Scan(
 		&i.ID,
@@ -37,27 +96,87 @@ func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.SearchVector,
+		&i.Status,
+		&i.PublishAt,
+		&i.ExpiresAt,
+		&i.DeletedAt,
 	)
 	return i, err
 }
 
-const deleteChirp = `-- name: DeleteChirp :exec
+const deleteExpiredChirps = `-- name: DeleteExpiredChirps :many
 DELETE FROM chirps
-WHERE id = $1
+WHERE id = ANY($1::uuid[])
+AND expires_at <= NOW()
+RETURNING id, created_at, updated_at, body, user_id, search_vector, status, publish_at, expires_at, deleted_at
 `
 
-func (q *Queries) DeleteChirp(ctx context.Context, id uuid.UUID) error {
-	_, err := q.db.ExecContext(ctx, deleteChirp, id)
-	return err
+func (q *Queries) DeleteExpiredChirps(ctx context.Context, ids []uuid.UUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, deleteExpiredChirps, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.SearchVector,
+			&i.Status,
+			&i.PublishAt,
+			&i.ExpiresAt,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
 const getAllChirps = `-- name: GetAllChirps :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps
-ORDER BY created_at
+SELECT id, created_at, updated_at, body, user_id, search_vector, status, publish_at, expires_at, deleted_at FROM chirps
+WHERE status = 'published'
+AND (expires_at IS NULL OR expires_at > NOW())
+AND deleted_at IS NULL
+AND ($1::timestamp IS NULL OR created_at >= $1::timestamp)
+AND ($2::timestamp IS NULL OR created_at < $2::timestamp)
+AND ($3::timestamp IS NULL OR (created_at, id) > ($3::timestamp, $4::uuid))
+AND NOT chirp_hidden(chirps.id, chirps.user_id, $5::uuid)
+ORDER BY created_at, id
+LIMIT $6
 `
 
-func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getAllChirps)
+type GetAllChirpsParams struct {
+	Since           sql.NullTime
+	Until           sql.NullTime
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	ViewerID        uuid.NullUUID
+	Limit           int32
+}
+
+func (q *Queries) GetAllChirps(ctx context.Context, arg GetAllChirpsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getAllChirps,
+		arg.Since,
+		arg.Until,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.ViewerID,
+		arg.Limit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -71,6 +190,74 @@ func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.SearchVector,
+			&i.Status,
+			&i.PublishAt,
+			&i.ExpiresAt,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getAllChirpsDesc = `-- name: GetAllChirpsDesc :many
+SELECT id, created_at, updated_at, body, user_id, search_vector, status, publish_at, expires_at, deleted_at FROM chirps
+WHERE status = 'published'
+AND (expires_at IS NULL OR expires_at > NOW())
+AND deleted_at IS NULL
+AND ($1::timestamp IS NULL OR created_at >= $1::timestamp)
+AND ($2::timestamp IS NULL OR created_at < $2::timestamp)
+AND ($3::timestamp IS NULL OR (created_at, id) < ($3::timestamp, $4::uuid))
+AND NOT chirp_hidden(chirps.id, chirps.user_id, $5::uuid)
+ORDER BY created_at DESC, id DESC
+LIMIT $6
+`
+
+type GetAllChirpsDescParams struct {
+	Since           sql.NullTime
+	Until           sql.NullTime
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	ViewerID        uuid.NullUUID
+	Limit           int32
+}
+
+func (q *Queries) GetAllChirpsDesc(ctx context.Context, arg GetAllChirpsDescParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getAllChirpsDesc,
+		arg.Since,
+		arg.Until,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.ViewerID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.SearchVector,
+			&i.Status,
+			&i.PublishAt,
+			&i.ExpiresAt,
+			&i.DeletedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -86,8 +273,10 @@ func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
 }
 
 const getChirp = `-- name: GetChirp :one
-SELECT id, created_at, updated_at, body, user_id FROM chirps
+SELECT id, created_at, updated_at, body, user_id, search_vector, status, publish_at, expires_at, deleted_at FROM chirps
 WHERE id = $1
+AND (expires_at IS NULL OR expires_at > NOW())
+AND deleted_at IS NULL
 `
 
 func (q *Queries) GetChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
@@ -99,18 +288,411 @@ func (q *Queries) GetChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.SearchVector,
+		&i.Status,
+		&i.PublishAt,
+		&i.ExpiresAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const getChirpIncludingDeleted = `-- name: GetChirpIncludingDeleted :one
+SELECT id, created_at, updated_at, body, user_id, search_vector, status, publish_at, expires_at, deleted_at FROM chirps
+WHERE id = $1
+`
+
+func (q *Queries) GetChirpIncludingDeleted(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getChirpIncludingDeleted, id)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.SearchVector,
+		&i.Status,
+		&i.PublishAt,
+		&i.ExpiresAt,
+		&i.DeletedAt,
 	)
 	return i, err
 }
 
+const getChirpsByIDs = `-- name: GetChirpsByIDs :many
+SELECT id, created_at, updated_at, body, user_id, search_vector, status, publish_at, expires_at, deleted_at FROM chirps
+WHERE id = ANY($1::uuid[])
+AND status = 'published'
+AND (expires_at IS NULL OR expires_at > NOW())
+AND deleted_at IS NULL
+AND NOT chirp_hidden(chirps.id, chirps.user_id, $2::uuid)
+`
+
+type GetChirpsByIDsParams struct {
+	Ids      []uuid.UUID
+	ViewerID uuid.NullUUID
+}
+
+func (q *Queries) GetChirpsByIDs(ctx context.Context, arg GetChirpsByIDsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsByIDs, pq.Array(arg.Ids), arg.ViewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.SearchVector,
+			&i.Status,
+			&i.PublishAt,
+			&i.ExpiresAt,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getChirpsByUser = `-- name: GetChirpsByUser :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps
+SELECT id, created_at, updated_at, body, user_id, search_vector, status, publish_at, expires_at, deleted_at FROM chirps
+WHERE user_id = $1
+AND status = 'published'
+AND (NOT $2::boolean OR NOT EXISTS (SELECT 1 FROM pinned_chirps WHERE pinned_chirps.chirp_id = chirps.id))
+AND (expires_at IS NULL OR expires_at > NOW())
+AND deleted_at IS NULL
+AND ($3::timestamp IS NULL OR created_at >= $3::timestamp)
+AND ($4::timestamp IS NULL OR created_at < $4::timestamp)
+AND ($5::timestamp IS NULL OR (created_at, id) > ($5::timestamp, $6::uuid))
+AND NOT chirp_hidden(chirps.id, chirps.user_id, $7::uuid)
+ORDER BY created_at, id
+LIMIT $8
+`
+
+type GetChirpsByUserParams struct {
+	UserID          uuid.UUID
+	ExcludePinned   bool
+	Since           sql.NullTime
+	Until           sql.NullTime
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	ViewerID        uuid.NullUUID
+	Limit           int32
+}
+
+func (q *Queries) GetChirpsByUser(ctx context.Context, arg GetChirpsByUserParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsByUser,
+		arg.UserID,
+		arg.ExcludePinned,
+		arg.Since,
+		arg.Until,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.ViewerID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.SearchVector,
+			&i.Status,
+			&i.PublishAt,
+			&i.ExpiresAt,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpsByUserDesc = `-- name: GetChirpsByUserDesc :many
+SELECT id, created_at, updated_at, body, user_id, search_vector, status, publish_at, expires_at, deleted_at FROM chirps
+WHERE user_id = $1
+AND status = 'published'
+AND (NOT $2::boolean OR NOT EXISTS (SELECT 1 FROM pinned_chirps WHERE pinned_chirps.chirp_id = chirps.id))
+AND (expires_at IS NULL OR expires_at > NOW())
+AND deleted_at IS NULL
+AND ($3::timestamp IS NULL OR created_at >= $3::timestamp)
+AND ($4::timestamp IS NULL OR created_at < $4::timestamp)
+AND ($5::timestamp IS NULL OR (created_at, id) < ($5::timestamp, $6::uuid))
+AND NOT chirp_hidden(chirps.id, chirps.user_id, $7::uuid)
+ORDER BY created_at DESC, id DESC
+LIMIT $8
+`
+
+type GetChirpsByUserDescParams struct {
+	UserID          uuid.UUID
+	ExcludePinned   bool
+	Since           sql.NullTime
+	Until           sql.NullTime
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	ViewerID        uuid.NullUUID
+	Limit           int32
+}
+
+func (q *Queries) GetChirpsByUserDesc(ctx context.Context, arg GetChirpsByUserDescParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsByUserDesc,
+		arg.UserID,
+		arg.ExcludePinned,
+		arg.Since,
+		arg.Until,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.ViewerID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.SearchVector,
+			&i.Status,
+			&i.PublishAt,
+			&i.ExpiresAt,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDeletedChirps = `-- name: GetDeletedChirps :many
+SELECT id, created_at, updated_at, body, user_id, search_vector, status, publish_at, expires_at, deleted_at FROM chirps
+WHERE deleted_at IS NOT NULL
+AND ($1::timestamp IS NULL OR (created_at, id) < ($1::timestamp, $2::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT $3
+`
+
+type GetDeletedChirpsParams struct {
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	Limit           int32
+}
+
+func (q *Queries) GetDeletedChirps(ctx context.Context, arg GetDeletedChirpsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getDeletedChirps, arg.CursorCreatedAt, arg.CursorID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.SearchVector,
+			&i.Status,
+			&i.PublishAt,
+			&i.ExpiresAt,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDraftsByUser = `-- name: GetDraftsByUser :many
+SELECT id, created_at, updated_at, body, user_id, search_vector, status, publish_at, expires_at, deleted_at FROM chirps
 WHERE user_id = $1
-ORDER BY created_at
+AND status <> 'published'
+AND deleted_at IS NULL
+AND ($2::timestamp IS NULL OR (created_at, id) < ($2::timestamp, $3::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT $4
+`
+
+type GetDraftsByUserParams struct {
+	UserID          uuid.UUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	Limit           int32
+}
+
+func (q *Queries) GetDraftsByUser(ctx context.Context, arg GetDraftsByUserParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getDraftsByUser,
+		arg.UserID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.SearchVector,
+			&i.Status,
+			&i.PublishAt,
+			&i.ExpiresAt,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTrashByUser = `-- name: GetTrashByUser :many
+SELECT id, created_at, updated_at, body, user_id, search_vector, status, publish_at, expires_at, deleted_at FROM chirps
+WHERE user_id = $1
+AND deleted_at > $2::timestamp
+AND ($3::timestamp IS NULL OR (created_at, id) < ($3::timestamp, $4::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT $5
+`
+
+type GetTrashByUserParams struct {
+	UserID          uuid.UUID
+	DeletedAfter    time.Time
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	Limit           int32
+}
+
+func (q *Queries) GetTrashByUser(ctx context.Context, arg GetTrashByUserParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getTrashByUser,
+		arg.UserID,
+		arg.DeletedAfter,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.SearchVector,
+			&i.Status,
+			&i.PublishAt,
+			&i.ExpiresAt,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const isChirpHidden = `-- name: IsChirpHidden :one
+SELECT chirp_hidden($1::uuid, $2::uuid, $3::uuid) AS hidden
+`
+
+type IsChirpHiddenParams struct {
+	ChirpID  uuid.UUID
+	AuthorID uuid.UUID
+	ViewerID uuid.UUID
+}
+
+func (q *Queries) IsChirpHidden(ctx context.Context, arg IsChirpHiddenParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, isChirpHidden, arg.ChirpID, arg.AuthorID, arg.ViewerID)
+	var hidden bool
+	err := row.Scan(&hidden)
+	return hidden, err
+}
+
+const listExpiredChirps = `-- name: ListExpiredChirps :many
+SELECT id, created_at, updated_at, body, user_id, search_vector, status, publish_at, expires_at, deleted_at FROM chirps
+WHERE expires_at <= NOW()
+ORDER BY expires_at
+LIMIT $1
 `
 
-func (q *Queries) GetChirpsByUser(ctx context.Context, userID uuid.UUID) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getChirpsByUser, userID)
+func (q *Queries) ListExpiredChirps(ctx context.Context, limit int32) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listExpiredChirps, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -124,6 +706,11 @@ func (q *Queries) GetChirpsByUser(ctx context.Context, userID uuid.UUID) ([]Chir
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.SearchVector,
+			&i.Status,
+			&i.PublishAt,
+			&i.ExpiresAt,
+			&i.DeletedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -137,3 +724,326 @@ func (q *Queries) GetChirpsByUser(ctx context.Context, userID uuid.UUID) ([]Chir
 	}
 	return items, nil
 }
+
+const listPurgeableChirps = `-- name: ListPurgeableChirps :many
+SELECT id, created_at, updated_at, body, user_id, search_vector, status, publish_at, expires_at, deleted_at FROM chirps
+WHERE deleted_at < $1
+ORDER BY deleted_at
+LIMIT $2
+`
+
+type ListPurgeableChirpsParams struct {
+	DeletedAt sql.NullTime
+	Limit     int32
+}
+
+func (q *Queries) ListPurgeableChirps(ctx context.Context, arg ListPurgeableChirpsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listPurgeableChirps, arg.DeletedAt, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.SearchVector,
+			&i.Status,
+			&i.PublishAt,
+			&i.ExpiresAt,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markChirpPublished = `-- name: MarkChirpPublished :one
+-- created_at is when the chirp actually went out rather than publish_at,
+-- so it sorts after every cursor handed out earlier and stream clients
+-- resuming with one still get it.
+UPDATE chirps
+SET status = 'published',
+created_at = NOW(),
+updated_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, body, user_id, search_vector, status, publish_at, expires_at, deleted_at
+`
+
+func (q *Queries) MarkChirpPublished(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, markChirpPublished, id)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.SearchVector,
+		&i.Status,
+		&i.PublishAt,
+		&i.ExpiresAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const notifyChirpPublished = `-- name: NotifyChirpPublished :exec
+SELECT pg_notify('chirp_published', $1::uuid::text)
+`
+
+func (q *Queries) NotifyChirpPublished(ctx context.Context, chirpID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, notifyChirpPublished, chirpID)
+	return err
+}
+
+const publishDraft = `-- name: PublishDraft :one
+UPDATE chirps
+SET status = 'published',
+publish_at = NULL,
+expires_at = NOW() + (expires_at - publish_at),
+created_at = NOW(),
+updated_at = NOW()
+WHERE id = $1
+AND user_id = $2
+AND status <> 'published'
+AND deleted_at IS NULL
+RETURNING id, created_at, updated_at, body, user_id, search_vector, status, publish_at, expires_at, deleted_at
+`
+
+type PublishDraftParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) PublishDraft(ctx context.Context, arg PublishDraftParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, publishDraft, arg.ID, arg.UserID)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.SearchVector,
+		&i.Status,
+		&i.PublishAt,
+		&i.ExpiresAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const purgeChirps = `-- name: PurgeChirps :execrows
+DELETE FROM chirps
+WHERE id = ANY($1::uuid[])
+AND deleted_at < $2::timestamp
+`
+
+type PurgeChirpsParams struct {
+	Ids           []uuid.UUID
+	DeletedBefore time.Time
+}
+
+func (q *Queries) PurgeChirps(ctx context.Context, arg PurgeChirpsParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, purgeChirps, pq.Array(arg.Ids), arg.DeletedBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const restoreChirp = `-- name: RestoreChirp :one
+UPDATE chirps
+SET deleted_at = NULL
+WHERE id = $1
+AND user_id = $2
+AND deleted_at > $3
+RETURNING id, created_at, updated_at, body, user_id, search_vector, status, publish_at, expires_at, deleted_at
+`
+
+type RestoreChirpParams struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	DeletedAt sql.NullTime
+}
+
+func (q *Queries) RestoreChirp(ctx context.Context, arg RestoreChirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, restoreChirp, arg.ID, arg.UserID, arg.DeletedAt)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.SearchVector,
+		&i.Status,
+		&i.PublishAt,
+		&i.ExpiresAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const searchChirps = `-- name: SearchChirps :many
+SELECT id, created_at, updated_at, body, user_id,
+    ts_rank_cd(search_vector, query) AS rank,
+    -- The snippet is served as HTML, so the body is escaped before <mark>
+    -- tags go in. The parser reads &lt; and friends as entities, not words.
+    ts_headline('english',
+        replace(replace(replace(replace(replace(body, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&quot;'), '''', '&#39;'),
+        query, 'StartSel=<mark>, StopSel=</mark>, MaxFragments=2') AS snippet
+FROM chirps
+CROSS JOIN to_tsquery('english', $1) AS query
+WHERE search_vector @@ query
+AND status = 'published'
+AND (expires_at IS NULL OR expires_at > NOW())
+AND deleted_at IS NULL
+AND ($2::uuid IS NULL OR user_id = $2::uuid)
+AND ($3::timestamp IS NULL OR created_at >= $3::timestamp)
+AND ($4::timestamp IS NULL OR created_at < $4::timestamp)
+AND ($5::real IS NULL OR (ts_rank_cd(search_vector, query), created_at, id) < ($5::real, $6::timestamp, $7::uuid))
+AND NOT chirp_hidden(chirps.id, chirps.user_id, $8::uuid)
+ORDER BY rank DESC, created_at DESC, id DESC
+LIMIT $9
+`
+
+type SearchChirpsParams struct {
+	Query           string
+	AuthorID        uuid.NullUUID
+	Since           sql.NullTime
+	Until           sql.NullTime
+	CursorRank      sql.NullFloat64
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	ViewerID        uuid.NullUUID
+	Limit           int32
+}
+
+type SearchChirpsRow struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Body      string
+	UserID    uuid.UUID
+	Rank      float32
+	Snippet   string
+}
+
+func (q *Queries) SearchChirps(ctx context.Context, arg SearchChirpsParams) ([]SearchChirpsRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchChirps,
+		arg.Query,
+		arg.AuthorID,
+		arg.Since,
+		arg.Until,
+		arg.CursorRank,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.ViewerID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchChirpsRow
+	for rows.Next() {
+		var i SearchChirpsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.Rank,
+			&i.Snippet,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const softDeleteChirp = `-- name: SoftDeleteChirp :execrows
+UPDATE chirps
+SET deleted_at = NOW()
+WHERE id = $1
+AND deleted_at IS NULL
+`
+
+func (q *Queries) SoftDeleteChirp(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, softDeleteChirp, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateDraft = `-- name: UpdateDraft :one
+UPDATE chirps
+SET body = $3,
+status = $4,
+publish_at = $5,
+expires_at = $6,
+updated_at = NOW()
+WHERE id = $1
+AND user_id = $2
+AND status <> 'published'
+AND deleted_at IS NULL
+RETURNING id, created_at, updated_at, body, user_id, search_vector, status, publish_at, expires_at, deleted_at
+`
+
+type UpdateDraftParams struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Body      string
+	Status    string
+	PublishAt sql.NullTime
+	ExpiresAt sql.NullTime
+}
+
+func (q *Queries) UpdateDraft(ctx context.Context, arg UpdateDraftParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, updateDraft,
+		arg.ID,
+		arg.UserID,
+		arg.Body,
+		arg.Status,
+		arg.PublishAt,
+		arg.ExpiresAt,
+	)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.SearchVector,
+		&i.Status,
+		&i.PublishAt,
+		&i.ExpiresAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
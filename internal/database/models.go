@@ -11,12 +11,220 @@ import (
 	"github.com/google/uuid"
 )
 
+type ActorKey struct {
+	UserID        uuid.UUID
+	PublicKeyPem  string
+	PrivateKeyPem string
+	CreatedAt     time.Time
+}
+
+type ApDelivery struct {
+	ID            uuid.UUID
+	UserID        uuid.UUID
+	Inbox         string
+	Activity      string
+	Status        string
+	Attempts      int32
+	NextAttemptAt sql.NullTime
+	LastError     string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+type Block struct {
+	BlockerID uuid.UUID
+	BlockedID uuid.UUID
+	CreatedAt time.Time
+}
+
+type Bookmark struct {
+	UserID       uuid.UUID
+	ChirpID      uuid.UUID
+	CollectionID uuid.NullUUID
+	CreatedAt    time.Time
+}
+
+type BookmarkCollection struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Name      string
+	CreatedAt time.Time
+}
+
 type Chirp struct {
 	ID        uuid.UUID `json:"id"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 	Body      string 	`json:"body"`
 	UserID    uuid.UUID `json:"user_id"`
+	SearchVector interface{} `json:"-"`
+	Status    string `json:"status"`
+	PublishAt sql.NullTime `json:"-"`
+	ExpiresAt sql.NullTime `json:"-"`
+	DeletedAt sql.NullTime `json:"-"`
+}
+
+type ChirpFlag struct {
+	ChirpID    uuid.UUID
+	CreatedAt  time.Time
+	Words      []string
+	ResolvedAt sql.NullTime
+}
+
+type ChirpHashtag struct {
+	ChirpID   uuid.UUID
+	HashtagID uuid.UUID
+}
+
+type Conversation struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	CreatedBy uuid.NullUUID
+	IsGroup   bool
+	Title     string
+	DirectKey sql.NullString
+}
+
+type ConversationMember struct {
+	ConversationID uuid.UUID
+	UserID         uuid.UUID
+	JoinedAt       time.Time
+	LastReadAt     sql.NullTime
+}
+
+type DmSetting struct {
+	UserID uuid.UUID
+	Policy string
+}
+
+type Follow struct {
+	FollowerID uuid.UUID
+	FolloweeID uuid.UUID
+	CreatedAt  time.Time
+}
+
+type HandleRedirect struct {
+	Handle    string
+	UserID    uuid.UUID
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+type Hashtag struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Tag       string    `json:"tag"`
+}
+
+type Medium struct {
+	ID              uuid.UUID
+	CreatedAt       time.Time
+	UserID          uuid.UUID
+	ChirpID         uuid.NullUUID
+	Position        int32
+	ContentType     string
+	Size            int32
+	Width           int32
+	Height          int32
+	ThumbnailWidth  int32
+	ThumbnailHeight int32
+	AltText         string
+}
+
+type Mention struct {
+	ChirpID   uuid.UUID
+	UserID    uuid.UUID
+	Handle    string
+	CreatedAt time.Time
+}
+
+type Message struct {
+	ID             uuid.UUID
+	ConversationID uuid.UUID
+	SenderID       uuid.UUID
+	Body           string
+	CreatedAt      time.Time
+	DeletedAt      sql.NullTime
+}
+
+type MessageDeletion struct {
+	MessageID uuid.UUID
+	UserID    uuid.UUID
+	CreatedAt time.Time
+}
+
+type ModerationRule struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Word      string    `json:"word"`
+	Action    string    `json:"action"`
+}
+
+type Mute struct {
+	MuterID   uuid.UUID
+	MutedID   uuid.UUID
+	CreatedAt time.Time
+}
+
+type MutedWord struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Kind      string
+	Word      string
+	CreatedAt time.Time
+	ExpiresAt sql.NullTime
+}
+
+type Notification struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Type      string
+	GroupKey  string
+	ChirpID   uuid.NullUUID
+	Detail    string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	ReadAt    sql.NullTime
+}
+
+type NotificationActor struct {
+	NotificationID uuid.UUID
+	ActorID        uuid.UUID
+	CreatedAt      time.Time
+}
+
+type NotificationPreference struct {
+	UserID  uuid.UUID
+	Type    string
+	Enabled bool
+}
+
+type PinnedChirp struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+}
+
+type Poll struct {
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+	ClosesAt  time.Time
+}
+
+type PollOption struct {
+	ID       uuid.UUID
+	ChirpID  uuid.UUID
+	Position int32
+	Text     string
+}
+
+type PollVote struct {
+	ChirpID   uuid.UUID
+	UserID    uuid.UUID
+	OptionID  uuid.UUID
+	CreatedAt time.Time
 }
 
 type RefreshToken struct {
@@ -28,6 +236,55 @@ type RefreshToken struct {
 	RevokedAt sql.NullTime
 }
 
+type RemoteActor struct {
+	ID           uuid.UUID
+	Uri          string
+	Username     string
+	Domain       string
+	DisplayName  string
+	Summary      string
+	Url          string
+	Inbox        string
+	SharedInbox  string
+	PublicKeyID  string
+	PublicKeyPem string
+	FetchedAt    time.Time
+	CreatedAt    time.Time
+}
+
+type RemoteFollow struct {
+	ID            uuid.UUID
+	UserID        uuid.UUID
+	RemoteActorID uuid.UUID
+	Accepted      bool
+	CreatedAt     time.Time
+}
+
+type RemoteFollower struct {
+	UserID        uuid.UUID
+	RemoteActorID uuid.UUID
+	FollowUri     string
+	CreatedAt     time.Time
+}
+
+type RemoteLike struct {
+	ChirpID       uuid.UUID
+	RemoteActorID uuid.UUID
+	LikeUri       string
+	CreatedAt     time.Time
+}
+
+type RemotePost struct {
+	ID            uuid.UUID
+	Uri           string
+	RemoteActorID uuid.UUID
+	Content       string
+	Url           string
+	PublishedAt   time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
 type User struct {
 	ID        uuid.UUID `json:"id"`
 	CreatedAt time.Time `json:"created_at"`
@@ -35,4 +292,46 @@ type User struct {
 	Email     string 	`json:"email"`
 	HashedPassword string `json:"-"`
 	IsChirpyRed    bool `json:"is_chirpy_red"`
+	Handle         string `json:"handle"`
+	DisplayName    string `json:"display_name"`
+	Bio            string `json:"bio"`
+	AvatarUrl      string `json:"avatar_url"`
+	Location       string `json:"location"`
+	HandleChangedAt sql.NullTime `json:"-"`
+	ChirpsChangedAt sql.NullTime `json:"-"`
+}
+
+type WebhookAttempt struct {
+	ID          uuid.UUID
+	DeliveryID  uuid.UUID
+	AttemptedAt time.Time
+	StatusCode  sql.NullInt32
+	Error       string
+	DurationMs  int32
+}
+
+type WebhookDelivery struct {
+	ID             uuid.UUID
+	EndpointID     uuid.UUID
+	EventID        uuid.UUID
+	EventType      string
+	Payload        string
+	Status         string
+	Attempts       int32
+	NextAttemptAt  sql.NullTime
+	LastStatusCode sql.NullInt32
+	LastError      string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+type WebhookEndpoint struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Url       string
+	Secret    string
+	Events    []string
+	Active    bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
 }
@@ -0,0 +1,6 @@
+package database
+
+// SchemaVersion is the migration in sql/schema that the queries in this
+// package were generated against. Bump it with every new migration; the
+// server refuses to start against a database at any other version.
+const SchemaVersion = 24
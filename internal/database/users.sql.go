@@ -7,29 +7,118 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+const changeHandle = `-- name: ChangeHandle :one
+UPDATE users
+SET handle = $2,
+handle_changed_at = NOW(),
+updated_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, avatar_url, location, handle_changed_at, chirps_changed_at
+`
+
+type ChangeHandleParams struct {
+	ID     uuid.UUID
+	Handle string
+}
+
+func (q *Queries) ChangeHandle(ctx context.Context, arg ChangeHandleParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, changeHandle, arg.ID, arg.Handle)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.Location,
+		&i.HandleChangedAt,
+		&i.ChirpsChangedAt,
+	)
+	return i, err
+}
+
 const createUser = `-- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, email, hashed_password)
+INSERT INTO users (id, created_at, updated_at, email, hashed_password, handle)
 VALUES (
     gen_random_uuid(),
     NOW(),
     NOW(),
     $1,
-    $2
+    $2,
+    COALESCE($3, 'user_' || substr(md5(gen_random_uuid()::text), 1, 12))
 )
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, avatar_url, location, handle_changed_at, chirps_changed_at
 `
 
 type CreateUserParams struct {
 	Email          string
 	HashedPassword string
+	Handle         sql.NullString
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
-	row := q.db.QueryRowContext(ctx, createUser, arg.Email, arg.HashedPassword)
+	row := q.db.QueryRowContext(ctx, createUser, arg.Email, arg.HashedPassword, arg.Handle)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.Location,
+		&i.HandleChangedAt,
+		&i.ChirpsChangedAt,
+	)
+	return i, err
+}
+
+const downgradeUser = `-- name: DowngradeUser :exec
+UPDATE users
+SET is_chirpy_red = FALSE
+WHERE id = $1
+`
+
+func (q *Queries) DowngradeUser(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, downgradeUser, id)
+	return err
+}
+
+const getLatestChirpsChangedAt = `-- name: GetLatestChirpsChangedAt :one
+SELECT MAX(chirps_changed_at)::timestamp AS chirps_changed_at
+FROM users
+`
+
+func (q *Queries) GetLatestChirpsChangedAt(ctx context.Context) (sql.NullTime, error) {
+	row := q.db.QueryRowContext(ctx, getLatestChirpsChangedAt)
+	var chirpsChangedAt sql.NullTime
+	err := row.Scan(&chirpsChangedAt)
+	return chirpsChangedAt, err
+}
+
+const getUser = `-- name: GetUser :one
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, avatar_url, location, handle_changed_at, chirps_changed_at FROM users
+WHERE id = $1
+`
+
+func (q *Queries) GetUser(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUser, id)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -38,12 +127,19 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.Location,
+		&i.HandleChangedAt,
+		&i.ChirpsChangedAt,
 	)
 	return i, err
 }
 
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red FROM users
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, avatar_url, location, handle_changed_at, chirps_changed_at FROM users
 WHERE email = $1
 `
 
@@ -57,6 +153,246 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.Location,
+		&i.HandleChangedAt,
+		&i.ChirpsChangedAt,
+	)
+	return i, err
+}
+
+const getUserByHandle = `-- name: GetUserByHandle :one
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, avatar_url, location, handle_changed_at, chirps_changed_at FROM users
+WHERE LOWER(handle) = LOWER($1)
+`
+
+func (q *Queries) GetUserByHandle(ctx context.Context, handle string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByHandle, handle)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.Location,
+		&i.HandleChangedAt,
+		&i.ChirpsChangedAt,
+	)
+	return i, err
+}
+
+const getUserCounts = `-- name: GetUserCounts :many
+SELECT users.id,
+    (SELECT COUNT(*) FROM chirps WHERE chirps.user_id = users.id AND chirps.status = 'published' AND (chirps.expires_at IS NULL OR chirps.expires_at > NOW()) AND chirps.deleted_at IS NULL) AS chirp_count,
+    (SELECT COUNT(*) FROM follows WHERE follows.followee_id = users.id) AS follower_count,
+    (SELECT COUNT(*) FROM follows WHERE follows.follower_id = users.id) AS following_count
+FROM users
+WHERE users.id = ANY($1::uuid[])
+`
+
+type GetUserCountsRow struct {
+	ID             uuid.UUID
+	ChirpCount     int64
+	FollowerCount  int64
+	FollowingCount int64
+}
+
+func (q *Queries) GetUserCounts(ctx context.Context, ids []uuid.UUID) ([]GetUserCountsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getUserCounts, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetUserCountsRow
+	for rows.Next() {
+		var i GetUserCountsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ChirpCount,
+			&i.FollowerCount,
+			&i.FollowingCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUserProfile = `-- name: GetUserProfile :one
+SELECT users.id, users.created_at, users.updated_at, users.email, users.hashed_password, users.is_chirpy_red, users.handle, users.display_name, users.bio, users.avatar_url, users.location, users.handle_changed_at, users.chirps_changed_at,
+    (SELECT COUNT(*) FROM chirps WHERE chirps.user_id = users.id AND chirps.status = 'published' AND (chirps.expires_at IS NULL OR chirps.expires_at > NOW()) AND chirps.deleted_at IS NULL) AS chirp_count,
+    (SELECT COUNT(*) FROM follows WHERE follows.followee_id = users.id) AS follower_count,
+    (SELECT COUNT(*) FROM follows WHERE follows.follower_id = users.id) AS following_count
+FROM users
+WHERE users.id = $1
+`
+
+type GetUserProfileRow struct {
+	ID              uuid.UUID
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	Email           string
+	HashedPassword  string
+	IsChirpyRed     bool
+	Handle          string
+	DisplayName     string
+	Bio             string
+	AvatarUrl       string
+	Location        string
+	HandleChangedAt sql.NullTime
+	ChirpsChangedAt sql.NullTime
+	ChirpCount      int64
+	FollowerCount   int64
+	FollowingCount  int64
+}
+
+func (q *Queries) GetUserProfile(ctx context.Context, id uuid.UUID) (GetUserProfileRow, error) {
+	row := q.db.QueryRowContext(ctx, getUserProfile, id)
+	var i GetUserProfileRow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.Location,
+		&i.HandleChangedAt,
+		&i.ChirpsChangedAt,
+		&i.ChirpCount,
+		&i.FollowerCount,
+		&i.FollowingCount,
+	)
+	return i, err
+}
+
+const getUsersByHandles = `-- name: GetUsersByHandles :many
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, avatar_url, location, handle_changed_at, chirps_changed_at FROM users
+WHERE LOWER(handle) = ANY($1::text[])
+`
+
+func (q *Queries) GetUsersByHandles(ctx context.Context, handles []string) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, getUsersByHandles, pq.Array(handles))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Email,
+			&i.HashedPassword,
+			&i.IsChirpyRed,
+			&i.Handle,
+			&i.DisplayName,
+			&i.Bio,
+			&i.AvatarUrl,
+			&i.Location,
+			&i.HandleChangedAt,
+			&i.ChirpsChangedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUsersByIDs = `-- name: GetUsersByIDs :many
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, avatar_url, location, handle_changed_at, chirps_changed_at FROM users
+WHERE id = ANY($1::uuid[])
+`
+
+func (q *Queries) GetUsersByIDs(ctx context.Context, ids []uuid.UUID) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, getUsersByIDs, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Email,
+			&i.HashedPassword,
+			&i.IsChirpyRed,
+			&i.Handle,
+			&i.DisplayName,
+			&i.Bio,
+			&i.AvatarUrl,
+			&i.Location,
+			&i.HandleChangedAt,
+			&i.ChirpsChangedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockUser = `-- name: LockUser :one
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, avatar_url, location, handle_changed_at, chirps_changed_at FROM users
+WHERE id = $1
+FOR UPDATE
+`
+
+func (q *Queries) LockUser(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, lockUser, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.Location,
+		&i.HandleChangedAt,
+		&i.ChirpsChangedAt,
 	)
 	return i, err
 }
@@ -70,11 +406,102 @@ func (q *Queries) ResetUsers(ctx context.Context) error {
 	return err
 }
 
+const setUserPassword = `-- name: SetUserPassword :one
+UPDATE users
+SET hashed_password = $2,
+updated_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, avatar_url, location, handle_changed_at, chirps_changed_at
+`
+
+type SetUserPasswordParams struct {
+	ID             uuid.UUID
+	HashedPassword string
+}
+
+func (q *Queries) SetUserPassword(ctx context.Context, arg SetUserPasswordParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, setUserPassword, arg.ID, arg.HashedPassword)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.Location,
+		&i.HandleChangedAt,
+		&i.ChirpsChangedAt,
+	)
+	return i, err
+}
+
+const touchChirpsChangedAt = `-- name: TouchChirpsChangedAt :exec
+UPDATE users
+SET chirps_changed_at = NOW()
+WHERE id = ANY($1::uuid[])
+`
+
+func (q *Queries) TouchChirpsChangedAt(ctx context.Context, ids []uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, touchChirpsChangedAt, pq.Array(ids))
+	return err
+}
+
+const updateProfile = `-- name: UpdateProfile :one
+UPDATE users
+SET display_name = COALESCE($1, display_name),
+bio = COALESCE($2, bio),
+avatar_url = COALESCE($3, avatar_url),
+location = COALESCE($4, location),
+updated_at = NOW()
+WHERE id = $5
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, avatar_url, location, handle_changed_at, chirps_changed_at
+`
+
+type UpdateProfileParams struct {
+	DisplayName sql.NullString
+	Bio         sql.NullString
+	AvatarUrl   sql.NullString
+	Location    sql.NullString
+	ID          uuid.UUID
+}
+
+func (q *Queries) UpdateProfile(ctx context.Context, arg UpdateProfileParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateProfile,
+		arg.DisplayName,
+		arg.Bio,
+		arg.AvatarUrl,
+		arg.Location,
+		arg.ID,
+	)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.Location,
+		&i.HandleChangedAt,
+		&i.ChirpsChangedAt,
+	)
+	return i, err
+}
+
 const updateUser = `-- name: UpdateUser :one
 UPDATE users
 SET email = $2, hashed_password = $3
 WHERE id = $1
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, avatar_url, location, handle_changed_at, chirps_changed_at
 `
 
 type UpdateUserParams struct {
@@ -93,6 +520,13 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.Location,
+		&i.HandleChangedAt,
+		&i.ChirpsChangedAt,
 	)
 	return i, err
 }
@@ -0,0 +1,74 @@
+package entities
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+const (
+	TypeHashtag = "hashtag"
+	TypeMention = "mention"
+)
+
+// Entity is a hashtag or mention found in a chirp body. Start and End are
+// offsets in characters (Unicode code points), not bytes, so clients can
+// slice the body directly. Value is the normalized tag or handle.
+type Entity struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+	Value string `json:"value"`
+	Start int `json:"start"`
+	End int `json:"end"`
+	UserID *uuid.UUID `json:"user_id,omitempty"`
+}
+
+var (
+	hashtagRegexp = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_&])(#[\p{L}\p{N}_]*\p{L}[\p{L}\p{N}_]*)`)
+	mentionRegexp = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_.@])(@[A-Za-z0-9_]{1,30})\b`)
+)
+
+// Parse returns the hashtags and mentions in body, ordered by position.
+func Parse(body string) []Entity {
+	var result []Entity
+	hashtags := hashtagRegexp.FindAllStringSubmatchIndex(body, -1)
+	mentions := mentionRegexp.FindAllStringSubmatchIndex(body, -1)
+	for len(hashtags) > 0 || len(mentions) > 0 {
+		if len(mentions) == 0 || (len(hashtags) > 0 && hashtags[0][2] < mentions[0][2]) {
+			result = append(result, newEntity(body, TypeHashtag, hashtags[0][2], hashtags[0][3]))
+			hashtags = hashtags[1:]
+			continue
+		}
+		result = append(result, newEntity(body, TypeMention, mentions[0][2], mentions[0][3]))
+		mentions = mentions[1:]
+	}
+	return result
+}
+
+// Values returns the distinct normalized values of the entities of the given
+// type.
+func Values(list []Entity, entityType string) []string {
+	seen := map[string]bool{}
+	var values []string
+	for _, entity := range list {
+		if entity.Type != entityType || seen[entity.Value] {
+			continue
+		}
+		seen[entity.Value] = true
+		values = append(values, entity.Value)
+	}
+	return values
+}
+
+func newEntity(body, entityType string, start, end int) Entity {
+	text := body[start:end]
+	return Entity{
+		Type: entityType,
+		Text: text,
+		Value: strings.ToLower(text[1:]),
+		Start: utf8.RuneCountInString(body[:start]),
+		End: utf8.RuneCountInString(body[:end]),
+	}
+}
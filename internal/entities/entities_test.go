@@ -0,0 +1,39 @@
+package entities
+
+import (
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	body := "héllo @Alice, loving #Golang and #go! mail me at bob@example.com #1 @bob"
+	expected := []Entity{
+		{Type: TypeMention, Text: "@Alice", Value: "alice", Start: 6, End: 12},
+		{Type: TypeHashtag, Text: "#Golang", Value: "golang", Start: 21, End: 28},
+		{Type: TypeHashtag, Text: "#go", Value: "go", Start: 33, End: 36},
+		{Type: TypeMention, Text: "@bob", Value: "bob", Start: 68, End: 72},
+	}
+	got := Parse(body)
+	if len(got) != len(expected) {
+		t.Logf("expected %d entities, got %v\n", len(expected), got)
+		t.FailNow()
+	}
+	runes := []rune(body)
+	for i := range expected {
+		if got[i].Type != expected[i].Type || got[i].Text != expected[i].Text || got[i].Value != expected[i].Value || got[i].Start != expected[i].Start || got[i].End != expected[i].End {
+			t.Logf("expected %v, got %v\n", expected[i], got[i])
+			t.Fail()
+		}
+		if string(runes[got[i].Start:got[i].End]) != got[i].Text {
+			t.Logf("offsets of %v do not match the body\n", got[i])
+			t.Fail()
+		}
+	}
+}
+
+func TestValues(t *testing.T) {
+	values := Values(Parse("#Go #go #rust @go"), TypeHashtag)
+	if len(values) != 2 || values[0] != "go" || values[1] != "rust" {
+		t.Logf("unexpected values %v\n", values)
+		t.Fail()
+	}
+}
@@ -0,0 +1,241 @@
+package feeds
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"html"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	AtomContentType = "application/atom+xml; charset=utf-8"
+	RSSContentType = "application/rss+xml; charset=utf-8"
+	JSONContentType = "application/feed+json; charset=utf-8"
+
+	maxTitleLength = 80
+)
+
+// Feed is a format-neutral feed. IDs should be stable URIs; URL is the
+// page the feed or item is about, and FeedURL where the feed itself lives.
+type Feed struct {
+	ID string
+	Title string
+	Description string
+	URL string
+	FeedURL string
+	Updated time.Time
+	Items []Item
+}
+
+type Item struct {
+	ID string
+	URL string
+	Content string
+	AuthorName string
+	AuthorURL string
+	Published time.Time
+	Updated time.Time
+}
+
+// Title makes a one-line title out of an item's plain text content.
+func (item Item) Title() string {
+	title, _, _ := strings.Cut(item.Content, "\n")
+	title = strings.TrimSpace(title)
+	if utf8.RuneCountInString(title) <= maxTitleLength {
+		return title
+	}
+	runes := []rune(title)
+	return strings.TrimSpace(string(runes[:maxTitleLength-1])) + "…"
+}
+
+// ETag is a strong validator for a rendered feed.
+func ETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+	URI string `xml:"uri,omitempty"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+type atomEntry struct {
+	ID string `xml:"id"`
+	Title string `xml:"title"`
+	Updated string `xml:"updated"`
+	Published string `xml:"published"`
+	Link atomLink `xml:"link"`
+	Author atomAuthor `xml:"author"`
+	Content atomContent `xml:"content"`
+}
+
+type atomFeed struct {
+	XMLName xml.Name `xml:"feed"`
+	Xmlns string `xml:"xmlns,attr"`
+	ID string `xml:"id"`
+	Title string `xml:"title"`
+	Subtitle string `xml:"subtitle,omitempty"`
+	Updated string `xml:"updated"`
+	Links []atomLink `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+func Atom(feed Feed) ([]byte, error) {
+	result := atomFeed{
+		Xmlns: "http://www.w3.org/2005/Atom",
+		ID: feed.ID,
+		Title: feed.Title,
+		Subtitle: feed.Description,
+		Updated: feed.Updated.UTC().Format(time.RFC3339),
+		Links: []atomLink{
+			{Href: feed.FeedURL, Rel: "self", Type: "application/atom+xml"},
+			{Href: feed.URL, Rel: "alternate"},
+		},
+	}
+	for _, item := range feed.Items {
+		result.Entries = append(result.Entries, atomEntry{
+			ID: item.ID,
+			Title: item.Title(),
+			Updated: item.Updated.UTC().Format(time.RFC3339),
+			Published: item.Published.UTC().Format(time.RFC3339),
+			Link: atomLink{Href: item.URL, Rel: "alternate"},
+			Author: atomAuthor{Name: item.AuthorName, URI: item.AuthorURL},
+			Content: atomContent{Type: "text", Body: item.Content},
+		})
+	}
+	return marshalXML(result)
+}
+
+type rssGUID struct {
+	IsPermaLink bool `xml:"isPermaLink,attr"`
+	Value string `xml:",chardata"`
+}
+
+type rssItem struct {
+	Title string `xml:"title"`
+	Link string `xml:"link"`
+	GUID rssGUID `xml:"guid"`
+	PubDate string `xml:"pubDate"`
+	Author string `xml:"dc:creator,omitempty"`
+	Description string `xml:"description"`
+}
+
+type rssChannel struct {
+	Title string `xml:"title"`
+	Link string `xml:"link"`
+	Description string `xml:"description"`
+	LastBuildDate string `xml:"lastBuildDate"`
+	Self atomLink `xml:"atom:link"`
+	Items []rssItem `xml:"item"`
+}
+
+type rssFeed struct {
+	XMLName xml.Name `xml:"rss"`
+	Version string `xml:"version,attr"`
+	AtomNS string `xml:"xmlns:atom,attr"`
+	DCNS string `xml:"xmlns:dc,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+func RSS(feed Feed) ([]byte, error) {
+	description := feed.Description
+	if description == "" {
+		description = feed.Title
+	}
+	result := rssFeed{
+		Version: "2.0",
+		AtomNS: "http://www.w3.org/2005/Atom",
+		DCNS: "http://purl.org/dc/elements/1.1/",
+		Channel: rssChannel{
+			Title: feed.Title,
+			Link: feed.URL,
+			Description: description,
+			LastBuildDate: feed.Updated.UTC().Format(time.RFC1123Z),
+			Self: atomLink{Href: feed.FeedURL, Rel: "self", Type: "application/rss+xml"},
+		},
+	}
+	for _, item := range feed.Items {
+		result.Channel.Items = append(result.Channel.Items, rssItem{
+			Title: item.Title(),
+			Link: item.URL,
+			GUID: rssGUID{IsPermaLink: true, Value: item.URL},
+			PubDate: item.Published.UTC().Format(time.RFC1123Z),
+			Author: item.AuthorName,
+			// Readers render the description as HTML, and content is
+			// plain text.
+			Description: html.EscapeString(item.Content),
+		})
+	}
+	return marshalXML(result)
+}
+
+func marshalXML(v any) ([]byte, error) {
+	body, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
+
+type jsonAuthor struct {
+	Name string `json:"name"`
+	URL string `json:"url,omitempty"`
+}
+
+type jsonItem struct {
+	ID string `json:"id"`
+	URL string `json:"url"`
+	Title string `json:"title"`
+	ContentText string `json:"content_text"`
+	DatePublished time.Time `json:"date_published"`
+	DateModified time.Time `json:"date_modified"`
+	Authors []jsonAuthor `json:"authors"`
+}
+
+type jsonFeed struct {
+	Version string `json:"version"`
+	Title string `json:"title"`
+	HomePageURL string `json:"home_page_url"`
+	FeedURL string `json:"feed_url"`
+	Description string `json:"description,omitempty"`
+	Items []jsonItem `json:"items"`
+}
+
+// JSON renders feed as JSON Feed 1.1.
+func JSON(feed Feed) ([]byte, error) {
+	result := jsonFeed{
+		Version: "https://jsonfeed.org/version/1.1",
+		Title: feed.Title,
+		HomePageURL: feed.URL,
+		FeedURL: feed.FeedURL,
+		Description: feed.Description,
+		Items: []jsonItem{},
+	}
+	for _, item := range feed.Items {
+		result.Items = append(result.Items, jsonItem{
+			ID: item.ID,
+			URL: item.URL,
+			Title: item.Title(),
+			ContentText: item.Content,
+			DatePublished: item.Published.UTC(),
+			DateModified: item.Updated.UTC(),
+			Authors: []jsonAuthor{{Name: item.AuthorName, URL: item.AuthorURL}},
+		})
+	}
+	return json.MarshalIndent(result, "", "  ")
+}
@@ -0,0 +1,171 @@
+package feeds
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testFeed() Feed {
+	published := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	return Feed{
+		ID: "https://chirpy.test/api/users/alice",
+		Title: "Alice on Chirpy",
+		URL: "https://chirpy.test/api/users/alice",
+		FeedURL: "https://chirpy.test/users/alice/feed.atom",
+		Updated: published,
+		Items: []Item{{
+			ID: "https://chirpy.test/api/chirps/1",
+			URL: "https://chirpy.test/api/chirps/1",
+			Content: "Release 1.2 is out <3 & more",
+			AuthorName: "alice",
+			Published: published,
+			Updated: published,
+		}},
+	}
+}
+
+func TestAtom(t *testing.T) {
+	body, err := Atom(testFeed())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var parsed struct {
+		Title string `xml:"title"`
+		Links []struct {
+			Href string `xml:"href,attr"`
+			Rel string `xml:"rel,attr"`
+		} `xml:"link"`
+		Entries []struct {
+			ID string `xml:"id"`
+			Content string `xml:"content"`
+			Published string `xml:"published"`
+		} `xml:"entry"`
+	}
+	if err := xml.Unmarshal(body, &parsed); err != nil {
+		t.Logf("expected valid XML, got %v\n", err)
+		t.FailNow()
+	}
+	if parsed.Title != "Alice on Chirpy" || len(parsed.Entries) != 1 {
+		t.Logf("unexpected feed %+v\n", parsed)
+		t.Fail()
+	}
+	if parsed.Links[0].Rel != "self" || parsed.Links[0].Href != "https://chirpy.test/users/alice/feed.atom" {
+		t.Logf("expected a self link, got %+v\n", parsed.Links)
+		t.Fail()
+	}
+	entry := parsed.Entries[0]
+	if entry.Content != "Release 1.2 is out <3 & more" || entry.Published != "2024-05-01T12:00:00Z" {
+		t.Logf("unexpected entry %+v\n", entry)
+		t.Fail()
+	}
+}
+
+func TestRSS(t *testing.T) {
+	body, err := RSS(testFeed())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var parsed struct {
+		Version string `xml:"version,attr"`
+		Channel struct {
+			Items []struct {
+				Link string `xml:"link"`
+				GUID string `xml:"guid"`
+				PubDate string `xml:"pubDate"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+	if err := xml.Unmarshal(body, &parsed); err != nil {
+		t.Logf("expected valid XML, got %v\n", err)
+		t.FailNow()
+	}
+	if parsed.Version != "2.0" || len(parsed.Channel.Items) != 1 {
+		t.Logf("unexpected feed %+v\n", parsed)
+		t.FailNow()
+	}
+	item := parsed.Channel.Items[0]
+	if item.Link != "https://chirpy.test/api/chirps/1" || item.GUID != item.Link || item.PubDate != "Wed, 01 May 2024 12:00:00 +0000" {
+		t.Logf("unexpected item %+v\n", item)
+		t.Fail()
+	}
+	if !strings.Contains(string(body), `xmlns:atom="http://www.w3.org/2005/Atom"`) {
+		t.Log("expected the atom namespace to be declared")
+		t.Fail()
+	}
+}
+
+func TestRSSEscapesContent(t *testing.T) {
+	feed := testFeed()
+	feed.Items[0].Content = `<script>alert("hi")</script>`
+	body, err := RSS(feed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var parsed struct {
+		Channel struct {
+			Items []struct {
+				Description string `xml:"description"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+	if err := xml.Unmarshal(body, &parsed); err != nil {
+		t.Fatal(err)
+	}
+	// What a reader gets once it's parsed the XML, and will render as HTML.
+	expected := `&lt;script&gt;alert(&#34;hi&#34;)&lt;/script&gt;`
+	if len(parsed.Channel.Items) != 1 || parsed.Channel.Items[0].Description != expected {
+		t.Logf("expected %q, got %+v\n", expected, parsed.Channel.Items)
+		t.Fail()
+	}
+}
+
+func TestJSON(t *testing.T) {
+	body, err := JSON(testFeed())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var parsed map[string]any
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		t.Fatal(err)
+	}
+	if parsed["version"] != "https://jsonfeed.org/version/1.1" {
+		t.Logf("unexpected version %v\n", parsed["version"])
+		t.Fail()
+	}
+	items := parsed["items"].([]any)
+	if len(items) != 1 || items[0].(map[string]any)["url"] != "https://chirpy.test/api/chirps/1" {
+		t.Logf("unexpected items %v\n", items)
+		t.Fail()
+	}
+}
+
+func TestTitle(t *testing.T) {
+	cases := []struct {
+		content string
+		expected string
+	}{
+		{"short", "short"},
+		{"first line\nsecond line", "first line"},
+		{strings.Repeat("a", 100), strings.Repeat("a", 79) + "…"},
+	}
+	for _, c := range cases {
+		if got := (Item{Content: c.content}).Title(); got != c.expected {
+			t.Logf("expected %q, got %q\n", c.expected, got)
+			t.Fail()
+		}
+	}
+}
+
+func TestETag(t *testing.T) {
+	if ETag([]byte("a")) == ETag([]byte("b")) {
+		t.Log("expected different bodies to get different ETags")
+		t.Fail()
+	}
+	if !strings.HasPrefix(ETag([]byte("a")), `"`) {
+		t.Log("expected a quoted ETag")
+		t.Fail()
+	}
+}
@@ -0,0 +1,112 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// ErrDraining is returned to connections that arrive after shutdown began.
+var ErrDraining = errors.New("gateway is shutting down")
+
+// Conn is what the registry needs from a live connection to drain it.
+type Conn interface {
+	// Drain asks the connection to say goodbye and close. It must not
+	// block; the connection calls Remove once it's gone.
+	Drain()
+}
+
+// Registry tracks the connections open on this instance, who they belong
+// to, and whether the gateway is draining.
+type Registry struct {
+	mu sync.Mutex
+	conns map[Conn]uuid.UUID
+	online map[uuid.UUID]int
+	draining bool
+	empty chan struct{}
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		conns: map[Conn]uuid.UUID{},
+		online: map[uuid.UUID]int{},
+	}
+}
+
+// Add registers conn for userID. first reports whether it's the user's
+// only connection to this instance.
+func (registry *Registry) Add(conn Conn, userID uuid.UUID) (first bool, err error) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	if registry.draining {
+		return false, ErrDraining
+	}
+	registry.conns[conn] = userID
+	registry.online[userID]++
+	return registry.online[userID] == 1, nil
+}
+
+// Remove forgets conn. last reports whether it was the user's last
+// connection to this instance.
+func (registry *Registry) Remove(conn Conn) (last bool) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	userID, ok := registry.conns[conn]
+	if !ok {
+		return false
+	}
+	delete(registry.conns, conn)
+	registry.online[userID]--
+	if registry.online[userID] == 0 {
+		delete(registry.online, userID)
+		last = true
+	}
+	if len(registry.conns) == 0 && registry.empty != nil {
+		close(registry.empty)
+		registry.empty = nil
+	}
+	return last
+}
+
+// Online reports whether userID has a connection to this instance.
+func (registry *Registry) Online(userID uuid.UUID) bool {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	return registry.online[userID] > 0
+}
+
+func (registry *Registry) Len() int {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	return len(registry.conns)
+}
+
+// Drain stops new connections, asks every open one to close, and waits
+// until they have or ctx is done.
+func (registry *Registry) Drain(ctx context.Context) error {
+	registry.mu.Lock()
+	registry.draining = true
+	if len(registry.conns) == 0 {
+		registry.mu.Unlock()
+		return nil
+	}
+	empty := make(chan struct{})
+	registry.empty = empty
+	conns := make([]Conn, 0, len(registry.conns))
+	for conn := range registry.conns {
+		conns = append(conns, conn)
+	}
+	registry.mu.Unlock()
+
+	for _, conn := range conns {
+		conn.Drain()
+	}
+	select {
+	case <-empty:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,94 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type fakeConn struct {
+	registry *Registry
+	drained bool
+}
+
+func (conn *fakeConn) Drain() {
+	conn.drained = true
+	go conn.registry.Remove(conn)
+}
+
+func TestPresence(t *testing.T) {
+	registry := NewRegistry()
+	userID := uuid.New()
+	phone := &fakeConn{registry: registry}
+	laptop := &fakeConn{registry: registry}
+	if first, err := registry.Add(phone, userID); !first || err != nil {
+		t.Logf("expected the first connection to bring the user online, got %v %v\n", first, err)
+		t.Fail()
+	}
+	if first, _ := registry.Add(laptop, userID); first {
+		t.Log("expected the second connection not to be first")
+		t.Fail()
+	}
+	if last := registry.Remove(phone); last {
+		t.Log("expected the user to still be online")
+		t.Fail()
+	}
+	if !registry.Online(userID) {
+		t.Log("expected the user to be online")
+		t.Fail()
+	}
+	if last := registry.Remove(laptop); !last {
+		t.Log("expected removing the last connection to report it")
+		t.Fail()
+	}
+	if registry.Remove(laptop) {
+		t.Log("expected removing twice to be a no-op")
+		t.Fail()
+	}
+	if registry.Online(userID) {
+		t.Log("expected the user to be offline")
+		t.Fail()
+	}
+}
+
+func TestDrain(t *testing.T) {
+	registry := NewRegistry()
+	conns := []*fakeConn{{registry: registry}, {registry: registry}}
+	for _, conn := range conns {
+		registry.Add(conn, uuid.New())
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := registry.Drain(ctx); err != nil {
+		t.Logf("expected drain to finish, got %v\n", err)
+		t.Fail()
+	}
+	for _, conn := range conns {
+		if !conn.drained {
+			t.Log("expected every connection to be drained")
+			t.Fail()
+		}
+	}
+	if _, err := registry.Add(&fakeConn{registry: registry}, uuid.New()); !errors.Is(err, ErrDraining) {
+		t.Logf("expected new connections to be refused, got %v\n", err)
+		t.Fail()
+	}
+}
+
+type stuckConn struct{}
+
+func (stuckConn) Drain() {}
+
+func TestDrainTimeout(t *testing.T) {
+	registry := NewRegistry()
+	registry.Add(stuckConn{}, uuid.New())
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := registry.Drain(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Logf("expected drain to give up, got %v\n", err)
+		t.Fail()
+	}
+}
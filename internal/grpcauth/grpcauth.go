@@ -0,0 +1,84 @@
+package grpcauth
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Baehry/chirpy/internal/auth"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type userIDKey struct{}
+
+// Authenticator checks the bearer token in a call's "authorization"
+// metadata with auth.ValidateJWT. Methods listed in Anonymous may also be
+// called without one; a token that is present must always be valid.
+type Authenticator struct {
+	Secret string
+	Anonymous map[string]bool
+}
+
+func (authenticator Authenticator) Unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := authenticator.authenticate(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func (authenticator Authenticator) Stream() grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticator.authenticate(stream.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authenticatedStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+func (authenticator Authenticator) authenticate(ctx context.Context, method string) (context.Context, error) {
+	token, ok := bearerToken(ctx)
+	if !ok {
+		if authenticator.Anonymous[method] {
+			return ctx, nil
+		}
+		return nil, status.Error(codes.Unauthenticated, "token string not found")
+	}
+	userID, err := auth.ValidateJWT(token, authenticator.Secret)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	return context.WithValue(ctx, userIDKey{}, userID), nil
+}
+
+func bearerToken(ctx context.Context) (string, bool) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return "", false
+	}
+	token, ok := strings.CutPrefix(values[0], "Bearer ")
+	return token, ok && token != ""
+}
+
+// UserID returns the user a call was authenticated as, or uuid.Nil for an
+// anonymous call.
+func UserID(ctx context.Context) uuid.UUID {
+	userID, _ := ctx.Value(userIDKey{}).(uuid.UUID)
+	return userID
+}
+
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (stream *authenticatedStream) Context() context.Context {
+	return stream.ctx
+}
@@ -0,0 +1,80 @@
+package grpcauth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Baehry/chirpy/internal/auth"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const secret = "kronos"
+
+func TestUnary(t *testing.T) {
+	userID := uuid.New()
+	good, _ := auth.MakeJWT(userID, secret, time.Minute)
+	expired, _ := auth.MakeJWT(userID, secret, -time.Minute)
+	authenticator := Authenticator{
+		Secret: secret,
+		Anonymous: map[string]bool{"/test.Service/Public": true},
+	}
+	cases := []struct {
+		name string
+		method string
+		authorization string
+		expectedCode codes.Code
+		expectedUser uuid.UUID
+	}{
+		{"valid token", "/test.Service/Private", "Bearer " + good, codes.OK, userID},
+		{"valid token on public method", "/test.Service/Public", "Bearer " + good, codes.OK, userID},
+		{"anonymous public method", "/test.Service/Public", "", codes.OK, uuid.Nil},
+		{"anonymous private method", "/test.Service/Private", "", codes.Unauthenticated, uuid.Nil},
+		{"expired token", "/test.Service/Public", "Bearer " + expired, codes.Unauthenticated, uuid.Nil},
+		{"wrong secret", "/test.Service/Private", "Bearer " + good + "x", codes.Unauthenticated, uuid.Nil},
+		{"not a bearer token", "/test.Service/Private", good, codes.Unauthenticated, uuid.Nil},
+	}
+	for _, c := range cases {
+		ctx := context.Background()
+		if c.authorization != "" {
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", c.authorization))
+		}
+		var got uuid.UUID
+		_, err := authenticator.Unary()(ctx, nil, &grpc.UnaryServerInfo{FullMethod: c.method}, func(ctx context.Context, req any) (any, error) {
+			got = UserID(ctx)
+			return nil, nil
+		})
+		if status.Code(err) != c.expectedCode || got != c.expectedUser {
+			t.Logf("%s: expected %v %v, got %v %v\n", c.name, c.expectedCode, c.expectedUser, status.Code(err), got)
+			t.Fail()
+		}
+	}
+}
+
+type fakeStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (stream fakeStream) Context() context.Context {
+	return stream.ctx
+}
+
+func TestStream(t *testing.T) {
+	userID := uuid.New()
+	token, _ := auth.MakeJWT(userID, secret, time.Minute)
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+token))
+	var got uuid.UUID
+	err := Authenticator{Secret: secret}.Stream()(nil, fakeStream{ctx: ctx}, &grpc.StreamServerInfo{FullMethod: "/test.Service/Stream"}, func(srv any, stream grpc.ServerStream) error {
+		got = UserID(stream.Context())
+		return nil
+	})
+	if err != nil || got != userID {
+		t.Logf("expected %v, got %v %v\n", userID, got, err)
+		t.Fail()
+	}
+}
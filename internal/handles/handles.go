@@ -0,0 +1,52 @@
+package handles
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var handleRegexp = regexp.MustCompile(`^[A-Za-z0-9_]{1,30}$`)
+
+// reserved handles would collide with routes, impersonate staff or confuse
+// people reading a mention.
+var reserved = map[string]bool{
+	"about": true,
+	"admin": true,
+	"administrator": true,
+	"api": true,
+	"app": true,
+	"chirpy": true,
+	"help": true,
+	"login": true,
+	"logout": true,
+	"me": true,
+	"mod": true,
+	"moderator": true,
+	"null": true,
+	"profile": true,
+	"root": true,
+	"security": true,
+	"settings": true,
+	"signup": true,
+	"staff": true,
+	"support": true,
+	"system": true,
+	"undefined": true,
+}
+
+// Normalize returns the form handles are compared in. Handles keep the case
+// their owner picked, but @Alice and @alice are the same user.
+func Normalize(handle string) string {
+	return strings.ToLower(handle)
+}
+
+func Validate(handle string) error {
+	if !handleRegexp.MatchString(handle) {
+		return fmt.Errorf("handles are 1 to 30 letters, digits or underscores")
+	}
+	if reserved[Normalize(handle)] {
+		return fmt.Errorf("handle %q is reserved", handle)
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+package handles
+
+import (
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	for _, handle := range []string{"alice", "Bob_42", "_", "abcdefghijklmnopqrstuvwxyz1234"} {
+		if err := Validate(handle); err != nil {
+			t.Logf("%q: %s\n", handle, err.Error())
+			t.Fail()
+		}
+	}
+	for _, handle := range []string{"", "has space", "dash-ed", "émile", "abcdefghijklmnopqrstuvwxyz12345", "admin", "Support"} {
+		if err := Validate(handle); err == nil {
+			t.Logf("expected %q to be rejected\n", handle)
+			t.Fail()
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+
+	"golang.org/x/image/draw"
+)
+
+const (
+	MaxUploadSize = 5 << 20
+	maxPixels = 40_000_000
+	ThumbnailSize = 320
+)
+
+var ErrUnsupportedType = errors.New("only JPEG, PNG and GIF images are supported")
+
+type Image struct {
+	Data []byte
+	ContentType string
+	Width int
+	Height int
+}
+
+// Processed is an upload ready to be stored: the image re-encoded without
+// any metadata, and a thumbnail that fits in ThumbnailSize pixels.
+type Processed struct {
+	Original Image
+	Thumbnail Image
+}
+
+// Process checks that data is an image by sniffing its content, not by
+// trusting the client's content type, and re-encodes it. Re-encoding drops
+// EXIF and every other metadata block, after applying the EXIF orientation
+// so the picture still shows the right way up.
+func Process(data []byte) (Processed, error) {
+	contentType := http.DetectContentType(data)
+	switch contentType {
+	case "image/jpeg", "image/png", "image/gif":
+	default:
+		return Processed{}, ErrUnsupportedType
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Processed{}, fmt.Errorf("invalid image: %w", err)
+	}
+	if config.Width*config.Height > maxPixels {
+		return Processed{}, fmt.Errorf("image is larger than %d pixels", maxPixels)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return Processed{}, fmt.Errorf("invalid image: %w", err)
+	}
+	if contentType == "image/jpeg" {
+		img = orient(img, jpegOrientation(data))
+	}
+	// Only the first frame of an animated GIF is kept, so GIFs become PNGs.
+	outputType := contentType
+	if outputType == "image/gif" {
+		outputType = "image/png"
+	}
+	original, err := encode(img, outputType)
+	if err != nil {
+		return Processed{}, err
+	}
+	thumbnail, err := encode(thumbnail(img), outputType)
+	if err != nil {
+		return Processed{}, err
+	}
+	return Processed{
+		Original: original,
+		Thumbnail: thumbnail,
+	}, nil
+}
+
+func encode(img image.Image, contentType string) (Image, error) {
+	var buf bytes.Buffer
+	var err error
+	if contentType == "image/jpeg" {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85})
+	} else {
+		err = png.Encode(&buf, img)
+	}
+	if err != nil {
+		return Image{}, err
+	}
+	bounds := img.Bounds()
+	return Image{
+		Data: buf.Bytes(),
+		ContentType: contentType,
+		Width: bounds.Dx(),
+		Height: bounds.Dy(),
+	}, nil
+}
+
+func thumbnail(img image.Image) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= ThumbnailSize && height <= ThumbnailSize {
+		return img
+	}
+	if width >= height {
+		height = max(1, height*ThumbnailSize/width)
+		width = ThumbnailSize
+	} else {
+		width = max(1, width*ThumbnailSize/height)
+		height = ThumbnailSize
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Over, nil)
+	return dst
+}
+
+// orient applies an EXIF orientation (1 to 8) to img.
+func orient(img image.Image, orientation int) image.Image {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if orientation >= 5 {
+		width, height = height, width
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			var dx, dy int
+			switch orientation {
+			case 2:
+				dx, dy = width-1-x, y
+			case 3:
+				dx, dy = width-1-x, height-1-y
+			case 4:
+				dx, dy = x, height-1-y
+			case 5:
+				dx, dy = y, x
+			case 6:
+				dx, dy = width-1-y, x
+			case 7:
+				dx, dy = width-1-y, height-1-x
+			case 8:
+				dx, dy = y, height-1-x
+			}
+			dst.Set(dx, dy, img.At(bounds.Min.X+x, bounds.Min.Y+y))
+		}
+	}
+	return dst
+}
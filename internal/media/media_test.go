@@ -0,0 +1,84 @@
+package media
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+// withOrientation inserts an EXIF block holding only an orientation tag
+// right after the JPEG start-of-image marker.
+func withOrientation(data []byte, orientation byte) []byte {
+	tiff := []byte{
+		'M', 'M', 0, 42, 0, 0, 0, 8,
+		0, 1,
+		0x01, 0x12, 0, 3, 0, 0, 0, 1, 0, orientation, 0, 0,
+		0, 0, 0, 0,
+	}
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	length := len(payload) + 2
+	segment := append([]byte{0xFF, 0xE1, byte(length >> 8), byte(length)}, payload...)
+	result := append([]byte{}, data[:2]...)
+	result = append(result, segment...)
+	return append(result, data[2:]...)
+}
+
+func testImage(width, height int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{uint8(x), uint8(y), 0, 255})
+		}
+	}
+	return img
+}
+
+func TestProcessJPEG(t *testing.T) {
+	var buf bytes.Buffer
+	jpeg.Encode(&buf, testImage(800, 400), nil)
+	data := withOrientation(buf.Bytes(), 6)
+	if jpegOrientation(data) != 6 {
+		t.Fatal("test image has no orientation")
+	}
+	processed, err := Process(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(processed.Original.Data, []byte("Exif")) {
+		t.Log("EXIF data was not stripped\n")
+		t.Fail()
+	}
+	if processed.Original.ContentType != "image/jpeg" || processed.Original.Width != 400 || processed.Original.Height != 800 {
+		t.Logf("unexpected original %s %dx%d\n", processed.Original.ContentType, processed.Original.Width, processed.Original.Height)
+		t.Fail()
+	}
+	if processed.Thumbnail.Width != 160 || processed.Thumbnail.Height != ThumbnailSize {
+		t.Logf("unexpected thumbnail %dx%d\n", processed.Thumbnail.Width, processed.Thumbnail.Height)
+		t.Fail()
+	}
+}
+
+func TestProcessPNG(t *testing.T) {
+	var buf bytes.Buffer
+	png.Encode(&buf, testImage(100, 50))
+	processed, err := Process(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if processed.Original.ContentType != "image/png" || processed.Thumbnail.Width != 100 || processed.Thumbnail.Height != 50 {
+		t.Logf("unexpected result %s %dx%d\n", processed.Original.ContentType, processed.Thumbnail.Width, processed.Thumbnail.Height)
+		t.Fail()
+	}
+}
+
+func TestProcessRejects(t *testing.T) {
+	for _, data := range [][]byte{[]byte("<html>not an image</html>"), []byte("\x89PNG\r\n\x1a\ntruncated")} {
+		if _, err := Process(data); err == nil {
+			t.Logf("expected %q to be rejected\n", data)
+			t.Fail()
+		}
+	}
+}
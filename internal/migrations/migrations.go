@@ -0,0 +1,60 @@
+// Package migrations applies the schema in sql/schema and checks that a
+// database matches what the generated queries expect.
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+
+	"github.com/Baehry/chirpy/internal/database"
+	"github.com/Baehry/chirpy/sql/schema"
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/lock"
+)
+
+// New returns a goose provider for the migrations embedded in the binary.
+//
+// The provider holds a Postgres advisory lock while it migrates or reads
+// the version, so replicas that start together take turns instead of
+// racing through the same migrations. Don't Close it: that closes db.
+func New(db *sql.DB) (*goose.Provider, error) {
+	return NewFS(db, schema.FS)
+}
+
+// NewFS is New for migrations read from fsys instead.
+func NewFS(db *sql.DB, fsys fs.FS) (*goose.Provider, error) {
+	locker, err := lock.NewPostgresSessionLocker()
+	if err != nil {
+		return nil, err
+	}
+	return goose.NewProvider(goose.DialectPostgres, db, fsys, goose.WithSessionLocker(locker))
+}
+
+// VersionError means the database's schema isn't the one this build was
+// generated against.
+type VersionError struct {
+	Database int64
+	Expected int64
+}
+
+func (err *VersionError) Error() string {
+	if err.Database < err.Expected {
+		return fmt.Sprintf("database schema is at version %d but this build needs %d; run `chirpy migrate up` or set AUTO_MIGRATE=true", err.Database, err.Expected)
+	}
+	return fmt.Sprintf("database schema is at version %d, newer than the %d this build knows; deploy a newer build or run `chirpy migrate down`", err.Database, err.Expected)
+}
+
+// Check returns a *VersionError unless the database is at
+// database.SchemaVersion.
+func Check(ctx context.Context, provider *goose.Provider) error {
+	version, err := provider.GetDBVersion(ctx)
+	if err != nil {
+		return err
+	}
+	if version != database.SchemaVersion {
+		return &VersionError{Database: version, Expected: database.SchemaVersion}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package migrations
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Baehry/chirpy/internal/database"
+	"github.com/Baehry/chirpy/sql/schema"
+)
+
+// TestEmbeddedMigrations checks that the embedded migrations run 1, 2, 3...
+// without gaps and that SchemaVersion was bumped with the latest one.
+func TestEmbeddedMigrations(t *testing.T) {
+	files, err := fs.Glob(schema.FS, "*.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no migrations embedded")
+	}
+	for i, file := range files {
+		prefix, _, _ := strings.Cut(file, "_")
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if err != nil || version != int64(i+1) {
+			t.Logf("%s: expected version %d\n", file, i+1)
+			t.Fail()
+		}
+		body, err := fs.ReadFile(schema.FS, file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(body), "-- +goose Up") || !strings.Contains(string(body), "-- +goose Down") {
+			t.Logf("%s: expected both goose Up and Down sections\n", file)
+			t.Fail()
+		}
+	}
+	if int64(len(files)) != database.SchemaVersion {
+		t.Logf("expected database.SchemaVersion to be %d, the latest migration, got %d\n", len(files), database.SchemaVersion)
+		t.Fail()
+	}
+}
+
+func TestVersionError(t *testing.T) {
+	cases := []struct {
+		database int64
+		expected int64
+		hint string
+	}{
+		{0, 23, "migrate up"},
+		{22, 23, "migrate up"},
+		{24, 23, "newer build"},
+	}
+	for _, c := range cases {
+		var err error = &VersionError{Database: c.database, Expected: c.expected}
+		var versionErr *VersionError
+		if !errors.As(fmt.Errorf("starting: %w", err), &versionErr) {
+			t.Logf("%d/%d: expected a wrapped *VersionError to be found\n", c.database, c.expected)
+			t.Fail()
+		}
+		if !strings.Contains(err.Error(), c.hint) {
+			t.Logf("%d/%d: expected %q in %q\n", c.database, c.expected, c.hint, err.Error())
+			t.Fail()
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package moderation
+
+import (
+	"strings"
+	"sync/atomic"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+type Action string
+
+const (
+	ActionMask Action = "mask"
+	ActionFlag Action = "flag"
+	ActionReject Action = "reject"
+)
+
+func (a Action) Valid() bool {
+	return a == ActionMask || a == ActionFlag || a == ActionReject
+}
+
+type Rule struct {
+	Word string
+	Action Action
+}
+
+type Match struct {
+	Word string `json:"word"`
+	Action Action `json:"action"`
+}
+
+// Result is what a Filter made of a chirp body. Body is the text to store,
+// which masking rules may have changed.
+type Result struct {
+	Body string
+	Rejected bool
+	Flagged bool
+	Matches []Match
+}
+
+type Filter interface {
+	Apply(body string) Result
+}
+
+// Pipeline runs filters in order, feeding each the body the previous one
+// produced.
+type Pipeline []Filter
+
+func (p Pipeline) Apply(body string) Result {
+	result := Result{
+		Body: body,
+	}
+	for _, filter := range p {
+		next := filter.Apply(result.Body)
+		result.Body = next.Body
+		result.Rejected = result.Rejected || next.Rejected
+		result.Flagged = result.Flagged || next.Flagged
+		result.Matches = append(result.Matches, next.Matches...)
+	}
+	return result
+}
+
+// Reloadable is a Filter that can be swapped out while requests are using
+// it. Until the first Set it rejects everything, so rules that failed to
+// load don't quietly turn moderation off.
+type Reloadable struct {
+	current atomic.Pointer[Filter]
+}
+
+func (r *Reloadable) Set(filter Filter) {
+	r.current.Store(&filter)
+}
+
+func (r *Reloadable) Apply(body string) Result {
+	filter := r.current.Load()
+	if filter == nil {
+		return Result{Body: body, Rejected: true}
+	}
+	return (*filter).Apply(body)
+}
+
+// WordFilter matches whole words against a word list, ignoring case,
+// accents, compatibility forms like fullwidth letters, surrounding
+// punctuation and common leetspeak substitutions.
+type WordFilter struct {
+	rules map[string]Action
+}
+
+func NewWordFilter(rules []Rule) *WordFilter {
+	filter := &WordFilter{
+		rules: map[string]Action{},
+	}
+	for _, rule := range rules {
+		filter.rules[Normalize(rule.Word)] = rule.Action
+	}
+	return filter
+}
+
+func (f *WordFilter) Apply(body string) Result {
+	result := Result{}
+	var masked strings.Builder
+	last := 0
+	for _, span := range words(body) {
+		word := body[span[0]:span[1]]
+		action, ok := f.rules[Normalize(word)]
+		if !ok {
+			// Leet symbols only count inside a word, so "fornax!" is
+			// fornax followed by punctuation.
+			start, end := trimSymbols(word)
+			word = word[start:end]
+			action, ok = f.rules[Normalize(word)]
+			span = [2]int{span[0] + start, span[0] + end}
+		}
+		if !ok || word == "" {
+			continue
+		}
+		result.Matches = append(result.Matches, Match{
+			Word: Normalize(word),
+			Action: action,
+		})
+		switch action {
+		case ActionReject:
+			result.Rejected = true
+		case ActionFlag:
+			result.Flagged = true
+		case ActionMask:
+			masked.WriteString(body[last:span[0]])
+			masked.WriteString("****")
+			last = span[1]
+		}
+	}
+	masked.WriteString(body[last:])
+	result.Body = masked.String()
+	return result
+}
+
+var leet = map[rune]rune{
+	'0': 'o',
+	'1': 'i',
+	'3': 'e',
+	'4': 'a',
+	'5': 's',
+	'7': 't',
+	'@': 'a',
+	'$': 's',
+	'!': 'i',
+	'|': 'l',
+}
+
+var fold = transform.Chain(norm.NFKD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+
+// Normalize maps a word to the form rules are compared in.
+func Normalize(word string) string {
+	folded, _, err := transform.String(fold, word)
+	if err != nil {
+		folded = word
+	}
+	return strings.Map(func(r rune) rune {
+		if replacement, ok := leet[r]; ok {
+			return replacement
+		}
+		return unicode.ToLower(r)
+	}, folded)
+}
+
+func isWordRune(r rune) bool {
+	_, ok := leet[r]
+	return ok || unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r)
+}
+
+// words returns the byte offsets of every run of word characters in body.
+func words(body string) [][2]int {
+	var spans [][2]int
+	start := -1
+	for i, r := range body {
+		if isWordRune(r) {
+			if start == -1 {
+				start = i
+			}
+			continue
+		}
+		if start != -1 {
+			spans = append(spans, [2]int{start, i})
+			start = -1
+		}
+	}
+	if start != -1 {
+		spans = append(spans, [2]int{start, len(body)})
+	}
+	return spans
+}
+
+// trimSymbols returns the bounds of word without leading and trailing leet
+// symbols that aren't letters or digits.
+func trimSymbols(word string) (int, int) {
+	start := strings.IndexFunc(word, func(r rune) bool {
+		return unicode.IsLetter(r) || unicode.IsDigit(r)
+	})
+	if start == -1 {
+		return 0, 0
+	}
+	end := strings.LastIndexFunc(word, func(r rune) bool {
+		return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r)
+	})
+	_, size := utf8.DecodeRuneInString(word[end:])
+	return start, end + size
+}
@@ -0,0 +1,73 @@
+package moderation
+
+import (
+	"testing"
+)
+
+var rules = []Rule{
+	{Word: "kerfuffle", Action: ActionMask},
+	{Word: "sharbert", Action: ActionMask},
+	{Word: "fornax", Action: ActionMask},
+	{Word: "shite", Action: ActionMask},
+	{Word: "scam", Action: ActionFlag},
+	{Word: "doxx", Action: ActionReject},
+}
+
+func TestMask(t *testing.T) {
+	filter := NewWordFilter(rules)
+	cases := map[string]string{
+		"This is a kerfuffle opinion I need to share with the world": "This is a **** opinion I need to share with the world",
+		"What a Kerfuffle!": "What a ****!",
+		"(sharbert), fornax.": "(****), ****.",
+		"k3rfuffl3 and SH4RB3RT": "**** and ****",
+		"kérfuffle": "****",
+		"ＫＥＲＦＵＦＦＬＥ time": "**** time",
+		"sh!te happens": "**** happens",
+		"kerfuffles are fine": "kerfuffles are fine",
+		"no bad words here": "no bad words here",
+	}
+	for input, expected := range cases {
+		result := filter.Apply(input)
+		if result.Body != expected {
+			t.Logf("%q: expected %q, got %q\n", input, expected, result.Body)
+			t.Fail()
+		}
+		if result.Rejected || result.Flagged {
+			t.Logf("%q: expected only masking\n", input)
+			t.Fail()
+		}
+	}
+}
+
+func TestRejectAndFlag(t *testing.T) {
+	filter := NewWordFilter(rules)
+	result := filter.Apply("Total SCAM, don't fall for it")
+	if !result.Flagged || result.Rejected || result.Body != "Total SCAM, don't fall for it" {
+		t.Logf("unexpected result %+v\n", result)
+		t.Fail()
+	}
+	result = filter.Apply("going to d0xx them")
+	if !result.Rejected || len(result.Matches) != 1 || result.Matches[0].Word != "doxx" {
+		t.Logf("unexpected result %+v\n", result)
+		t.Fail()
+	}
+}
+
+func TestPipeline(t *testing.T) {
+	var reloadable Reloadable
+	pipeline := Pipeline{&reloadable, NewWordFilter([]Rule{{Word: "scam", Action: ActionFlag}})}
+	if result := pipeline.Apply("kerfuffle scam"); !result.Rejected {
+		t.Logf("expected rejection before the rules load, got %+v\n", result)
+		t.Fail()
+	}
+	reloadable.Set(NewWordFilter(nil))
+	if result := pipeline.Apply("kerfuffle scam"); result.Body != "kerfuffle scam" || !result.Flagged || result.Rejected {
+		t.Logf("unexpected result %+v\n", result)
+		t.Fail()
+	}
+	reloadable.Set(NewWordFilter(rules[:1]))
+	if result := pipeline.Apply("kerfuffle scam"); result.Body != "**** scam" || !result.Flagged || len(result.Matches) != 2 {
+		t.Logf("unexpected result %+v\n", result)
+		t.Fail()
+	}
+}
@@ -0,0 +1,97 @@
+// Package netguard keeps requests that users can aim, like webhook
+// deliveries and ActivityPub fetches, away from the server's own network:
+// loopback, private, link-local (cloud metadata lives at 169.254.169.254)
+// and other addresses that aren't publicly routable.
+package netguard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+var ErrForbiddenAddress = errors.New("address is not publicly routable")
+
+// reserved covers ranges the netip predicates don't.
+var reserved = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("2001:db8::/32"),
+}
+
+// Allowed reports whether addr is a public unicast address.
+func Allowed(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() || !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, prefix := range reserved {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckHost resolves host, a name or an IP literal, and fails unless every
+// address it has is Allowed. It's for rejecting bad URLs up front with a
+// clear error; Control is what actually enforces the rule.
+func CheckHost(ctx context.Context, host string) error {
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if !Allowed(addr) {
+			return fmt.Errorf("%s: %w", host, ErrForbiddenAddress)
+		}
+		return nil
+	}
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return err
+	}
+	for _, addr := range addrs {
+		if !Allowed(addr) {
+			return fmt.Errorf("%s resolves to %s: %w", host, addr, ErrForbiddenAddress)
+		}
+	}
+	return nil
+}
+
+// Control is a net.Dialer Control function that refuses connections to
+// addresses that aren't Allowed. It sees the address being dialed after
+// DNS resolution, so a name that re-resolves to an internal address after
+// CheckHost passed it is still stopped.
+func Control(network, address string, conn syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	if !Allowed(addrPort.Addr()) {
+		return fmt.Errorf("%s: %w", address, ErrForbiddenAddress)
+	}
+	return nil
+}
+
+// Transport is an http.Transport that dials through Control. It ignores
+// proxy settings, since through a proxy Control would only see the proxy.
+func Transport() *http.Transport {
+	dialer := &net.Dialer{
+		Timeout: 30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control: Control,
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return transport
+}
@@ -0,0 +1,75 @@
+package netguard
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestAllowed(t *testing.T) {
+	cases := []struct {
+		addr string
+		allowed bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"224.0.0.1", false},
+		{"255.255.255.255", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:169.254.169.254", false},
+		{"64:ff9b::a9fe:a9fe", false},
+	}
+	for _, c := range cases {
+		if allowed := Allowed(netip.MustParseAddr(c.addr)); allowed != c.allowed {
+			t.Logf("%s: expected %v, got %v\n", c.addr, c.allowed, allowed)
+			t.Fail()
+		}
+	}
+}
+
+func TestCheckHost(t *testing.T) {
+	cases := []struct {
+		host string
+		allowed bool
+	}{
+		{"93.184.216.34", true},
+		{"127.0.0.1", false},
+		{"169.254.169.254", false},
+		{"::1", false},
+		{"localhost", false},
+	}
+	for _, c := range cases {
+		err := CheckHost(context.Background(), c.host)
+		if (err == nil) != c.allowed || (err != nil && !errors.Is(err, ErrForbiddenAddress)) {
+			t.Logf("%s: expected allowed=%v, got %v\n", c.host, c.allowed, err)
+			t.Fail()
+		}
+	}
+}
+
+func TestTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(204)
+	}))
+	defer server.Close()
+	client := &http.Client{Transport: Transport()}
+	_, err := client.Get(server.URL)
+	if !errors.Is(err, ErrForbiddenAddress) {
+		t.Logf("expected a loopback server to be refused, got %v\n", err)
+		t.Fail()
+	}
+}
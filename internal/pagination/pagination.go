@@ -0,0 +1,79 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Cursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+func EncodeCursor(createdAt time.Time, id uuid.UUID) string {
+	raw := createdAt.UTC().Format(time.RFC3339Nano) + "|" + id.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func DecodeCursor(cursor string) (Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return Cursor{}, fmt.Errorf("invalid cursor")
+	}
+	parts := strings.SplitN(string(raw), "|", 2)
+	if len(parts) != 2 {
+		return Cursor{}, fmt.Errorf("invalid cursor")
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return Cursor{}, fmt.Errorf("invalid cursor")
+	}
+	id, err := uuid.Parse(parts[1])
+	if err != nil {
+		return Cursor{}, fmt.Errorf("invalid cursor")
+	}
+	return Cursor{
+		CreatedAt: createdAt,
+		ID: id,
+	}, nil
+}
+
+// RankCursor continues a listing ordered by a relevance score first, as
+// search results are.
+type RankCursor struct {
+	Rank float32
+	Cursor
+}
+
+func EncodeRankCursor(rank float32, createdAt time.Time, id uuid.UUID) string {
+	raw := strconv.FormatFloat(float64(rank), 'g', -1, 32) + "|" + EncodeCursor(createdAt, id)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func DecodeRankCursor(cursor string) (RankCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return RankCursor{}, fmt.Errorf("invalid cursor")
+	}
+	parts := strings.SplitN(string(raw), "|", 2)
+	if len(parts) != 2 {
+		return RankCursor{}, fmt.Errorf("invalid cursor")
+	}
+	rank, err := strconv.ParseFloat(parts[0], 32)
+	if err != nil {
+		return RankCursor{}, fmt.Errorf("invalid cursor")
+	}
+	c, err := DecodeCursor(parts[1])
+	if err != nil {
+		return RankCursor{}, err
+	}
+	return RankCursor{
+		Rank: float32(rank),
+		Cursor: c,
+	}, nil
+}
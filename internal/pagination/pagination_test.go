@@ -0,0 +1,49 @@
+package pagination
+
+import (
+	"time"
+	"github.com/google/uuid"
+	"testing"
+)
+
+func TestRoundTrip(t *testing.T) {
+	createdAt := time.Date(2025, 3, 14, 15, 9, 26, 535897000, time.UTC)
+	id := uuid.New()
+	cursor, err := DecodeCursor(EncodeCursor(createdAt, id))
+	if err != nil {
+		t.Log(err.Error() + "\n")
+		t.Fail()
+	}
+	if !cursor.CreatedAt.Equal(createdAt) || cursor.ID != id {
+		t.Log("cursor did not round trip\n")
+		t.Fail()
+	}
+}
+
+func TestInvalid(t *testing.T) {
+	for _, cursor := range []string{"", "not base64!", "bm9waXBl"} {
+		if _, err := DecodeCursor(cursor); err == nil {
+			t.Logf("expected error for %q\n", cursor)
+			t.Fail()
+		}
+	}
+}
+
+func TestRankRoundTrip(t *testing.T) {
+	createdAt := time.Date(2025, 3, 14, 15, 9, 26, 0, time.UTC)
+	id := uuid.New()
+	var rank float32 = 0.0607927
+	cursor, err := DecodeRankCursor(EncodeRankCursor(rank, createdAt, id))
+	if err != nil {
+		t.Log(err.Error() + "\n")
+		t.Fail()
+	}
+	if cursor.Rank != rank || !cursor.CreatedAt.Equal(createdAt) || cursor.ID != id {
+		t.Log("rank cursor did not round trip\n")
+		t.Fail()
+	}
+	if _, err := DecodeCursor(EncodeRankCursor(rank, createdAt, id)); err == nil {
+		t.Log("expected plain cursor decode to reject a rank cursor\n")
+		t.Fail()
+	}
+}
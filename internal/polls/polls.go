@@ -0,0 +1,52 @@
+package polls
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	MinOptions = 2
+	MaxOptions = 4
+	MaxOptionLength = 25
+	MinDuration = 5 * time.Minute
+	MaxDuration = 7 * 24 * time.Hour
+)
+
+// Options trims the given poll options and checks there's a sensible number
+// of distinct, non-empty ones.
+func Options(options []string) ([]string, error) {
+	if len(options) < MinOptions || len(options) > MaxOptions {
+		return nil, fmt.Errorf("polls need %d to %d options", MinOptions, MaxOptions)
+	}
+	seen := map[string]bool{}
+	result := make([]string, len(options))
+	for i, option := range options {
+		option = strings.TrimSpace(option)
+		if option == "" {
+			return nil, fmt.Errorf("poll options can't be empty")
+		}
+		if utf8.RuneCountInString(option) > MaxOptionLength {
+			return nil, fmt.Errorf("poll options can be at most %d characters", MaxOptionLength)
+		}
+		key := strings.ToLower(option)
+		if seen[key] {
+			return nil, fmt.Errorf("poll option %q appears twice", option)
+		}
+		seen[key] = true
+		result[i] = option
+	}
+	return result, nil
+}
+
+// ValidateClosing checks that a poll opening at opensAt stays open for a
+// reasonable time before closesAt.
+func ValidateClosing(opensAt, closesAt time.Time) error {
+	duration := closesAt.Sub(opensAt)
+	if duration < MinDuration || duration > MaxDuration {
+		return fmt.Errorf("polls must stay open between %v and %v", MinDuration, MaxDuration)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package polls
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOptions(t *testing.T) {
+	options, err := Options([]string{" Yes ", "No"})
+	if err != nil {
+		t.Log(err.Error())
+		t.Fail()
+	} else if options[0] != "Yes" || options[1] != "No" {
+		t.Logf("expected options to be trimmed, got %q\n", options)
+		t.Fail()
+	}
+	for _, options := range [][]string{
+		{"Only one"},
+		{"a", "b", "c", "d", "e"},
+		{"a", "  "},
+		{"Yes", "yes"},
+		{"a", strings.Repeat("x", MaxOptionLength+1)},
+	} {
+		if _, err := Options(options); err == nil {
+			t.Logf("expected %q to be rejected\n", options)
+			t.Fail()
+		}
+	}
+}
+
+func TestValidateClosing(t *testing.T) {
+	opensAt := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	if err := ValidateClosing(opensAt, opensAt.Add(24*time.Hour)); err != nil {
+		t.Log(err.Error())
+		t.Fail()
+	}
+	for _, closesAt := range []time.Time{opensAt.Add(-time.Hour), opensAt.Add(time.Minute), opensAt.Add(8 * 24 * time.Hour)} {
+		if err := ValidateClosing(opensAt, closesAt); err == nil {
+			t.Logf("expected closing at %v to be rejected\n", closesAt)
+			t.Fail()
+		}
+	}
+}
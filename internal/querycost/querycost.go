@@ -0,0 +1,611 @@
+package querycost
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrSyntax = errors.New("invalid query")
+	ErrTooComplex = errors.New("query is too complex")
+)
+
+// Estimate works out what running an operation in a GraphQL query would
+// cost before it runs. Every field costs 1, and a paginated field
+// multiplies the cost of its selection by its first argument, or by its
+// entry in paginated, the page size it defaults to, when first is left
+// out. operationName picks the operation when the query holds several.
+//
+// It gives up with ErrTooComplex as soon as the cost passes limit, so a
+// query built to cost far more than that is cheap to turn away.
+func Estimate(query string, operationName string, variables map[string]any, paginated map[string]int, limit int) (int, error) {
+	parser := &parser{lexer: lexer{input: query}}
+	document, err := parser.parseDocument()
+	if err != nil {
+		return 0, err
+	}
+	var operation *operation
+	for i := range document.operations {
+		if operationName == "" || document.operations[i].name == operationName {
+			operation = &document.operations[i]
+			break
+		}
+	}
+	if operation == nil {
+		return 0, fmt.Errorf("no operation named %q", operationName)
+	}
+	estimator := estimator{
+		fragments: document.fragments,
+		variables: map[string]any{},
+		paginated: paginated,
+		limit: limit,
+		visiting: map[string]bool{},
+		costs: map[string]int{},
+	}
+	for name, value := range operation.defaults {
+		estimator.variables[name] = value
+	}
+	for name, value := range variables {
+		estimator.variables[name] = value
+	}
+	return estimator.cost(operation.selections)
+}
+
+type estimator struct {
+	fragments map[string][]selection
+	variables map[string]any
+	paginated map[string]int
+	limit int
+	visiting map[string]bool
+	// costs remembers each fragment's cost, so fragments that spread each
+	// other many times over are only worked out once.
+	costs map[string]int
+}
+
+// cost adds up selections, failing as soon as the total passes the limit.
+// Every cost it returns is at most the limit, which keeps the arithmetic
+// from overflowing.
+func (estimator *estimator) cost(selections []selection) (int, error) {
+	total := 0
+	for _, selection := range selections {
+		var cost int
+		var err error
+		switch {
+		case selection.spread != "":
+			cost, err = estimator.fragmentCost(selection.spread)
+		case selection.field == "":
+			cost, err = estimator.cost(selection.children)
+		default:
+			cost, err = estimator.cost(selection.children)
+			multiplier := estimator.multiplier(selection)
+			if err == nil && cost > 0 && multiplier > (estimator.limit-1)/cost {
+				err = ErrTooComplex
+			}
+			cost = 1 + multiplier*cost
+		}
+		if err != nil {
+			return 0, err
+		}
+		total += cost
+		if total > estimator.limit {
+			return 0, ErrTooComplex
+		}
+	}
+	return total, nil
+}
+
+func (estimator *estimator) fragmentCost(name string) (int, error) {
+	if cost, ok := estimator.costs[name]; ok {
+		return cost, nil
+	}
+	children, ok := estimator.fragments[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown fragment %q", name)
+	}
+	if estimator.visiting[name] {
+		return 0, fmt.Errorf("fragment %q spreads itself", name)
+	}
+	estimator.visiting[name] = true
+	cost, err := estimator.cost(children)
+	estimator.visiting[name] = false
+	if err != nil {
+		return 0, err
+	}
+	estimator.costs[name] = cost
+	return cost, nil
+}
+
+func (estimator *estimator) multiplier(field selection) int {
+	defaultLimit, ok := estimator.paginated[field.field]
+	if !ok {
+		return 1
+	}
+	value := field.arguments["first"]
+	if variable, ok := value.(variable); ok {
+		value = estimator.variables[string(variable)]
+	}
+	switch n := value.(type) {
+	case int:
+		return max(n, 0)
+	case int64:
+		return max(int(n), 0)
+	case float64:
+		// Converting a float too big for an int isn't defined.
+		return int(max(min(n, float64(math.MaxInt32)), 0))
+	}
+	return defaultLimit
+}
+
+// The parser only keeps what the estimate needs: fields, their
+// arguments, fragments and variable defaults. Everything else is checked
+// for syntax and dropped.
+
+type variable string
+
+type selection struct {
+	// field is empty for fragment spreads, which set spread to the
+	// fragment's name, and for inline fragments.
+	field string
+	spread string
+	arguments map[string]any
+	children []selection
+}
+
+type operation struct {
+	name string
+	defaults map[string]any
+	selections []selection
+}
+
+type document struct {
+	operations []operation
+	fragments map[string][]selection
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenPunctuator
+	tokenName
+	tokenInt
+	tokenFloat
+	tokenString
+)
+
+type token struct {
+	kind tokenKind
+	value string
+}
+
+type lexer struct {
+	input string
+	position int
+}
+
+func (lexer *lexer) next() (token, error) {
+	for lexer.position < len(lexer.input) {
+		c := lexer.input[lexer.position]
+		if c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',' {
+			lexer.position++
+			continue
+		}
+		if c == '#' {
+			for lexer.position < len(lexer.input) && lexer.input[lexer.position] != '\n' {
+				lexer.position++
+			}
+			continue
+		}
+		break
+	}
+	if lexer.position >= len(lexer.input) {
+		return token{kind: tokenEOF}, nil
+	}
+	start := lexer.position
+	c := lexer.input[start]
+	switch {
+	case strings.HasPrefix(lexer.input[start:], "..."):
+		lexer.position += 3
+		return token{kind: tokenPunctuator, value: "..."}, nil
+	case strings.ContainsRune("!$&()[]{}:=@|", rune(c)):
+		lexer.position++
+		return token{kind: tokenPunctuator, value: string(c)}, nil
+	case c == '_' || isLetter(c):
+		for lexer.position < len(lexer.input) && (lexer.input[lexer.position] == '_' || isLetter(lexer.input[lexer.position]) || isDigit(lexer.input[lexer.position])) {
+			lexer.position++
+		}
+		return token{kind: tokenName, value: lexer.input[start:lexer.position]}, nil
+	case c == '-' || isDigit(c):
+		lexer.position++
+		kind := tokenInt
+		for lexer.position < len(lexer.input) {
+			c := lexer.input[lexer.position]
+			if c == '.' || c == 'e' || c == 'E' || ((c == '+' || c == '-') && kind == tokenFloat) {
+				kind = tokenFloat
+			} else if !isDigit(c) {
+				break
+			}
+			lexer.position++
+		}
+		return token{kind: kind, value: lexer.input[start:lexer.position]}, nil
+	case strings.HasPrefix(lexer.input[start:], `"""`):
+		end := strings.Index(lexer.input[start+3:], `"""`)
+		if end < 0 {
+			return token{}, ErrSyntax
+		}
+		lexer.position = start + 3 + end + 3
+		return token{kind: tokenString, value: lexer.input[start+3 : start+3+end]}, nil
+	case c == '"':
+		lexer.position++
+		for lexer.position < len(lexer.input) {
+			switch lexer.input[lexer.position] {
+			case '\\':
+				lexer.position += 2
+				continue
+			case '"':
+				lexer.position++
+				return token{kind: tokenString, value: lexer.input[start+1 : lexer.position-1]}, nil
+			case '\n':
+				return token{}, ErrSyntax
+			}
+			lexer.position++
+		}
+		return token{}, ErrSyntax
+	}
+	return token{}, ErrSyntax
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+type parser struct {
+	lexer lexer
+	current token
+	peeked bool
+	// depth stops deeply nested input from exhausting the stack before
+	// the schema's own depth limit gets a chance to reject it.
+	depth int
+}
+
+const maxNesting = 256
+
+func (parser *parser) peek() (token, error) {
+	if !parser.peeked {
+		next, err := parser.lexer.next()
+		if err != nil {
+			return token{}, err
+		}
+		parser.current = next
+		parser.peeked = true
+	}
+	return parser.current, nil
+}
+
+func (parser *parser) take() (token, error) {
+	next, err := parser.peek()
+	parser.peeked = false
+	return next, err
+}
+
+func (parser *parser) is(kind tokenKind, value string) bool {
+	next, err := parser.peek()
+	return err == nil && next.kind == kind && (value == "" || next.value == value)
+}
+
+func (parser *parser) expect(kind tokenKind, value string) (token, error) {
+	next, err := parser.take()
+	if err != nil {
+		return token{}, err
+	}
+	if next.kind != kind || (value != "" && next.value != value) {
+		return token{}, ErrSyntax
+	}
+	return next, nil
+}
+
+func (parser *parser) parseDocument() (document, error) {
+	result := document{fragments: map[string][]selection{}}
+	for !parser.is(tokenEOF, "") {
+		if parser.is(tokenPunctuator, "{") {
+			selections, err := parser.parseSelectionSet()
+			if err != nil {
+				return document{}, err
+			}
+			result.operations = append(result.operations, operation{selections: selections})
+			continue
+		}
+		keyword, err := parser.expect(tokenName, "")
+		if err != nil {
+			return document{}, err
+		}
+		switch keyword.value {
+		case "query", "mutation", "subscription":
+			op := operation{defaults: map[string]any{}}
+			if parser.is(tokenName, "") {
+				name, _ := parser.take()
+				op.name = name.value
+			}
+			if parser.is(tokenPunctuator, "(") {
+				if op.defaults, err = parser.parseVariableDefinitions(); err != nil {
+					return document{}, err
+				}
+			}
+			if err := parser.skipDirectives(); err != nil {
+				return document{}, err
+			}
+			if op.selections, err = parser.parseSelectionSet(); err != nil {
+				return document{}, err
+			}
+			result.operations = append(result.operations, op)
+		case "fragment":
+			name, err := parser.expect(tokenName, "")
+			if err != nil {
+				return document{}, err
+			}
+			if _, err := parser.expect(tokenName, "on"); err != nil {
+				return document{}, err
+			}
+			if _, err := parser.expect(tokenName, ""); err != nil {
+				return document{}, err
+			}
+			if err := parser.skipDirectives(); err != nil {
+				return document{}, err
+			}
+			selections, err := parser.parseSelectionSet()
+			if err != nil {
+				return document{}, err
+			}
+			result.fragments[name.value] = selections
+		default:
+			return document{}, ErrSyntax
+		}
+	}
+	if len(result.operations) == 0 {
+		return document{}, ErrSyntax
+	}
+	return result, nil
+}
+
+func (parser *parser) parseVariableDefinitions() (map[string]any, error) {
+	defaults := map[string]any{}
+	parser.take()
+	for !parser.is(tokenPunctuator, ")") {
+		if _, err := parser.expect(tokenPunctuator, "$"); err != nil {
+			return nil, err
+		}
+		name, err := parser.expect(tokenName, "")
+		if err != nil {
+			return nil, err
+		}
+		if _, err := parser.expect(tokenPunctuator, ":"); err != nil {
+			return nil, err
+		}
+		if err := parser.skipType(); err != nil {
+			return nil, err
+		}
+		if parser.is(tokenPunctuator, "=") {
+			parser.take()
+			value, err := parser.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			defaults[name.value] = value
+		}
+		if err := parser.skipDirectives(); err != nil {
+			return nil, err
+		}
+	}
+	parser.take()
+	return defaults, nil
+}
+
+func (parser *parser) skipType() error {
+	if parser.is(tokenPunctuator, "[") {
+		parser.take()
+		if err := parser.skipType(); err != nil {
+			return err
+		}
+		if _, err := parser.expect(tokenPunctuator, "]"); err != nil {
+			return err
+		}
+	} else if _, err := parser.expect(tokenName, ""); err != nil {
+		return err
+	}
+	if parser.is(tokenPunctuator, "!") {
+		parser.take()
+	}
+	return nil
+}
+
+func (parser *parser) skipDirectives() error {
+	for parser.is(tokenPunctuator, "@") {
+		parser.take()
+		if _, err := parser.expect(tokenName, ""); err != nil {
+			return err
+		}
+		if parser.is(tokenPunctuator, "(") {
+			if _, err := parser.parseArguments(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (parser *parser) parseSelectionSet() ([]selection, error) {
+	parser.depth++
+	defer func() { parser.depth-- }()
+	if parser.depth > maxNesting {
+		return nil, ErrSyntax
+	}
+	if _, err := parser.expect(tokenPunctuator, "{"); err != nil {
+		return nil, err
+	}
+	var selections []selection
+	for !parser.is(tokenPunctuator, "}") {
+		if parser.is(tokenEOF, "") {
+			return nil, ErrSyntax
+		}
+		var current selection
+		var err error
+		if parser.is(tokenPunctuator, "...") {
+			parser.take()
+			if parser.is(tokenName, "") && !parser.is(tokenName, "on") {
+				name, _ := parser.take()
+				current.spread = name.value
+				err = parser.skipDirectives()
+			} else {
+				if parser.is(tokenName, "on") {
+					parser.take()
+					if _, err := parser.expect(tokenName, ""); err != nil {
+						return nil, err
+					}
+				}
+				if err := parser.skipDirectives(); err != nil {
+					return nil, err
+				}
+				current.children, err = parser.parseSelectionSet()
+			}
+		} else {
+			current, err = parser.parseField()
+		}
+		if err != nil {
+			return nil, err
+		}
+		selections = append(selections, current)
+	}
+	parser.take()
+	return selections, nil
+}
+
+func (parser *parser) parseField() (selection, error) {
+	name, err := parser.expect(tokenName, "")
+	if err != nil {
+		return selection{}, err
+	}
+	if parser.is(tokenPunctuator, ":") {
+		parser.take()
+		if name, err = parser.expect(tokenName, ""); err != nil {
+			return selection{}, err
+		}
+	}
+	field := selection{field: name.value, arguments: map[string]any{}}
+	if parser.is(tokenPunctuator, "(") {
+		if field.arguments, err = parser.parseArguments(); err != nil {
+			return selection{}, err
+		}
+	}
+	if err := parser.skipDirectives(); err != nil {
+		return selection{}, err
+	}
+	if parser.is(tokenPunctuator, "{") {
+		if field.children, err = parser.parseSelectionSet(); err != nil {
+			return selection{}, err
+		}
+	}
+	return field, nil
+}
+
+func (parser *parser) parseArguments() (map[string]any, error) {
+	arguments := map[string]any{}
+	parser.take()
+	for !parser.is(tokenPunctuator, ")") {
+		name, err := parser.expect(tokenName, "")
+		if err != nil {
+			return nil, err
+		}
+		if _, err := parser.expect(tokenPunctuator, ":"); err != nil {
+			return nil, err
+		}
+		value, err := parser.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		arguments[name.value] = value
+	}
+	parser.take()
+	return arguments, nil
+}
+
+func (parser *parser) parseValue() (any, error) {
+	parser.depth++
+	defer func() { parser.depth-- }()
+	if parser.depth > maxNesting {
+		return nil, ErrSyntax
+	}
+	next, err := parser.take()
+	if err != nil {
+		return nil, err
+	}
+	switch next.kind {
+	case tokenInt:
+		n, err := strconv.ParseInt(next.value, 10, 64)
+		if err != nil {
+			return nil, ErrSyntax
+		}
+		return n, nil
+	case tokenFloat:
+		n, err := strconv.ParseFloat(next.value, 64)
+		if err != nil {
+			return nil, ErrSyntax
+		}
+		return n, nil
+	case tokenString:
+		return next.value, nil
+	case tokenName:
+		switch next.value {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+		return next.value, nil
+	case tokenPunctuator:
+		switch next.value {
+		case "$":
+			name, err := parser.expect(tokenName, "")
+			if err != nil {
+				return nil, err
+			}
+			return variable(name.value), nil
+		case "[":
+			var list []any
+			for !parser.is(tokenPunctuator, "]") {
+				value, err := parser.parseValue()
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, value)
+			}
+			parser.take()
+			return list, nil
+		case "{":
+			object := map[string]any{}
+			for !parser.is(tokenPunctuator, "}") {
+				name, err := parser.expect(tokenName, "")
+				if err != nil {
+					return nil, err
+				}
+				if _, err := parser.expect(tokenPunctuator, ":"); err != nil {
+					return nil, err
+				}
+				if object[name.value], err = parser.parseValue(); err != nil {
+					return nil, err
+				}
+			}
+			parser.take()
+			return object, nil
+		}
+	}
+	return nil, ErrSyntax
+}
@@ -0,0 +1,102 @@
+package querycost
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+var paginated = map[string]int{"chirps": 20, "notifications": 20}
+
+const limit = 5000
+
+// fanOut is a query whose fragments each spread the next one twice, so
+// it costs 2^depth while staying short.
+func fanOut(depth int) string {
+	var query strings.Builder
+	query.WriteString("{ me { ...f0 } }")
+	for i := range depth {
+		fmt.Fprintf(&query, " fragment f%d on User { ...f%d ...f%d }", i, i+1, i+1)
+	}
+	fmt.Fprintf(&query, " fragment f%d on User { id }", depth)
+	return query.String()
+}
+
+func TestEstimate(t *testing.T) {
+	cases := []struct {
+		name string
+		query string
+		operationName string
+		variables map[string]any
+		expected int
+	}{
+		{"single field", `{ me { id } }`, "", nil, 2},
+		{"page size", `{ user(handle: "a") { chirps(first: 5) { nodes { id body } } } }`, "", nil, 1 + 1 + 5*(1+2)},
+		{"default page size", `{ user(handle: "a") { chirps { nodes { id } } } }`, "", nil, 1 + 1 + 20*2},
+		{"variable", `query Q($n: Int) { chirps(first: $n) { nodes { id } } }`, "", map[string]any{"n": float64(3)}, 1 + 3*2},
+		{"variable default", `query Q($n: Int = 4) { chirps(first: $n) { nodes { id } } }`, "", nil, 1 + 4*2},
+		{"nested pages multiply", `{ chirps(first: 10) { nodes { author { chirps(first: 10) { nodes { id } } } } } }`, "", nil, 1 + 10*(1+1+1+10*2)},
+		{"fragments", `query { me { ...f } } fragment f on User { id handle }`, "", nil, 3},
+		{"inline fragments", `{ timeline { items { ... on Chirp { id } ... on RemotePost { id content } } } }`, "", nil, 5},
+		{"named operation", `query A { me { id } } query B { me { id handle } }`, "B", nil, 3},
+		{"comments and strings", "{ # comment\n user(handle: \"a}b\") { id } }", "", nil, 2},
+		{"fragment fan-out under the limit", fanOut(10), "", nil, 1 + 1<<10},
+	}
+	for _, c := range cases {
+		got, err := Estimate(c.query, c.operationName, c.variables, paginated, limit)
+		if err != nil || got != c.expected {
+			t.Logf("%s: expected %d, got %d %v\n", c.name, c.expected, got, err)
+			t.Fail()
+		}
+	}
+}
+
+func TestEstimateRejects(t *testing.T) {
+	cases := []struct {
+		name string
+		query string
+	}{
+		{"unclosed", `{ me { id }`},
+		{"empty", ``},
+		{"unknown fragment", `{ me { ...f } }`},
+		{"fragment cycle", `{ me { ...a } } fragment a on User { ...b } fragment b on User { ...a }`},
+		{"unterminated string", `{ user(handle: "a) { id } }`},
+		{"too deep", strings.Repeat("{ a ", 1000) + strings.Repeat("}", 1000)},
+		{"fragment fan-out", fanOut(24)},
+	}
+	for _, c := range cases {
+		if _, err := Estimate(c.query, "", nil, paginated, limit); err == nil {
+			t.Logf("%s: expected an error\n", c.name)
+			t.Fail()
+		}
+	}
+}
+
+func TestEstimateTooComplex(t *testing.T) {
+	cases := []struct {
+		name string
+		query string
+		variables map[string]any
+	}{
+		{"over the limit", `{ chirps(first: 1000) { nodes { id body author { id } } } }`, nil},
+		// Each fragment doubles the cost, and would double the time taken
+		// to work it out if fragments weren't costed once.
+		{"fragment fan-out", fanOut(500), nil},
+		{"huge pages", `{ chirps(first: 9223372036854775807) { nodes { chirps(first: 9223372036854775807) { nodes { id } } } } }`, nil},
+		{"huge variable", `query Q($n: Int) { chirps(first: $n) { nodes { id } } }`, map[string]any{"n": 1e300}},
+	}
+	for _, c := range cases {
+		start := time.Now()
+		_, err := Estimate(c.query, "", c.variables, paginated, limit)
+		if !errors.Is(err, ErrTooComplex) {
+			t.Logf("%s: expected ErrTooComplex, got %v\n", c.name, err)
+			t.Fail()
+		}
+		if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+			t.Logf("%s: took %v\n", c.name, elapsed)
+			t.Fail()
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// ParseQuery turns user input into a Postgres to_tsquery expression. Every
+// term must match; "quoted phrases" match adjacent words and a trailing *
+// matches any word with that prefix.
+func ParseQuery(q string) (string, error) {
+	var terms []string
+	for _, token := range tokenize(q) {
+		prefix := !token.phrase && strings.HasSuffix(token.text, "*")
+		words := strings.FieldsFunc(token.text, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		if len(words) == 0 {
+			continue
+		}
+		for i, word := range words {
+			words[i] = "'" + strings.ToLower(word) + "'"
+		}
+		if prefix {
+			words[len(words)-1] += ":*"
+		}
+		if len(words) == 1 {
+			terms = append(terms, words[0])
+			continue
+		}
+		terms = append(terms, "("+strings.Join(words, " <-> ")+")")
+	}
+	if len(terms) == 0 {
+		return "", fmt.Errorf("search query is empty")
+	}
+	return strings.Join(terms, " & "), nil
+}
+
+type token struct {
+	text string
+	phrase bool
+}
+
+func tokenize(q string) []token {
+	var tokens []token
+	for {
+		q = strings.TrimSpace(q)
+		if q == "" {
+			return tokens
+		}
+		if q[0] == '"' {
+			end := strings.IndexByte(q[1:], '"')
+			if end == -1 {
+				tokens = append(tokens, token{text: q[1:], phrase: true})
+				return tokens
+			}
+			tokens = append(tokens, token{text: q[1:end+1], phrase: true})
+			q = q[end+2:]
+			continue
+		}
+		end := strings.IndexFunc(q, func(r rune) bool {
+			return unicode.IsSpace(r) || r == '"'
+		})
+		if end == -1 {
+			end = len(q)
+		}
+		tokens = append(tokens, token{text: q[:end]})
+		q = q[end:]
+	}
+}
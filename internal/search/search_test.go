@@ -0,0 +1,40 @@
+package search
+
+import (
+	"testing"
+)
+
+func TestParseQuery(t *testing.T) {
+	cases := map[string]string{
+		"hello": "'hello'",
+		"Hello World": "'hello' & 'world'",
+		"\"hello world\"": "('hello' <-> 'world')",
+		"chir*": "'chir':*",
+		"\"big fan\" chir*": "('big' <-> 'fan') & 'chir':*",
+		"it's": "('it' <-> 's')",
+		"\"unterminated phrase": "('unterminated' <-> 'phrase')",
+		"'); DROP TABLE chirps; --": "'drop' & 'table' & 'chirps'",
+		"café": "'café'",
+	}
+	for input, expected := range cases {
+		got, err := ParseQuery(input)
+		if err != nil {
+			t.Logf("%q: %s\n", input, err.Error())
+			t.Fail()
+			continue
+		}
+		if got != expected {
+			t.Logf("%q: expected %q, got %q\n", input, expected, got)
+			t.Fail()
+		}
+	}
+}
+
+func TestEmptyQuery(t *testing.T) {
+	for _, input := range []string{"", "   ", "\"\"", "!!! ***"} {
+		if _, err := ParseQuery(input); err == nil {
+			t.Logf("expected error for %q\n", input)
+			t.Fail()
+		}
+	}
+}
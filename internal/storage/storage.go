@@ -0,0 +1,17 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+var ErrNotFound = errors.New("blob not found")
+
+// BlobStore keeps uploaded files. Keys are slash separated paths chosen by
+// the caller, like "media/<id>".
+type BlobStore interface {
+	Put(ctx context.Context, key string, body []byte, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, string, error)
+	Delete(ctx context.Context, key string) error
+}
@@ -0,0 +1,156 @@
+package stream
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrSlowConsumer is reported by a subscription the hub dropped because it
+// wasn't keeping up with events.
+var ErrSlowConsumer = errors.New("subscriber fell behind and was dropped")
+
+// Event is one message fanned out to subscribers. ID is opaque to the hub;
+// Topics holds every topic the event was published to, so subscribers can
+// narrow things down further.
+type Event struct {
+	ID string
+	Type string
+	Data []byte
+	Topics []string
+}
+
+// Has reports whether event was published to topic.
+func (event Event) Has(topic string) bool {
+	for _, t := range event.Topics {
+		if t == topic {
+			return true
+		}
+	}
+	return false
+}
+
+// Hub fans events out to subscribers by topic. Publishing never blocks: a
+// subscriber whose buffer is full is dropped instead, and is expected to
+// reconnect and catch up from its last event.
+type Hub struct {
+	mu sync.Mutex
+	topics map[string]map[*Subscription]bool
+}
+
+func NewHub() *Hub {
+	return &Hub{
+		topics: map[string]map[*Subscription]bool{},
+	}
+}
+
+type Subscription struct {
+	hub *Hub
+	events chan Event
+	topics map[string]bool
+	closed bool
+	err error
+}
+
+// Subscribe starts a subscription to topics that buffers up to buffer
+// undelivered events.
+func (hub *Hub) Subscribe(buffer int, topics ...string) *Subscription {
+	sub := &Subscription{
+		hub: hub,
+		events: make(chan Event, buffer),
+		topics: map[string]bool{},
+	}
+	sub.Add(topics...)
+	return sub
+}
+
+// Events is closed when the subscription ends.
+func (sub *Subscription) Events() <-chan Event {
+	return sub.events
+}
+
+// Err says why the subscription ended, if it wasn't closed by its owner.
+func (sub *Subscription) Err() error {
+	sub.hub.mu.Lock()
+	defer sub.hub.mu.Unlock()
+	return sub.err
+}
+
+func (sub *Subscription) Add(topics ...string) {
+	sub.hub.mu.Lock()
+	defer sub.hub.mu.Unlock()
+	if sub.closed {
+		return
+	}
+	for _, topic := range topics {
+		if sub.hub.topics[topic] == nil {
+			sub.hub.topics[topic] = map[*Subscription]bool{}
+		}
+		sub.hub.topics[topic][sub] = true
+		sub.topics[topic] = true
+	}
+}
+
+func (sub *Subscription) Remove(topics ...string) {
+	sub.hub.mu.Lock()
+	defer sub.hub.mu.Unlock()
+	for _, topic := range topics {
+		sub.hub.unsubscribe(sub, topic)
+	}
+}
+
+// Topics returns the number of topics sub is subscribed to.
+func (sub *Subscription) Topics() int {
+	sub.hub.mu.Lock()
+	defer sub.hub.mu.Unlock()
+	return len(sub.topics)
+}
+
+func (sub *Subscription) Close() {
+	sub.hub.mu.Lock()
+	defer sub.hub.mu.Unlock()
+	sub.hub.drop(sub, nil)
+}
+
+// Publish delivers event to everyone subscribed to any of topics, once per
+// subscriber.
+func (hub *Hub) Publish(event Event, topics ...string) {
+	event.Topics = topics
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	delivered := map[*Subscription]bool{}
+	for _, topic := range topics {
+		for sub := range hub.topics[topic] {
+			if delivered[sub] {
+				continue
+			}
+			delivered[sub] = true
+			select {
+			case sub.events <- event:
+			default:
+				hub.drop(sub, ErrSlowConsumer)
+			}
+		}
+	}
+}
+
+// drop must be called with hub.mu held.
+func (hub *Hub) drop(sub *Subscription, err error) {
+	if sub.closed {
+		return
+	}
+	for topic := range sub.topics {
+		hub.unsubscribe(sub, topic)
+	}
+	sub.closed = true
+	sub.err = err
+	close(sub.events)
+}
+
+// unsubscribe must be called with hub.mu held.
+func (hub *Hub) unsubscribe(sub *Subscription, topic string) {
+	delete(sub.topics, topic)
+	delete(hub.topics[topic], sub)
+	if len(hub.topics[topic]) == 0 {
+		delete(hub.topics, topic)
+	}
+}
@@ -0,0 +1,64 @@
+package stream
+
+import (
+	"testing"
+)
+
+func TestPublish(t *testing.T) {
+	hub := NewHub()
+	all := hub.Subscribe(4, "chirps")
+	tagged := hub.Subscribe(4, "hashtag:go", "author:alice")
+	hub.Publish(Event{ID: "1"}, "chirps", "author:alice", "hashtag:go")
+	hub.Publish(Event{ID: "2"}, "chirps", "author:bob")
+	if len(all.Events()) != 2 {
+		t.Logf("expected 2 events on chirps, got %d\n", len(all.Events()))
+		t.Fail()
+	}
+	if len(tagged.Events()) != 1 {
+		t.Logf("expected 1 event for alice or #go, got %d\n", len(tagged.Events()))
+		t.Fail()
+	}
+	event := <-tagged.Events()
+	if event.ID != "1" || !event.Has("hashtag:go") || event.Has("author:bob") {
+		t.Logf("unexpected event %+v\n", event)
+		t.Fail()
+	}
+}
+
+func TestSlowConsumer(t *testing.T) {
+	hub := NewHub()
+	sub := hub.Subscribe(1, "chirps")
+	hub.Publish(Event{ID: "1"}, "chirps")
+	hub.Publish(Event{ID: "2"}, "chirps")
+	if _, ok := <-sub.Events(); !ok {
+		t.Log("expected the buffered event before the close")
+		t.Fail()
+	}
+	if _, ok := <-sub.Events(); ok {
+		t.Log("expected the subscription to be dropped")
+		t.Fail()
+	}
+	if sub.Err() != ErrSlowConsumer {
+		t.Logf("expected ErrSlowConsumer, got %v\n", sub.Err())
+		t.Fail()
+	}
+	hub.Publish(Event{ID: "3"}, "chirps")
+	sub.Close()
+}
+
+func TestRemove(t *testing.T) {
+	hub := NewHub()
+	sub := hub.Subscribe(4, "a", "b")
+	sub.Remove("a")
+	hub.Publish(Event{ID: "1"}, "a")
+	if len(sub.Events()) != 0 || sub.Topics() != 1 {
+		t.Log("expected no events after unsubscribing")
+		t.Fail()
+	}
+	sub.Close()
+	sub.Close()
+	if len(hub.topics) != 0 {
+		t.Logf("expected no topics left, got %v\n", hub.topics)
+		t.Fail()
+	}
+}
@@ -0,0 +1,136 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	mathrand "math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	SignatureHeader = "Chirpy-Signature"
+	EventHeader = "Chirpy-Event"
+	DeliveryHeader = "Chirpy-Delivery"
+
+	baseBackoff = 30 * time.Second
+	maxBackoff = 6 * time.Hour
+)
+
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// NewSecret makes a signing secret for a new endpoint.
+func NewSecret() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(key), nil
+}
+
+// Sign returns the signature header value for body sent at timestamp:
+// "t=<unix seconds>,v1=<hex HMAC-SHA256 of "<t>.<body>">". Signing the
+// timestamp along with the body lets receivers reject replays.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	t := strconv.FormatInt(timestamp.Unix(), 10)
+	return fmt.Sprintf("t=%s,v1=%s", t, signature(secret, t, body))
+}
+
+func signature(secret string, t string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(t))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a signature header against body, and that it was made
+// within tolerance of now.
+func Verify(secret string, header string, body []byte, tolerance time.Duration, now time.Time) error {
+	var t string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "t":
+			t = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+	seconds, err := strconv.ParseInt(t, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if now.Sub(time.Unix(seconds, 0)).Abs() > tolerance {
+		return ErrInvalidSignature
+	}
+	expected := signature(secret, t, body)
+	for _, candidate := range signatures {
+		if hmac.Equal([]byte(candidate), []byte(expected)) {
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
+
+// Backoff is how long to wait before retrying after attempt failed, with
+// attempts counted from 1. It doubles each time from 30 seconds up to six
+// hours, give or take 10% so retries from an outage don't arrive in step.
+func Backoff(attempt int) time.Duration {
+	wait := maxBackoff
+	if attempt < 20 {
+		wait = min(time.Duration(float64(baseBackoff)*math.Pow(2, float64(attempt-1))), maxBackoff)
+	}
+	jitter := 0.9 + mathrand.Float64()*0.2
+	return time.Duration(float64(wait) * jitter)
+}
+
+// Result describes one delivery attempt.
+type Result struct {
+	StatusCode int
+	Duration time.Duration
+}
+
+// OK reports whether the receiver accepted the delivery.
+func (result Result) OK() bool {
+	return result.StatusCode >= 200 && result.StatusCode < 300
+}
+
+// Deliver POSTs a signed body to url. An error means no response was
+// received; a response that isn't 2xx is reported through Result.
+func Deliver(ctx context.Context, client *http.Client, url string, secret string, event string, deliveryID string, body []byte) (Result, error) {
+	request, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return Result{}, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "Chirpy-Webhooks/1.0")
+	request.Header.Set(EventHeader, event)
+	request.Header.Set(DeliveryHeader, deliveryID)
+	request.Header.Set(SignatureHeader, Sign(secret, time.Now(), body))
+	start := time.Now()
+	response, err := client.Do(request)
+	if err != nil {
+		return Result{Duration: time.Since(start)}, err
+	}
+	defer response.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(response.Body, 64<<10))
+	return Result{
+		StatusCode: response.StatusCode,
+		Duration: time.Since(start),
+	}, nil
+}
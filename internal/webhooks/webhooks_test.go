@@ -0,0 +1,96 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	body := []byte(`{"type":"chirp.created"}`)
+	header := Sign("whsec_test", now, body)
+	cases := []struct {
+		name string
+		secret string
+		header string
+		body []byte
+		now time.Time
+		valid bool
+	}{
+		{"valid", "whsec_test", header, body, now, true},
+		{"within tolerance", "whsec_test", header, body, now.Add(4 * time.Minute), true},
+		{"too old", "whsec_test", header, body, now.Add(10 * time.Minute), false},
+		{"wrong secret", "whsec_other", header, body, now, false},
+		{"tampered body", "whsec_test", header, []byte(`{"type":"chirp.deleted"}`), now, false},
+		{"garbage", "whsec_test", "nonsense", body, now, false},
+	}
+	for _, c := range cases {
+		err := Verify(c.secret, c.header, c.body, 5*time.Minute, c.now)
+		if (err == nil) != c.valid {
+			t.Logf("%s: expected valid=%v, got %v\n", c.name, c.valid, err)
+			t.Fail()
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	cases := []struct {
+		attempt int
+		expected time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{5, 8 * time.Minute},
+		{12, 6 * time.Hour},
+		{100, 6 * time.Hour},
+	}
+	for _, c := range cases {
+		got := Backoff(c.attempt)
+		low := time.Duration(float64(c.expected) * 0.9)
+		high := time.Duration(float64(c.expected) * 1.1)
+		if got < low || got > high {
+			t.Logf("attempt %d: expected about %v, got %v\n", c.attempt, c.expected, got)
+			t.Fail()
+		}
+	}
+}
+
+func TestDeliver(t *testing.T) {
+	body := []byte(`{"id":"1"}`)
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		received, _ := io.ReadAll(request.Body)
+		if err := Verify("whsec_test", request.Header.Get(SignatureHeader), received, time.Minute, time.Now()); err != nil {
+			writer.WriteHeader(401)
+			return
+		}
+		if request.Header.Get(EventHeader) != "chirp.created" || request.Header.Get(DeliveryHeader) != "d1" {
+			writer.WriteHeader(400)
+			return
+		}
+		writer.WriteHeader(204)
+	}))
+	defer server.Close()
+	result, err := Deliver(context.Background(), server.Client(), server.URL, "whsec_test", "chirp.created", "d1", body)
+	if err != nil || !result.OK() {
+		t.Logf("expected a successful delivery, got %+v %v\n", result, err)
+		t.Fail()
+	}
+	result, err = Deliver(context.Background(), server.Client(), server.URL, "whsec_wrong", "chirp.created", "d1", body)
+	if err != nil || result.OK() || result.StatusCode != 401 {
+		t.Logf("expected the receiver to reject a bad signature, got %+v %v\n", result, err)
+		t.Fail()
+	}
+	server.Close()
+	if _, err := Deliver(context.Background(), server.Client(), server.URL, "whsec_test", "chirp.created", "d1", body); err == nil {
+		t.Log("expected an error when nobody is listening")
+		t.Fail()
+	} else if errors.Is(err, ErrInvalidSignature) {
+		t.Logf("unexpected error %v\n", err)
+		t.Fail()
+	}
+}
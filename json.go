@@ -0,0 +1,27 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+func respondWithError(writer http.ResponseWriter, code int, msg string) {
+	type errorObj struct {
+		Error string `json:"error"`
+	}
+	respondWithJSON(writer, code, errorObj{
+		Error: msg,
+	})
+}
+
+func respondWithJSON(writer http.ResponseWriter, code int, payload interface{}) {
+	dat, err := json.Marshal(payload)
+	if err != nil {
+		writer.WriteHeader(500)
+		writer.Write([]byte(err.Error()))
+		return
+	}
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(code)
+	writer.Write(dat)
+}
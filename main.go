@@ -7,22 +7,49 @@ import (
 	"os"
 	"sync/atomic"
 	"encoding/json"
-	"strings"
 	"github.com/joho/godotenv"
 	"github.com/Baehry/chirpy/internal/database"
 	"database/sql"
 	"github.com/google/uuid"
 	"github.com/Baehry/chirpy/internal/auth"
 	"time"
-	"sort"
+	"context"
+	"github.com/Baehry/chirpy/internal/handles"
+	"github.com/Baehry/chirpy/internal/moderation"
+	"github.com/Baehry/chirpy/internal/storage"
+	"github.com/Baehry/chirpy/internal/stream"
+	"github.com/Baehry/chirpy/internal/gateway"
+	"github.com/Baehry/chirpy/internal/activitypub"
+	graphql "github.com/graph-gophers/graphql-go"
+	"errors"
+	"os/signal"
+	"net"
+	"google.golang.org/grpc"
+	"syscall"
+	"github.com/lib/pq"
 )
 
+const shutdownTimeout = 30 * time.Second
+
 type apiConfig struct {
 	fileserverHits atomic.Int32
+	db *sql.DB
 	dbQueries *database.Queries
 	platform string
 	tokenSecret string
 	polkaKey string
+	adminKey string
+	publicURL string
+	moderationRules moderation.Reloadable
+	contentFilter moderation.Filter
+	blobStore storage.BlobStore
+	pinLimit int
+	pinLimitRed int
+	hub *stream.Hub
+	gateway *gateway.Registry
+	draining chan struct{}
+	federation *activitypub.Client
+	graphqlSchema *graphql.Schema
 }
 
 func main() {
@@ -33,36 +60,188 @@ func main() {
 		fmt.Printf("%v\n", err)
 		os.Exit(1)
 	}
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(runMigrate(context.Background(), db, os.Args[2:]))
+	}
+	if err := prepareSchema(context.Background(), db); err != nil {
+		fmt.Printf("%v\n", err)
+		os.Exit(1)
+	}
 	dbQueries := database.New(db)
 	var apiCfg apiConfig
+	apiCfg.db = db
 	apiCfg.dbQueries = dbQueries
 	apiCfg.platform = os.Getenv("PLATFORM")
 	apiCfg.tokenSecret = os.Getenv("SECRET")
 	apiCfg.polkaKey = os.Getenv("POLKA_KEY")
-	mux := http.NewServeMux()
-	mux.Handle("/app/", http.StripPrefix("/app", apiCfg.middlewareMetricsInc(http.FileServer(http.Dir(".")))))
-	mux.HandleFunc("GET /api/healthz", HealthzHandler)
-	mux.HandleFunc("GET /admin/metrics", apiCfg.MetricsHandler)
-	mux.HandleFunc("POST /admin/reset", apiCfg.ResetHandler)
-	mux.HandleFunc("POST /api/users", apiCfg.UsersHandler)
-	mux.HandleFunc("POST /api/chirps", apiCfg.ChirpsHandler)
-	mux.HandleFunc("GET /api/chirps", apiCfg.GetChirpsHandler)
-	mux.HandleFunc("GET /api/chirps/{chirpID}", apiCfg.GetChirpHandler)
-	mux.HandleFunc("POST /api/login", apiCfg.LoginHandler)
-	mux.HandleFunc("POST /api/refresh", apiCfg.RefreshHandler)
-	mux.HandleFunc("POST /api/revoke", apiCfg.RevokeHandler)
-	mux.HandleFunc("PUT /api/users", apiCfg.PutUsersHandler)
-	mux.HandleFunc("DELETE /api/chirps/{chirpID}", apiCfg.DeleteChirpHandler)
-	mux.HandleFunc("POST /api/polka/webhooks", apiCfg.WebhooksHandler)
-	server := http.Server {
-		Handler: mux,
-		Addr: ":8080",
+	apiCfg.adminKey = os.Getenv("ADMIN_KEY")
+	apiCfg.publicURL = os.Getenv("PUBLIC_URL")
+	apiCfg.pinLimit = envInt("PIN_LIMIT", defaultPinLimit)
+	apiCfg.pinLimitRed = envInt("PIN_LIMIT_RED", defaultPinLimitRed)
+	apiCfg.contentFilter = moderation.Pipeline{&apiCfg.moderationRules}
+	if err := apiCfg.loadModerationRules(context.Background()); err != nil {
+		fmt.Printf("loading moderation rules: %v\n", err)
+		os.Exit(1)
 	}
-	err = server.ListenAndServe()
+	go apiCfg.watchModerationRules(context.Background())
+	blobStore, err := newBlobStore()
 	if err != nil {
-		fmt.Printf("%s\n", err)
+		fmt.Printf("%v\n", err)
 		os.Exit(1)
 	}
+	apiCfg.blobStore = blobStore
+	go apiCfg.reapOrphanedMedia(context.Background())
+	go apiCfg.publishScheduledChirps(context.Background())
+	go apiCfg.reapExpiredChirps(context.Background())
+	go apiCfg.reapExpiredMutedWords(context.Background())
+	go apiCfg.purgeDeletedChirps(context.Background())
+	go apiCfg.deliverWebhooks(context.Background())
+	apiCfg.federation = &activitypub.Client{
+		HTTP: federationHTTPClient,
+		Scheme: os.Getenv("FEDERATION_SCHEME"),
+	}
+	go apiCfg.deliverActivities(context.Background())
+	apiCfg.hub = stream.NewHub()
+	go apiCfg.listenForEvents(context.Background(), dbURL)
+	apiCfg.gateway = gateway.NewRegistry()
+	apiCfg.draining = make(chan struct{})
+	apiCfg.graphqlSchema = newGraphQLSchema(&apiCfg)
+	server := http.Server {
+		Handler: apiCfg.routes(),
+		Addr: ":8080",
+	}
+	// gRPC is opt-in, so it doesn't take a port where nothing uses it.
+	var grpcServer *grpc.Server
+	var grpcListener net.Listener
+	if grpcAddr := os.Getenv("GRPC_ADDR"); grpcAddr != "" {
+		grpcListener, err = net.Listen("tcp", grpcAddr)
+		if err != nil {
+			fmt.Printf("%v\n", err)
+			os.Exit(1)
+		}
+		grpcServer = apiCfg.newGRPCServer()
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		err := server.ListenAndServe()
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fmt.Printf("%s\n", err)
+			os.Exit(1)
+		}
+	}()
+	if grpcServer != nil {
+		go func() {
+			if err := grpcServer.Serve(grpcListener); err != nil {
+				fmt.Printf("%s\n", err)
+				os.Exit(1)
+			}
+		}()
+	}
+	<-ctx.Done()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := apiCfg.shutdown(shutdownCtx, &server, grpcServer); err != nil {
+		fmt.Printf("shutting down: %v\n", err)
+	}
+}
+
+func (cfg *apiConfig) routes() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.Handle("/app/", http.StripPrefix("/app", cfg.middlewareMetricsInc(http.FileServer(http.Dir(".")))))
+	mux.HandleFunc("GET /api/healthz", HealthzHandler)
+	mux.HandleFunc("GET /admin/metrics", cfg.MetricsHandler)
+	mux.HandleFunc("POST /admin/reset", cfg.ResetHandler)
+	mux.HandleFunc("GET /admin/moderation/rules", cfg.ListModerationRulesHandler)
+	mux.HandleFunc("PUT /admin/moderation/rules", cfg.PutModerationRuleHandler)
+	mux.HandleFunc("DELETE /admin/moderation/rules/{ruleID}", cfg.DeleteModerationRuleHandler)
+	mux.HandleFunc("GET /admin/moderation/flags", cfg.ListChirpFlagsHandler)
+	mux.HandleFunc("POST /admin/moderation/flags/{chirpID}/resolve", cfg.ResolveChirpFlagHandler)
+	mux.HandleFunc("POST /api/users", cfg.UsersHandler)
+	mux.HandleFunc("POST /api/chirps", cfg.ChirpsHandler)
+	mux.HandleFunc("GET /api/chirps", cfg.GetChirpsHandler)
+	mux.HandleFunc("GET /api/chirps/search", cfg.SearchChirpsHandler)
+	mux.HandleFunc("GET /api/chirps/{chirpID}", cfg.GetChirpHandler)
+	mux.HandleFunc("POST /api/login", cfg.LoginHandler)
+	mux.HandleFunc("POST /api/refresh", cfg.RefreshHandler)
+	mux.HandleFunc("POST /api/revoke", cfg.RevokeHandler)
+	mux.HandleFunc("PUT /api/users", cfg.PutUsersHandler)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}", cfg.DeleteChirpHandler)
+	mux.HandleFunc("PUT /api/chirps/{chirpID}", cfg.PutChirpHandler)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/publish", cfg.PublishChirpHandler)
+	mux.HandleFunc("GET /api/drafts", cfg.DraftsHandler)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/restore", cfg.RestoreChirpHandler)
+	mux.HandleFunc("GET /api/trash", cfg.TrashHandler)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/pin", cfg.PinChirpHandler)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/pin", cfg.UnpinChirpHandler)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/poll/votes", cfg.VoteHandler)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/bookmark", cfg.BookmarkHandler)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/bookmark", cfg.UnbookmarkHandler)
+	mux.HandleFunc("GET /api/bookmarks", cfg.BookmarksHandler)
+	mux.HandleFunc("GET /api/bookmarks/collections", cfg.BookmarkCollectionsHandler)
+	mux.HandleFunc("DELETE /api/bookmarks/collections/{collectionID}", cfg.DeleteBookmarkCollectionHandler)
+	mux.HandleFunc("POST /api/users/{handle}/block", cfg.BlockHandler)
+	mux.HandleFunc("DELETE /api/users/{handle}/block", cfg.UnblockHandler)
+	mux.HandleFunc("POST /api/users/{handle}/mute", cfg.MuteHandler)
+	mux.HandleFunc("DELETE /api/users/{handle}/mute", cfg.UnmuteHandler)
+	mux.HandleFunc("GET /api/blocks", cfg.BlocksHandler)
+	mux.HandleFunc("GET /api/mutes", cfg.MutesHandler)
+	mux.HandleFunc("GET /api/muted_words", cfg.MutedWordsHandler)
+	mux.HandleFunc("POST /api/muted_words", cfg.CreateMutedWordHandler)
+	mux.HandleFunc("DELETE /api/muted_words/{wordID}", cfg.DeleteMutedWordHandler)
+	mux.HandleFunc("GET /api/notifications", cfg.NotificationsHandler)
+	mux.HandleFunc("GET /api/stream/chirps", cfg.StreamChirpsHandler)
+	mux.HandleFunc("GET /api/ws", cfg.GatewayHandler)
+	mux.HandleFunc("POST /api/conversations", cfg.StartConversationHandler)
+	mux.HandleFunc("GET /api/conversations", cfg.ConversationsHandler)
+	mux.HandleFunc("GET /api/conversations/{conversationID}", cfg.ConversationHandler)
+	mux.HandleFunc("GET /api/conversations/{conversationID}/messages", cfg.MessagesHandler)
+	mux.HandleFunc("POST /api/conversations/{conversationID}/messages", cfg.SendMessageHandler)
+	mux.HandleFunc("DELETE /api/conversations/{conversationID}/messages/{messageID}", cfg.DeleteMessageHandler)
+	mux.HandleFunc("POST /api/conversations/{conversationID}/read", cfg.MarkConversationReadHandler)
+	mux.HandleFunc("GET /api/dm_settings", cfg.DMSettingsHandler)
+	for _, format := range []string{"atom", "rss", "json"} {
+		mux.HandleFunc("GET /users/{handle}/feed."+format, cfg.UserFeedHandler)
+		mux.HandleFunc("GET /hashtags/{tag}/feed."+format, cfg.HashtagFeedHandler)
+	}
+	mux.HandleFunc("POST /api/webhooks", cfg.CreateWebhookEndpointHandler)
+	mux.HandleFunc("GET /api/webhooks", cfg.WebhookEndpointsHandler)
+	mux.HandleFunc("PUT /api/webhooks/{webhookID}", cfg.PutWebhookEndpointHandler)
+	mux.HandleFunc("DELETE /api/webhooks/{webhookID}", cfg.DeleteWebhookEndpointHandler)
+	mux.HandleFunc("GET /api/webhooks/{webhookID}/deliveries", cfg.WebhookDeliveriesHandler)
+	mux.HandleFunc("POST /api/webhooks/{webhookID}/deliveries/{deliveryID}/redeliver", cfg.RedeliverWebhookHandler)
+	mux.HandleFunc("PUT /api/dm_settings", cfg.PutDMSettingsHandler)
+	mux.HandleFunc("POST /api/notifications/read", cfg.MarkNotificationsReadHandler)
+	mux.HandleFunc("GET /api/notifications/preferences", cfg.NotificationPreferencesHandler)
+	mux.HandleFunc("PUT /api/notifications/preferences", cfg.PutNotificationPreferencesHandler)
+	mux.HandleFunc("GET /admin/chirps/deleted", cfg.AdminDeletedChirpsHandler)
+	mux.HandleFunc("GET /admin/chirps/{chirpID}", cfg.AdminChirpHandler)
+	mux.HandleFunc("DELETE /admin/chirps/{chirpID}", cfg.AdminDeleteChirpHandler)
+	mux.HandleFunc("POST /api/polka/webhooks", cfg.WebhooksHandler)
+	mux.HandleFunc("GET /api/hashtags/{tag}/chirps", cfg.HashtagChirpsHandler)
+	mux.HandleFunc("POST /api/media", cfg.UploadMediaHandler)
+	mux.HandleFunc("PUT /api/media/{mediaID}", cfg.PutMediaHandler)
+	mux.HandleFunc("GET /api/media/{mediaID}", cfg.GetMediaFileHandler)
+	mux.HandleFunc("GET /api/media/{mediaID}/thumbnail", cfg.GetMediaThumbnailHandler)
+	mux.HandleFunc("GET /api/users/{id}/mentions", cfg.MentionsHandler)
+	mux.HandleFunc("PUT /api/users/profile", cfg.PutProfileHandler)
+	mux.HandleFunc("GET /api/users/{handle}", cfg.ProfileHandler)
+	mux.HandleFunc("POST /api/users/{handle}/follow", cfg.FollowHandler)
+	mux.HandleFunc("DELETE /api/users/{handle}/follow", cfg.UnfollowHandler)
+	mux.HandleFunc("GET /api/timeline", cfg.HomeTimelineHandler)
+	mux.HandleFunc("POST /api/remote_follows", cfg.RemoteFollowHandler)
+	mux.HandleFunc("GET /api/remote_follows", cfg.RemoteFollowsHandler)
+	mux.HandleFunc("DELETE /api/remote_follows/{remoteActorID}", cfg.RemoteUnfollowHandler)
+	mux.HandleFunc("GET /.well-known/webfinger", cfg.WebFingerHandler)
+	mux.HandleFunc("GET /ap/users/{userID}", cfg.ActorHandler)
+	mux.HandleFunc("GET /ap/users/{userID}/outbox", cfg.OutboxHandler)
+	mux.HandleFunc("GET /ap/users/{userID}/followers", cfg.FollowersHandler)
+	mux.HandleFunc("GET /ap/users/{userID}/following", cfg.FollowingHandler)
+	mux.HandleFunc("POST /ap/users/{userID}/inbox", cfg.InboxHandler)
+	mux.HandleFunc("POST /ap/inbox", cfg.InboxHandler)
+	mux.HandleFunc("GET /ap/chirps/{chirpID}", cfg.NoteHandler)
+	mux.HandleFunc("POST /graphql", cfg.GraphQLHandler)
+	return mux
 }
 
 func HealthzHandler(writer http.ResponseWriter, request *http.Request) {
@@ -92,6 +271,11 @@ func (cfg *apiConfig) ChirpsHandler(writer http.ResponseWriter, request *http.Re
 	writer.Header().Add("Content-Type", "text/json; charset=utf-8")
 	type parameters struct {
         Body string `json:"body"`
+		MediaIDs []uuid.UUID `json:"media_ids"`
+		Status string `json:"status"`
+		PublishAt *time.Time `json:"publish_at"`
+		ExpiresIn *int `json:"expires_in"`
+		Poll *pollParams `json:"poll"`
     }
 	type errorObj struct {
 		Error string `json:"error"`
@@ -126,62 +310,126 @@ func (cfg *apiConfig) ChirpsHandler(writer http.ResponseWriter, request *http.Re
 		writer.Write(dat)
 		return
 	}
-	if len(params.Body) > 140 {
-		errObj := errorObj{
-			Error: "Chirp is too long",
-		}
-		dat, _ := json.Marshal(errObj)
-		writer.WriteHeader(400)
-		writer.Write(dat)
+	if len(params.MediaIDs) > maxMediaPerChirp {
+		respondWithError(writer, 400, fmt.Sprintf("chirps can have at most %d attachments", maxMediaPerChirp))
+		return
+	}
+	status, publishAt, err := chirpSchedule(params.Status, params.PublishAt)
+	if err != nil {
+		respondWithError(writer, 400, err.Error())
+		return
+	}
+	expiresAt, err := cfg.chirpExpiry(request.Context(), id, params.ExpiresIn, status, publishAt)
+	if err != nil {
+		respondWithError(writer, 400, err.Error())
+		return
+	}
+	moderated, err := cfg.moderateChirp(params.Body)
+	if err != nil {
+		respondWithError(writer, 400, err.Error())
 		return
 	}
-	splitString := strings.Split(params.Body, " ")
-	for i, word := range splitString {
-		if strings.ToLower(word) == "kerfuffle" || strings.ToLower(word) == "sharbert" || strings.ToLower(word) == "fornax" {
-			splitString[i] = "****"
+	var pollOptions []string
+	if params.Poll != nil {
+		pollOptions, err = cfg.preparePoll(params.Poll, status, publishAt)
+		if err != nil {
+			respondWithError(writer, 400, err.Error())
+			return
 		}
 	}
-	result, err := cfg.dbQueries.CreateChirp(request.Context(), database.CreateChirpParams{
-		Body: strings.Join(splitString, " "),
+	tx, err := cfg.db.BeginTx(request.Context(), nil)
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+	result, err := qtx.CreateChirp(request.Context(), database.CreateChirpParams{
+		Body: moderated.Body,
 		UserID: id,
+		Status: status,
+		PublishAt: publishAt,
+		ExpiresAt: expiresAt,
 	})
 	if err != nil {
-		errObj := errorObj {
-			Error: err.Error(),
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	if err := saveChirpDetails(request.Context(), qtx, result, moderated); err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	if err := attachMedia(request.Context(), qtx, result, params.MediaIDs); err != nil {
+		if errors.Is(err, errUnknownMedia) {
+			respondWithError(writer, 400, err.Error())
+			return
 		}
-		dat, _ := json.Marshal(errObj)
-		writer.WriteHeader(500)
-		writer.Write(dat)
+		respondWithError(writer, 500, err.Error())
+		return
 	}
-	dat, err := json.Marshal(result)
-	if err != nil {
-		errObj := errorObj {
-			Error: err.Error(),
+	if params.Poll != nil {
+		if err := createPoll(request.Context(), qtx, result, pollOptions, params.Poll.ClosesAt); err != nil {
+			respondWithError(writer, 500, err.Error())
+			return
 		}
-		dat, _ := json.Marshal(errObj)
-		writer.WriteHeader(500)
-		writer.Write(dat)
 	}
-	writer.WriteHeader(201)
-	writer.Write(dat)
-	return
+	if result.Status == statusPublished {
+		if err := cfg.chirpPublished(request.Context(), qtx, result); err != nil {
+			respondWithError(writer, 500, err.Error())
+			return
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	chirp, err := cfg.chirpResponseFor(request.Context(), id, result)
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	respondWithJSON(writer, 201, chirp)
 }
 
 func (cfg *apiConfig) UsersHandler(writer http.ResponseWriter, request *http.Request) {
 	type parameters struct {
 		Password string `json:"password"`
         Email string `json:"email"`
+		Handle *string `json:"handle"`
     }
 	decoder := json.NewDecoder(request.Body)
     var params parameters
     decoder.Decode(&params)
+	if params.Handle != nil {
+		if err := handles.Validate(*params.Handle); err != nil {
+			respondWithError(writer, 400, err.Error())
+			return
+		}
+		if _, err := cfg.dbQueries.GetHandleRedirect(request.Context(), handles.Normalize(*params.Handle)); err == nil {
+			respondWithError(writer, 409, "handle is taken")
+			return
+		}
+	}
 	hashedPassword, _ := auth.HashPassword(params.Password)
 	user, err := cfg.dbQueries.CreateUser(request.Context(), database.CreateUserParams{
 		Email: params.Email,
 		HashedPassword: hashedPassword,
+		Handle: nullString(params.Handle),
 	})
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		// The handle pre-check above can race with another signup; the
+		// unique indexes on email and handle settle it.
+		if pqErr.Constraint == "users_email_key" {
+			respondWithError(writer, 409, "email is taken")
+		} else {
+			respondWithError(writer, 409, "handle is taken")
+		}
+		return
+	}
 	if err != nil {
-		fmt.Printf("%v\n", err)
+		respondWithError(writer, 500, err.Error())
+		return
 	}
 	dat, _ := json.Marshal(user)
 	writer.WriteHeader(201)
@@ -189,26 +437,103 @@ func (cfg *apiConfig) UsersHandler(writer http.ResponseWriter, request *http.Req
 }
 
 func (cfg *apiConfig) GetChirpsHandler(writer http.ResponseWriter, request *http.Request) {
-	sortOp, exist := request.URL.Query()["sort"]
-	if !exist {
-		sortOp[0] = "asc"
- 	}
- 	authorID, exists := request.URL.Query()["author_id"]
+	query := request.URL.Query()
+	page, err := parsePageParams(query)
+	if err != nil {
+		respondWithError(writer, 400, err.Error())
+		return
+	}
+	sortOp := query.Get("sort")
+	if sortOp == "" {
+		sortOp = "asc"
+	}
+	if sortOp != "asc" && sortOp != "desc" {
+		respondWithError(writer, 400, "sort must be asc or desc")
+		return
+	}
+	pinnedFirst := query.Get("pinned_first") == "true"
+	viewer := cfg.viewer(request)
 	var result []database.Chirp
-	if exists {
-		id, _ := uuid.Parse(authorID[0])
-		result, _ = cfg.dbQueries.GetChirpsByUser(request.Context(), id)
+	if authorID := query.Get("author_id"); authorID != "" {
+		id, err := uuid.Parse(authorID)
+		if err != nil {
+			respondWithError(writer, 400, "invalid author_id")
+			return
+		}
+		result, err = cfg.getChirpsByUser(request.Context(), id, viewer, sortOp == "desc", pinnedFirst, page)
+		if err != nil {
+			respondWithError(writer, 500, err.Error())
+			return
+		}
+		if pinnedFirst && !page.CursorCreatedAt.Valid {
+			pinned, err := cfg.dbQueries.GetPinnedChirps(request.Context(), database.GetPinnedChirpsParams{
+				UserID: id,
+				ViewerID: viewer,
+			})
+			if err != nil {
+				respondWithError(writer, 500, err.Error())
+				return
+			}
+			cfg.respondWithPinnedChirpPage(writer, request, pinned, result, page)
+			return
+		}
+	} else if pinnedFirst {
+		respondWithError(writer, 400, "pinned_first requires author_id")
+		return
 	} else {
-		result, _ = cfg.dbQueries.GetAllChirps(request.Context())
+		result, err = cfg.getAllChirps(request.Context(), viewer, sortOp == "desc", page)
+	}
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
 	}
-	if sortOp[0] == "desc" {
-		sort.Slice(result, func(i, j int) bool {
-    		return result[i].CreatedAt.After(result[j].CreatedAt)
+	cfg.respondWithChirpPage(writer, request, result, page)
+}
+
+func (cfg *apiConfig) getAllChirps(ctx context.Context, viewer uuid.NullUUID, desc bool, page pageParams) ([]database.Chirp, error) {
+	if desc {
+		return cfg.dbQueries.GetAllChirpsDesc(ctx, database.GetAllChirpsDescParams{
+			Since: page.Since,
+			Until: page.Until,
+			CursorCreatedAt: page.CursorCreatedAt,
+			CursorID: page.CursorID,
+			ViewerID: viewer,
+			Limit: page.fetchLimit(),
 		})
 	}
-	dat, _ := json.Marshal(result)
-	writer.WriteHeader(200)
-	writer.Write(dat)
+	return cfg.dbQueries.GetAllChirps(ctx, database.GetAllChirpsParams{
+		Since: page.Since,
+		Until: page.Until,
+		CursorCreatedAt: page.CursorCreatedAt,
+		CursorID: page.CursorID,
+		ViewerID: viewer,
+		Limit: page.fetchLimit(),
+	})
+}
+
+func (cfg *apiConfig) getChirpsByUser(ctx context.Context, userID uuid.UUID, viewer uuid.NullUUID, desc bool, excludePinned bool, page pageParams) ([]database.Chirp, error) {
+	if desc {
+		return cfg.dbQueries.GetChirpsByUserDesc(ctx, database.GetChirpsByUserDescParams{
+			UserID: userID,
+			ExcludePinned: excludePinned,
+			Since: page.Since,
+			Until: page.Until,
+			CursorCreatedAt: page.CursorCreatedAt,
+			CursorID: page.CursorID,
+			ViewerID: viewer,
+			Limit: page.fetchLimit(),
+		})
+	}
+	return cfg.dbQueries.GetChirpsByUser(ctx, database.GetChirpsByUserParams{
+		UserID: userID,
+		ExcludePinned: excludePinned,
+		Since: page.Since,
+		Until: page.Until,
+		CursorCreatedAt: page.CursorCreatedAt,
+		CursorID: page.CursorID,
+		ViewerID: viewer,
+		Limit: page.fetchLimit(),
+	})
 }
 
 func (cfg *apiConfig) GetChirpHandler(writer http.ResponseWriter, request *http.Request) {
@@ -222,9 +547,26 @@ func (cfg *apiConfig) GetChirpHandler(writer http.ResponseWriter, request *http.
 		writer.WriteHeader(404)
 		return
 	}
-	dat, _ := json.Marshal(result)
-	writer.WriteHeader(200)
-	writer.Write(dat)
+	viewerID, _ := cfg.authenticatedUserID(request)
+	if !canView(result, viewerID) {
+		writer.WriteHeader(404)
+		return
+	}
+	blocked, err := cfg.isBlocked(request.Context(), viewerID, result.UserID)
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	if blocked {
+		writer.WriteHeader(404)
+		return
+	}
+	chirp, err := cfg.chirpResponseFor(request.Context(), viewerID, result)
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	respondWithJSON(writer, 200, chirp)
 }
 
 func (cfg *apiConfig) LoginHandler(writer http.ResponseWriter, request *http.Request) {
@@ -237,6 +579,7 @@ func (cfg *apiConfig) LoginHandler(writer http.ResponseWriter, request *http.Req
 		CreatedAt time.Time `json:"created_at"`
 		UpdatedAt time.Time `json:"updated_at"`
 		Email string `json:"email"`
+		Handle string `json:"handle"`
 		Token string `json:"token"`
 		RefreshToken string `json:"refresh_token"`
 		IsChirpyRed bool `json:"is_chirpy_red"`
@@ -282,6 +625,7 @@ func (cfg *apiConfig) LoginHandler(writer http.ResponseWriter, request *http.Req
 		CreatedAt: user.CreatedAt,
 		UpdatedAt: user.UpdatedAt,
 		Email: user.Email,
+		Handle: user.Handle,
 		Token: token,
 		RefreshToken: rt.Token,
 		IsChirpyRed: user.IsChirpyRed,
@@ -364,6 +708,7 @@ func (cfg *apiConfig) PutUsersHandler(writer http.ResponseWriter, request *http.
 	type parameters struct {
 		Email string `json:"email"`
 		Password string `json:"password"`
+		Handle *string `json:"handle"`
 	}
 	decoder := json.NewDecoder(request.Body)
     var params parameters
@@ -379,6 +724,14 @@ func (cfg *apiConfig) PutUsersHandler(writer http.ResponseWriter, request *http.
 		writer.WriteHeader(401)
 		return
 	}
+	if params.Handle != nil {
+		var code int
+		user, code, err = cfg.changeHandle(request.Context(), user, *params.Handle)
+		if err != nil {
+			respondWithError(writer, code, err.Error())
+			return
+		}
+	}
 	dat, err := json.Marshal(user)
 	if err != nil {
 		writer.WriteHeader(401)
@@ -419,9 +772,8 @@ func (cfg *apiConfig) DeleteChirpHandler(writer http.ResponseWriter, request *ht
 		writer.Write([]byte("wrong user"))
 		return
 	}
-	if err := cfg.dbQueries.DeleteChirp(request.Context(), result.ID); err != nil {
-		writer.WriteHeader(401)
-		writer.Write([]byte(err.Error()))
+	if err := cfg.deleteChirp(request.Context(), result); err != nil {
+		respondWithError(writer, 500, err.Error())
 		return
 	}
 	writer.WriteHeader(204)
@@ -450,15 +802,86 @@ func (cfg *apiConfig) WebhooksHandler(writer http.ResponseWriter, request *http.
 		writer.WriteHeader(204)
 		return
 	}
-	if err := cfg.dbQueries.UpgradeUser(request.Context(), params.Data.UserID); err != nil {
+	tx, err := cfg.db.BeginTx(request.Context(), nil)
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+	if err := qtx.UpgradeUser(request.Context(), params.Data.UserID); err != nil {
 		writer.WriteHeader(401)
 		writer.Write([]byte(err.Error()))
 		return
 	}
+	if err := notify(request.Context(), qtx, notificationEvent{
+		UserID: params.Data.UserID,
+		Type: notificationSubscription,
+		Detail: params.Event,
+	}); err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
 	writer.WriteHeader(204)
 	return
 }
 
+func newBlobStore() (storage.BlobStore, error) {
+	if os.Getenv("MEDIA_STORE") == "s3" {
+		return storage.NewS3Store(storage.S3Config{
+			Endpoint: os.Getenv("S3_ENDPOINT"),
+			Region: os.Getenv("S3_REGION"),
+			Bucket: os.Getenv("S3_BUCKET"),
+			AccessKeyID: os.Getenv("S3_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+		})
+	}
+	dir := os.Getenv("MEDIA_DIR")
+	if dir == "" {
+		dir = "media"
+	}
+	return storage.NewFileStore(dir)
+}
+
+// shutdown stops taking requests and gives the ones in flight, streams and
+// gateway connections included, until ctx is done to wrap up. grpcServer
+// is nil when gRPC isn't enabled.
+func (cfg *apiConfig) shutdown(ctx context.Context, server *http.Server, grpcServer *grpc.Server) error {
+	close(cfg.draining)
+	drained := make(chan error, 1)
+	go func() {
+		drained <- cfg.gateway.Drain(ctx)
+	}()
+	grpcStopped := make(chan struct{})
+	go func() {
+		if grpcServer != nil {
+			grpcServer.GracefulStop()
+		}
+		close(grpcStopped)
+	}()
+	err := server.Shutdown(ctx)
+	select {
+	case <-grpcStopped:
+	case <-ctx.Done():
+		if grpcServer != nil {
+			grpcServer.Stop()
+		}
+	}
+	return errors.Join(err, <-drained)
+}
+
+func (cfg *apiConfig) authenticatedUserID(request *http.Request) (uuid.UUID, error) {
+	token, err := auth.GetBearerToken(request.Header)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return auth.ValidateJWT(token, cfg.tokenSecret)
+}
+
 func (cfg *apiConfig) middlewareMetricsInc(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		cfg.fileserverHits.Add(1)
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Baehry/chirpy/internal/gateway"
+)
+
+func TestShutdownWithoutGRPC(t *testing.T) {
+	cfg := &apiConfig{gateway: gateway.NewRegistry(), draining: make(chan struct{})}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := cfg.shutdown(ctx, &http.Server{}, nil); err != nil {
+		t.Logf("expected a clean shutdown, got %v\n", err)
+		t.Fail()
+	}
+}
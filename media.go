@@ -0,0 +1,309 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Baehry/chirpy/internal/database"
+	"github.com/Baehry/chirpy/internal/media"
+	"github.com/Baehry/chirpy/internal/storage"
+	"github.com/google/uuid"
+)
+
+const (
+	maxMediaPerChirp = 4
+	maxAltTextLength = 1000
+	// Uploads that never make it into a chirp, and the files of deleted
+	// chirps, are removed after this long.
+	orphanedMediaTTL = 24 * time.Hour
+	mediaReapInterval = time.Hour
+)
+
+type mediaResponse struct {
+	ID uuid.UUID `json:"id"`
+	URL string `json:"url"`
+	ThumbnailURL string `json:"thumbnail_url"`
+	ContentType string `json:"content_type"`
+	Width int32 `json:"width"`
+	Height int32 `json:"height"`
+	AltText string `json:"alt_text"`
+}
+
+func newMediaResponse(medium database.Medium) mediaResponse {
+	return mediaResponse{
+		ID: medium.ID,
+		URL: "/api/media/" + medium.ID.String(),
+		ThumbnailURL: "/api/media/" + medium.ID.String() + "/thumbnail",
+		ContentType: medium.ContentType,
+		Width: medium.Width,
+		Height: medium.Height,
+		AltText: medium.AltText,
+	}
+}
+
+func originalKey(id uuid.UUID) string {
+	return "media/" + id.String() + "/original"
+}
+
+func thumbnailKey(id uuid.UUID) string {
+	return "media/" + id.String() + "/thumbnail"
+}
+
+func (cfg *apiConfig) UploadMediaHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, err := cfg.authenticatedUserID(request)
+	if err != nil {
+		respondWithError(writer, 401, err.Error())
+		return
+	}
+	// Leave some room for the rest of the multipart form.
+	request.Body = http.MaxBytesReader(writer, request.Body, media.MaxUploadSize+64<<10)
+	if err := request.ParseMultipartForm(media.MaxUploadSize); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			respondWithError(writer, 413, fmt.Sprintf("uploads are limited to %d bytes", media.MaxUploadSize))
+			return
+		}
+		respondWithError(writer, 400, err.Error())
+		return
+	}
+	altText := request.FormValue("alt_text")
+	if utf8.RuneCountInString(altText) > maxAltTextLength {
+		respondWithError(writer, 400, "alt text is too long")
+		return
+	}
+	file, _, err := request.FormFile("file")
+	if err != nil {
+		respondWithError(writer, 400, "file is required")
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, media.MaxUploadSize+1))
+	if err != nil {
+		respondWithError(writer, 400, err.Error())
+		return
+	}
+	if len(data) > media.MaxUploadSize {
+		respondWithError(writer, 413, fmt.Sprintf("uploads are limited to %d bytes", media.MaxUploadSize))
+		return
+	}
+	processed, err := media.Process(data)
+	if errors.Is(err, media.ErrUnsupportedType) {
+		respondWithError(writer, 415, err.Error())
+		return
+	}
+	if err != nil {
+		respondWithError(writer, 400, err.Error())
+		return
+	}
+	// The row goes in first, so blobs are never stored without one: if a
+	// Put fails it's removed again, and if we die before that it's an
+	// orphan for reapOrphanedMedia.
+	medium, err := cfg.dbQueries.CreateMedia(request.Context(), database.CreateMediaParams{
+		ID: uuid.New(),
+		UserID: userID,
+		ContentType: processed.Original.ContentType,
+		Size: int32(len(processed.Original.Data)),
+		Width: int32(processed.Original.Width),
+		Height: int32(processed.Original.Height),
+		ThumbnailWidth: int32(processed.Thumbnail.Width),
+		ThumbnailHeight: int32(processed.Thumbnail.Height),
+		AltText: altText,
+	})
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	err = cfg.blobStore.Put(request.Context(), originalKey(medium.ID), processed.Original.Data, processed.Original.ContentType)
+	if err == nil {
+		err = cfg.blobStore.Put(request.Context(), thumbnailKey(medium.ID), processed.Thumbnail.Data, processed.Thumbnail.ContentType)
+	}
+	if err != nil {
+		if cleanupErr := cfg.deleteMedia(context.WithoutCancel(request.Context()), medium); cleanupErr != nil {
+			fmt.Printf("deleting media %s: %v\n", medium.ID, cleanupErr)
+		}
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	respondWithJSON(writer, 201, newMediaResponse(medium))
+}
+
+func (cfg *apiConfig) PutMediaHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, err := cfg.authenticatedUserID(request)
+	if err != nil {
+		respondWithError(writer, 401, err.Error())
+		return
+	}
+	id, err := uuid.Parse(request.PathValue("mediaID"))
+	if err != nil {
+		respondWithError(writer, 404, "media not found")
+		return
+	}
+	type parameters struct {
+		AltText string `json:"alt_text"`
+	}
+	decoder := json.NewDecoder(request.Body)
+	var params parameters
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(writer, 400, err.Error())
+		return
+	}
+	if utf8.RuneCountInString(params.AltText) > maxAltTextLength {
+		respondWithError(writer, 400, "alt text is too long")
+		return
+	}
+	medium, err := cfg.dbQueries.UpdateMediaAltText(request.Context(), database.UpdateMediaAltTextParams{
+		ID: id,
+		UserID: userID,
+		AltText: params.AltText,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(writer, 404, "media not found")
+		return
+	}
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	respondWithJSON(writer, 200, newMediaResponse(medium))
+}
+
+func (cfg *apiConfig) GetMediaFileHandler(writer http.ResponseWriter, request *http.Request) {
+	cfg.serveMedia(writer, request, originalKey)
+}
+
+func (cfg *apiConfig) GetMediaThumbnailHandler(writer http.ResponseWriter, request *http.Request) {
+	cfg.serveMedia(writer, request, thumbnailKey)
+}
+
+func (cfg *apiConfig) serveMedia(writer http.ResponseWriter, request *http.Request, key func(uuid.UUID) string) {
+	id, err := uuid.Parse(request.PathValue("mediaID"))
+	if err != nil {
+		respondWithError(writer, 404, "media not found")
+		return
+	}
+	medium, err := cfg.dbQueries.GetMedia(request.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(writer, 404, "media not found")
+		return
+	}
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	viewerID, _ := cfg.authenticatedUserID(request)
+	visible, err := cfg.canViewMedia(request.Context(), medium, viewerID)
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	if !visible {
+		respondWithError(writer, 404, "media not found")
+		return
+	}
+	// The file never changes, but whether this viewer may see it does, so
+	// caches revalidate every time and get a 304 while it's still visible.
+	etag := `"` + key(id) + `"`
+	writer.Header().Set("ETag", etag)
+	writer.Header().Set("Cache-Control", "private, no-cache")
+	if request.Header.Get("If-None-Match") == etag {
+		writer.WriteHeader(304)
+		return
+	}
+	body, contentType, err := cfg.blobStore.Get(request.Context(), key(id))
+	if errors.Is(err, storage.ErrNotFound) {
+		respondWithError(writer, 404, "media not found")
+		return
+	}
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	defer body.Close()
+	writer.Header().Set("Content-Type", contentType)
+	writer.Header().Set("X-Content-Type-Options", "nosniff")
+	writer.WriteHeader(200)
+	io.Copy(writer, body)
+}
+
+// canViewMedia applies the chirp it's attached to's visibility to an
+// upload: GetChirp's rules, drafts and blocks. Uploads that aren't attached
+// yet are only visible to their owner.
+func (cfg *apiConfig) canViewMedia(ctx context.Context, medium database.Medium, viewerID uuid.UUID) (bool, error) {
+	if !medium.ChirpID.Valid {
+		return viewerID == medium.UserID, nil
+	}
+	chirp, err := cfg.dbQueries.GetChirp(ctx, medium.ChirpID.UUID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if !canView(chirp, viewerID) {
+		return false, nil
+	}
+	blocked, err := cfg.isBlocked(ctx, viewerID, chirp.UserID)
+	return !blocked, err
+}
+
+// attachMedia links uploads to a new chirp. Every upload must belong to the
+// author and not be attached to anything yet.
+func attachMedia(ctx context.Context, q *database.Queries, chirp database.Chirp, ids []uuid.UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	attached, err := q.AttachMedia(ctx, database.AttachMediaParams{
+		ChirpID: uuid.NullUUID{UUID: chirp.ID, Valid: true},
+		Ids: ids,
+		UserID: chirp.UserID,
+	})
+	if err != nil {
+		return err
+	}
+	if attached != int64(len(ids)) {
+		return errUnknownMedia
+	}
+	return nil
+}
+
+var errUnknownMedia = errors.New("media_ids must be your own uploads that aren't attached to a chirp yet")
+
+func (cfg *apiConfig) deleteMedia(ctx context.Context, medium database.Medium) error {
+	if err := cfg.blobStore.Delete(ctx, originalKey(medium.ID)); err != nil {
+		return err
+	}
+	if err := cfg.blobStore.Delete(ctx, thumbnailKey(medium.ID)); err != nil {
+		return err
+	}
+	return cfg.dbQueries.DeleteMedia(ctx, medium.ID)
+}
+
+// reapOrphanedMedia deletes uploads that aren't attached to any chirp,
+// either because they never were or because their chirp was deleted.
+func (cfg *apiConfig) reapOrphanedMedia(ctx context.Context) {
+	ticker := time.NewTicker(mediaReapInterval)
+	defer ticker.Stop()
+	for {
+		orphans, err := cfg.dbQueries.ListOrphanedMedia(ctx, time.Now().UTC().Add(-orphanedMediaTTL))
+		if err != nil {
+			fmt.Printf("listing orphaned media: %v\n", err)
+		}
+		for _, medium := range orphans {
+			if err := cfg.deleteMedia(ctx, medium); err != nil {
+				fmt.Printf("deleting media %s: %v\n", medium.ID, err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,771 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Baehry/chirpy/internal/database"
+	"github.com/Baehry/chirpy/internal/pagination"
+	"github.com/google/uuid"
+)
+
+const (
+	dmPolicyEveryone = "everyone"
+	dmPolicyFollowing = "following"
+	dmPolicyNobody = "nobody"
+
+	maxMessageLength = 1000
+	maxConversationTitleLength = 100
+	// maxConversationMembers includes whoever starts the conversation.
+	maxConversationMembers = 10
+)
+
+type messageResponse struct {
+	ID uuid.UUID `json:"id"`
+	ConversationID uuid.UUID `json:"conversation_id"`
+	SenderID uuid.UUID `json:"sender_id"`
+	Body string `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+	Deleted bool `json:"deleted"`
+}
+
+func newMessageResponse(message database.Message) messageResponse {
+	return messageResponse{
+		ID: message.ID,
+		ConversationID: message.ConversationID,
+		SenderID: message.SenderID,
+		Body: message.Body,
+		CreatedAt: message.CreatedAt,
+		Deleted: message.DeletedAt.Valid,
+	}
+}
+
+type conversationResponse struct {
+	ID uuid.UUID `json:"id"`
+	IsGroup bool `json:"is_group"`
+	Title string `json:"title"`
+	Members []userSummaryResponse `json:"members"`
+	LastMessage *messageResponse `json:"last_message"`
+	UnreadCount int64 `json:"unread_count"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// conversationTopic is the hub topic that carries a conversation's messages
+// and typing signals.
+func conversationTopic(conversationID uuid.UUID) string {
+	return "conversation:" + conversationID.String()
+}
+
+// directKey identifies the one-to-one conversation between two users,
+// whichever of them starts it.
+func directKey(userID, otherID uuid.UUID) string {
+	ids := []string{userID.String(), otherID.String()}
+	slices.Sort(ids)
+	return strings.Join(ids, ":")
+}
+
+// canMessage reports whether sender may message recipient: neither has
+// blocked the other, and recipient's DM setting lets sender in.
+func (cfg *apiConfig) canMessage(ctx context.Context, q *database.Queries, senderID, recipientID uuid.UUID) (bool, error) {
+	blocked, err := q.IsBlocked(ctx, database.IsBlockedParams{
+		UserID: senderID,
+		OtherID: recipientID,
+	})
+	if err != nil || blocked {
+		return false, err
+	}
+	policy, err := q.GetDMPolicy(ctx, recipientID)
+	if err != nil {
+		return false, err
+	}
+	follows := false
+	if policy == dmPolicyFollowing {
+		follows, err = q.IsFollowing(ctx, database.IsFollowingParams{
+			FollowerID: recipientID,
+			FolloweeID: senderID,
+		})
+		if err != nil {
+			return false, err
+		}
+	}
+	return dmAllowed(policy, blocked, follows), nil
+}
+
+// dmAllowed applies a recipient's DM policy. follows is whether the
+// recipient follows the sender.
+func dmAllowed(policy string, blocked bool, follows bool) bool {
+	if blocked {
+		return false
+	}
+	switch policy {
+	case dmPolicyEveryone:
+		return true
+	case dmPolicyFollowing:
+		return follows
+	}
+	return false
+}
+
+// checkRecipients makes sure everyone the sender adds to a conversation
+// would take a direct message from the sender and from everyone else added,
+// so a group can't be used to reach someone who wouldn't. It returns a
+// status and error for the first pair that fails.
+func (cfg *apiConfig) checkRecipients(ctx context.Context, q *database.Queries, senderID uuid.UUID, recipients []database.User) (int, error) {
+	for _, recipient := range recipients {
+		if recipient.ID == senderID {
+			return 400, errors.New("you can't message yourself")
+		}
+		allowed, err := cfg.canMessage(ctx, q, senderID, recipient.ID)
+		if err != nil {
+			return 500, err
+		}
+		if !allowed {
+			return 403, fmt.Errorf("@%s isn't accepting messages from you", recipient.Handle)
+		}
+	}
+	for _, recipient := range recipients {
+		for _, other := range recipients {
+			if other.ID == recipient.ID {
+				continue
+			}
+			allowed, err := cfg.canMessage(ctx, q, other.ID, recipient.ID)
+			if err != nil {
+				return 500, err
+			}
+			if !allowed {
+				return 403, fmt.Errorf("@%s isn't accepting messages from @%s", recipient.Handle, other.Handle)
+			}
+		}
+	}
+	return 0, nil
+}
+
+func validateMessage(body string) error {
+	if strings.TrimSpace(body) == "" {
+		return errors.New("message is empty")
+	}
+	if utf8.RuneCountInString(body) > maxMessageLength {
+		return fmt.Errorf("message is longer than %d characters", maxMessageLength)
+	}
+	return nil
+}
+
+// sendMessage stores a message and announces it to the conversation's
+// gateway topic once the transaction commits.
+func (cfg *apiConfig) sendMessage(ctx context.Context, q *database.Queries, conversationID, senderID uuid.UUID, body string) (database.Message, error) {
+	message, err := q.CreateMessage(ctx, database.CreateMessageParams{
+		ConversationID: conversationID,
+		SenderID: senderID,
+		Body: body,
+	})
+	if err != nil {
+		return message, err
+	}
+	if err := q.TouchConversation(ctx, conversationID); err != nil {
+		return message, err
+	}
+	return message, cfg.sendSignal(ctx, q, conversationTopic(conversationID), "message", newMessageResponse(message))
+}
+
+// conversationResponses builds the JSON for conversations as seen by
+// userID.
+func (cfg *apiConfig) conversationResponses(ctx context.Context, userID uuid.UUID, conversations []database.GetConversationsRow) ([]conversationResponse, error) {
+	ids := make([]uuid.UUID, len(conversations))
+	for i, conversation := range conversations {
+		ids[i] = conversation.ID
+	}
+	members, err := cfg.dbQueries.GetConversationMembers(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	membersByConversation := map[uuid.UUID][]userSummaryResponse{}
+	for _, member := range members {
+		membersByConversation[member.ConversationID] = append(membersByConversation[member.ConversationID], userSummaryResponse{
+			ID: member.ID,
+			Handle: member.Handle,
+			DisplayName: member.DisplayName,
+			AvatarURL: member.AvatarUrl,
+		})
+	}
+	lastMessages, err := cfg.dbQueries.GetLastMessages(ctx, database.GetLastMessagesParams{
+		ConversationIds: ids,
+		ViewerID: userID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	lastMessage := map[uuid.UUID]*messageResponse{}
+	for _, message := range lastMessages {
+		response := newMessageResponse(message)
+		lastMessage[message.ConversationID] = &response
+	}
+	result := make([]conversationResponse, len(conversations))
+	for i, conversation := range conversations {
+		result[i] = conversationResponse{
+			ID: conversation.ID,
+			IsGroup: conversation.IsGroup,
+			Title: conversation.Title,
+			Members: membersByConversation[conversation.ID],
+			LastMessage: lastMessage[conversation.ID],
+			UnreadCount: conversation.UnreadCount,
+			CreatedAt: conversation.CreatedAt,
+			UpdatedAt: conversation.UpdatedAt,
+		}
+		if result[i].Members == nil {
+			result[i].Members = []userSummaryResponse{}
+		}
+	}
+	return result, nil
+}
+
+func (cfg *apiConfig) conversationResponseFor(ctx context.Context, userID, conversationID uuid.UUID) (conversationResponse, error) {
+	conversation, err := cfg.dbQueries.GetConversationForMember(ctx, database.GetConversationForMemberParams{
+		UserID: userID,
+		ID: conversationID,
+	})
+	if err != nil {
+		return conversationResponse{}, err
+	}
+	result, err := cfg.conversationResponses(ctx, userID, []database.GetConversationsRow{database.GetConversationsRow(conversation)})
+	if err != nil {
+		return conversationResponse{}, err
+	}
+	return result[0], nil
+}
+
+// memberConversation resolves {conversationID} and makes sure userID is in
+// it. Conversations the user isn't in look like they don't exist.
+func (cfg *apiConfig) memberConversation(writer http.ResponseWriter, request *http.Request, userID uuid.UUID) (database.GetConversationForMemberRow, bool) {
+	conversationID, err := uuid.Parse(request.PathValue("conversationID"))
+	if err != nil {
+		respondWithError(writer, 404, "conversation not found")
+		return database.GetConversationForMemberRow{}, false
+	}
+	conversation, err := cfg.dbQueries.GetConversationForMember(request.Context(), database.GetConversationForMemberParams{
+		UserID: userID,
+		ID: conversationID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(writer, 404, "conversation not found")
+		return conversation, false
+	}
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return conversation, false
+	}
+	return conversation, true
+}
+
+// StartConversationHandler starts a conversation with the given handles,
+// optionally with a first message. Starting a one-to-one conversation that
+// already exists returns it instead.
+func (cfg *apiConfig) StartConversationHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, err := cfg.authenticatedUserID(request)
+	if err != nil {
+		respondWithError(writer, 401, err.Error())
+		return
+	}
+	type parameters struct {
+		Handles []string `json:"handles"`
+		Title string `json:"title"`
+		Body string `json:"body"`
+	}
+	decoder := json.NewDecoder(request.Body)
+	var params parameters
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(writer, 400, err.Error())
+		return
+	}
+	handles := []string{}
+	for _, handle := range params.Handles {
+		handle = strings.ToLower(strings.TrimPrefix(handle, "@"))
+		if !slices.Contains(handles, handle) {
+			handles = append(handles, handle)
+		}
+	}
+	if len(handles) == 0 {
+		respondWithError(writer, 400, "a conversation needs someone to talk to")
+		return
+	}
+	if len(handles)+1 > maxConversationMembers {
+		respondWithError(writer, 400, fmt.Sprintf("conversations can have at most %d members", maxConversationMembers))
+		return
+	}
+	isGroup := len(handles) > 1
+	title := strings.TrimSpace(params.Title)
+	if title != "" && !isGroup {
+		respondWithError(writer, 400, "only group conversations have titles")
+		return
+	}
+	if utf8.RuneCountInString(title) > maxConversationTitleLength {
+		respondWithError(writer, 400, fmt.Sprintf("title is longer than %d characters", maxConversationTitleLength))
+		return
+	}
+	if params.Body != "" {
+		if err := validateMessage(params.Body); err != nil {
+			respondWithError(writer, 400, err.Error())
+			return
+		}
+	}
+	recipients, err := cfg.dbQueries.GetUsersByHandles(request.Context(), handles)
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	if len(recipients) != len(handles) {
+		respondWithError(writer, 404, "user not found")
+		return
+	}
+	if code, err := cfg.checkRecipients(request.Context(), cfg.dbQueries, userID, recipients); err != nil {
+		respondWithError(writer, code, err.Error())
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(request.Context(), nil)
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+	var key sql.NullString
+	if !isGroup {
+		key = sql.NullString{String: directKey(userID, recipients[0].ID), Valid: true}
+	}
+	status := 201
+	conversation, err := qtx.CreateConversation(request.Context(), database.CreateConversationParams{
+		CreatedBy: userID,
+		IsGroup: isGroup,
+		Title: title,
+		DirectKey: key,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		conversation, err = qtx.GetConversationByDirectKey(request.Context(), key.String)
+		status = 200
+	}
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	for _, memberID := range append([]uuid.UUID{userID}, idsOf(recipients)...) {
+		if err := qtx.AddConversationMember(request.Context(), database.AddConversationMemberParams{
+			ConversationID: conversation.ID,
+			UserID: memberID,
+		}); err != nil {
+			respondWithError(writer, 500, err.Error())
+			return
+		}
+	}
+	if params.Body != "" {
+		if _, err := cfg.sendMessage(request.Context(), qtx, conversation.ID, userID, params.Body); err != nil {
+			respondWithError(writer, 500, err.Error())
+			return
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	result, err := cfg.conversationResponseFor(request.Context(), userID, conversation.ID)
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	respondWithJSON(writer, status, result)
+}
+
+func idsOf(users []database.User) []uuid.UUID {
+	ids := make([]uuid.UUID, len(users))
+	for i, user := range users {
+		ids[i] = user.ID
+	}
+	return ids
+}
+
+func (cfg *apiConfig) ConversationsHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, err := cfg.authenticatedUserID(request)
+	if err != nil {
+		respondWithError(writer, 401, err.Error())
+		return
+	}
+	page, err := parsePageParams(request.URL.Query())
+	if err != nil {
+		respondWithError(writer, 400, err.Error())
+		return
+	}
+	conversations, err := cfg.dbQueries.GetConversations(request.Context(), database.GetConversationsParams{
+		UserID: userID,
+		CursorCreatedAt: page.CursorCreatedAt,
+		CursorID: page.CursorID,
+		Limit: page.fetchLimit(),
+	})
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	var nextCursor *string
+	if len(conversations) > page.Limit {
+		conversations = conversations[:page.Limit]
+		last := conversations[len(conversations)-1]
+		cursor := pagination.EncodeCursor(last.UpdatedAt, last.ID)
+		nextCursor = &cursor
+	}
+	result, err := cfg.conversationResponses(request.Context(), userID, conversations)
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	unread, err := cfg.dbQueries.CountUnreadMessages(request.Context(), userID)
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	type response struct {
+		Conversations []conversationResponse `json:"conversations"`
+		UnreadCount int64 `json:"unread_count"`
+		NextCursor *string `json:"next_cursor"`
+	}
+	setNextLink(writer, request, nextCursor)
+	respondWithJSON(writer, 200, response{
+		Conversations: result,
+		UnreadCount: unread,
+		NextCursor: nextCursor,
+	})
+}
+
+func (cfg *apiConfig) ConversationHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, err := cfg.authenticatedUserID(request)
+	if err != nil {
+		respondWithError(writer, 401, err.Error())
+		return
+	}
+	conversation, ok := cfg.memberConversation(writer, request, userID)
+	if !ok {
+		return
+	}
+	result, err := cfg.conversationResponses(request.Context(), userID, []database.GetConversationsRow{database.GetConversationsRow(conversation)})
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	respondWithJSON(writer, 200, result[0])
+}
+
+// MessagesHandler pages through a conversation, newest first.
+func (cfg *apiConfig) MessagesHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, err := cfg.authenticatedUserID(request)
+	if err != nil {
+		respondWithError(writer, 401, err.Error())
+		return
+	}
+	conversation, ok := cfg.memberConversation(writer, request, userID)
+	if !ok {
+		return
+	}
+	page, err := parsePageParams(request.URL.Query())
+	if err != nil {
+		respondWithError(writer, 400, err.Error())
+		return
+	}
+	messages, err := cfg.dbQueries.GetMessages(request.Context(), database.GetMessagesParams{
+		ConversationID: conversation.ID,
+		ViewerID: userID,
+		CursorCreatedAt: page.CursorCreatedAt,
+		CursorID: page.CursorID,
+		Limit: page.fetchLimit(),
+	})
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	var nextCursor *string
+	if len(messages) > page.Limit {
+		messages = messages[:page.Limit]
+		last := messages[len(messages)-1]
+		cursor := pagination.EncodeCursor(last.CreatedAt, last.ID)
+		nextCursor = &cursor
+	}
+	type response struct {
+		Messages []messageResponse `json:"messages"`
+		NextCursor *string `json:"next_cursor"`
+	}
+	result := make([]messageResponse, len(messages))
+	for i, message := range messages {
+		result[i] = newMessageResponse(message)
+	}
+	setNextLink(writer, request, nextCursor)
+	respondWithJSON(writer, 200, response{
+		Messages: result,
+		NextCursor: nextCursor,
+	})
+}
+
+// SendMessageHandler posts to a conversation. One-to-one conversations
+// check blocks and DM settings again on every message, since either may
+// have changed since the conversation started.
+func (cfg *apiConfig) SendMessageHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, err := cfg.authenticatedUserID(request)
+	if err != nil {
+		respondWithError(writer, 401, err.Error())
+		return
+	}
+	conversation, ok := cfg.memberConversation(writer, request, userID)
+	if !ok {
+		return
+	}
+	type parameters struct {
+		Body string `json:"body"`
+	}
+	decoder := json.NewDecoder(request.Body)
+	var params parameters
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(writer, 400, err.Error())
+		return
+	}
+	if err := validateMessage(params.Body); err != nil {
+		respondWithError(writer, 400, err.Error())
+		return
+	}
+	if !conversation.IsGroup {
+		members, err := cfg.dbQueries.GetConversationMembers(request.Context(), []uuid.UUID{conversation.ID})
+		if err != nil {
+			respondWithError(writer, 500, err.Error())
+			return
+		}
+		for _, member := range members {
+			if member.ID == userID {
+				continue
+			}
+			allowed, err := cfg.canMessage(request.Context(), cfg.dbQueries, userID, member.ID)
+			if err != nil {
+				respondWithError(writer, 500, err.Error())
+				return
+			}
+			if !allowed {
+				respondWithError(writer, 403, fmt.Sprintf("@%s isn't accepting messages from you", member.Handle))
+				return
+			}
+		}
+	}
+	tx, err := cfg.db.BeginTx(request.Context(), nil)
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+	message, err := cfg.sendMessage(request.Context(), qtx, conversation.ID, userID, params.Body)
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	// Sending a message means you've read everything before it.
+	if err := qtx.MarkConversationRead(request.Context(), database.MarkConversationReadParams{
+		MessageID: uuid.NullUUID{UUID: message.ID, Valid: true},
+		ConversationID: conversation.ID,
+		UserID: userID,
+	}); err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	respondWithJSON(writer, 201, newMessageResponse(message))
+}
+
+// MarkConversationReadHandler marks a conversation read up to message_id,
+// or all of it when no message is given. Read markers never move backwards.
+func (cfg *apiConfig) MarkConversationReadHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, err := cfg.authenticatedUserID(request)
+	if err != nil {
+		respondWithError(writer, 401, err.Error())
+		return
+	}
+	conversation, ok := cfg.memberConversation(writer, request, userID)
+	if !ok {
+		return
+	}
+	type parameters struct {
+		MessageID *uuid.UUID `json:"message_id"`
+	}
+	var params parameters
+	if request.ContentLength != 0 {
+		decoder := json.NewDecoder(request.Body)
+		if err := decoder.Decode(&params); err != nil {
+			respondWithError(writer, 400, err.Error())
+			return
+		}
+	}
+	var messageID uuid.NullUUID
+	if params.MessageID != nil {
+		if _, err := cfg.dbQueries.GetMessage(request.Context(), database.GetMessageParams{
+			ID: *params.MessageID,
+			ConversationID: conversation.ID,
+		}); err != nil {
+			respondWithError(writer, 404, "message not found")
+			return
+		}
+		messageID = uuid.NullUUID{UUID: *params.MessageID, Valid: true}
+	}
+	if err := cfg.dbQueries.MarkConversationRead(request.Context(), database.MarkConversationReadParams{
+		MessageID: messageID,
+		ConversationID: conversation.ID,
+		UserID: userID,
+	}); err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	result, err := cfg.conversationResponseFor(request.Context(), userID, conversation.ID)
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	type response struct {
+		UnreadCount int64 `json:"unread_count"`
+	}
+	respondWithJSON(writer, 200, response{
+		UnreadCount: result.UnreadCount,
+	})
+}
+
+// DeleteMessageHandler hides a message from the caller, or with
+// ?for=everyone, lets its sender take it back for the whole conversation.
+func (cfg *apiConfig) DeleteMessageHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, err := cfg.authenticatedUserID(request)
+	if err != nil {
+		respondWithError(writer, 401, err.Error())
+		return
+	}
+	conversation, ok := cfg.memberConversation(writer, request, userID)
+	if !ok {
+		return
+	}
+	messageID, err := uuid.Parse(request.PathValue("messageID"))
+	if err != nil {
+		respondWithError(writer, 404, "message not found")
+		return
+	}
+	message, err := cfg.dbQueries.GetMessage(request.Context(), database.GetMessageParams{
+		ID: messageID,
+		ConversationID: conversation.ID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(writer, 404, "message not found")
+		return
+	}
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	switch request.URL.Query().Get("for") {
+	case "", "me":
+		if err := cfg.dbQueries.DeleteMessageForUser(request.Context(), database.DeleteMessageForUserParams{
+			MessageID: message.ID,
+			UserID: userID,
+		}); err != nil {
+			respondWithError(writer, 500, err.Error())
+			return
+		}
+	case "everyone":
+		if message.SenderID != userID {
+			respondWithError(writer, 403, "only the sender can delete a message for everyone")
+			return
+		}
+		tx, err := cfg.db.BeginTx(request.Context(), nil)
+		if err != nil {
+			respondWithError(writer, 500, err.Error())
+			return
+		}
+		defer tx.Rollback()
+		qtx := cfg.dbQueries.WithTx(tx)
+		if _, err := qtx.DeleteMessageForEveryone(request.Context(), database.DeleteMessageForEveryoneParams{
+			ID: message.ID,
+			SenderID: userID,
+		}); err != nil {
+			respondWithError(writer, 500, err.Error())
+			return
+		}
+		type deleted struct {
+			ID uuid.UUID `json:"id"`
+			ConversationID uuid.UUID `json:"conversation_id"`
+			SenderID uuid.UUID `json:"sender_id"`
+		}
+		if err := cfg.sendSignal(request.Context(), qtx, conversationTopic(conversation.ID), "message_deleted", deleted{
+			ID: message.ID,
+			ConversationID: conversation.ID,
+			SenderID: userID,
+		}); err != nil {
+			respondWithError(writer, 500, err.Error())
+			return
+		}
+		if err := tx.Commit(); err != nil {
+			respondWithError(writer, 500, err.Error())
+			return
+		}
+	default:
+		respondWithError(writer, 400, "for must be me or everyone")
+		return
+	}
+	writer.WriteHeader(204)
+}
+
+func (cfg *apiConfig) DMSettingsHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, err := cfg.authenticatedUserID(request)
+	if err != nil {
+		respondWithError(writer, 401, err.Error())
+		return
+	}
+	policy, err := cfg.dbQueries.GetDMPolicy(request.Context(), userID)
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	type response struct {
+		Policy string `json:"policy"`
+	}
+	respondWithJSON(writer, 200, response{
+		Policy: policy,
+	})
+}
+
+// PutDMSettingsHandler sets who can start conversations with the user:
+// everyone, only people they follow, or nobody.
+func (cfg *apiConfig) PutDMSettingsHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, err := cfg.authenticatedUserID(request)
+	if err != nil {
+		respondWithError(writer, 401, err.Error())
+		return
+	}
+	type parameters struct {
+		Policy string `json:"policy"`
+	}
+	decoder := json.NewDecoder(request.Body)
+	var params parameters
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(writer, 400, err.Error())
+		return
+	}
+	if !slices.Contains([]string{dmPolicyEveryone, dmPolicyFollowing, dmPolicyNobody}, params.Policy) {
+		respondWithError(writer, 400, "policy must be everyone, following or nobody")
+		return
+	}
+	if err := cfg.dbQueries.SetDMPolicy(request.Context(), database.SetDMPolicyParams{
+		UserID: userID,
+		Policy: params.Policy,
+	}); err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	respondWithJSON(writer, 200, params)
+}
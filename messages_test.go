@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/Baehry/chirpy/client"
+)
+
+func TestDMAllowed(t *testing.T) {
+	cases := []struct {
+		policy string
+		blocked bool
+		follows bool
+		allowed bool
+	}{
+		{dmPolicyEveryone, false, false, true},
+		{dmPolicyEveryone, true, true, false},
+		{dmPolicyFollowing, false, true, true},
+		{dmPolicyFollowing, false, false, false},
+		{dmPolicyFollowing, true, true, false},
+		{dmPolicyNobody, false, true, false},
+		{"", false, true, false},
+	}
+	for _, c := range cases {
+		if allowed := dmAllowed(c.policy, c.blocked, c.follows); allowed != c.allowed {
+			t.Logf("%q blocked=%v follows=%v: expected %v, got %v\n", c.policy, c.blocked, c.follows, c.allowed, allowed)
+			t.Fail()
+		}
+	}
+}
+
+// TestDMPolicy needs TEST_DB_URL, like TestClient.
+func TestDMPolicy(t *testing.T) {
+	dbURL := os.Getenv("TEST_DB_URL")
+	if dbURL == "" {
+		t.Skip("TEST_DB_URL not set")
+	}
+	server := newTestServer(t, dbURL)
+	ctx := context.Background()
+	admin, _ := client.New(server.URL)
+	if err := admin.Reset(ctx); err != nil {
+		t.Fatal(err)
+	}
+	users := map[string]*client.Client{}
+	for _, handle := range []string{"alice", "bob", "carol"} {
+		c, _ := client.New(server.URL)
+		email := handle + "@example.com"
+		if _, err := c.CreateUser(ctx, email, "hunter2", handle); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := c.Login(ctx, email, "hunter2"); err != nil {
+			t.Fatal(err)
+		}
+		users[handle] = c
+	}
+	// carol only takes messages from people she follows, which is alice.
+	if _, err := users["carol"].SetDMPolicy(ctx, dmPolicyFollowing); err != nil {
+		t.Fatal(err)
+	}
+	if err := users["carol"].Follow(ctx, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		from string
+		to []string
+		err error
+	}{
+		{"alice", []string{"carol"}, nil},
+		{"bob", []string{"carol"}, client.ErrForbidden},
+		// alice may message both, but bob may not message carol.
+		{"alice", []string{"bob", "carol"}, client.ErrForbidden},
+		// Starting the group is carol's own consent to hear from bob.
+		{"carol", []string{"alice", "bob"}, nil},
+	}
+	for _, c := range cases {
+		_, err := users[c.from].StartConversation(ctx, c.to, "", "hi")
+		if !errors.Is(err, c.err) {
+			t.Logf("%s to %v: expected %v, got %v\n", c.from, c.to, c.err, err)
+			t.Fail()
+		}
+	}
+	if err := users["alice"].Block(ctx, "carol"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := users["carol"].StartConversation(ctx, []string{"alice"}, "", "hi"); !errors.Is(err, client.ErrForbidden) {
+		t.Logf("blocked: expected ErrForbidden, got %v\n", err)
+		t.Fail()
+	}
+}
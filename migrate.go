@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Baehry/chirpy/internal/database"
+	"github.com/Baehry/chirpy/internal/migrations"
+	"github.com/pressly/goose/v3"
+)
+
+const migrateUsage = "usage: chirpy migrate up|down|status"
+
+// runMigrate handles `chirpy migrate ...` with the migrations built into
+// the binary, and returns the exit code.
+func runMigrate(ctx context.Context, db *sql.DB, args []string) int {
+	if len(args) != 1 {
+		fmt.Println(migrateUsage)
+		return 2
+	}
+	provider, err := migrations.New(db)
+	if err != nil {
+		fmt.Printf("%v\n", err)
+		return 1
+	}
+	switch args[0] {
+	case "up":
+		results, err := provider.Up(ctx)
+		printMigrationResults(results)
+		if err != nil {
+			fmt.Printf("%v\n", err)
+			return 1
+		}
+		fmt.Printf("applied %d migration(s)\n", len(results))
+	case "down":
+		result, err := provider.Down(ctx)
+		if err != nil {
+			fmt.Printf("%v\n", err)
+			return 1
+		}
+		printMigrationResults([]*goose.MigrationResult{result})
+	case "status":
+		statuses, err := provider.Status(ctx)
+		if err != nil {
+			fmt.Printf("%v\n", err)
+			return 1
+		}
+		for _, status := range statuses {
+			applied := "pending"
+			if status.State == goose.StateApplied {
+				applied = status.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%-24s %s\n", filepath.Base(status.Source.Path), applied)
+		}
+		version, err := provider.GetDBVersion(ctx)
+		if err != nil {
+			fmt.Printf("%v\n", err)
+			return 1
+		}
+		fmt.Printf("database is at version %d; this build needs %d\n", version, database.SchemaVersion)
+	default:
+		fmt.Println(migrateUsage)
+		return 2
+	}
+	return 0
+}
+
+func printMigrationResults(results []*goose.MigrationResult) {
+	for _, result := range results {
+		if result == nil {
+			continue
+		}
+		fmt.Printf("%-4s %s (%s)\n", result.Direction, filepath.Base(result.Source.Path), result.Duration.Round(time.Millisecond))
+	}
+}
+
+// prepareSchema applies pending migrations when AUTO_MIGRATE is true, then
+// makes sure the database is at the version the queries were generated
+// against. Replicas booting together serialize on goose's advisory lock,
+// so only the first one does the work.
+func prepareSchema(ctx context.Context, db *sql.DB) error {
+	provider, err := migrations.New(db)
+	if err != nil {
+		return err
+	}
+	if os.Getenv("AUTO_MIGRATE") == "true" {
+		results, err := provider.Up(ctx)
+		printMigrationResults(results)
+		if err != nil {
+			return fmt.Errorf("migrating: %w", err)
+		}
+	}
+	return migrations.Check(ctx, provider)
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Baehry/chirpy/internal/auth"
+	"github.com/Baehry/chirpy/internal/database"
+	"github.com/Baehry/chirpy/internal/moderation"
+	"github.com/google/uuid"
+)
+
+const moderationReloadInterval = 30 * time.Second
+
+func (cfg *apiConfig) loadModerationRules(ctx context.Context) error {
+	rows, err := cfg.dbQueries.ListModerationRules(ctx)
+	if err != nil {
+		return err
+	}
+	rules := make([]moderation.Rule, len(rows))
+	for i, row := range rows {
+		rules[i] = moderation.Rule{
+			Word: row.Word,
+			Action: moderation.Action(row.Action),
+		}
+	}
+	cfg.moderationRules.Set(moderation.NewWordFilter(rules))
+	return nil
+}
+
+// watchModerationRules keeps the word list in sync with the database, so
+// changes made through another instance reach this one too. main does the
+// first load itself and won't start without it; a failed reload keeps the
+// rules from the last one.
+func (cfg *apiConfig) watchModerationRules(ctx context.Context) {
+	ticker := time.NewTicker(moderationReloadInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := cfg.loadModerationRules(ctx); err != nil {
+			fmt.Printf("loading moderation rules: %v\n", err)
+		}
+	}
+}
+
+func (cfg *apiConfig) isAdmin(request *http.Request) bool {
+	apiKey, err := auth.GetAPIKey(request.Header)
+	return err == nil && cfg.adminKey != "" && apiKey == cfg.adminKey
+}
+
+func (cfg *apiConfig) ListModerationRulesHandler(writer http.ResponseWriter, request *http.Request) {
+	if !cfg.isAdmin(request) {
+		respondWithError(writer, 401, "admin key required")
+		return
+	}
+	rules, err := cfg.dbQueries.ListModerationRules(request.Context())
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	if rules == nil {
+		rules = []database.ModerationRule{}
+	}
+	respondWithJSON(writer, 200, rules)
+}
+
+func (cfg *apiConfig) PutModerationRuleHandler(writer http.ResponseWriter, request *http.Request) {
+	if !cfg.isAdmin(request) {
+		respondWithError(writer, 401, "admin key required")
+		return
+	}
+	type parameters struct {
+		Word string `json:"word"`
+		Action moderation.Action `json:"action"`
+	}
+	decoder := json.NewDecoder(request.Body)
+	var params parameters
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(writer, 400, err.Error())
+		return
+	}
+	word := moderation.Normalize(params.Word)
+	if word == "" {
+		respondWithError(writer, 400, "word is required")
+		return
+	}
+	if !params.Action.Valid() {
+		respondWithError(writer, 400, "action must be mask, flag or reject")
+		return
+	}
+	rule, err := cfg.dbQueries.UpsertModerationRule(request.Context(), database.UpsertModerationRuleParams{
+		Word: word,
+		Action: string(params.Action),
+	})
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	if err := cfg.loadModerationRules(request.Context()); err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	respondWithJSON(writer, 200, rule)
+}
+
+func (cfg *apiConfig) DeleteModerationRuleHandler(writer http.ResponseWriter, request *http.Request) {
+	if !cfg.isAdmin(request) {
+		respondWithError(writer, 401, "admin key required")
+		return
+	}
+	id, err := uuid.Parse(request.PathValue("ruleID"))
+	if err != nil {
+		respondWithError(writer, 404, "rule not found")
+		return
+	}
+	deleted, err := cfg.dbQueries.DeleteModerationRule(request.Context(), id)
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	if deleted == 0 {
+		respondWithError(writer, 404, "rule not found")
+		return
+	}
+	if err := cfg.loadModerationRules(request.Context()); err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	writer.WriteHeader(204)
+}
+
+func (cfg *apiConfig) ListChirpFlagsHandler(writer http.ResponseWriter, request *http.Request) {
+	if !cfg.isAdmin(request) {
+		respondWithError(writer, 401, "admin key required")
+		return
+	}
+	type flag struct {
+		ChirpID uuid.UUID `json:"chirp_id"`
+		CreatedAt time.Time `json:"created_at"`
+		Words []string `json:"words"`
+		Body string `json:"body"`
+		UserID uuid.UUID `json:"user_id"`
+	}
+	rows, err := cfg.dbQueries.ListOpenChirpFlags(request.Context())
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	flags := []flag{}
+	for _, row := range rows {
+		flags = append(flags, flag{
+			ChirpID: row.ChirpID,
+			CreatedAt: row.CreatedAt,
+			Words: row.Words,
+			Body: row.Body,
+			UserID: row.UserID,
+		})
+	}
+	respondWithJSON(writer, 200, flags)
+}
+
+func (cfg *apiConfig) ResolveChirpFlagHandler(writer http.ResponseWriter, request *http.Request) {
+	if !cfg.isAdmin(request) {
+		respondWithError(writer, 401, "admin key required")
+		return
+	}
+	id, err := uuid.Parse(request.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(writer, 404, "flag not found")
+		return
+	}
+	resolved, err := cfg.dbQueries.ResolveChirpFlag(request.Context(), id)
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	if resolved == 0 {
+		respondWithError(writer, 404, "flag not found")
+		return
+	}
+	writer.WriteHeader(204)
+}
+
+func flaggedWords(matches []moderation.Match) []string {
+	var words []string
+	for _, match := range matches {
+		if match.Action == moderation.ActionFlag {
+			words = append(words, match.Word)
+		}
+	}
+	return words
+}
@@ -0,0 +1,376 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Baehry/chirpy/internal/database"
+	"github.com/Baehry/chirpy/internal/pagination"
+	"github.com/Baehry/chirpy/internal/stream"
+	"github.com/google/uuid"
+)
+
+// Notification types. There are no replies or rechirps yet, so there are
+// no types for them; like only comes from other servers so far.
+const (
+	notificationMention = "mention"
+	notificationLike = "like"
+	notificationFollow = "follow"
+	notificationSubscription = "subscription"
+
+	// maxNotificationActors is how many of the people behind a grouped
+	// notification are listed; the rest only show up in actor_count.
+	maxNotificationActors = 3
+)
+
+var notificationTypes = []string{
+	notificationMention,
+	notificationLike,
+	notificationFollow,
+	notificationSubscription,
+}
+
+type notificationEvent struct {
+	UserID uuid.UUID
+	Type string
+	ChirpID uuid.NullUUID
+	ActorID uuid.NullUUID
+	Detail string
+}
+
+// groupKey decides which events fold into one notification while it's
+// unread: events about a chirp group per chirp ("5 people liked your
+// chirp"), follows group together, and subscription changes stand alone.
+func (event notificationEvent) groupKey() string {
+	if event.Type == notificationSubscription {
+		return event.Type + ":" + uuid.NewString()
+	}
+	if event.ChirpID.Valid {
+		return event.Type + ":" + event.ChirpID.UUID.String()
+	}
+	return event.Type
+}
+
+// notify records event for its recipient. It does nothing if the recipient
+// turned this type off, or if the actor is the recipient, is blocked by or
+// blocking them, or is muted by them.
+func notify(ctx context.Context, q *database.Queries, event notificationEvent) error {
+	notification, err := q.UpsertNotification(ctx, database.UpsertNotificationParams{
+		UserID: event.UserID,
+		Type: event.Type,
+		GroupKey: event.groupKey(),
+		ChirpID: event.ChirpID,
+		Detail: event.Detail,
+		ActorID: event.ActorID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := q.NotifyNotificationsChanged(ctx, event.UserID); err != nil {
+		return err
+	}
+	if !event.ActorID.Valid {
+		return nil
+	}
+	return q.AddNotificationActor(ctx, database.AddNotificationActorParams{
+		NotificationID: notification.ID,
+		ActorID: event.ActorID.UUID,
+	})
+}
+
+// publishNotificationCount tells the user's gateway connections how many
+// unread notifications they have now. payload is the user's ID.
+func (cfg *apiConfig) publishNotificationCount(ctx context.Context, payload string) error {
+	userID, err := uuid.Parse(payload)
+	if err != nil {
+		return err
+	}
+	unread, err := cfg.dbQueries.CountUnreadNotifications(ctx, userID)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(map[string]int64{"unread_count": unread})
+	if err != nil {
+		return err
+	}
+	cfg.hub.Publish(stream.Event{
+		Type: "notifications",
+		Data: data,
+	}, notificationsTopic(userID))
+	return nil
+}
+
+func notificationsTopic(userID uuid.UUID) string {
+	return "notifications:" + userID.String()
+}
+
+// notifyMentions tells everyone a newly published chirp mentions about it.
+func notifyMentions(ctx context.Context, q *database.Queries, chirp database.Chirp) error {
+	mentions, err := q.GetMentionsForChirps(ctx, []uuid.UUID{chirp.ID})
+	if err != nil {
+		return err
+	}
+	for _, mention := range mentions {
+		if err := notify(ctx, q, notificationEvent{
+			UserID: mention.UserID,
+			Type: notificationMention,
+			ChirpID: uuid.NullUUID{UUID: chirp.ID, Valid: true},
+			ActorID: uuid.NullUUID{UUID: chirp.UserID, Valid: true},
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type notificationResponse struct {
+	ID uuid.UUID `json:"id"`
+	Type string `json:"type"`
+	ChirpID *uuid.UUID `json:"chirp_id"`
+	Detail string `json:"detail,omitempty"`
+	Actors []userSummaryResponse `json:"actors"`
+	ActorCount int64 `json:"actor_count"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Read bool `json:"read"`
+}
+
+// notificationPage reads one page of userID's notifications, most
+// recently updated first.
+func (cfg *apiConfig) notificationPage(ctx context.Context, userID uuid.UUID, unreadOnly bool, page pageParams) ([]notificationResponse, *string, error) {
+	notifications, err := cfg.dbQueries.GetNotifications(ctx, database.GetNotificationsParams{
+		UserID: userID,
+		UnreadOnly: unreadOnly,
+		CursorCreatedAt: page.CursorCreatedAt,
+		CursorID: page.CursorID,
+		Limit: page.fetchLimit(),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	var nextCursor *string
+	if len(notifications) > page.Limit {
+		notifications = notifications[:page.Limit]
+		last := notifications[len(notifications)-1]
+		cursor := pagination.EncodeCursor(last.UpdatedAt, last.ID)
+		nextCursor = &cursor
+	}
+	ids := make([]uuid.UUID, len(notifications))
+	for i, notification := range notifications {
+		ids[i] = notification.ID
+	}
+	actors, err := cfg.dbQueries.GetNotificationActors(ctx, ids)
+	if err != nil {
+		return nil, nil, err
+	}
+	actorsByNotification := map[uuid.UUID][]userSummaryResponse{}
+	for _, actor := range actors {
+		if len(actorsByNotification[actor.NotificationID]) == maxNotificationActors {
+			continue
+		}
+		actorsByNotification[actor.NotificationID] = append(actorsByNotification[actor.NotificationID], userSummaryResponse{
+			ID: actor.ID,
+			Handle: actor.Handle,
+			DisplayName: actor.DisplayName,
+			AvatarURL: actor.AvatarUrl,
+		})
+	}
+	result := make([]notificationResponse, len(notifications))
+	for i, notification := range notifications {
+		result[i] = notificationResponse{
+			ID: notification.ID,
+			Type: notification.Type,
+			Detail: notification.Detail,
+			Actors: actorsByNotification[notification.ID],
+			ActorCount: notification.ActorCount,
+			CreatedAt: notification.CreatedAt,
+			UpdatedAt: notification.UpdatedAt,
+			Read: notification.ReadAt.Valid,
+		}
+		if notification.ChirpID.Valid {
+			result[i].ChirpID = &notification.ChirpID.UUID
+		}
+		if result[i].Actors == nil {
+			result[i].Actors = []userSummaryResponse{}
+		}
+	}
+	return result, nextCursor, nil
+}
+
+func (cfg *apiConfig) NotificationsHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, err := cfg.authenticatedUserID(request)
+	if err != nil {
+		respondWithError(writer, 401, err.Error())
+		return
+	}
+	query := request.URL.Query()
+	page, err := parsePageParams(query)
+	if err != nil {
+		respondWithError(writer, 400, err.Error())
+		return
+	}
+	result, nextCursor, err := cfg.notificationPage(request.Context(), userID, query.Get("unread") == "true", page)
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	unread, err := cfg.dbQueries.CountUnreadNotifications(request.Context(), userID)
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	type response struct {
+		Notifications []notificationResponse `json:"notifications"`
+		UnreadCount int64 `json:"unread_count"`
+		NextCursor *string `json:"next_cursor"`
+	}
+	setNextLink(writer, request, nextCursor)
+	respondWithJSON(writer, 200, response{
+		Notifications: result,
+		UnreadCount: unread,
+		NextCursor: nextCursor,
+	})
+}
+
+// MarkNotificationsReadHandler marks the listed notifications as read, or
+// all of them when no ids are given.
+func (cfg *apiConfig) MarkNotificationsReadHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, err := cfg.authenticatedUserID(request)
+	if err != nil {
+		respondWithError(writer, 401, err.Error())
+		return
+	}
+	type parameters struct {
+		IDs []uuid.UUID `json:"ids"`
+	}
+	decoder := json.NewDecoder(request.Body)
+	var params parameters
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(writer, 400, err.Error())
+		return
+	}
+	if len(params.IDs) == 0 {
+		_, err = cfg.dbQueries.MarkAllNotificationsRead(request.Context(), userID)
+	} else {
+		_, err = cfg.dbQueries.MarkNotificationsRead(request.Context(), database.MarkNotificationsReadParams{
+			UserID: userID,
+			Ids: params.IDs,
+		})
+	}
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	if err := cfg.dbQueries.NotifyNotificationsChanged(request.Context(), userID); err != nil {
+		fmt.Printf("notifying about read notifications: %v\n", err)
+	}
+	unread, err := cfg.dbQueries.CountUnreadNotifications(request.Context(), userID)
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	type response struct {
+		UnreadCount int64 `json:"unread_count"`
+	}
+	respondWithJSON(writer, 200, response{
+		UnreadCount: unread,
+	})
+}
+
+func (cfg *apiConfig) notificationPreferences(ctx context.Context, userID uuid.UUID) (map[string]bool, error) {
+	preferences, err := cfg.dbQueries.GetNotificationPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return mergeNotificationPreferences(preferences), nil
+}
+
+// mergeNotificationPreferences fills in the types a user hasn't set, which
+// are on, and leaves out stored settings for types that no longer exist.
+func mergeNotificationPreferences(preferences []database.NotificationPreference) map[string]bool {
+	result := map[string]bool{}
+	for _, notificationType := range notificationTypes {
+		result[notificationType] = true
+	}
+	for _, preference := range preferences {
+		if _, ok := result[preference.Type]; ok {
+			result[preference.Type] = preference.Enabled
+		}
+	}
+	return result
+}
+
+func (cfg *apiConfig) NotificationPreferencesHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, err := cfg.authenticatedUserID(request)
+	if err != nil {
+		respondWithError(writer, 401, err.Error())
+		return
+	}
+	preferences, err := cfg.notificationPreferences(request.Context(), userID)
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	respondWithJSON(writer, 200, preferences)
+}
+
+// PutNotificationPreferencesHandler turns notification types on or off.
+// Types left out of the request keep their current setting.
+func (cfg *apiConfig) PutNotificationPreferencesHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, err := cfg.authenticatedUserID(request)
+	if err != nil {
+		respondWithError(writer, 401, err.Error())
+		return
+	}
+	decoder := json.NewDecoder(request.Body)
+	var params map[string]bool
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(writer, 400, err.Error())
+		return
+	}
+	known := map[string]bool{}
+	for _, notificationType := range notificationTypes {
+		known[notificationType] = true
+	}
+	for notificationType := range params {
+		if !known[notificationType] {
+			respondWithError(writer, 400, fmt.Sprintf("unknown notification type %q", notificationType))
+			return
+		}
+	}
+	tx, err := cfg.db.BeginTx(request.Context(), nil)
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+	for notificationType, enabled := range params {
+		if err := qtx.SetNotificationPreference(request.Context(), database.SetNotificationPreferenceParams{
+			UserID: userID,
+			Type: notificationType,
+			Enabled: enabled,
+		}); err != nil {
+			respondWithError(writer, 500, err.Error())
+			return
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	preferences, err := cfg.notificationPreferences(request.Context(), userID)
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	respondWithJSON(writer, 200, preferences)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"maps"
+	"testing"
+
+	"github.com/Baehry/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+func TestNotificationGroupKey(t *testing.T) {
+	chirpID := uuid.NullUUID{UUID: uuid.New(), Valid: true}
+	otherChirpID := uuid.NullUUID{UUID: uuid.New(), Valid: true}
+	cases := []struct {
+		name string
+		a notificationEvent
+		b notificationEvent
+		grouped bool
+	}{
+		{"likes on one chirp", notificationEvent{Type: notificationLike, ChirpID: chirpID}, notificationEvent{Type: notificationLike, ChirpID: chirpID}, true},
+		{"likes on different chirps", notificationEvent{Type: notificationLike, ChirpID: chirpID}, notificationEvent{Type: notificationLike, ChirpID: otherChirpID}, false},
+		{"a like and a mention", notificationEvent{Type: notificationLike, ChirpID: chirpID}, notificationEvent{Type: notificationMention, ChirpID: chirpID}, false},
+		{"follows", notificationEvent{Type: notificationFollow}, notificationEvent{Type: notificationFollow}, true},
+		{"subscription changes", notificationEvent{Type: notificationSubscription}, notificationEvent{Type: notificationSubscription}, false},
+	}
+	for _, c := range cases {
+		if grouped := c.a.groupKey() == c.b.groupKey(); grouped != c.grouped {
+			t.Logf("%s: expected grouped=%v, got %v\n", c.name, c.grouped, grouped)
+			t.Fail()
+		}
+	}
+}
+
+func TestMergeNotificationPreferences(t *testing.T) {
+	cases := []struct {
+		name string
+		stored []database.NotificationPreference
+		expected map[string]bool
+	}{
+		{
+			"nothing set",
+			nil,
+			map[string]bool{notificationMention: true, notificationLike: true, notificationFollow: true, notificationSubscription: true},
+		},
+		{
+			"some turned off",
+			[]database.NotificationPreference{{Type: notificationLike, Enabled: false}, {Type: notificationFollow, Enabled: true}},
+			map[string]bool{notificationMention: true, notificationLike: false, notificationFollow: true, notificationSubscription: true},
+		},
+		{
+			"types that no longer exist",
+			[]database.NotificationPreference{{Type: "reply", Enabled: false}, {Type: "rechirp", Enabled: true}},
+			map[string]bool{notificationMention: true, notificationLike: true, notificationFollow: true, notificationSubscription: true},
+		},
+	}
+	for _, c := range cases {
+		if got := mergeNotificationPreferences(c.stored); !maps.Equal(got, c.expected) {
+			t.Logf("%s: expected %v, got %v\n", c.name, c.expected, got)
+			t.Fail()
+		}
+	}
+}
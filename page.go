@@ -0,0 +1,79 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/Baehry/chirpy/internal/pagination"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit = 100
+)
+
+type pageParams struct {
+	Limit int
+	Since sql.NullTime
+	Until sql.NullTime
+	CursorCreatedAt sql.NullTime
+	CursorID uuid.NullUUID
+}
+
+func parsePageParams(query url.Values) (pageParams, error) {
+	page := pageParams{
+		Limit: defaultPageLimit,
+	}
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 {
+			return pageParams{}, fmt.Errorf("invalid limit")
+		}
+		page.Limit = min(n, maxPageLimit)
+	}
+	if since := query.Get("since"); since != "" {
+		t, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			return pageParams{}, fmt.Errorf("invalid since")
+		}
+		page.Since = sql.NullTime{Time: t.UTC(), Valid: true}
+	}
+	if until := query.Get("until"); until != "" {
+		t, err := time.Parse(time.RFC3339, until)
+		if err != nil {
+			return pageParams{}, fmt.Errorf("invalid until")
+		}
+		page.Until = sql.NullTime{Time: t.UTC(), Valid: true}
+	}
+	if cursor := query.Get("cursor"); cursor != "" {
+		c, err := pagination.DecodeCursor(cursor)
+		if err != nil {
+			return pageParams{}, err
+		}
+		page.CursorCreatedAt = sql.NullTime{Time: c.CreatedAt, Valid: true}
+		page.CursorID = uuid.NullUUID{UUID: c.ID, Valid: true}
+	}
+	return page, nil
+}
+
+// fetchLimit asks the database for one row more than the page holds, so we
+// know whether there is a next page without running a second query.
+func (page pageParams) fetchLimit() int32 {
+	return int32(page.Limit + 1)
+}
+
+func setNextLink(writer http.ResponseWriter, request *http.Request, nextCursor *string) {
+	if nextCursor == nil {
+		return
+	}
+	next := *request.URL
+	query := next.Query()
+	query.Set("cursor", *nextCursor)
+	next.RawQuery = query.Encode()
+	writer.Header().Set("Link", fmt.Sprintf("<%s>; rel=\"next\"", next.RequestURI()))
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/Baehry/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultPinLimit = 3
+	defaultPinLimitRed = 10
+)
+
+// envInt reads a positive integer from the environment, falling back to def
+// when the variable is unset or invalid.
+func envInt(name string, def int) int {
+	n, err := strconv.Atoi(os.Getenv(name))
+	if err != nil || n <= 0 {
+		return def
+	}
+	return n
+}
+
+func (cfg *apiConfig) maxPinnedChirps(user database.User) int {
+	if user.IsChirpyRed {
+		return cfg.pinLimitRed
+	}
+	return cfg.pinLimit
+}
+
+func (cfg *apiConfig) PinChirpHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, err := cfg.authenticatedUserID(request)
+	if err != nil {
+		respondWithError(writer, 401, err.Error())
+		return
+	}
+	id, err := uuid.Parse(request.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(writer, 404, "chirp not found")
+		return
+	}
+	tx, err := cfg.db.BeginTx(request.Context(), nil)
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+	// Locking the user row serialises concurrent pins, so the limit holds.
+	user, err := qtx.LockUser(request.Context(), userID)
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	chirp, err := qtx.GetChirp(request.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && chirp.Status != statusPublished) {
+		respondWithError(writer, 404, "chirp not found")
+		return
+	}
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	if chirp.UserID != userID {
+		respondWithError(writer, 403, "you can only pin your own chirps")
+		return
+	}
+	pinned, err := qtx.CountPinnedChirps(request.Context(), userID)
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	limit := cfg.maxPinnedChirps(user)
+	if pinned >= int64(limit) {
+		respondWithError(writer, 409, fmt.Sprintf("you can pin at most %d chirps", limit))
+		return
+	}
+	if err := qtx.PinChirp(request.Context(), database.PinChirpParams{
+		UserID: userID,
+		ChirpID: chirp.ID,
+	}); err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	writer.WriteHeader(204)
+}
+
+func (cfg *apiConfig) UnpinChirpHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, err := cfg.authenticatedUserID(request)
+	if err != nil {
+		respondWithError(writer, 401, err.Error())
+		return
+	}
+	id, err := uuid.Parse(request.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(writer, 404, "chirp not found")
+		return
+	}
+	unpinned, err := cfg.dbQueries.UnpinChirp(request.Context(), database.UnpinChirpParams{
+		UserID: userID,
+		ChirpID: id,
+	})
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	if unpinned == 0 {
+		respondWithError(writer, 404, "chirp is not pinned")
+		return
+	}
+	writer.WriteHeader(204)
+}
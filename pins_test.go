@@ -0,0 +1,44 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/Baehry/chirpy/internal/database"
+)
+
+func TestMaxPinnedChirps(t *testing.T) {
+	cfg := &apiConfig{pinLimit: defaultPinLimit, pinLimitRed: defaultPinLimitRed}
+	cases := []struct {
+		red bool
+		limit int
+	}{
+		{false, defaultPinLimit},
+		{true, defaultPinLimitRed},
+	}
+	for _, c := range cases {
+		if limit := cfg.maxPinnedChirps(database.User{IsChirpyRed: c.red}); limit != c.limit {
+			t.Logf("red=%v: expected %d, got %d\n", c.red, c.limit, limit)
+			t.Fail()
+		}
+	}
+}
+
+func TestEnvInt(t *testing.T) {
+	cases := []struct {
+		value string
+		expected int
+	}{
+		{"", 3},
+		{"5", 5},
+		{"0", 3},
+		{"-2", 3},
+		{"lots", 3},
+	}
+	for _, c := range cases {
+		t.Setenv("CHIRPY_TEST_INT", c.value)
+		if n := envInt("CHIRPY_TEST_INT", 3); n != c.expected {
+			t.Logf("%q: expected %d, got %d\n", c.value, c.expected, n)
+			t.Fail()
+		}
+	}
+}
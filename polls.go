@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Baehry/chirpy/internal/database"
+	"github.com/Baehry/chirpy/internal/polls"
+	"github.com/google/uuid"
+)
+
+type pollParams struct {
+	Options []string `json:"options"`
+	ClosesAt time.Time `json:"closes_at"`
+}
+
+type pollOptionResponse struct {
+	ID uuid.UUID `json:"id"`
+	Text string `json:"text"`
+	Votes *int64 `json:"votes,omitempty"`
+}
+
+// pollResponse only carries vote counts once the viewer has voted or the
+// poll has closed, so results can't sway anyone still deciding.
+type pollResponse struct {
+	ClosesAt time.Time `json:"closes_at"`
+	Closed bool `json:"closed"`
+	Options []pollOptionResponse `json:"options"`
+	TotalVotes *int64 `json:"total_votes,omitempty"`
+	VotedOptionID *uuid.UUID `json:"voted_option_id,omitempty"`
+}
+
+// preparePoll validates a poll for a new chirp and runs its options through
+// the content filter. It returns the options to store.
+func (cfg *apiConfig) preparePoll(params *pollParams, status string, publishAt sql.NullTime) ([]string, error) {
+	if status == statusDraft {
+		return nil, fmt.Errorf("drafts can't have polls")
+	}
+	options, err := polls.Options(params.Options)
+	if err != nil {
+		return nil, err
+	}
+	opensAt := time.Now()
+	if publishAt.Valid {
+		opensAt = publishAt.Time
+	}
+	if err := polls.ValidateClosing(opensAt, params.ClosesAt); err != nil {
+		return nil, err
+	}
+	for i, option := range options {
+		moderated := cfg.contentFilter.Apply(option)
+		if moderated.Rejected {
+			return nil, fmt.Errorf("Poll option contains prohibited words")
+		}
+		options[i] = moderated.Body
+	}
+	return options, nil
+}
+
+func createPoll(ctx context.Context, q *database.Queries, chirp database.Chirp, options []string, closesAt time.Time) error {
+	if err := q.CreatePoll(ctx, database.CreatePollParams{
+		ChirpID: chirp.ID,
+		ClosesAt: closesAt.UTC(),
+	}); err != nil {
+		return err
+	}
+	for i, option := range options {
+		if err := q.CreatePollOption(ctx, database.CreatePollOptionParams{
+			ChirpID: chirp.ID,
+			Position: int32(i),
+			Text: option,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pollResponses loads the polls attached to the given chirps, as seen by
+// viewerID.
+func (cfg *apiConfig) pollResponses(ctx context.Context, viewerID uuid.UUID, ids []uuid.UUID) (map[uuid.UUID]*pollResponse, error) {
+	result := map[uuid.UUID]*pollResponse{}
+	chirpPolls, err := cfg.dbQueries.GetPollsForChirps(ctx, ids)
+	if err != nil || len(chirpPolls) == 0 {
+		return result, err
+	}
+	pollIDs := make([]uuid.UUID, len(chirpPolls))
+	for i, poll := range chirpPolls {
+		pollIDs[i] = poll.ChirpID
+	}
+	options, err := cfg.dbQueries.GetPollOptionsForChirps(ctx, pollIDs)
+	if err != nil {
+		return nil, err
+	}
+	votes, err := cfg.dbQueries.GetPollVotesByUser(ctx, database.GetPollVotesByUserParams{
+		UserID: viewerID,
+		ChirpIds: pollIDs,
+	})
+	if err != nil {
+		return nil, err
+	}
+	voted := map[uuid.UUID]uuid.UUID{}
+	for _, vote := range votes {
+		voted[vote.ChirpID] = vote.OptionID
+	}
+	now := time.Now().UTC()
+	for _, poll := range chirpPolls {
+		result[poll.ChirpID] = &pollResponse{
+			ClosesAt: poll.ClosesAt,
+			Closed: !poll.ClosesAt.After(now),
+			Options: []pollOptionResponse{},
+		}
+		if optionID, ok := voted[poll.ChirpID]; ok {
+			result[poll.ChirpID].VotedOptionID = &optionID
+		}
+	}
+	totals := map[uuid.UUID]int64{}
+	for _, option := range options {
+		poll := result[option.ChirpID]
+		response := pollOptionResponse{
+			ID: option.ID,
+			Text: option.Text,
+		}
+		if poll.Closed || poll.VotedOptionID != nil {
+			response.Votes = &option.Votes
+			totals[option.ChirpID] += option.Votes
+		}
+		poll.Options = append(poll.Options, response)
+	}
+	for chirpID, poll := range result {
+		if poll.Closed || poll.VotedOptionID != nil {
+			total := totals[chirpID]
+			poll.TotalVotes = &total
+		}
+	}
+	return result, nil
+}
+
+func (cfg *apiConfig) VoteHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, err := cfg.authenticatedUserID(request)
+	if err != nil {
+		respondWithError(writer, 401, err.Error())
+		return
+	}
+	id, err := uuid.Parse(request.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(writer, 404, "chirp not found")
+		return
+	}
+	type parameters struct {
+		OptionID uuid.UUID `json:"option_id"`
+	}
+	decoder := json.NewDecoder(request.Body)
+	var params parameters
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(writer, 400, err.Error())
+		return
+	}
+	chirp, err := cfg.dbQueries.GetChirp(request.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && chirp.Status != statusPublished) {
+		respondWithError(writer, 404, "chirp not found")
+		return
+	}
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	blocked, err := cfg.isBlocked(request.Context(), userID, chirp.UserID)
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	if blocked {
+		respondWithError(writer, 404, "chirp not found")
+		return
+	}
+	// The insert itself checks the poll is open and the primary key allows
+	// one vote per user, so concurrent votes can't slip past either rule.
+	inserted, err := cfg.dbQueries.VoteInPoll(request.Context(), database.VoteInPollParams{
+		UserID: userID,
+		OptionID: params.OptionID,
+		ChirpID: chirp.ID,
+	})
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	if inserted == 0 {
+		status, msg, err := cfg.explainRejectedVote(request.Context(), chirp.ID, userID)
+		if err != nil {
+			respondWithError(writer, 500, err.Error())
+			return
+		}
+		respondWithError(writer, status, msg)
+		return
+	}
+	result, err := cfg.chirpResponseFor(request.Context(), userID, chirp)
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	respondWithJSON(writer, 201, result)
+}
+
+// explainRejectedVote works out why VoteInPoll didn't insert anything.
+func (cfg *apiConfig) explainRejectedVote(ctx context.Context, chirpID, userID uuid.UUID) (int, string, error) {
+	poll, err := cfg.dbQueries.GetPoll(ctx, chirpID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 404, "chirp has no poll", nil
+	}
+	if err != nil {
+		return 0, "", err
+	}
+	votes, err := cfg.dbQueries.GetPollVotesByUser(ctx, database.GetPollVotesByUserParams{
+		UserID: userID,
+		ChirpIds: []uuid.UUID{chirpID},
+	})
+	if err != nil {
+		return 0, "", err
+	}
+	if len(votes) > 0 {
+		return 409, "you have already voted in this poll", nil
+	}
+	if !poll.ClosesAt.After(time.Now().UTC()) {
+		return 409, "poll is closed", nil
+	}
+	return 400, "unknown poll option", nil
+}
@@ -0,0 +1,320 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Baehry/chirpy/internal/database"
+	"github.com/Baehry/chirpy/internal/handles"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const (
+	handleChangeCooldown = 24 * time.Hour
+	maxDisplayNameLength = 50
+	maxBioLength = 160
+	maxLocationLength = 30
+)
+
+type profileResponse struct {
+	ID uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Handle string `json:"handle"`
+	DisplayName string `json:"display_name"`
+	Bio string `json:"bio"`
+	AvatarURL string `json:"avatar_url"`
+	Location string `json:"location"`
+	IsChirpyRed bool `json:"is_chirpy_red"`
+	ChirpCount int64 `json:"chirp_count"`
+	FollowerCount int64 `json:"follower_count"`
+	FollowingCount int64 `json:"following_count"`
+	PinnedChirps []chirpResponse `json:"pinned_chirps"`
+}
+
+// changeHandle renames a user, keeping the old handle reserved as a redirect
+// for a while. The returned status is the HTTP status to report on error.
+func (cfg *apiConfig) changeHandle(ctx context.Context, user database.User, handle string) (database.User, int, error) {
+	if err := handles.Validate(handle); err != nil {
+		return user, 400, err
+	}
+	if handle == user.Handle {
+		return user, 200, nil
+	}
+	oldHandle := handles.Normalize(user.Handle)
+	newHandle := handles.Normalize(handle)
+	// Changing only the case of the handle doesn't free anything up, so
+	// it doesn't count against the cooldown.
+	if oldHandle != newHandle && user.HandleChangedAt.Valid && time.Since(user.HandleChangedAt.Time) < handleChangeCooldown {
+		return user, 429, fmt.Errorf("handles can only be changed once every %v", handleChangeCooldown)
+	}
+	redirect, err := cfg.dbQueries.GetHandleRedirect(ctx, newHandle)
+	if err == nil && redirect.UserID != user.ID {
+		return user, 409, fmt.Errorf("handle %q is taken", handle)
+	}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return user, 500, err
+	}
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return user, 500, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+	updated, err := qtx.ChangeHandle(ctx, database.ChangeHandleParams{
+		ID: user.ID,
+		Handle: handle,
+	})
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return user, 409, fmt.Errorf("handle %q is taken", handle)
+	}
+	if err != nil {
+		return user, 500, err
+	}
+	if oldHandle != newHandle {
+		if err := qtx.DeleteHandleRedirect(ctx, newHandle); err != nil {
+			return user, 500, err
+		}
+		if err := qtx.CreateHandleRedirect(ctx, database.CreateHandleRedirectParams{
+			Handle: oldHandle,
+			UserID: user.ID,
+		}); err != nil {
+			return user, 500, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return user, 500, err
+	}
+	return updated, 200, nil
+}
+
+func (cfg *apiConfig) ProfileHandler(writer http.ResponseWriter, request *http.Request) {
+	handle := request.PathValue("handle")
+	user, err := cfg.dbQueries.GetUserByHandle(request.Context(), handle)
+	if errors.Is(err, sql.ErrNoRows) {
+		redirect, err := cfg.dbQueries.GetHandleRedirect(request.Context(), handles.Normalize(handle))
+		if err != nil {
+			respondWithError(writer, 404, "user not found")
+			return
+		}
+		user, err := cfg.dbQueries.GetUser(request.Context(), redirect.UserID)
+		if err != nil {
+			respondWithError(writer, 404, "user not found")
+			return
+		}
+		http.Redirect(writer, request, "/api/users/"+url.PathEscape(user.Handle), http.StatusFound)
+		return
+	}
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	viewerID, _ := cfg.authenticatedUserID(request)
+	blocked, err := cfg.isBlocked(request.Context(), viewerID, user.ID)
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	if blocked {
+		respondWithError(writer, 404, "user not found")
+		return
+	}
+	profile, err := cfg.dbQueries.GetUserProfile(request.Context(), user.ID)
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	pinned, err := cfg.dbQueries.GetPinnedChirps(request.Context(), database.GetPinnedChirpsParams{
+		UserID: user.ID,
+		ViewerID: uuid.NullUUID{UUID: viewerID, Valid: viewerID != uuid.Nil},
+	})
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	pinnedChirps, err := cfg.chirpResponses(request.Context(), viewerID, pinned)
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	respondWithJSON(writer, 200, profileResponse{
+		ID: profile.ID,
+		CreatedAt: profile.CreatedAt,
+		Handle: profile.Handle,
+		DisplayName: profile.DisplayName,
+		Bio: profile.Bio,
+		AvatarURL: profile.AvatarUrl,
+		Location: profile.Location,
+		IsChirpyRed: profile.IsChirpyRed,
+		ChirpCount: profile.ChirpCount,
+		FollowerCount: profile.FollowerCount,
+		FollowingCount: profile.FollowingCount,
+		PinnedChirps: pinnedChirps,
+	})
+}
+
+func (cfg *apiConfig) PutProfileHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, err := cfg.authenticatedUserID(request)
+	if err != nil {
+		respondWithError(writer, 401, err.Error())
+		return
+	}
+	type parameters struct {
+		Handle *string `json:"handle"`
+		DisplayName *string `json:"display_name"`
+		Bio *string `json:"bio"`
+		AvatarURL *string `json:"avatar_url"`
+		Location *string `json:"location"`
+	}
+	decoder := json.NewDecoder(request.Body)
+	var params parameters
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(writer, 400, err.Error())
+		return
+	}
+	if params.DisplayName != nil && utf8.RuneCountInString(*params.DisplayName) > maxDisplayNameLength {
+		respondWithError(writer, 400, "display name is too long")
+		return
+	}
+	if params.Bio != nil && utf8.RuneCountInString(*params.Bio) > maxBioLength {
+		respondWithError(writer, 400, "bio is too long")
+		return
+	}
+	if params.Location != nil && utf8.RuneCountInString(*params.Location) > maxLocationLength {
+		respondWithError(writer, 400, "location is too long")
+		return
+	}
+	if params.AvatarURL != nil && *params.AvatarURL != "" {
+		avatarURL, err := url.Parse(*params.AvatarURL)
+		if err != nil || (avatarURL.Scheme != "https" && avatarURL.Scheme != "http") || avatarURL.Host == "" {
+			respondWithError(writer, 400, "avatar_url must be an http or https URL")
+			return
+		}
+	}
+	user, err := cfg.dbQueries.UpdateProfile(request.Context(), database.UpdateProfileParams{
+		ID: userID,
+		DisplayName: nullString(params.DisplayName),
+		Bio: nullString(params.Bio),
+		AvatarUrl: nullString(params.AvatarURL),
+		Location: nullString(params.Location),
+	})
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	if params.Handle != nil {
+		var code int
+		user, code, err = cfg.changeHandle(request.Context(), user, *params.Handle)
+		if err != nil {
+			respondWithError(writer, code, err.Error())
+			return
+		}
+	}
+	respondWithJSON(writer, 200, user)
+}
+
+func (cfg *apiConfig) FollowHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, err := cfg.authenticatedUserID(request)
+	if err != nil {
+		respondWithError(writer, 401, err.Error())
+		return
+	}
+	followee, err := cfg.dbQueries.GetUserByHandle(request.Context(), request.PathValue("handle"))
+	if err != nil {
+		respondWithError(writer, 404, "user not found")
+		return
+	}
+	if followee.ID == userID {
+		respondWithError(writer, 400, "you can't follow yourself")
+		return
+	}
+	blocked, err := cfg.isBlocked(request.Context(), userID, followee.ID)
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	if blocked {
+		respondWithError(writer, 403, "you can't follow this user")
+		return
+	}
+	tx, err := cfg.db.BeginTx(request.Context(), nil)
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+	inserted, err := qtx.FollowUser(request.Context(), database.FollowUserParams{
+		FollowerID: userID,
+		FolloweeID: followee.ID,
+	})
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	if inserted == 0 {
+		// Already following: nothing new to tell anyone about.
+		writer.WriteHeader(204)
+		return
+	}
+	if err := notify(request.Context(), qtx, notificationEvent{
+		UserID: followee.ID,
+		Type: notificationFollow,
+		ActorID: uuid.NullUUID{UUID: userID, Valid: true},
+	}); err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	type followed struct {
+		FollowerID uuid.UUID `json:"follower_id"`
+		FolloweeID uuid.UUID `json:"followee_id"`
+	}
+	if err := enqueueWebhook(request.Context(), qtx, followee.ID, webhookUserFollowed, followed{
+		FollowerID: userID,
+		FolloweeID: followee.ID,
+	}); err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	writer.WriteHeader(204)
+}
+
+func (cfg *apiConfig) UnfollowHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, err := cfg.authenticatedUserID(request)
+	if err != nil {
+		respondWithError(writer, 401, err.Error())
+		return
+	}
+	followee, err := cfg.dbQueries.GetUserByHandle(request.Context(), request.PathValue("handle"))
+	if err != nil {
+		respondWithError(writer, 404, "user not found")
+		return
+	}
+	if err := cfg.dbQueries.UnfollowUser(request.Context(), database.UnfollowUserParams{
+		FollowerID: userID,
+		FolloweeID: followee.ID,
+	}); err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	writer.WriteHeader(204)
+}
+
+func nullString(s *string) sql.NullString {
+	if s == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: *s, Valid: true}
+}
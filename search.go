@@ -0,0 +1,97 @@
+package main
+
+import (
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/Baehry/chirpy/internal/database"
+	"github.com/Baehry/chirpy/internal/pagination"
+	"github.com/Baehry/chirpy/internal/search"
+	"github.com/google/uuid"
+)
+
+func (cfg *apiConfig) SearchChirpsHandler(writer http.ResponseWriter, request *http.Request) {
+	type result struct {
+		ID uuid.UUID `json:"id"`
+		CreatedAt time.Time `json:"created_at"`
+		UpdatedAt time.Time `json:"updated_at"`
+		Body string `json:"body"`
+		UserID uuid.UUID `json:"user_id"`
+		Rank float32 `json:"rank"`
+		Snippet string `json:"snippet"`
+	}
+	type response struct {
+		Results []result `json:"results"`
+		NextCursor *string `json:"next_cursor"`
+	}
+	query := request.URL.Query()
+	tsquery, err := search.ParseQuery(query.Get("q"))
+	if err != nil {
+		respondWithError(writer, 400, err.Error())
+		return
+	}
+	// Search results are ordered by rank, so they use their own cursor
+	// instead of the (created_at, id) one parsePageParams understands.
+	cursor := query.Get("cursor")
+	query.Del("cursor")
+	page, err := parsePageParams(query)
+	if err != nil {
+		respondWithError(writer, 400, err.Error())
+		return
+	}
+	params := database.SearchChirpsParams{
+		Query: tsquery,
+		Since: page.Since,
+		Until: page.Until,
+		ViewerID: cfg.viewer(request),
+		Limit: page.fetchLimit(),
+	}
+	if cursor != "" {
+		c, err := pagination.DecodeRankCursor(cursor)
+		if err != nil {
+			respondWithError(writer, 400, err.Error())
+			return
+		}
+		params.CursorRank = sql.NullFloat64{Float64: float64(c.Rank), Valid: true}
+		params.CursorCreatedAt = sql.NullTime{Time: c.CreatedAt, Valid: true}
+		params.CursorID = uuid.NullUUID{UUID: c.ID, Valid: true}
+	}
+	if authorID := query.Get("author_id"); authorID != "" {
+		id, err := uuid.Parse(authorID)
+		if err != nil {
+			respondWithError(writer, 400, "invalid author_id")
+			return
+		}
+		params.AuthorID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+	rows, err := cfg.dbQueries.SearchChirps(request.Context(), params)
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	var nextCursor *string
+	if len(rows) > page.Limit {
+		rows = rows[:page.Limit]
+		last := rows[len(rows)-1]
+		c := pagination.EncodeRankCursor(last.Rank, last.CreatedAt, last.ID)
+		nextCursor = &c
+	}
+	results := []result{}
+	for _, row := range rows {
+		results = append(results, result{
+			ID: row.ID,
+			CreatedAt: row.CreatedAt,
+			UpdatedAt: row.UpdatedAt,
+			Body: row.Body,
+			UserID: row.UserID,
+			Rank: row.Rank,
+			Snippet: row.Snippet,
+		})
+	}
+	setNextLink(writer, request, nextCursor)
+	respondWithJSON(writer, 200, response{
+		Results: results,
+		NextCursor: nextCursor,
+	})
+}
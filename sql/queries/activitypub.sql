@@ -0,0 +1,235 @@
+-- name: GetActorKey :one
+SELECT * FROM actor_keys
+WHERE user_id = $1;
+
+-- name: CreateActorKey :exec
+INSERT INTO actor_keys (user_id, public_key_pem, private_key_pem, created_at)
+VALUES (
+    $1,
+    $2,
+    $3,
+    NOW()
+)
+ON CONFLICT (user_id) DO NOTHING;
+
+-- name: UpsertRemoteActor :one
+INSERT INTO remote_actors (id, uri, username, domain, display_name, summary, url, inbox, shared_inbox, public_key_id, public_key_pem, fetched_at, created_at)
+VALUES (
+    gen_random_uuid(),
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9,
+    $10,
+    NOW(),
+    NOW()
+)
+ON CONFLICT (uri) DO UPDATE
+SET username = EXCLUDED.username,
+    domain = EXCLUDED.domain,
+    display_name = EXCLUDED.display_name,
+    summary = EXCLUDED.summary,
+    url = EXCLUDED.url,
+    inbox = EXCLUDED.inbox,
+    shared_inbox = EXCLUDED.shared_inbox,
+    public_key_id = EXCLUDED.public_key_id,
+    public_key_pem = EXCLUDED.public_key_pem,
+    fetched_at = NOW()
+RETURNING *;
+
+-- name: GetRemoteActor :one
+SELECT * FROM remote_actors
+WHERE id = $1;
+
+-- name: GetRemoteActorByURI :one
+SELECT * FROM remote_actors
+WHERE uri = $1;
+
+-- name: GetRemoteActorByKeyID :one
+SELECT * FROM remote_actors
+WHERE public_key_id = $1;
+
+-- name: GetRemoteActorsByIDs :many
+SELECT * FROM remote_actors
+WHERE id = ANY(sqlc.arg('ids')::uuid[]);
+
+-- name: DeleteRemoteActor :execrows
+DELETE FROM remote_actors
+WHERE uri = $1;
+
+-- name: AddRemoteFollower :execrows
+INSERT INTO remote_followers (user_id, remote_actor_id, follow_uri, created_at)
+VALUES (
+    $1,
+    $2,
+    $3,
+    NOW()
+)
+ON CONFLICT (user_id, remote_actor_id) DO UPDATE
+SET follow_uri = EXCLUDED.follow_uri;
+
+-- name: RemoveRemoteFollower :execrows
+DELETE FROM remote_followers
+WHERE remote_actor_id = sqlc.arg('remote_actor_id')
+AND (follow_uri = sqlc.arg('follow_uri')::text OR user_id = sqlc.narg('user_id')::uuid);
+
+-- name: CountRemoteFollowers :one
+SELECT COUNT(*) FROM remote_followers
+WHERE user_id = $1;
+
+-- name: GetFollowerInboxes :many
+SELECT DISTINCT COALESCE(NULLIF(remote_actors.shared_inbox, ''), remote_actors.inbox)::text AS inbox
+FROM remote_followers
+JOIN remote_actors ON remote_actors.id = remote_followers.remote_actor_id
+WHERE remote_followers.user_id = $1;
+
+-- name: CreateRemoteFollow :one
+INSERT INTO remote_follows (id, user_id, remote_actor_id, created_at)
+VALUES (
+    gen_random_uuid(),
+    $1,
+    $2,
+    NOW()
+)
+ON CONFLICT (user_id, remote_actor_id) DO UPDATE
+SET user_id = EXCLUDED.user_id
+RETURNING *;
+
+-- name: GetRemoteFollows :many
+SELECT * FROM remote_follows
+WHERE user_id = $1
+ORDER BY created_at DESC;
+
+-- name: CountRemoteFollows :one
+SELECT COUNT(*) FROM remote_follows
+WHERE user_id = $1
+AND accepted;
+
+-- name: AcceptRemoteFollow :execrows
+UPDATE remote_follows
+SET accepted = TRUE
+WHERE id = $1
+AND remote_actor_id = $2;
+
+-- name: RejectRemoteFollow :execrows
+DELETE FROM remote_follows
+WHERE id = $1
+AND remote_actor_id = $2;
+
+-- name: DeleteRemoteFollow :one
+DELETE FROM remote_follows
+WHERE user_id = $1
+AND remote_actor_id = $2
+RETURNING *;
+
+-- name: IsRemoteActorFollowed :one
+SELECT EXISTS (
+    SELECT 1 FROM remote_follows
+    WHERE remote_actor_id = $1
+    AND accepted
+) AS followed;
+
+-- name: UpsertRemotePost :exec
+INSERT INTO remote_posts (id, uri, remote_actor_id, content, url, published_at, created_at, updated_at)
+VALUES (
+    gen_random_uuid(),
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    NOW(),
+    NOW()
+)
+ON CONFLICT (uri) DO UPDATE
+SET content = EXCLUDED.content,
+    url = EXCLUDED.url,
+    updated_at = NOW()
+WHERE remote_posts.remote_actor_id = EXCLUDED.remote_actor_id;
+
+-- name: DeleteRemotePost :execrows
+DELETE FROM remote_posts
+WHERE uri = $1
+AND remote_actor_id = $2;
+
+-- name: GetRemotePostsByIDs :many
+SELECT * FROM remote_posts
+WHERE id = ANY(sqlc.arg('ids')::uuid[]);
+
+-- name: AddRemoteLike :execrows
+INSERT INTO remote_likes (chirp_id, remote_actor_id, like_uri, created_at)
+VALUES (
+    $1,
+    $2,
+    $3,
+    NOW()
+)
+ON CONFLICT DO NOTHING;
+
+-- name: RemoveRemoteLike :execrows
+DELETE FROM remote_likes
+WHERE remote_actor_id = $1
+AND like_uri = $2;
+
+-- name: EnqueueActivity :exec
+INSERT INTO ap_deliveries (id, user_id, inbox, activity, status, next_attempt_at, created_at, updated_at)
+SELECT gen_random_uuid(), sqlc.arg('user_id')::uuid, inbox, sqlc.arg('activity')::text, 'pending', NOW(), NOW(), NOW()
+FROM unnest(sqlc.arg('inboxes')::text[]) AS inbox;
+
+-- name: ClaimActivityDeliveries :many
+UPDATE ap_deliveries
+SET next_attempt_at = NOW() + INTERVAL '5 minutes', updated_at = NOW()
+WHERE ap_deliveries.id IN (
+    SELECT due.id FROM ap_deliveries AS due
+    WHERE due.status IN ('pending', 'failed')
+    AND due.next_attempt_at <= NOW()
+    ORDER BY due.next_attempt_at
+    LIMIT $1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: FinishActivityDelivery :exec
+UPDATE ap_deliveries
+SET status = sqlc.arg('status')::text,
+    attempts = attempts + 1,
+    next_attempt_at = NOW() + sqlc.narg('retry_after_seconds')::integer * INTERVAL '1 second',
+    last_error = sqlc.arg('error')::text,
+    updated_at = NOW()
+WHERE id = sqlc.arg('id');
+
+-- name: DeleteFinishedActivityDeliveries :execrows
+DELETE FROM ap_deliveries
+WHERE status IN ('succeeded', 'dead')
+AND updated_at < NOW() - INTERVAL '7 days';
+
+-- name: GetHomeTimeline :many
+SELECT timeline.kind, timeline.id, timeline.created_at FROM (
+    SELECT 'chirp'::text AS kind, chirps.id, chirps.created_at
+    FROM chirps
+    WHERE (chirps.user_id = sqlc.arg('user_id')::uuid OR chirps.user_id IN (SELECT followee_id FROM follows WHERE follower_id = sqlc.arg('user_id')::uuid))
+    AND chirps.status = 'published'
+    AND (chirps.expires_at IS NULL OR chirps.expires_at > NOW())
+    AND chirps.deleted_at IS NULL
+    AND NOT chirp_hidden(chirps.id, chirps.user_id, sqlc.arg('user_id')::uuid)
+    UNION ALL
+    SELECT 'remote_post'::text AS kind, remote_posts.id, remote_posts.published_at AS created_at
+    FROM remote_posts
+    JOIN remote_follows ON remote_follows.remote_actor_id = remote_posts.remote_actor_id
+    WHERE remote_follows.user_id = sqlc.arg('user_id')::uuid
+    AND remote_follows.accepted
+) AS timeline
+WHERE (sqlc.narg('cursor_created_at')::timestamp IS NULL OR (timeline.created_at, timeline.id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY timeline.created_at DESC, timeline.id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: CountLikesForChirps :many
+SELECT chirp_id, COUNT(*) AS like_count
+FROM remote_likes
+WHERE chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+GROUP BY chirp_id;
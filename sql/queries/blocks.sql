@@ -0,0 +1,80 @@
+-- name: BlockUser :exec
+INSERT INTO blocks (blocker_id, blocked_id, created_at)
+VALUES (
+    $1,
+    $2,
+    NOW()
+)
+ON CONFLICT DO NOTHING;
+
+-- name: UnblockUser :execrows
+DELETE FROM blocks
+WHERE blocker_id = $1
+AND blocked_id = $2;
+
+-- name: IsBlocked :one
+SELECT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE (blocker_id = sqlc.arg('user_id') AND blocked_id = sqlc.arg('other_id'))
+    OR (blocker_id = sqlc.arg('other_id') AND blocked_id = sqlc.arg('user_id'))
+) AS blocked;
+
+-- name: ListBlockedUsers :many
+SELECT users.* FROM users
+JOIN blocks ON blocks.blocked_id = users.id
+WHERE blocks.blocker_id = $1
+ORDER BY blocks.created_at DESC;
+
+-- name: RemoveFollowsBetween :exec
+DELETE FROM follows
+WHERE (follower_id = sqlc.arg('user_id') AND followee_id = sqlc.arg('other_id'))
+OR (follower_id = sqlc.arg('other_id') AND followee_id = sqlc.arg('user_id'));
+
+-- name: MuteUser :exec
+INSERT INTO mutes (muter_id, muted_id, created_at)
+VALUES (
+    $1,
+    $2,
+    NOW()
+)
+ON CONFLICT DO NOTHING;
+
+-- name: UnmuteUser :execrows
+DELETE FROM mutes
+WHERE muter_id = $1
+AND muted_id = $2;
+
+-- name: ListMutedUsers :many
+SELECT users.* FROM users
+JOIN mutes ON mutes.muted_id = users.id
+WHERE mutes.muter_id = $1
+ORDER BY mutes.created_at DESC;
+
+-- name: CreateMutedWord :one
+INSERT INTO muted_words (id, user_id, kind, word, created_at, expires_at)
+VALUES (
+    gen_random_uuid(),
+    $1,
+    $2,
+    $3,
+    NOW(),
+    $4
+)
+ON CONFLICT (user_id, kind, word) DO UPDATE
+SET expires_at = EXCLUDED.expires_at
+RETURNING *;
+
+-- name: ListMutedWords :many
+SELECT * FROM muted_words
+WHERE user_id = $1
+AND (expires_at IS NULL OR expires_at > NOW())
+ORDER BY created_at DESC;
+
+-- name: DeleteMutedWord :execrows
+DELETE FROM muted_words
+WHERE id = $1
+AND user_id = $2;
+
+-- name: DeleteExpiredMutedWords :exec
+DELETE FROM muted_words
+WHERE expires_at <= NOW();
@@ -0,0 +1,58 @@
+-- name: UpsertBookmarkCollection :one
+INSERT INTO bookmark_collections (id, user_id, name, created_at)
+VALUES (
+    gen_random_uuid(),
+    $1,
+    $2,
+    NOW()
+)
+ON CONFLICT (user_id, LOWER(name)) DO UPDATE
+SET name = bookmark_collections.name
+RETURNING *;
+
+-- name: GetBookmarkCollectionByName :one
+SELECT * FROM bookmark_collections
+WHERE user_id = sqlc.arg('user_id')
+AND LOWER(name) = LOWER(sqlc.arg('name'));
+
+-- name: ListBookmarkCollections :many
+SELECT bookmark_collections.*, COUNT(bookmarks.chirp_id) AS bookmark_count FROM bookmark_collections
+LEFT JOIN bookmarks ON bookmarks.collection_id = bookmark_collections.id
+WHERE bookmark_collections.user_id = $1
+GROUP BY bookmark_collections.id
+ORDER BY bookmark_collections.name;
+
+-- name: DeleteBookmarkCollection :execrows
+DELETE FROM bookmark_collections
+WHERE id = $1
+AND user_id = $2;
+
+-- name: CreateBookmark :one
+INSERT INTO bookmarks (user_id, chirp_id, collection_id, created_at)
+VALUES (
+    $1,
+    $2,
+    $3,
+    NOW()
+)
+ON CONFLICT (user_id, chirp_id) DO UPDATE
+SET collection_id = EXCLUDED.collection_id
+RETURNING *;
+
+-- name: DeleteBookmark :execrows
+DELETE FROM bookmarks
+WHERE user_id = $1
+AND chirp_id = $2;
+
+-- name: GetBookmarks :many
+SELECT * FROM bookmarks
+WHERE user_id = sqlc.arg('user_id')
+AND (sqlc.narg('collection_id')::uuid IS NULL OR collection_id = sqlc.narg('collection_id')::uuid)
+AND (sqlc.narg('cursor_created_at')::timestamp IS NULL OR (created_at, chirp_id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY created_at DESC, chirp_id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetBookmarkedChirpIDs :many
+SELECT chirp_id FROM bookmarks
+WHERE user_id = sqlc.arg('user_id')
+AND chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[]);
@@ -1,27 +1,219 @@
 -- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, status, publish_at, expires_at)
 VALUES (
     gen_random_uuid(),
     NOW(),
     NOW(),
     $1,
-    $2
+    $2,
+    $3,
+    $4,
+    $5
 )
 RETURNING *;
 
 -- name: GetAllChirps :many
 SELECT * FROM chirps
-ORDER BY created_at;
+WHERE status = 'published'
+AND (expires_at IS NULL OR expires_at > NOW())
+AND deleted_at IS NULL
+AND (sqlc.narg('since')::timestamp IS NULL OR created_at >= sqlc.narg('since')::timestamp)
+AND (sqlc.narg('until')::timestamp IS NULL OR created_at < sqlc.narg('until')::timestamp)
+AND (sqlc.narg('cursor_created_at')::timestamp IS NULL OR (created_at, id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+AND NOT chirp_hidden(chirps.id, chirps.user_id, sqlc.narg('viewer_id')::uuid)
+ORDER BY created_at, id
+LIMIT sqlc.arg('limit');
+
+-- name: GetAllChirpsDesc :many
+SELECT * FROM chirps
+WHERE status = 'published'
+AND (expires_at IS NULL OR expires_at > NOW())
+AND deleted_at IS NULL
+AND (sqlc.narg('since')::timestamp IS NULL OR created_at >= sqlc.narg('since')::timestamp)
+AND (sqlc.narg('until')::timestamp IS NULL OR created_at < sqlc.narg('until')::timestamp)
+AND (sqlc.narg('cursor_created_at')::timestamp IS NULL OR (created_at, id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+AND NOT chirp_hidden(chirps.id, chirps.user_id, sqlc.narg('viewer_id')::uuid)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
 
 -- name: GetChirp :one
 SELECT * FROM chirps
-WHERE id = $1;
+WHERE id = $1
+AND (expires_at IS NULL OR expires_at > NOW())
+AND deleted_at IS NULL;
+
+-- name: SoftDeleteChirp :execrows
+UPDATE chirps
+SET deleted_at = NOW()
+WHERE id = $1
+AND deleted_at IS NULL;
+
+-- name: GetChirpsByUser :many
+SELECT * FROM chirps
+WHERE user_id = sqlc.arg('user_id')
+AND status = 'published'
+AND (NOT sqlc.arg('exclude_pinned')::boolean OR NOT EXISTS (SELECT 1 FROM pinned_chirps WHERE pinned_chirps.chirp_id = chirps.id))
+AND (expires_at IS NULL OR expires_at > NOW())
+AND deleted_at IS NULL
+AND (sqlc.narg('since')::timestamp IS NULL OR created_at >= sqlc.narg('since')::timestamp)
+AND (sqlc.narg('until')::timestamp IS NULL OR created_at < sqlc.narg('until')::timestamp)
+AND (sqlc.narg('cursor_created_at')::timestamp IS NULL OR (created_at, id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+AND NOT chirp_hidden(chirps.id, chirps.user_id, sqlc.narg('viewer_id')::uuid)
+ORDER BY created_at, id
+LIMIT sqlc.arg('limit');
+
+-- name: GetChirpsByUserDesc :many
+SELECT * FROM chirps
+WHERE user_id = sqlc.arg('user_id')
+AND status = 'published'
+AND (NOT sqlc.arg('exclude_pinned')::boolean OR NOT EXISTS (SELECT 1 FROM pinned_chirps WHERE pinned_chirps.chirp_id = chirps.id))
+AND (expires_at IS NULL OR expires_at > NOW())
+AND deleted_at IS NULL
+AND (sqlc.narg('since')::timestamp IS NULL OR created_at >= sqlc.narg('since')::timestamp)
+AND (sqlc.narg('until')::timestamp IS NULL OR created_at < sqlc.narg('until')::timestamp)
+AND (sqlc.narg('cursor_created_at')::timestamp IS NULL OR (created_at, id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+AND NOT chirp_hidden(chirps.id, chirps.user_id, sqlc.narg('viewer_id')::uuid)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: SearchChirps :many
+SELECT id, created_at, updated_at, body, user_id,
+    ts_rank_cd(search_vector, query) AS rank,
+    -- The snippet is served as HTML, so the body is escaped before <mark>
+    -- tags go in. The parser reads &lt; and friends as entities, not words.
+    ts_headline('english',
+        replace(replace(replace(replace(replace(body, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&quot;'), '''', '&#39;'),
+        query, 'StartSel=<mark>, StopSel=</mark>, MaxFragments=2') AS snippet
+FROM chirps
+CROSS JOIN to_tsquery('english', sqlc.arg('query')) AS query
+WHERE search_vector @@ query
+AND status = 'published'
+AND (expires_at IS NULL OR expires_at > NOW())
+AND deleted_at IS NULL
+AND (sqlc.narg('author_id')::uuid IS NULL OR user_id = sqlc.narg('author_id')::uuid)
+AND (sqlc.narg('since')::timestamp IS NULL OR created_at >= sqlc.narg('since')::timestamp)
+AND (sqlc.narg('until')::timestamp IS NULL OR created_at < sqlc.narg('until')::timestamp)
+AND (sqlc.narg('cursor_rank')::real IS NULL OR (ts_rank_cd(search_vector, query), created_at, id) < (sqlc.narg('cursor_rank')::real, sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+AND NOT chirp_hidden(chirps.id, chirps.user_id, sqlc.narg('viewer_id')::uuid)
+ORDER BY rank DESC, created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
 
--- name: DeleteChirp :exec
+
+-- name: GetDraftsByUser :many
+SELECT * FROM chirps
+WHERE user_id = sqlc.arg('user_id')
+AND status <> 'published'
+AND deleted_at IS NULL
+AND (sqlc.narg('cursor_created_at')::timestamp IS NULL OR (created_at, id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: UpdateDraft :one
+UPDATE chirps
+SET body = $3,
+status = $4,
+publish_at = $5,
+expires_at = $6,
+updated_at = NOW()
+WHERE id = $1
+AND user_id = $2
+AND status <> 'published'
+AND deleted_at IS NULL
+RETURNING *;
+
+-- name: PublishDraft :one
+UPDATE chirps
+SET status = 'published',
+publish_at = NULL,
+expires_at = NOW() + (expires_at - publish_at),
+created_at = NOW(),
+updated_at = NOW()
+WHERE id = $1
+AND user_id = $2
+AND status <> 'published'
+AND deleted_at IS NULL
+RETURNING *;
+
+-- name: ClaimDueChirps :many
+SELECT * FROM chirps
+WHERE status = 'scheduled'
+AND deleted_at IS NULL
+AND publish_at <= NOW()
+ORDER BY publish_at
+LIMIT $1
+FOR UPDATE SKIP LOCKED;
+
+-- name: MarkChirpPublished :one
+-- created_at is when the chirp actually went out rather than publish_at,
+-- so it sorts after every cursor handed out earlier and stream clients
+-- resuming with one still get it.
+UPDATE chirps
+SET status = 'published',
+created_at = NOW(),
+updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: ListExpiredChirps :many
+SELECT * FROM chirps
+WHERE expires_at <= NOW()
+ORDER BY expires_at
+LIMIT $1;
+
+-- name: DeleteExpiredChirps :many
 DELETE FROM chirps
+WHERE id = ANY(sqlc.arg('ids')::uuid[])
+AND expires_at <= NOW()
+RETURNING *;
+
+-- name: GetTrashByUser :many
+SELECT * FROM chirps
+WHERE user_id = sqlc.arg('user_id')
+AND deleted_at > sqlc.arg('deleted_after')::timestamp
+AND (sqlc.narg('cursor_created_at')::timestamp IS NULL OR (created_at, id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: RestoreChirp :one
+UPDATE chirps
+SET deleted_at = NULL
+WHERE id = $1
+AND user_id = $2
+AND deleted_at > $3
+RETURNING *;
+
+-- name: GetDeletedChirps :many
+SELECT * FROM chirps
+WHERE deleted_at IS NOT NULL
+AND (sqlc.narg('cursor_created_at')::timestamp IS NULL OR (created_at, id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetChirpIncludingDeleted :one
+SELECT * FROM chirps
 WHERE id = $1;
 
--- name: GetChirpsByUser :many
+-- name: ListPurgeableChirps :many
 SELECT * FROM chirps
-WHERE user_id = $1
-ORDER BY created_at;
\ No newline at end of file
+WHERE deleted_at < $1
+ORDER BY deleted_at
+LIMIT $2;
+
+-- name: PurgeChirps :execrows
+DELETE FROM chirps
+WHERE id = ANY(sqlc.arg('ids')::uuid[])
+AND deleted_at < sqlc.arg('deleted_before')::timestamp;
+
+-- name: GetChirpsByIDs :many
+SELECT * FROM chirps
+WHERE id = ANY(sqlc.arg('ids')::uuid[])
+AND status = 'published'
+AND (expires_at IS NULL OR expires_at > NOW())
+AND deleted_at IS NULL
+AND NOT chirp_hidden(chirps.id, chirps.user_id, sqlc.narg('viewer_id')::uuid);
+
+-- name: NotifyChirpPublished :exec
+SELECT pg_notify('chirp_published', sqlc.arg('chirp_id')::uuid::text);
+
+-- name: IsChirpHidden :one
+SELECT chirp_hidden(sqlc.arg('chirp_id')::uuid, sqlc.arg('author_id')::uuid, sqlc.arg('viewer_id')::uuid) AS hidden;
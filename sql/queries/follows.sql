@@ -0,0 +1,20 @@
+-- name: FollowUser :execrows
+INSERT INTO follows (follower_id, followee_id, created_at)
+VALUES (
+    $1,
+    $2,
+    NOW()
+)
+ON CONFLICT DO NOTHING;
+
+-- name: UnfollowUser :exec
+DELETE FROM follows
+WHERE follower_id = $1
+AND followee_id = $2;
+
+-- name: IsFollowing :one
+SELECT EXISTS (
+    SELECT 1 FROM follows
+    WHERE follower_id = $1
+    AND followee_id = $2
+) AS following;
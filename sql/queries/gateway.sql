@@ -0,0 +1,2 @@
+-- name: NotifyGatewaySignal :exec
+SELECT pg_notify('gateway_signal', sqlc.arg('payload')::text);
@@ -0,0 +1,21 @@
+-- name: CreateHandleRedirect :exec
+INSERT INTO handle_redirects (handle, user_id, created_at, expires_at)
+VALUES (
+    $1,
+    $2,
+    NOW(),
+    NOW() + INTERVAL '30 days'
+)
+ON CONFLICT (handle) DO UPDATE
+SET user_id = EXCLUDED.user_id,
+created_at = EXCLUDED.created_at,
+expires_at = EXCLUDED.expires_at;
+
+-- name: GetHandleRedirect :one
+SELECT * FROM handle_redirects
+WHERE handle = $1
+AND expires_at > NOW();
+
+-- name: DeleteHandleRedirect :exec
+DELETE FROM handle_redirects
+WHERE handle = $1;
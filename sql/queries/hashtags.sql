@@ -0,0 +1,50 @@
+-- name: UpsertHashtag :one
+INSERT INTO hashtags (id, created_at, tag)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    $1
+)
+ON CONFLICT (tag) DO UPDATE SET tag = EXCLUDED.tag
+RETURNING *;
+
+-- name: AddChirpHashtag :exec
+INSERT INTO chirp_hashtags (chirp_id, hashtag_id)
+VALUES (
+    $1,
+    $2
+)
+ON CONFLICT DO NOTHING;
+
+-- name: GetChirpsByHashtag :many
+SELECT chirps.* FROM chirps
+JOIN chirp_hashtags ON chirp_hashtags.chirp_id = chirps.id
+JOIN hashtags ON hashtags.id = chirp_hashtags.hashtag_id
+WHERE hashtags.tag = sqlc.arg('tag')
+AND chirps.status = 'published'
+AND (chirps.expires_at IS NULL OR chirps.expires_at > NOW())
+AND chirps.deleted_at IS NULL
+AND (sqlc.narg('since')::timestamp IS NULL OR chirps.created_at >= sqlc.narg('since')::timestamp)
+AND (sqlc.narg('until')::timestamp IS NULL OR chirps.created_at < sqlc.narg('until')::timestamp)
+AND (sqlc.narg('cursor_created_at')::timestamp IS NULL OR (chirps.created_at, chirps.id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+AND NOT chirp_hidden(chirps.id, chirps.user_id, sqlc.narg('viewer_id')::uuid)
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetChirpsByHashtagAsc :many
+SELECT chirps.* FROM chirps
+JOIN chirp_hashtags ON chirp_hashtags.chirp_id = chirps.id
+JOIN hashtags ON hashtags.id = chirp_hashtags.hashtag_id
+WHERE hashtags.tag = sqlc.arg('tag')
+AND chirps.status = 'published'
+AND (chirps.expires_at IS NULL OR chirps.expires_at > NOW())
+AND chirps.deleted_at IS NULL
+AND (sqlc.narg('author_id')::uuid IS NULL OR chirps.user_id = sqlc.narg('author_id')::uuid)
+AND (sqlc.narg('cursor_created_at')::timestamp IS NULL OR (chirps.created_at, chirps.id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+AND NOT chirp_hidden(chirps.id, chirps.user_id, sqlc.narg('viewer_id')::uuid)
+ORDER BY chirps.created_at, chirps.id
+LIMIT sqlc.arg('limit');
+
+-- name: DeleteChirpHashtags :exec
+DELETE FROM chirp_hashtags
+WHERE chirp_id = $1;
\ No newline at end of file
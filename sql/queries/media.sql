@@ -0,0 +1,49 @@
+-- name: CreateMedia :one
+INSERT INTO media (id, created_at, user_id, content_type, size, width, height, thumbnail_width, thumbnail_height, alt_text)
+VALUES (
+    $1,
+    NOW(),
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9
+)
+RETURNING *;
+
+-- name: GetMedia :one
+SELECT * FROM media
+WHERE id = $1;
+
+-- name: UpdateMediaAltText :one
+UPDATE media
+SET alt_text = $3
+WHERE id = $1
+AND user_id = $2
+RETURNING *;
+
+-- name: AttachMedia :execrows
+UPDATE media
+SET chirp_id = sqlc.arg('chirp_id'),
+position = array_position(sqlc.arg('ids')::uuid[], id)
+WHERE id = ANY(sqlc.arg('ids')::uuid[])
+AND user_id = sqlc.arg('user_id')
+AND chirp_id IS NULL;
+
+-- name: GetMediaForChirps :many
+SELECT * FROM media
+WHERE chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+ORDER BY chirp_id, position;
+
+-- name: ListOrphanedMedia :many
+SELECT * FROM media
+WHERE chirp_id IS NULL
+AND created_at < $1
+LIMIT 100;
+
+-- name: DeleteMedia :exec
+DELETE FROM media
+WHERE id = $1;
@@ -0,0 +1,32 @@
+-- name: CreateMention :exec
+INSERT INTO mentions (chirp_id, user_id, handle, created_at)
+VALUES (
+    $1,
+    $2,
+    $3,
+    NOW()
+)
+ON CONFLICT DO NOTHING;
+
+-- name: GetMentionsForChirps :many
+SELECT * FROM mentions
+WHERE chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[]);
+
+-- name: GetChirpsMentioningUser :many
+SELECT chirps.* FROM chirps
+JOIN mentions ON mentions.chirp_id = chirps.id
+WHERE mentions.user_id = sqlc.arg('user_id')
+AND chirps.status = 'published'
+AND (chirps.expires_at IS NULL OR chirps.expires_at > NOW())
+AND chirps.deleted_at IS NULL
+AND (sqlc.narg('since')::timestamp IS NULL OR chirps.created_at >= sqlc.narg('since')::timestamp)
+AND (sqlc.narg('until')::timestamp IS NULL OR chirps.created_at < sqlc.narg('until')::timestamp)
+AND (sqlc.narg('cursor_created_at')::timestamp IS NULL OR (chirps.created_at, chirps.id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+AND NOT chirp_hidden(chirps.id, chirps.user_id, sqlc.narg('viewer_id')::uuid)
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg('limit');
+
+
+-- name: DeleteChirpMentions :exec
+DELETE FROM mentions
+WHERE chirp_id = $1;
\ No newline at end of file
@@ -0,0 +1,141 @@
+-- name: GetDMPolicy :one
+SELECT COALESCE((SELECT policy FROM dm_settings WHERE user_id = $1), 'everyone')::text AS policy;
+
+-- name: SetDMPolicy :exec
+INSERT INTO dm_settings (user_id, policy)
+VALUES (
+    $1,
+    $2
+)
+ON CONFLICT (user_id) DO UPDATE
+SET policy = EXCLUDED.policy;
+
+-- name: CreateConversation :one
+INSERT INTO conversations (id, created_at, updated_at, created_by, is_group, title, direct_key)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    sqlc.arg('created_by')::uuid,
+    sqlc.arg('is_group')::boolean,
+    sqlc.arg('title')::text,
+    sqlc.narg('direct_key')::text
+)
+ON CONFLICT (direct_key) DO NOTHING
+RETURNING *;
+
+-- name: GetConversationByDirectKey :one
+SELECT * FROM conversations
+WHERE direct_key = sqlc.arg('direct_key')::text;
+
+-- name: AddConversationMember :exec
+INSERT INTO conversation_members (conversation_id, user_id, joined_at)
+VALUES (
+    $1,
+    $2,
+    NOW()
+)
+ON CONFLICT DO NOTHING;
+
+-- name: IsConversationMember :one
+SELECT EXISTS (
+    SELECT 1 FROM conversation_members
+    WHERE conversation_id = $1
+    AND user_id = $2
+) AS member;
+
+-- name: SharesConversation :one
+SELECT EXISTS (
+    SELECT 1 FROM conversation_members AS mine
+    JOIN conversation_members AS theirs ON theirs.conversation_id = mine.conversation_id
+    WHERE mine.user_id = sqlc.arg('user_id')
+    AND theirs.user_id = sqlc.arg('other_id')
+) AS shared;
+
+-- name: GetConversationForMember :one
+SELECT conversations.*, unread_messages(conversations.id, sqlc.arg('user_id')::uuid) AS unread_count FROM conversations
+JOIN conversation_members ON conversation_members.conversation_id = conversations.id
+WHERE conversations.id = sqlc.arg('id')
+AND conversation_members.user_id = sqlc.arg('user_id')::uuid;
+
+-- name: GetConversations :many
+SELECT conversations.*, unread_messages(conversations.id, sqlc.arg('user_id')::uuid) AS unread_count FROM conversations
+JOIN conversation_members ON conversation_members.conversation_id = conversations.id
+WHERE conversation_members.user_id = sqlc.arg('user_id')::uuid
+AND (sqlc.narg('cursor_created_at')::timestamp IS NULL OR (conversations.updated_at, conversations.id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY conversations.updated_at DESC, conversations.id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: CountUnreadMessages :one
+SELECT COALESCE(SUM(unread_messages(conversation_id, user_id)), 0)::bigint AS unread_count FROM conversation_members
+WHERE user_id = $1;
+
+-- name: GetConversationMembers :many
+SELECT conversation_members.conversation_id, users.id, users.handle, users.display_name, users.avatar_url FROM conversation_members
+JOIN users ON users.id = conversation_members.user_id
+WHERE conversation_members.conversation_id = ANY(sqlc.arg('conversation_ids')::uuid[])
+ORDER BY conversation_members.conversation_id, conversation_members.joined_at, users.id;
+
+-- name: GetLastMessages :many
+SELECT DISTINCT ON (messages.conversation_id) messages.* FROM messages
+WHERE messages.conversation_id = ANY(sqlc.arg('conversation_ids')::uuid[])
+AND NOT message_hidden(messages.id, messages.sender_id, sqlc.arg('viewer_id')::uuid)
+ORDER BY messages.conversation_id, messages.created_at DESC, messages.id DESC;
+
+-- name: TouchConversation :exec
+UPDATE conversations
+SET updated_at = NOW()
+WHERE id = $1;
+
+-- name: CreateMessage :one
+INSERT INTO messages (id, conversation_id, sender_id, body, created_at)
+VALUES (
+    gen_random_uuid(),
+    $1,
+    $2,
+    $3,
+    NOW()
+)
+RETURNING *;
+
+-- name: GetMessage :one
+SELECT * FROM messages
+WHERE id = $1
+AND conversation_id = $2;
+
+-- name: GetMessages :many
+SELECT * FROM messages
+WHERE conversation_id = sqlc.arg('conversation_id')
+AND NOT message_hidden(messages.id, messages.sender_id, sqlc.arg('viewer_id')::uuid)
+AND (sqlc.narg('cursor_created_at')::timestamp IS NULL OR (created_at, id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: MarkConversationRead :exec
+UPDATE conversation_members
+SET last_read_at = GREATEST(
+    COALESCE(last_read_at, '-infinity'),
+    COALESCE((
+        SELECT messages.created_at FROM messages
+        WHERE messages.id = sqlc.narg('message_id')::uuid
+        AND messages.conversation_id = sqlc.arg('conversation_id')
+    ), NOW())
+)
+WHERE conversation_id = sqlc.arg('conversation_id')
+AND user_id = sqlc.arg('user_id');
+
+-- name: DeleteMessageForEveryone :execrows
+UPDATE messages
+SET deleted_at = NOW(), body = ''
+WHERE id = $1
+AND sender_id = $2
+AND deleted_at IS NULL;
+
+-- name: DeleteMessageForUser :exec
+INSERT INTO message_deletions (message_id, user_id, created_at)
+VALUES (
+    $1,
+    $2,
+    NOW()
+)
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,46 @@
+-- name: ListModerationRules :many
+SELECT * FROM moderation_rules
+ORDER BY word;
+
+-- name: UpsertModerationRule :one
+INSERT INTO moderation_rules (id, created_at, updated_at, word, action)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    $1,
+    $2
+)
+ON CONFLICT (word) DO UPDATE
+SET action = EXCLUDED.action,
+updated_at = NOW()
+RETURNING *;
+
+-- name: DeleteModerationRule :execrows
+DELETE FROM moderation_rules
+WHERE id = $1;
+
+-- name: FlagChirp :exec
+INSERT INTO chirp_flags (chirp_id, created_at, words)
+VALUES (
+    $1,
+    NOW(),
+    $2
+)
+ON CONFLICT (chirp_id) DO UPDATE
+SET created_at = EXCLUDED.created_at,
+words = EXCLUDED.words,
+resolved_at = NULL;
+
+-- name: ListOpenChirpFlags :many
+SELECT chirp_flags.chirp_id, chirp_flags.created_at, chirp_flags.words, chirps.body, chirps.user_id FROM chirp_flags
+JOIN chirps ON chirps.id = chirp_flags.chirp_id
+WHERE chirp_flags.resolved_at IS NULL
+AND (chirps.expires_at IS NULL OR chirps.expires_at > NOW())
+ORDER BY chirp_flags.created_at;
+
+-- name: ResolveChirpFlag :execrows
+UPDATE chirp_flags
+SET resolved_at = NOW()
+WHERE chirp_id = $1
+AND resolved_at IS NULL;
@@ -0,0 +1,87 @@
+-- name: UpsertNotification :one
+INSERT INTO notifications (id, user_id, type, group_key, chirp_id, detail, created_at, updated_at)
+SELECT gen_random_uuid(), sqlc.arg('user_id')::uuid, sqlc.arg('type')::text, sqlc.arg('group_key')::text, sqlc.narg('chirp_id')::uuid, sqlc.arg('detail')::text, NOW(), NOW()
+WHERE EXISTS (SELECT 1 FROM users WHERE users.id = sqlc.arg('user_id')::uuid)
+AND NOT EXISTS (
+    SELECT 1 FROM notification_preferences
+    WHERE notification_preferences.user_id = sqlc.arg('user_id')::uuid
+    AND notification_preferences.type = sqlc.arg('type')::text
+    AND NOT notification_preferences.enabled
+)
+AND (sqlc.narg('actor_id')::uuid IS NULL OR (
+    sqlc.narg('actor_id')::uuid <> sqlc.arg('user_id')::uuid
+    AND NOT EXISTS (
+        SELECT 1 FROM blocks
+        WHERE (blocks.blocker_id = sqlc.arg('user_id')::uuid AND blocks.blocked_id = sqlc.narg('actor_id')::uuid)
+        OR (blocks.blocker_id = sqlc.narg('actor_id')::uuid AND blocks.blocked_id = sqlc.arg('user_id')::uuid)
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM mutes
+        WHERE mutes.muter_id = sqlc.arg('user_id')::uuid
+        AND mutes.muted_id = sqlc.narg('actor_id')::uuid
+    )
+))
+ON CONFLICT (user_id, group_key) WHERE read_at IS NULL DO UPDATE
+SET updated_at = NOW()
+RETURNING *;
+
+-- name: AddNotificationActor :exec
+INSERT INTO notification_actors (notification_id, actor_id, created_at)
+VALUES (
+    $1,
+    $2,
+    NOW()
+)
+ON CONFLICT (notification_id, actor_id) DO UPDATE
+SET created_at = NOW();
+
+-- name: GetNotifications :many
+SELECT notifications.*,
+    (SELECT COUNT(*) FROM notification_actors WHERE notification_actors.notification_id = notifications.id) AS actor_count
+FROM notifications
+WHERE notifications.user_id = sqlc.arg('user_id')
+AND (NOT sqlc.arg('unread_only')::boolean OR notifications.read_at IS NULL)
+AND (sqlc.narg('cursor_created_at')::timestamp IS NULL OR (notifications.updated_at, notifications.id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY notifications.updated_at DESC, notifications.id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetNotificationActors :many
+SELECT notification_actors.notification_id, users.id, users.handle, users.display_name, users.avatar_url FROM notification_actors
+JOIN users ON users.id = notification_actors.actor_id
+WHERE notification_actors.notification_id = ANY(sqlc.arg('notification_ids')::uuid[])
+ORDER BY notification_actors.notification_id, notification_actors.created_at DESC;
+
+-- name: CountUnreadNotifications :one
+SELECT COUNT(*) FROM notifications
+WHERE user_id = $1
+AND read_at IS NULL;
+
+-- name: MarkNotificationsRead :execrows
+UPDATE notifications
+SET read_at = NOW()
+WHERE user_id = sqlc.arg('user_id')
+AND id = ANY(sqlc.arg('ids')::uuid[])
+AND read_at IS NULL;
+
+-- name: MarkAllNotificationsRead :execrows
+UPDATE notifications
+SET read_at = NOW()
+WHERE user_id = $1
+AND read_at IS NULL;
+
+-- name: GetNotificationPreferences :many
+SELECT * FROM notification_preferences
+WHERE user_id = $1;
+
+-- name: SetNotificationPreference :exec
+INSERT INTO notification_preferences (user_id, type, enabled)
+VALUES (
+    $1,
+    $2,
+    $3
+)
+ON CONFLICT (user_id, type) DO UPDATE
+SET enabled = EXCLUDED.enabled;
+
+-- name: NotifyNotificationsChanged :exec
+SELECT pg_notify('notifications_changed', sqlc.arg('user_id')::uuid::text);
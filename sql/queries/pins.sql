@@ -0,0 +1,37 @@
+-- name: PinChirp :exec
+INSERT INTO pinned_chirps (user_id, chirp_id, created_at)
+VALUES (
+    $1,
+    $2,
+    NOW()
+)
+ON CONFLICT DO NOTHING;
+
+-- name: UnpinChirp :execrows
+DELETE FROM pinned_chirps
+WHERE user_id = $1
+AND chirp_id = $2;
+
+-- name: DeleteChirpPins :exec
+DELETE FROM pinned_chirps
+WHERE chirp_id = $1;
+
+-- name: CountPinnedChirps :one
+SELECT COUNT(*) FROM pinned_chirps
+JOIN chirps ON chirps.id = pinned_chirps.chirp_id
+WHERE pinned_chirps.user_id = $1
+AND (chirps.expires_at IS NULL OR chirps.expires_at > NOW());
+
+-- name: GetPinnedChirps :many
+SELECT chirps.* FROM chirps
+JOIN pinned_chirps ON pinned_chirps.chirp_id = chirps.id
+WHERE pinned_chirps.user_id = sqlc.arg('user_id')
+AND chirps.status = 'published'
+AND (chirps.expires_at IS NULL OR chirps.expires_at > NOW())
+AND chirps.deleted_at IS NULL
+AND NOT chirp_hidden(chirps.id, chirps.user_id, sqlc.narg('viewer_id')::uuid)
+ORDER BY pinned_chirps.created_at DESC;
+
+-- name: GetPinnedChirpIDs :many
+SELECT chirp_id FROM pinned_chirps
+WHERE chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[]);
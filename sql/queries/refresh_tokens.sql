@@ -23,4 +23,15 @@ SELECT (revoked_at IS NULL AND expires_at > NOW()) AS Valid FROM refresh_tokens;
 UPDATE refresh_tokens
 SET updated_at = NOW(),
 revoked_at = NOW()
-WHERE token = $1;
\ No newline at end of file
+WHERE token = $1;
+-- name: GetRefreshTokensByUser :many
+SELECT * FROM refresh_tokens
+WHERE user_id = $1
+ORDER BY created_at DESC;
+
+-- name: RevokeUserRefreshTokens :execrows
+UPDATE refresh_tokens
+SET updated_at = NOW(),
+revoked_at = NOW()
+WHERE user_id = $1
+AND revoked_at IS NULL;
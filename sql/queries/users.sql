@@ -1,11 +1,12 @@
 -- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, email, hashed_password)
+INSERT INTO users (id, created_at, updated_at, email, hashed_password, handle)
 VALUES (
     gen_random_uuid(),
     NOW(),
     NOW(),
-    $1,
-    $2
+    sqlc.arg('email'),
+    sqlc.arg('hashed_password'),
+    COALESCE(sqlc.narg('handle'), 'user_' || substr(md5(gen_random_uuid()::text), 1, 12))
 )
 RETURNING *;
 
@@ -25,4 +26,79 @@ RETURNING *;
 -- name: UpgradeUser :exec
 UPDATE users
 SET is_chirpy_red = TRUE
-WHERE id = $1;
\ No newline at end of file
+WHERE id = $1;
+
+-- name: GetUsersByHandles :many
+SELECT * FROM users
+WHERE LOWER(handle) = ANY(sqlc.arg('handles')::text[]);
+
+-- name: GetUsersByIDs :many
+SELECT * FROM users
+WHERE id = ANY(sqlc.arg('ids')::uuid[]);
+
+-- name: GetUserByHandle :one
+SELECT * FROM users
+WHERE LOWER(handle) = LOWER(sqlc.arg('handle'));
+
+-- name: GetUserProfile :one
+SELECT users.*,
+    (SELECT COUNT(*) FROM chirps WHERE chirps.user_id = users.id AND chirps.status = 'published' AND (chirps.expires_at IS NULL OR chirps.expires_at > NOW()) AND chirps.deleted_at IS NULL) AS chirp_count,
+    (SELECT COUNT(*) FROM follows WHERE follows.followee_id = users.id) AS follower_count,
+    (SELECT COUNT(*) FROM follows WHERE follows.follower_id = users.id) AS following_count
+FROM users
+WHERE users.id = $1;
+
+-- name: GetUserCounts :many
+SELECT users.id,
+    (SELECT COUNT(*) FROM chirps WHERE chirps.user_id = users.id AND chirps.status = 'published' AND (chirps.expires_at IS NULL OR chirps.expires_at > NOW()) AND chirps.deleted_at IS NULL) AS chirp_count,
+    (SELECT COUNT(*) FROM follows WHERE follows.followee_id = users.id) AS follower_count,
+    (SELECT COUNT(*) FROM follows WHERE follows.follower_id = users.id) AS following_count
+FROM users
+WHERE users.id = ANY(sqlc.arg('ids')::uuid[]);
+
+-- name: UpdateProfile :one
+UPDATE users
+SET display_name = COALESCE(sqlc.narg('display_name'), display_name),
+bio = COALESCE(sqlc.narg('bio'), bio),
+avatar_url = COALESCE(sqlc.narg('avatar_url'), avatar_url),
+location = COALESCE(sqlc.narg('location'), location),
+updated_at = NOW()
+WHERE id = sqlc.arg('id')
+RETURNING *;
+
+-- name: ChangeHandle :one
+UPDATE users
+SET handle = $2,
+handle_changed_at = NOW(),
+updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+-- name: GetUser :one
+SELECT * FROM users
+WHERE id = $1;
+
+-- name: LockUser :one
+SELECT * FROM users
+WHERE id = $1
+FOR UPDATE;
+
+-- name: DowngradeUser :exec
+UPDATE users
+SET is_chirpy_red = FALSE
+WHERE id = $1;
+
+-- name: SetUserPassword :one
+UPDATE users
+SET hashed_password = $2,
+updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: TouchChirpsChangedAt :exec
+UPDATE users
+SET chirps_changed_at = NOW()
+WHERE id = ANY(sqlc.arg('ids')::uuid[]);
+
+-- name: GetLatestChirpsChangedAt :one
+SELECT MAX(chirps_changed_at)::timestamp AS chirps_changed_at
+FROM users;
@@ -0,0 +1,112 @@
+-- name: CreateWebhookEndpoint :one
+INSERT INTO webhook_endpoints (id, user_id, url, secret, events, created_at, updated_at)
+VALUES (
+    gen_random_uuid(),
+    $1,
+    $2,
+    $3,
+    $4,
+    NOW(),
+    NOW()
+)
+RETURNING *;
+
+-- name: CountWebhookEndpoints :one
+SELECT COUNT(*) FROM webhook_endpoints
+WHERE user_id = $1;
+
+-- name: GetWebhookEndpoints :many
+SELECT * FROM webhook_endpoints
+WHERE user_id = $1
+ORDER BY created_at DESC;
+
+-- name: GetWebhookEndpoint :one
+SELECT * FROM webhook_endpoints
+WHERE id = $1
+AND user_id = $2;
+
+-- name: UpdateWebhookEndpoint :one
+UPDATE webhook_endpoints
+SET url = $3, events = $4, active = $5, updated_at = NOW()
+WHERE id = $1
+AND user_id = $2
+RETURNING *;
+
+-- name: DeleteWebhookEndpoint :execrows
+DELETE FROM webhook_endpoints
+WHERE id = $1
+AND user_id = $2;
+
+-- name: EnqueueWebhook :exec
+INSERT INTO webhook_deliveries (id, endpoint_id, event_id, event_type, payload, status, next_attempt_at, created_at, updated_at)
+SELECT gen_random_uuid(), webhook_endpoints.id, sqlc.arg('event_id')::uuid, sqlc.arg('event_type')::text, sqlc.arg('payload')::text, 'pending', NOW(), NOW(), NOW()
+FROM webhook_endpoints
+WHERE webhook_endpoints.user_id = sqlc.arg('user_id')::uuid
+AND webhook_endpoints.active
+AND sqlc.arg('event_type')::text = ANY(webhook_endpoints.events);
+
+-- name: ClaimWebhookDeliveries :many
+UPDATE webhook_deliveries
+SET next_attempt_at = NOW() + INTERVAL '5 minutes', updated_at = NOW()
+WHERE webhook_deliveries.id IN (
+    SELECT due.id FROM webhook_deliveries AS due
+    JOIN webhook_endpoints ON webhook_endpoints.id = due.endpoint_id
+    WHERE due.status IN ('pending', 'failed')
+    AND due.next_attempt_at <= NOW()
+    AND webhook_endpoints.active
+    ORDER BY due.next_attempt_at
+    LIMIT $1
+    FOR UPDATE OF due SKIP LOCKED
+)
+RETURNING *;
+
+-- name: GetWebhookEndpointsByIDs :many
+SELECT * FROM webhook_endpoints
+WHERE id = ANY(sqlc.arg('ids')::uuid[]);
+
+-- name: RecordWebhookAttempt :exec
+INSERT INTO webhook_attempts (id, delivery_id, attempted_at, status_code, error, duration_ms)
+VALUES (
+    gen_random_uuid(),
+    $1,
+    NOW(),
+    $2,
+    $3,
+    $4
+);
+
+-- name: FinishWebhookAttempt :exec
+UPDATE webhook_deliveries
+SET status = sqlc.arg('status')::text,
+    attempts = attempts + 1,
+    next_attempt_at = NOW() + sqlc.narg('retry_after_seconds')::integer * INTERVAL '1 second',
+    last_status_code = sqlc.narg('status_code')::integer,
+    last_error = sqlc.arg('error')::text,
+    updated_at = NOW()
+WHERE id = sqlc.arg('id');
+
+-- name: GetWebhookDeliveries :many
+SELECT * FROM webhook_deliveries
+WHERE endpoint_id = sqlc.arg('endpoint_id')
+AND (sqlc.narg('status')::text IS NULL OR status = sqlc.narg('status')::text)
+AND (sqlc.narg('cursor_created_at')::timestamp IS NULL OR (created_at, id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetWebhookAttempts :many
+SELECT * FROM webhook_attempts
+WHERE delivery_id = ANY(sqlc.arg('delivery_ids')::uuid[])
+ORDER BY delivery_id, attempted_at;
+
+-- name: RedeliverWebhook :one
+UPDATE webhook_deliveries
+SET status = 'pending', attempts = 0, next_attempt_at = NOW(), updated_at = NOW()
+WHERE id = $1
+AND endpoint_id = $2
+AND status <> 'pending'
+RETURNING *;
+
+-- name: DeleteFinishedWebhookDeliveries :execrows
+DELETE FROM webhook_deliveries
+WHERE status IN ('succeeded', 'dead')
+AND updated_at < NOW() - INTERVAL '30 days';
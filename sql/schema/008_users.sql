@@ -0,0 +1,13 @@
+-- +goose Up
+ALTER TABLE users
+ADD COLUMN
+handle TEXT NOT NULL DEFAULT ('user_' || substr(md5(gen_random_uuid()::text), 1, 12));
+
+CREATE UNIQUE INDEX users_handle_lower_idx ON users (LOWER(handle));
+
+-- +goose Down
+DROP INDEX users_handle_lower_idx;
+
+ALTER TABLE users
+DROP COLUMN
+handle;
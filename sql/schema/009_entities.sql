@@ -0,0 +1,29 @@
+-- +goose Up
+CREATE TABLE hashtags (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    tag TEXT UNIQUE NOT NULL
+);
+
+CREATE TABLE chirp_hashtags (
+    chirp_id UUID NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    hashtag_id UUID NOT NULL REFERENCES hashtags(id) ON DELETE CASCADE,
+    PRIMARY KEY (chirp_id, hashtag_id)
+);
+
+CREATE INDEX chirp_hashtags_hashtag_id_idx ON chirp_hashtags (hashtag_id);
+
+CREATE TABLE mentions (
+    chirp_id UUID NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    handle TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (chirp_id, user_id)
+);
+
+CREATE INDEX mentions_user_id_idx ON mentions (user_id);
+
+-- +goose Down
+DROP TABLE mentions;
+DROP TABLE chirp_hashtags;
+DROP TABLE hashtags;
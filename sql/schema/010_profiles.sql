@@ -0,0 +1,35 @@
+-- +goose Up
+ALTER TABLE users
+ADD COLUMN display_name TEXT NOT NULL DEFAULT '',
+ADD COLUMN bio TEXT NOT NULL DEFAULT '',
+ADD COLUMN avatar_url TEXT NOT NULL DEFAULT '',
+ADD COLUMN location TEXT NOT NULL DEFAULT '',
+ADD COLUMN handle_changed_at TIMESTAMP;
+
+CREATE TABLE handle_redirects (
+    handle TEXT PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE follows (
+    follower_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    followee_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (follower_id, followee_id),
+    CHECK (follower_id <> followee_id)
+);
+
+CREATE INDEX follows_followee_id_idx ON follows (followee_id);
+
+-- +goose Down
+DROP TABLE follows;
+DROP TABLE handle_redirects;
+
+ALTER TABLE users
+DROP COLUMN display_name,
+DROP COLUMN bio,
+DROP COLUMN avatar_url,
+DROP COLUMN location,
+DROP COLUMN handle_changed_at;
@@ -0,0 +1,25 @@
+-- +goose Up
+CREATE TABLE moderation_rules (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    word TEXT UNIQUE NOT NULL,
+    action TEXT NOT NULL CHECK (action IN ('mask', 'flag', 'reject'))
+);
+
+INSERT INTO moderation_rules (id, created_at, updated_at, word, action)
+VALUES
+    (gen_random_uuid(), NOW(), NOW(), 'kerfuffle', 'mask'),
+    (gen_random_uuid(), NOW(), NOW(), 'sharbert', 'mask'),
+    (gen_random_uuid(), NOW(), NOW(), 'fornax', 'mask');
+
+CREATE TABLE chirp_flags (
+    chirp_id UUID PRIMARY KEY REFERENCES chirps(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    words TEXT[] NOT NULL,
+    resolved_at TIMESTAMP
+);
+
+-- +goose Down
+DROP TABLE chirp_flags;
+DROP TABLE moderation_rules;
@@ -0,0 +1,20 @@
+-- +goose Up
+CREATE TABLE media (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    chirp_id UUID REFERENCES chirps(id) ON DELETE SET NULL,
+    position INTEGER NOT NULL DEFAULT 0,
+    content_type TEXT NOT NULL,
+    size INTEGER NOT NULL,
+    width INTEGER NOT NULL,
+    height INTEGER NOT NULL,
+    thumbnail_width INTEGER NOT NULL,
+    thumbnail_height INTEGER NOT NULL,
+    alt_text TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX media_chirp_id_idx ON media (chirp_id);
+
+-- +goose Down
+DROP TABLE media;
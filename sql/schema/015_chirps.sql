@@ -0,0 +1,13 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD COLUMN deleted_at TIMESTAMP;
+
+CREATE INDEX chirps_deleted_user_id_idx ON chirps (user_id, created_at, id) WHERE deleted_at IS NOT NULL;
+CREATE INDEX chirps_deleted_at_idx ON chirps (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- +goose Down
+DROP INDEX chirps_deleted_at_idx;
+DROP INDEX chirps_deleted_user_id_idx;
+
+ALTER TABLE chirps
+DROP COLUMN deleted_at;
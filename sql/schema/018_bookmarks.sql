@@ -0,0 +1,23 @@
+-- +goose Up
+CREATE TABLE bookmark_collections (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE UNIQUE INDEX bookmark_collections_user_id_name_idx ON bookmark_collections (user_id, LOWER(name));
+
+CREATE TABLE bookmarks (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    chirp_id UUID NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    collection_id UUID REFERENCES bookmark_collections(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, chirp_id)
+);
+
+CREATE INDEX bookmarks_user_id_created_at_idx ON bookmarks (user_id, created_at, chirp_id);
+
+-- +goose Down
+DROP TABLE bookmarks;
+DROP TABLE bookmark_collections;
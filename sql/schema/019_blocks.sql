@@ -0,0 +1,73 @@
+-- +goose Up
+CREATE TABLE blocks (
+    blocker_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    blocked_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (blocker_id, blocked_id),
+    CHECK (blocker_id <> blocked_id)
+);
+
+CREATE INDEX blocks_blocked_id_idx ON blocks (blocked_id);
+
+CREATE TABLE mutes (
+    muter_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    muted_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (muter_id, muted_id),
+    CHECK (muter_id <> muted_id)
+);
+
+CREATE TABLE muted_words (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL CHECK (kind IN ('keyword', 'hashtag')),
+    word TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP,
+    UNIQUE (user_id, kind, word)
+);
+
+-- chirp_hidden reports whether a chirp should be left out of the reads of
+-- viewer: either side has blocked the other, the viewer muted the author,
+-- or the chirp matches one of the viewer's muted words. Anonymous reads
+-- pass a NULL viewer and see everything.
+-- +goose StatementBegin
+CREATE FUNCTION chirp_hidden(target_chirp_id UUID, target_author_id UUID, viewer UUID) RETURNS BOOLEAN
+LANGUAGE sql STABLE AS $$
+    SELECT viewer IS NOT NULL AND viewer <> target_author_id AND (
+        EXISTS (
+            SELECT 1 FROM blocks
+            WHERE (blocks.blocker_id = viewer AND blocks.blocked_id = target_author_id)
+            OR (blocks.blocker_id = target_author_id AND blocks.blocked_id = viewer)
+        )
+        OR EXISTS (
+            SELECT 1 FROM mutes
+            WHERE mutes.muter_id = viewer
+            AND mutes.muted_id = target_author_id
+        )
+        OR EXISTS (
+            SELECT 1 FROM muted_words
+            JOIN chirps ON chirps.id = target_chirp_id
+            WHERE muted_words.user_id = viewer
+            AND muted_words.kind = 'keyword'
+            AND (muted_words.expires_at IS NULL OR muted_words.expires_at > NOW())
+            AND chirps.search_vector @@ phraseto_tsquery('english', muted_words.word)
+        )
+        OR EXISTS (
+            SELECT 1 FROM muted_words
+            JOIN hashtags ON hashtags.tag = muted_words.word
+            JOIN chirp_hashtags ON chirp_hashtags.hashtag_id = hashtags.id
+            WHERE muted_words.user_id = viewer
+            AND muted_words.kind = 'hashtag'
+            AND (muted_words.expires_at IS NULL OR muted_words.expires_at > NOW())
+            AND chirp_hashtags.chirp_id = target_chirp_id
+        )
+    )
+$$;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP FUNCTION chirp_hidden(UUID, UUID, UUID);
+DROP TABLE muted_words;
+DROP TABLE mutes;
+DROP TABLE blocks;
@@ -0,0 +1,35 @@
+-- +goose Up
+CREATE TABLE notifications (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type TEXT NOT NULL,
+    group_key TEXT NOT NULL,
+    chirp_id UUID REFERENCES chirps(id) ON DELETE CASCADE,
+    detail TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    read_at TIMESTAMP
+);
+
+-- Similar events are folded into one unread notification per group.
+CREATE UNIQUE INDEX notifications_unread_group_idx ON notifications (user_id, group_key) WHERE read_at IS NULL;
+CREATE INDEX notifications_user_id_updated_at_idx ON notifications (user_id, updated_at, id);
+
+CREATE TABLE notification_actors (
+    notification_id UUID NOT NULL REFERENCES notifications(id) ON DELETE CASCADE,
+    actor_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (notification_id, actor_id)
+);
+
+CREATE TABLE notification_preferences (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type TEXT NOT NULL,
+    enabled BOOLEAN NOT NULL,
+    PRIMARY KEY (user_id, type)
+);
+
+-- +goose Down
+DROP TABLE notification_preferences;
+DROP TABLE notification_actors;
+DROP TABLE notifications;
@@ -0,0 +1,90 @@
+-- +goose Up
+CREATE TABLE dm_settings (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    policy TEXT NOT NULL CHECK (policy IN ('everyone', 'following', 'nobody'))
+);
+
+CREATE TABLE conversations (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    is_group BOOLEAN NOT NULL,
+    title TEXT NOT NULL DEFAULT '',
+    -- direct_key is set on one-to-one conversations so a pair of users only
+    -- ever has one of them.
+    direct_key TEXT UNIQUE
+);
+
+CREATE TABLE conversation_members (
+    conversation_id UUID NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    joined_at TIMESTAMP NOT NULL,
+    last_read_at TIMESTAMP,
+    PRIMARY KEY (conversation_id, user_id)
+);
+
+CREATE INDEX conversation_members_user_id_idx ON conversation_members (user_id);
+
+CREATE TABLE messages (
+    id UUID PRIMARY KEY,
+    conversation_id UUID NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+    sender_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    deleted_at TIMESTAMP
+);
+
+CREATE INDEX messages_conversation_id_created_at_idx ON messages (conversation_id, created_at DESC, id DESC);
+
+-- message_deletions hides a message from one member only.
+CREATE TABLE message_deletions (
+    message_id UUID NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (message_id, user_id)
+);
+
+-- message_hidden reports whether viewer shouldn't see a message: they
+-- deleted it for themselves, or they and the sender have blocked one
+-- another.
+-- +goose StatementBegin
+CREATE FUNCTION message_hidden(target_message_id UUID, sender UUID, viewer UUID) RETURNS BOOLEAN
+LANGUAGE sql STABLE AS $$
+    SELECT EXISTS (
+        SELECT 1 FROM message_deletions
+        WHERE message_deletions.message_id = target_message_id
+        AND message_deletions.user_id = viewer
+    )
+    OR (sender <> viewer AND EXISTS (
+        SELECT 1 FROM blocks
+        WHERE (blocks.blocker_id = viewer AND blocks.blocked_id = sender)
+        OR (blocks.blocker_id = sender AND blocks.blocked_id = viewer)
+    ))
+$$;
+-- +goose StatementEnd
+
+-- unread_messages counts the messages in a conversation that viewer hasn't
+-- read yet.
+-- +goose StatementBegin
+CREATE FUNCTION unread_messages(target_conversation_id UUID, viewer UUID) RETURNS BIGINT
+LANGUAGE sql STABLE AS $$
+    SELECT COUNT(*) FROM messages
+    JOIN conversation_members ON conversation_members.conversation_id = messages.conversation_id
+    AND conversation_members.user_id = viewer
+    WHERE messages.conversation_id = target_conversation_id
+    AND messages.sender_id <> viewer
+    AND messages.deleted_at IS NULL
+    AND messages.created_at > COALESCE(conversation_members.last_read_at, '-infinity')
+    AND NOT message_hidden(messages.id, messages.sender_id, viewer)
+$$;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP FUNCTION unread_messages(UUID, UUID);
+DROP FUNCTION message_hidden(UUID, UUID, UUID);
+DROP TABLE message_deletions;
+DROP TABLE messages;
+DROP TABLE conversation_members;
+DROP TABLE conversations;
+DROP TABLE dm_settings;
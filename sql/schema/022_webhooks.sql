@@ -0,0 +1,51 @@
+-- +goose Up
+CREATE TABLE webhook_endpoints (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    events TEXT[] NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX webhook_endpoints_user_id_idx ON webhook_endpoints (user_id);
+
+-- webhook_deliveries is the outbound queue. A delivery is pending until its
+-- first attempt, failed while it's being retried, and ends up succeeded or,
+-- once it runs out of attempts, dead.
+CREATE TABLE webhook_deliveries (
+    id UUID PRIMARY KEY,
+    endpoint_id UUID NOT NULL REFERENCES webhook_endpoints(id) ON DELETE CASCADE,
+    event_id UUID NOT NULL,
+    event_type TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('pending', 'failed', 'succeeded', 'dead')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP,
+    last_status_code INTEGER,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX webhook_deliveries_next_attempt_at_idx ON webhook_deliveries (next_attempt_at)
+WHERE status IN ('pending', 'failed');
+CREATE INDEX webhook_deliveries_endpoint_id_idx ON webhook_deliveries (endpoint_id, created_at DESC, id DESC);
+
+CREATE TABLE webhook_attempts (
+    id UUID PRIMARY KEY,
+    delivery_id UUID NOT NULL REFERENCES webhook_deliveries(id) ON DELETE CASCADE,
+    attempted_at TIMESTAMP NOT NULL,
+    status_code INTEGER,
+    error TEXT NOT NULL DEFAULT '',
+    duration_ms INTEGER NOT NULL
+);
+
+CREATE INDEX webhook_attempts_delivery_id_idx ON webhook_attempts (delivery_id);
+
+-- +goose Down
+DROP TABLE webhook_attempts;
+DROP TABLE webhook_deliveries;
+DROP TABLE webhook_endpoints;
@@ -0,0 +1,105 @@
+-- +goose Up
+-- actor_keys holds the key each user signs outgoing activities with. It's
+-- made the first time the user's actor is needed.
+CREATE TABLE actor_keys (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    public_key_pem TEXT NOT NULL,
+    private_key_pem TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+-- remote_actors caches the actor documents of accounts on other servers.
+CREATE TABLE remote_actors (
+    id UUID PRIMARY KEY,
+    uri TEXT UNIQUE NOT NULL,
+    username TEXT NOT NULL,
+    domain TEXT NOT NULL,
+    display_name TEXT NOT NULL DEFAULT '',
+    summary TEXT NOT NULL DEFAULT '',
+    url TEXT NOT NULL DEFAULT '',
+    inbox TEXT NOT NULL,
+    shared_inbox TEXT NOT NULL DEFAULT '',
+    public_key_id TEXT NOT NULL,
+    public_key_pem TEXT NOT NULL,
+    fetched_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE UNIQUE INDEX remote_actors_public_key_id_idx ON remote_actors (public_key_id);
+CREATE UNIQUE INDEX remote_actors_account_idx ON remote_actors (LOWER(username), LOWER(domain));
+
+-- remote_followers are remote accounts following local users; follow_uri
+-- is their Follow activity, which an Undo refers back to.
+CREATE TABLE remote_followers (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    remote_actor_id UUID NOT NULL REFERENCES remote_actors(id) ON DELETE CASCADE,
+    follow_uri TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, remote_actor_id)
+);
+
+CREATE INDEX remote_followers_remote_actor_id_idx ON remote_followers (remote_actor_id);
+
+-- remote_follows are local users following remote accounts. They're
+-- pending until the remote server accepts them.
+CREATE TABLE remote_follows (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    remote_actor_id UUID NOT NULL REFERENCES remote_actors(id) ON DELETE CASCADE,
+    accepted BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL,
+    UNIQUE (user_id, remote_actor_id)
+);
+
+CREATE INDEX remote_follows_remote_actor_id_idx ON remote_follows (remote_actor_id);
+
+CREATE TABLE remote_posts (
+    id UUID PRIMARY KEY,
+    uri TEXT UNIQUE NOT NULL,
+    remote_actor_id UUID NOT NULL REFERENCES remote_actors(id) ON DELETE CASCADE,
+    content TEXT NOT NULL,
+    url TEXT NOT NULL DEFAULT '',
+    published_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX remote_posts_remote_actor_id_published_at_idx ON remote_posts (remote_actor_id, published_at DESC, id DESC);
+
+CREATE TABLE remote_likes (
+    chirp_id UUID NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    remote_actor_id UUID NOT NULL REFERENCES remote_actors(id) ON DELETE CASCADE,
+    like_uri TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (chirp_id, remote_actor_id)
+);
+
+CREATE INDEX remote_likes_remote_actor_id_idx ON remote_likes (remote_actor_id);
+
+-- ap_deliveries is the outbound queue of activities, one row per inbox,
+-- signed with user_id's key when they're sent. Statuses work like
+-- webhook_deliveries'.
+CREATE TABLE ap_deliveries (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    inbox TEXT NOT NULL,
+    activity TEXT NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('pending', 'failed', 'succeeded', 'dead')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX ap_deliveries_next_attempt_at_idx ON ap_deliveries (next_attempt_at)
+WHERE status IN ('pending', 'failed');
+
+-- +goose Down
+DROP TABLE ap_deliveries;
+DROP TABLE remote_likes;
+DROP TABLE remote_posts;
+DROP TABLE remote_follows;
+DROP TABLE remote_followers;
+DROP TABLE remote_actors;
+DROP TABLE actor_keys;
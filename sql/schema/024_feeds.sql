@@ -0,0 +1,14 @@
+-- +goose Up
+-- chirps_changed_at is when one of the user's published chirps last went
+-- away or came back: trashed, expired or restored. None of those leave a
+-- newer updated_at behind, so feeds date themselves by this too.
+ALTER TABLE users
+ADD COLUMN chirps_changed_at TIMESTAMP;
+
+CREATE INDEX users_chirps_changed_at_idx ON users (chirps_changed_at);
+
+-- +goose Down
+DROP INDEX users_chirps_changed_at_idx;
+
+ALTER TABLE users
+DROP COLUMN chirps_changed_at;
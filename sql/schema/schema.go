@@ -0,0 +1,9 @@
+// Package schema embeds the goose migrations in this directory, so the
+// server and chirpyctl can apply them without the files on disk. sqlc
+// generates internal/database from the same files.
+package schema
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
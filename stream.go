@@ -0,0 +1,319 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Baehry/chirpy/internal/database"
+	"github.com/Baehry/chirpy/internal/entities"
+	"github.com/Baehry/chirpy/internal/pagination"
+	"github.com/Baehry/chirpy/internal/stream"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const (
+	chirpPublishedChannel = "chirp_published"
+	streamBufferSize = 64
+	streamHeartbeatInterval = 15 * time.Second
+	listenerPingInterval = 90 * time.Second
+	maxStreamReplay = 500
+	streamReplayBatchSize = 100
+)
+
+// chirpTopics lists the hub topics a chirp is published to.
+func chirpTopics(chirp database.Chirp) []string {
+	topics := []string{"chirps", "author:" + chirp.UserID.String()}
+	for _, tag := range entities.Values(entities.Parse(chirp.Body), entities.TypeHashtag) {
+		topics = append(topics, "hashtag:"+tag)
+	}
+	return topics
+}
+
+// eventAuthor recovers the author of a chirp event from its topics.
+func eventAuthor(event stream.Event) (uuid.UUID, bool) {
+	for _, topic := range event.Topics {
+		if author, ok := strings.CutPrefix(topic, "author:"); ok {
+			id, err := uuid.Parse(author)
+			return id, err == nil
+		}
+	}
+	return uuid.Nil, false
+}
+
+// listenForEvents feeds the hub from Postgres. chirpPublished sends a
+// NOTIFY in the publishing transaction, so every instance hears about every
+// chirp once it's committed, whichever instance published it. Anything
+// missed while the listener reconnects can be caught up on with
+// Last-Event-ID. Notification counts and gateway signals travel the same
+// way.
+func (cfg *apiConfig) listenForEvents(ctx context.Context, dbURL string) {
+	listener := pq.NewListener(dbURL, 10*time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			fmt.Printf("event listener: %v\n", err)
+		}
+	})
+	defer listener.Close()
+	for _, channel := range []string{chirpPublishedChannel, notificationsChangedChannel, gatewaySignalChannel} {
+		if err := listener.Listen(channel); err != nil {
+			fmt.Printf("listening on %s: %v\n", channel, err)
+			return
+		}
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case notification := <-listener.Notify:
+			// A nil notification means the connection was re-established.
+			if notification == nil {
+				continue
+			}
+			var err error
+			switch notification.Channel {
+			case chirpPublishedChannel:
+				err = cfg.handleChirpPublished(ctx, notification.Extra)
+			case notificationsChangedChannel:
+				err = cfg.publishNotificationCount(ctx, notification.Extra)
+			case gatewaySignalChannel:
+				err = cfg.publishSignal(notification.Extra)
+			}
+			if err != nil {
+				fmt.Printf("handling %s: %v\n", notification.Channel, err)
+			}
+		case <-time.After(listenerPingInterval):
+			go listener.Ping()
+		}
+	}
+}
+
+func (cfg *apiConfig) handleChirpPublished(ctx context.Context, payload string) error {
+	id, err := uuid.Parse(payload)
+	if err != nil {
+		return err
+	}
+	chirp, err := cfg.dbQueries.GetChirp(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return cfg.publishChirpEvent(ctx, chirp)
+}
+
+func (cfg *apiConfig) publishChirpEvent(ctx context.Context, chirp database.Chirp) error {
+	event, err := cfg.chirpEvent(ctx, chirp)
+	if err != nil {
+		return err
+	}
+	cfg.hub.Publish(event, chirpTopics(chirp)...)
+	return nil
+}
+
+// chirpEvent renders chirp as a stream event. Its ID is a pagination
+// cursor, which is what lets a client resume with Last-Event-ID.
+func (cfg *apiConfig) chirpEvent(ctx context.Context, chirp database.Chirp) (stream.Event, error) {
+	result, err := cfg.chirpResponseFor(ctx, uuid.Nil, chirp)
+	if err != nil {
+		return stream.Event{}, err
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return stream.Event{}, err
+	}
+	return stream.Event{
+		ID: pagination.EncodeCursor(chirp.CreatedAt, chirp.ID),
+		Type: "chirp",
+		Data: data,
+	}, nil
+}
+
+// chirpEventHidden reports whether viewer has blocked or muted their way
+// out of seeing a chirp event. When in doubt the event is hidden.
+func (cfg *apiConfig) chirpEventHidden(ctx context.Context, event stream.Event, viewer uuid.NullUUID) bool {
+	if !viewer.Valid {
+		return false
+	}
+	cursor, err := pagination.DecodeCursor(event.ID)
+	if err != nil {
+		return true
+	}
+	author, ok := eventAuthor(event)
+	if !ok {
+		return true
+	}
+	hidden, err := cfg.dbQueries.IsChirpHidden(ctx, database.IsChirpHiddenParams{
+		ChirpID: cursor.ID,
+		AuthorID: author,
+		ViewerID: viewer.UUID,
+	})
+	return err != nil || hidden
+}
+
+func writeEvent(writer http.ResponseWriter, event stream.Event) error {
+	_, err := fmt.Fprintf(writer, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, event.Data)
+	return err
+}
+
+func (cfg *apiConfig) StreamChirpsHandler(writer http.ResponseWriter, request *http.Request) {
+	flusher, ok := writer.(http.Flusher)
+	if !ok {
+		respondWithError(writer, 500, "streaming is not supported")
+		return
+	}
+	query := request.URL.Query()
+	var authorID uuid.NullUUID
+	if author := query.Get("author_id"); author != "" {
+		id, err := uuid.Parse(author)
+		if err != nil {
+			respondWithError(writer, 400, "invalid author_id")
+			return
+		}
+		authorID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+	hashtag := strings.ToLower(strings.TrimPrefix(query.Get("hashtag"), "#"))
+	lastEventID := request.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = query.Get("last_event_id")
+	}
+	var resumeFrom pagination.Cursor
+	if lastEventID != "" {
+		cursor, err := pagination.DecodeCursor(lastEventID)
+		if err != nil {
+			respondWithError(writer, 400, "invalid Last-Event-ID")
+			return
+		}
+		resumeFrom = cursor
+	}
+	viewer := cfg.viewer(request)
+
+	topic := "chirps"
+	if authorID.Valid {
+		topic = "author:" + authorID.UUID.String()
+	} else if hashtag != "" {
+		topic = "hashtag:" + hashtag
+	}
+	// Subscribe before replaying so nothing published in between is lost.
+	sub := cfg.hub.Subscribe(streamBufferSize, topic)
+	defer sub.Close()
+
+	writer.Header().Set("Content-Type", "text/event-stream")
+	writer.Header().Set("Cache-Control", "no-cache")
+	writer.Header().Set("Connection", "keep-alive")
+	writer.WriteHeader(200)
+	flusher.Flush()
+
+	ctx := request.Context()
+	replayed := map[uuid.UUID]bool{}
+	if lastEventID != "" {
+		send := func(event stream.Event) error {
+			return writeEvent(writer, event)
+		}
+		if err := cfg.replayChirps(ctx, send, resumeFrom, authorID, hashtag, viewer, replayed); err != nil {
+			fmt.Printf("replaying chirps: %v\n", err)
+			return
+		}
+		flusher.Flush()
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-cfg.draining:
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(writer, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case event, ok := <-sub.Events():
+			// A dropped subscription ends the response; the client
+			// reconnects and catches up from its last event.
+			if !ok {
+				return
+			}
+			if hashtag != "" && !event.Has("hashtag:"+hashtag) {
+				continue
+			}
+			cursor, err := pagination.DecodeCursor(event.ID)
+			if err != nil || replayed[cursor.ID] {
+				continue
+			}
+			if cfg.chirpEventHidden(ctx, event, viewer) {
+				continue
+			}
+			if err := writeEvent(writer, event); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// replayChirps sends the chirps published after from, oldest first, up to
+// maxStreamReplay of them. Every filter runs in SQL, so each batch is sent
+// in full and the replay can't page on and on looking for matches.
+func (cfg *apiConfig) replayChirps(ctx context.Context, send func(stream.Event) error, from pagination.Cursor, authorID uuid.NullUUID, hashtag string, viewer uuid.NullUUID, replayed map[uuid.UUID]bool) error {
+	fetch := func(page pageParams) ([]database.Chirp, error) {
+		switch {
+		case hashtag != "":
+			return cfg.dbQueries.GetChirpsByHashtagAsc(ctx, database.GetChirpsByHashtagAscParams{
+				Tag: hashtag,
+				AuthorID: authorID,
+				CursorCreatedAt: page.CursorCreatedAt,
+				CursorID: page.CursorID,
+				ViewerID: viewer,
+				Limit: page.fetchLimit(),
+			})
+		case authorID.Valid:
+			return cfg.getChirpsByUser(ctx, authorID.UUID, viewer, false, false, page)
+		default:
+			return cfg.getAllChirps(ctx, viewer, false, page)
+		}
+	}
+	return replay(from, fetch, func(chirp database.Chirp) error {
+		event, err := cfg.chirpEvent(ctx, chirp)
+		if err != nil {
+			return err
+		}
+		return send(event)
+	}, replayed)
+}
+
+// replay pages through fetch from the cursor after from, passing each
+// chirp to send and recording it in replayed.
+func replay(from pagination.Cursor, fetch func(pageParams) ([]database.Chirp, error), send func(database.Chirp) error, replayed map[uuid.UUID]bool) error {
+	page := pageParams{
+		Limit: streamReplayBatchSize,
+		CursorCreatedAt: sql.NullTime{Time: from.CreatedAt, Valid: true},
+		CursorID: uuid.NullUUID{UUID: from.ID, Valid: true},
+	}
+	for len(replayed) < maxStreamReplay {
+		chirps, err := fetch(page)
+		if err != nil {
+			return err
+		}
+		for _, chirp := range chirps {
+			page.CursorCreatedAt.Time = chirp.CreatedAt
+			page.CursorID.UUID = chirp.ID
+			if err := send(chirp); err != nil {
+				return err
+			}
+			replayed[chirp.ID] = true
+		}
+		if len(chirps) < int(page.fetchLimit()) {
+			return nil
+		}
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Baehry/chirpy/internal/database"
+	"github.com/Baehry/chirpy/internal/pagination"
+	"github.com/Baehry/chirpy/internal/stream"
+	"github.com/google/uuid"
+)
+
+// fakeChirps serves chirps the way the ascending listing queries do: after
+// the page's cursor, oldest first, at most fetchLimit of them.
+func fakeChirps(chirps []database.Chirp, fetches *int) func(pageParams) ([]database.Chirp, error) {
+	return func(page pageParams) ([]database.Chirp, error) {
+		*fetches++
+		var result []database.Chirp
+		for _, chirp := range chirps {
+			after := chirp.CreatedAt.After(page.CursorCreatedAt.Time) ||
+				(chirp.CreatedAt.Equal(page.CursorCreatedAt.Time) && chirp.ID.String() > page.CursorID.UUID.String())
+			if after && len(result) < int(page.fetchLimit()) {
+				result = append(result, chirp)
+			}
+		}
+		return result, nil
+	}
+}
+
+func TestReplay(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	chirpsAt := func(n int) []database.Chirp {
+		chirps := make([]database.Chirp, n)
+		for i := range chirps {
+			chirps[i] = database.Chirp{ID: uuid.New(), CreatedAt: start.Add(time.Duration(i+1) * time.Second)}
+		}
+		return chirps
+	}
+	cases := []struct {
+		name string
+		chirps []database.Chirp
+		from int
+		minSent int
+		maxSent int
+	}{
+		{"nothing new", chirpsAt(3), 3, 0, 0},
+		{"one page", chirpsAt(10), 4, 6, 6},
+		{"several pages", chirpsAt(250), 0, 250, 250},
+		{"capped", chirpsAt(2000), 0, maxStreamReplay, maxStreamReplay + streamReplayBatchSize + 1},
+	}
+	for _, c := range cases {
+		from := pagination.Cursor{CreatedAt: start}
+		if c.from > 0 {
+			last := c.chirps[c.from-1]
+			from = pagination.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}
+		}
+		var sent []database.Chirp
+		fetches := 0
+		replayed := map[uuid.UUID]bool{}
+		err := replay(from, fakeChirps(c.chirps, &fetches), func(chirp database.Chirp) error {
+			sent = append(sent, chirp)
+			return nil
+		}, replayed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(sent) < c.minSent || len(sent) > c.maxSent || len(replayed) != len(sent) {
+			t.Logf("%s: expected %d to %d chirps, sent %d and recorded %d\n", c.name, c.minSent, c.maxSent, len(sent), len(replayed))
+			t.Fail()
+			continue
+		}
+		for i, chirp := range sent {
+			if chirp.ID != c.chirps[c.from+i].ID {
+				t.Logf("%s: chirp %d is out of order\n", c.name, i)
+				t.Fail()
+				break
+			}
+		}
+		if maxFetches := c.maxSent/streamReplayBatchSize + 1; fetches > maxFetches {
+			t.Logf("%s: expected at most %d fetches, got %d\n", c.name, maxFetches, fetches)
+			t.Fail()
+		}
+	}
+}
+
+func TestStreamChirpsHandler(t *testing.T) {
+	cfg := &apiConfig{
+		tokenSecret: testSecret,
+		hub: stream.NewHub(),
+		draining: make(chan struct{}),
+	}
+	server := httptest.NewServer(http.HandlerFunc(cfg.StreamChirpsHandler))
+	defer server.Close()
+	author := uuid.New()
+	event := func(topics ...string) (stream.Event, []string) {
+		return stream.Event{
+			ID: pagination.EncodeCursor(time.Now(), uuid.New()),
+			Type: "chirp",
+			Data: []byte(`{}`),
+		}, append([]string{"chirps", "author:" + author.String()}, topics...)
+	}
+	cases := []struct {
+		name string
+		query string
+		want int
+	}{
+		// Only the second event carries the hashtag.
+		{"everything", "", 0},
+		{"author", "?author_id=" + author.String(), 0},
+		{"author and hashtag", "?author_id=" + author.String() + "&hashtag=%23Go", 1},
+	}
+	for _, c := range cases {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		request, _ := http.NewRequestWithContext(ctx, "GET", server.URL+c.query, nil)
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The handler subscribes before it sends the headers.
+		first, topics := event()
+		cfg.hub.Publish(first, topics...)
+		second, topics := event("hashtag:go")
+		cfg.hub.Publish(second, topics...)
+		want := []stream.Event{first, second}[c.want].ID
+		reader := bufio.NewReader(response.Body)
+		var got string
+		for got == "" {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				break
+			}
+			if id, ok := strings.CutPrefix(strings.TrimSpace(line), "id: "); ok {
+				got = id
+			}
+		}
+		if got != want {
+			t.Logf("%s: expected event %s first, got %q\n", c.name, want, got)
+			t.Fail()
+		}
+		response.Body.Close()
+		cancel()
+	}
+
+	response, err := http.Get(server.URL + "?last_event_id=nonsense")
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if response.StatusCode != 400 {
+		t.Logf("invalid Last-Event-ID: expected 400, got %d\n", response.StatusCode)
+		t.Fail()
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/Baehry/chirpy/internal/database"
+	"github.com/Baehry/chirpy/internal/pagination"
+	"github.com/google/uuid"
+)
+
+const (
+	timelineChirp = "chirp"
+	timelineRemotePost = "remote_post"
+)
+
+type remotePostResponse struct {
+	ID uuid.UUID `json:"id"`
+	URI string `json:"uri"`
+	URL string `json:"url"`
+	Content string `json:"content"`
+	Author remoteActorResponse `json:"author"`
+	PublishedAt time.Time `json:"published_at"`
+}
+
+// timelineItem is a chirp or a post from another server; Type says which
+// of the two fields is set.
+type timelineItem struct {
+	Type string `json:"type"`
+	Chirp *chirpResponse `json:"chirp,omitempty"`
+	RemotePost *remotePostResponse `json:"remote_post,omitempty"`
+}
+
+// homeTimeline reads one page of userID's home timeline: their own
+// chirps, chirps from the people they follow here, and posts from the
+// remote accounts they follow, newest first.
+func (cfg *apiConfig) homeTimeline(ctx context.Context, userID uuid.UUID, page pageParams) ([]timelineItem, *string, error) {
+	rows, err := cfg.dbQueries.GetHomeTimeline(ctx, database.GetHomeTimelineParams{
+		UserID: userID,
+		CursorCreatedAt: page.CursorCreatedAt,
+		CursorID: page.CursorID,
+		Limit: page.fetchLimit(),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	var nextCursor *string
+	if len(rows) > page.Limit {
+		rows = rows[:page.Limit]
+		last := rows[len(rows)-1]
+		cursor := pagination.EncodeCursor(last.CreatedAt, last.ID)
+		nextCursor = &cursor
+	}
+	chirpIDs := []uuid.UUID{}
+	postIDs := []uuid.UUID{}
+	for _, row := range rows {
+		if row.Kind == timelineChirp {
+			chirpIDs = append(chirpIDs, row.ID)
+		} else {
+			postIDs = append(postIDs, row.ID)
+		}
+	}
+	chirps, err := cfg.dbQueries.GetChirpsByIDs(ctx, database.GetChirpsByIDsParams{
+		Ids: chirpIDs,
+		ViewerID: uuid.NullUUID{UUID: userID, Valid: true},
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	chirpResults, err := cfg.chirpResponses(ctx, userID, chirps)
+	if err != nil {
+		return nil, nil, err
+	}
+	chirpsByID := map[uuid.UUID]*chirpResponse{}
+	for i := range chirpResults {
+		chirpsByID[chirpResults[i].ID] = &chirpResults[i]
+	}
+	posts, err := cfg.dbQueries.GetRemotePostsByIDs(ctx, postIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	actorIDs := []uuid.UUID{}
+	for _, post := range posts {
+		actorIDs = append(actorIDs, post.RemoteActorID)
+	}
+	actors, err := cfg.dbQueries.GetRemoteActorsByIDs(ctx, actorIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	actorsByID := map[uuid.UUID]database.RemoteActor{}
+	for _, actor := range actors {
+		actorsByID[actor.ID] = actor
+	}
+	postsByID := map[uuid.UUID]*remotePostResponse{}
+	for _, post := range posts {
+		postsByID[post.ID] = &remotePostResponse{
+			ID: post.ID,
+			URI: post.Uri,
+			URL: post.Url,
+			Content: post.Content,
+			Author: newRemoteActorResponse(actorsByID[post.RemoteActorID]),
+			PublishedAt: post.PublishedAt,
+		}
+	}
+	items := []timelineItem{}
+	for _, row := range rows {
+		item := timelineItem{
+			Type: row.Kind,
+			Chirp: chirpsByID[row.ID],
+			RemotePost: postsByID[row.ID],
+		}
+		// Anything deleted since the timeline was read is left out.
+		if item.Chirp == nil && item.RemotePost == nil {
+			continue
+		}
+		items = append(items, item)
+	}
+	return items, nextCursor, nil
+}
+
+func (cfg *apiConfig) HomeTimelineHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, err := cfg.authenticatedUserID(request)
+	if err != nil {
+		respondWithError(writer, 401, err.Error())
+		return
+	}
+	page, err := parsePageParams(request.URL.Query())
+	if err != nil {
+		respondWithError(writer, 400, err.Error())
+		return
+	}
+	items, nextCursor, err := cfg.homeTimeline(request.Context(), userID, page)
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	type response struct {
+		Items []timelineItem `json:"items"`
+		NextCursor *string `json:"next_cursor"`
+	}
+	setNextLink(writer, request, nextCursor)
+	respondWithJSON(writer, 200, response{
+		Items: items,
+		NextCursor: nextCursor,
+	})
+}
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Baehry/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	trashRetention = 30 * 24 * time.Hour
+	trashPurgeInterval = time.Hour
+	trashPurgeBatchSize = 100
+)
+
+func (cfg *apiConfig) TrashHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, err := cfg.authenticatedUserID(request)
+	if err != nil {
+		respondWithError(writer, 401, err.Error())
+		return
+	}
+	page, err := parsePageParams(request.URL.Query())
+	if err != nil {
+		respondWithError(writer, 400, err.Error())
+		return
+	}
+	chirps, err := cfg.dbQueries.GetTrashByUser(request.Context(), database.GetTrashByUserParams{
+		UserID: userID,
+		DeletedAfter: time.Now().UTC().Add(-trashRetention),
+		CursorCreatedAt: page.CursorCreatedAt,
+		CursorID: page.CursorID,
+		Limit: page.fetchLimit(),
+	})
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	cfg.respondWithChirpPage(writer, request, chirps, page)
+}
+
+func (cfg *apiConfig) RestoreChirpHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, err := cfg.authenticatedUserID(request)
+	if err != nil {
+		respondWithError(writer, 401, err.Error())
+		return
+	}
+	id, err := uuid.Parse(request.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(writer, 404, "chirp not found")
+		return
+	}
+	tx, err := cfg.db.BeginTx(request.Context(), nil)
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+	chirp, err := qtx.RestoreChirp(request.Context(), database.RestoreChirpParams{
+		ID: id,
+		UserID: userID,
+		DeletedAt: sql.NullTime{Time: time.Now().UTC().Add(-trashRetention), Valid: true},
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(writer, 404, "chirp not found in trash")
+		return
+	}
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	// Subscribers were told a published chirp was deleted, so they're told
+	// it's back, and its author's feeds count as changed.
+	if chirp.Status == statusPublished {
+		if err := qtx.TouchChirpsChangedAt(request.Context(), []uuid.UUID{chirp.UserID}); err != nil {
+			respondWithError(writer, 500, err.Error())
+			return
+		}
+		if err := enqueueWebhook(request.Context(), qtx, chirp.UserID, webhookChirpRestored, chirp); err != nil {
+			respondWithError(writer, 500, err.Error())
+			return
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	result, err := cfg.chirpResponseFor(request.Context(), userID, chirp)
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	respondWithJSON(writer, 200, result)
+}
+
+func (cfg *apiConfig) AdminDeletedChirpsHandler(writer http.ResponseWriter, request *http.Request) {
+	if !cfg.isAdmin(request) {
+		respondWithError(writer, 401, "admin key required")
+		return
+	}
+	page, err := parsePageParams(request.URL.Query())
+	if err != nil {
+		respondWithError(writer, 400, err.Error())
+		return
+	}
+	chirps, err := cfg.dbQueries.GetDeletedChirps(request.Context(), database.GetDeletedChirpsParams{
+		CursorCreatedAt: page.CursorCreatedAt,
+		CursorID: page.CursorID,
+		Limit: page.fetchLimit(),
+	})
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	cfg.respondWithChirpPage(writer, request, chirps, page)
+}
+
+// AdminChirpHandler shows a chirp whatever its state: deleted, expired or
+// not yet published.
+func (cfg *apiConfig) AdminChirpHandler(writer http.ResponseWriter, request *http.Request) {
+	if !cfg.isAdmin(request) {
+		respondWithError(writer, 401, "admin key required")
+		return
+	}
+	id, err := uuid.Parse(request.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(writer, 404, "chirp not found")
+		return
+	}
+	chirp, err := cfg.dbQueries.GetChirpIncludingDeleted(request.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(writer, 404, "chirp not found")
+		return
+	}
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	result, err := cfg.chirpResponseFor(request.Context(), uuid.Nil, chirp)
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	respondWithJSON(writer, 200, result)
+}
+
+// AdminDeleteChirpHandler moves anyone's chirp to the trash, the same way
+// its author deleting it would.
+func (cfg *apiConfig) AdminDeleteChirpHandler(writer http.ResponseWriter, request *http.Request) {
+	if !cfg.isAdmin(request) {
+		respondWithError(writer, 401, "admin key required")
+		return
+	}
+	id, err := uuid.Parse(request.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(writer, 404, "chirp not found")
+		return
+	}
+	chirp, err := cfg.dbQueries.GetChirpIncludingDeleted(request.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) || err == nil && chirp.DeletedAt.Valid {
+		respondWithError(writer, 404, "chirp not found")
+		return
+	}
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	if err := cfg.deleteChirp(request.Context(), chirp); err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	writer.WriteHeader(204)
+}
+
+// deleteChirp soft-deletes a chirp and tells webhooks and remote followers
+// it is gone.
+func (cfg *apiConfig) deleteChirp(ctx context.Context, chirp database.Chirp) error {
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+	if _, err := qtx.SoftDeleteChirp(ctx, chirp.ID); err != nil {
+		return err
+	}
+	if err := qtx.DeleteChirpPins(ctx, chirp.ID); err != nil {
+		return err
+	}
+	if chirp.Status == statusPublished {
+		if err := qtx.TouchChirpsChangedAt(ctx, []uuid.UUID{chirp.UserID}); err != nil {
+			return err
+		}
+		if err := chirpDeletedWebhook(ctx, qtx, chirp); err != nil {
+			return err
+		}
+		if err := cfg.federateChirpDeleted(ctx, qtx, chirp); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// purgeDeletedChirps hard-deletes chirps that have been in the trash for
+// longer than trashRetention.
+func (cfg *apiConfig) purgeDeletedChirps(ctx context.Context) {
+	ticker := time.NewTicker(trashPurgeInterval)
+	defer ticker.Stop()
+	for {
+		for {
+			purged, err := cfg.purgeDeletedBatch(ctx)
+			if err != nil {
+				fmt.Printf("purging deleted chirps: %v\n", err)
+			}
+			if err != nil || purged < trashPurgeBatchSize {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (cfg *apiConfig) purgeDeletedBatch(ctx context.Context) (int, error) {
+	cutoff := time.Now().UTC().Add(-trashRetention)
+	chirps, err := cfg.dbQueries.ListPurgeableChirps(ctx, database.ListPurgeableChirpsParams{
+		DeletedAt: sql.NullTime{Time: cutoff, Valid: true},
+		Limit: trashPurgeBatchSize,
+	})
+	if err != nil {
+		return 0, err
+	}
+	ids, err := cfg.deleteChirpMedia(ctx, chirps)
+	if err != nil {
+		return 0, err
+	}
+	purged, err := cfg.dbQueries.PurgeChirps(ctx, database.PurgeChirpsParams{
+		Ids: ids,
+		DeletedBefore: cutoff,
+	})
+	return int(purged), err
+}
+
+// deleteChirpMedia removes the attachments of chirps that are about to be
+// hard-deleted and returns the chirps' IDs. Everything else hanging off a
+// chirp goes with the row.
+func (cfg *apiConfig) deleteChirpMedia(ctx context.Context, chirps []database.Chirp) ([]uuid.UUID, error) {
+	ids := make([]uuid.UUID, len(chirps))
+	for i, chirp := range chirps {
+		ids[i] = chirp.ID
+	}
+	if len(ids) == 0 {
+		return ids, nil
+	}
+	attachments, err := cfg.dbQueries.GetMediaForChirps(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, medium := range attachments {
+		if err := cfg.deleteMedia(ctx, medium); err != nil {
+			return nil, fmt.Errorf("deleting media %s: %w", medium.ID, err)
+		}
+	}
+	return ids, nil
+}
@@ -0,0 +1,601 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Baehry/chirpy/internal/database"
+	"github.com/Baehry/chirpy/internal/netguard"
+	"github.com/Baehry/chirpy/internal/pagination"
+	"github.com/Baehry/chirpy/internal/webhooks"
+	"github.com/google/uuid"
+)
+
+// Webhook event types. chirp.deleted is sent when a chirp is trashed or
+// expires, and chirp.restored, with the whole chirp, when it comes back
+// out of the trash.
+const (
+	webhookChirpCreated = "chirp.created"
+	webhookChirpDeleted = "chirp.deleted"
+	webhookChirpRestored = "chirp.restored"
+	webhookChirpMentioned = "chirp.mentioned"
+	webhookUserFollowed = "user.followed"
+
+	webhookPending = "pending"
+	webhookFailed = "failed"
+	webhookSucceeded = "succeeded"
+	webhookDead = "dead"
+
+	maxWebhookEndpoints = 10
+	maxWebhookAttempts = 8
+	webhookBatchSize = 20
+	webhookTimeout = 10 * time.Second
+	webhookDeliveryInterval = 5 * time.Second
+	webhookPurgeInterval = time.Hour
+)
+
+var webhookEvents = []string{
+	webhookChirpCreated,
+	webhookChirpDeleted,
+	webhookChirpRestored,
+	webhookChirpMentioned,
+	webhookUserFollowed,
+}
+
+var webhookClient = &http.Client{
+	Timeout: webhookTimeout,
+	// Users pick the URL, so it mustn't reach anything internal, however
+	// its name resolves at delivery time.
+	Transport: netguard.Transport(),
+	// A redirect could point the signed payload somewhere the user never
+	// registered, so redirects count as failed deliveries.
+	CheckRedirect: func(request *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// webhookPayload is the body of every delivery. ID is shared by all the
+// deliveries of one event, so receivers can tell redeliveries apart from
+// new events.
+type webhookPayload struct {
+	ID uuid.UUID `json:"id"`
+	Type string `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	Data any `json:"data"`
+}
+
+// enqueueWebhook queues eventType for every active endpoint of userID
+// that subscribed to it. Run inside a transaction, nothing is sent unless
+// it commits.
+func enqueueWebhook(ctx context.Context, q *database.Queries, userID uuid.UUID, eventType string, data any) error {
+	eventID := uuid.New()
+	payload, err := json.Marshal(webhookPayload{
+		ID: eventID,
+		Type: eventType,
+		CreatedAt: time.Now().UTC(),
+		Data: data,
+	})
+	if err != nil {
+		return err
+	}
+	return q.EnqueueWebhook(ctx, database.EnqueueWebhookParams{
+		EventID: eventID,
+		EventType: eventType,
+		Payload: string(payload),
+		UserID: userID,
+	})
+}
+
+// chirpWebhooks queues the events for a newly published chirp: one for its
+// author, and one for everyone it mentions.
+func chirpWebhooks(ctx context.Context, q *database.Queries, chirp database.Chirp) error {
+	if err := enqueueWebhook(ctx, q, chirp.UserID, webhookChirpCreated, chirp); err != nil {
+		return err
+	}
+	mentions, err := q.GetMentionsForChirps(ctx, []uuid.UUID{chirp.ID})
+	if err != nil {
+		return err
+	}
+	for _, mention := range mentions {
+		if err := enqueueWebhook(ctx, q, mention.UserID, webhookChirpMentioned, chirp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// chirpDeletedWebhook queues chirp.deleted for a chirp's author. The chirp
+// itself is gone, so only its IDs are sent.
+func chirpDeletedWebhook(ctx context.Context, q *database.Queries, chirp database.Chirp) error {
+	type deleted struct {
+		ID uuid.UUID `json:"id"`
+		UserID uuid.UUID `json:"user_id"`
+	}
+	return enqueueWebhook(ctx, q, chirp.UserID, webhookChirpDeleted, deleted{
+		ID: chirp.ID,
+		UserID: chirp.UserID,
+	})
+}
+
+// deliverWebhooks works through the delivery queue. Deliveries are claimed
+// by pushing their next attempt a few minutes out, so several instances can
+// run this at once, and a delivery whose instance dies mid-attempt is
+// picked up again once that lease runs out.
+func (cfg *apiConfig) deliverWebhooks(ctx context.Context) {
+	ticker := time.NewTicker(webhookDeliveryInterval)
+	defer ticker.Stop()
+	var lastPurge time.Time
+	for {
+		for {
+			delivered, err := cfg.deliverWebhookBatch(ctx)
+			if err != nil {
+				fmt.Printf("delivering webhooks: %v\n", err)
+			}
+			if err != nil || delivered < webhookBatchSize {
+				break
+			}
+		}
+		if time.Since(lastPurge) > webhookPurgeInterval {
+			if _, err := cfg.dbQueries.DeleteFinishedWebhookDeliveries(ctx); err != nil {
+				fmt.Printf("purging webhook deliveries: %v\n", err)
+			}
+			lastPurge = time.Now()
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (cfg *apiConfig) deliverWebhookBatch(ctx context.Context) (int, error) {
+	deliveries, err := cfg.dbQueries.ClaimWebhookDeliveries(ctx, webhookBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	endpointIDs := []uuid.UUID{}
+	for _, delivery := range deliveries {
+		if !slices.Contains(endpointIDs, delivery.EndpointID) {
+			endpointIDs = append(endpointIDs, delivery.EndpointID)
+		}
+	}
+	endpoints, err := cfg.dbQueries.GetWebhookEndpointsByIDs(ctx, endpointIDs)
+	if err != nil {
+		return 0, err
+	}
+	endpointsByID := map[uuid.UUID]database.WebhookEndpoint{}
+	for _, endpoint := range endpoints {
+		endpointsByID[endpoint.ID] = endpoint
+	}
+	var wg sync.WaitGroup
+	for _, delivery := range deliveries {
+		endpoint, ok := endpointsByID[delivery.EndpointID]
+		if !ok {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := cfg.attemptWebhook(ctx, endpoint, delivery); err != nil {
+				fmt.Printf("recording webhook delivery %s: %v\n", delivery.ID, err)
+			}
+		}()
+	}
+	wg.Wait()
+	return len(deliveries), nil
+}
+
+// attemptWebhook makes one delivery attempt and schedules the next one if
+// it failed, until the delivery runs out of attempts and is dead.
+func (cfg *apiConfig) attemptWebhook(ctx context.Context, endpoint database.WebhookEndpoint, delivery database.WebhookDelivery) error {
+	result, err := webhooks.Deliver(ctx, webhookClient, endpoint.Url, endpoint.Secret, delivery.EventType, delivery.ID.String(), []byte(delivery.Payload))
+	var statusCode sql.NullInt32
+	if result.StatusCode != 0 {
+		statusCode = sql.NullInt32{Int32: int32(result.StatusCode), Valid: true}
+	}
+	message := ""
+	if err != nil {
+		message = err.Error()
+	} else if !result.OK() {
+		message = fmt.Sprintf("endpoint responded with %d", result.StatusCode)
+	}
+	if err := cfg.dbQueries.RecordWebhookAttempt(ctx, database.RecordWebhookAttemptParams{
+		DeliveryID: delivery.ID,
+		StatusCode: statusCode,
+		Error: message,
+		DurationMs: int32(result.Duration.Milliseconds()),
+	}); err != nil {
+		return err
+	}
+	attempts := int(delivery.Attempts) + 1
+	status := webhookSucceeded
+	var retryAfter sql.NullInt32
+	if message != "" {
+		status = webhookDead
+		if attempts < maxWebhookAttempts {
+			status = webhookFailed
+			retryAfter = sql.NullInt32{Int32: int32(webhooks.Backoff(attempts).Seconds()), Valid: true}
+		}
+	}
+	return cfg.dbQueries.FinishWebhookAttempt(ctx, database.FinishWebhookAttemptParams{
+		Status: status,
+		RetryAfterSeconds: retryAfter,
+		StatusCode: statusCode,
+		Error: message,
+		ID: delivery.ID,
+	})
+}
+
+type webhookEndpointResponse struct {
+	ID uuid.UUID `json:"id"`
+	URL string `json:"url"`
+	Events []string `json:"events"`
+	Active bool `json:"active"`
+	Secret string `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func newWebhookEndpointResponse(endpoint database.WebhookEndpoint) webhookEndpointResponse {
+	return webhookEndpointResponse{
+		ID: endpoint.ID,
+		URL: endpoint.Url,
+		Events: endpoint.Events,
+		Active: endpoint.Active,
+		CreatedAt: endpoint.CreatedAt,
+		UpdatedAt: endpoint.UpdatedAt,
+	}
+}
+
+// validateWebhookEndpoint checks an endpoint's URL and events. Endpoints
+// must use HTTPS, except in development, and resolve to public addresses.
+func (cfg *apiConfig) validateWebhookEndpoint(ctx context.Context, rawURL string, events []string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" {
+		return errors.New("invalid url")
+	}
+	if parsed.Scheme != "https" && !(cfg.platform == "dev" && parsed.Scheme == "http") {
+		return errors.New("webhook urls must use https")
+	}
+	if err := netguard.CheckHost(ctx, parsed.Hostname()); errors.Is(err, netguard.ErrForbiddenAddress) {
+		return errors.New("webhook urls must point at a public address")
+	} else if err != nil {
+		return fmt.Errorf("can't resolve %s", parsed.Hostname())
+	}
+	if len(events) == 0 {
+		return errors.New("pick at least one event")
+	}
+	for _, event := range events {
+		if !slices.Contains(webhookEvents, event) {
+			return fmt.Errorf("unknown event %q", event)
+		}
+	}
+	return nil
+}
+
+func (cfg *apiConfig) webhookEndpoint(writer http.ResponseWriter, request *http.Request, userID uuid.UUID) (database.WebhookEndpoint, bool) {
+	endpointID, err := uuid.Parse(request.PathValue("webhookID"))
+	if err != nil {
+		respondWithError(writer, 404, "webhook not found")
+		return database.WebhookEndpoint{}, false
+	}
+	endpoint, err := cfg.dbQueries.GetWebhookEndpoint(request.Context(), database.GetWebhookEndpointParams{
+		ID: endpointID,
+		UserID: userID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(writer, 404, "webhook not found")
+		return endpoint, false
+	}
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return endpoint, false
+	}
+	return endpoint, true
+}
+
+// CreateWebhookEndpointHandler registers an endpoint. Its signing secret
+// is only ever shown in this response.
+func (cfg *apiConfig) CreateWebhookEndpointHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, err := cfg.authenticatedUserID(request)
+	if err != nil {
+		respondWithError(writer, 401, err.Error())
+		return
+	}
+	type parameters struct {
+		URL string `json:"url"`
+		Events []string `json:"events"`
+	}
+	decoder := json.NewDecoder(request.Body)
+	var params parameters
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(writer, 400, err.Error())
+		return
+	}
+	if err := cfg.validateWebhookEndpoint(request.Context(), params.URL, params.Events); err != nil {
+		respondWithError(writer, 400, err.Error())
+		return
+	}
+	count, err := cfg.dbQueries.CountWebhookEndpoints(request.Context(), userID)
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	if count >= maxWebhookEndpoints {
+		respondWithError(writer, 409, fmt.Sprintf("you can have at most %d webhooks", maxWebhookEndpoints))
+		return
+	}
+	secret, err := webhooks.NewSecret()
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	endpoint, err := cfg.dbQueries.CreateWebhookEndpoint(request.Context(), database.CreateWebhookEndpointParams{
+		UserID: userID,
+		Url: params.URL,
+		Secret: secret,
+		Events: params.Events,
+	})
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	result := newWebhookEndpointResponse(endpoint)
+	result.Secret = endpoint.Secret
+	respondWithJSON(writer, 201, result)
+}
+
+func (cfg *apiConfig) WebhookEndpointsHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, err := cfg.authenticatedUserID(request)
+	if err != nil {
+		respondWithError(writer, 401, err.Error())
+		return
+	}
+	endpoints, err := cfg.dbQueries.GetWebhookEndpoints(request.Context(), userID)
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	result := make([]webhookEndpointResponse, len(endpoints))
+	for i, endpoint := range endpoints {
+		result[i] = newWebhookEndpointResponse(endpoint)
+	}
+	respondWithJSON(writer, 200, result)
+}
+
+// PutWebhookEndpointHandler changes an endpoint's URL or events, or pauses
+// it. Deliveries for a paused endpoint wait in the queue until it's
+// reactivated.
+func (cfg *apiConfig) PutWebhookEndpointHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, err := cfg.authenticatedUserID(request)
+	if err != nil {
+		respondWithError(writer, 401, err.Error())
+		return
+	}
+	endpoint, ok := cfg.webhookEndpoint(writer, request, userID)
+	if !ok {
+		return
+	}
+	type parameters struct {
+		URL string `json:"url"`
+		Events []string `json:"events"`
+		Active bool `json:"active"`
+	}
+	decoder := json.NewDecoder(request.Body)
+	var params parameters
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(writer, 400, err.Error())
+		return
+	}
+	if err := cfg.validateWebhookEndpoint(request.Context(), params.URL, params.Events); err != nil {
+		respondWithError(writer, 400, err.Error())
+		return
+	}
+	endpoint, err = cfg.dbQueries.UpdateWebhookEndpoint(request.Context(), database.UpdateWebhookEndpointParams{
+		ID: endpoint.ID,
+		UserID: userID,
+		Url: params.URL,
+		Events: params.Events,
+		Active: params.Active,
+	})
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	respondWithJSON(writer, 200, newWebhookEndpointResponse(endpoint))
+}
+
+func (cfg *apiConfig) DeleteWebhookEndpointHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, err := cfg.authenticatedUserID(request)
+	if err != nil {
+		respondWithError(writer, 401, err.Error())
+		return
+	}
+	endpointID, err := uuid.Parse(request.PathValue("webhookID"))
+	if err != nil {
+		respondWithError(writer, 404, "webhook not found")
+		return
+	}
+	deleted, err := cfg.dbQueries.DeleteWebhookEndpoint(request.Context(), database.DeleteWebhookEndpointParams{
+		ID: endpointID,
+		UserID: userID,
+	})
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	if deleted == 0 {
+		respondWithError(writer, 404, "webhook not found")
+		return
+	}
+	writer.WriteHeader(204)
+}
+
+type webhookAttemptResponse struct {
+	AttemptedAt time.Time `json:"attempted_at"`
+	StatusCode *int32 `json:"status_code"`
+	Error string `json:"error,omitempty"`
+	DurationMs int32 `json:"duration_ms"`
+}
+
+type webhookDeliveryResponse struct {
+	ID uuid.UUID `json:"id"`
+	EventID uuid.UUID `json:"event_id"`
+	EventType string `json:"event_type"`
+	Status string `json:"status"`
+	Attempts int32 `json:"attempts"`
+	NextAttemptAt *time.Time `json:"next_attempt_at"`
+	LastStatusCode *int32 `json:"last_status_code"`
+	LastError string `json:"last_error,omitempty"`
+	Payload json.RawMessage `json:"payload"`
+	AttemptLog []webhookAttemptResponse `json:"attempt_log"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func newWebhookDeliveryResponse(delivery database.WebhookDelivery) webhookDeliveryResponse {
+	result := webhookDeliveryResponse{
+		ID: delivery.ID,
+		EventID: delivery.EventID,
+		EventType: delivery.EventType,
+		Status: delivery.Status,
+		Attempts: delivery.Attempts,
+		LastError: delivery.LastError,
+		Payload: json.RawMessage(delivery.Payload),
+		AttemptLog: []webhookAttemptResponse{},
+		CreatedAt: delivery.CreatedAt,
+		UpdatedAt: delivery.UpdatedAt,
+	}
+	// Finished deliveries have no next attempt.
+	if delivery.NextAttemptAt.Valid && (delivery.Status == webhookPending || delivery.Status == webhookFailed) {
+		result.NextAttemptAt = &delivery.NextAttemptAt.Time
+	}
+	if delivery.LastStatusCode.Valid {
+		result.LastStatusCode = &delivery.LastStatusCode.Int32
+	}
+	return result
+}
+
+// WebhookDeliveriesHandler is the delivery log of an endpoint, newest
+// first, optionally narrowed down with ?status=.
+func (cfg *apiConfig) WebhookDeliveriesHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, err := cfg.authenticatedUserID(request)
+	if err != nil {
+		respondWithError(writer, 401, err.Error())
+		return
+	}
+	endpoint, ok := cfg.webhookEndpoint(writer, request, userID)
+	if !ok {
+		return
+	}
+	query := request.URL.Query()
+	page, err := parsePageParams(query)
+	if err != nil {
+		respondWithError(writer, 400, err.Error())
+		return
+	}
+	var status sql.NullString
+	if s := query.Get("status"); s != "" {
+		if !slices.Contains([]string{webhookPending, webhookFailed, webhookSucceeded, webhookDead}, s) {
+			respondWithError(writer, 400, "invalid status")
+			return
+		}
+		status = sql.NullString{String: s, Valid: true}
+	}
+	deliveries, err := cfg.dbQueries.GetWebhookDeliveries(request.Context(), database.GetWebhookDeliveriesParams{
+		EndpointID: endpoint.ID,
+		Status: status,
+		CursorCreatedAt: page.CursorCreatedAt,
+		CursorID: page.CursorID,
+		Limit: page.fetchLimit(),
+	})
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	var nextCursor *string
+	if len(deliveries) > page.Limit {
+		deliveries = deliveries[:page.Limit]
+		last := deliveries[len(deliveries)-1]
+		cursor := pagination.EncodeCursor(last.CreatedAt, last.ID)
+		nextCursor = &cursor
+	}
+	ids := make([]uuid.UUID, len(deliveries))
+	for i, delivery := range deliveries {
+		ids[i] = delivery.ID
+	}
+	attempts, err := cfg.dbQueries.GetWebhookAttempts(request.Context(), ids)
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	attemptLog := map[uuid.UUID][]webhookAttemptResponse{}
+	for _, attempt := range attempts {
+		entry := webhookAttemptResponse{
+			AttemptedAt: attempt.AttemptedAt,
+			Error: attempt.Error,
+			DurationMs: attempt.DurationMs,
+		}
+		if attempt.StatusCode.Valid {
+			entry.StatusCode = &attempt.StatusCode.Int32
+		}
+		attemptLog[attempt.DeliveryID] = append(attemptLog[attempt.DeliveryID], entry)
+	}
+	type response struct {
+		Deliveries []webhookDeliveryResponse `json:"deliveries"`
+		NextCursor *string `json:"next_cursor"`
+	}
+	result := make([]webhookDeliveryResponse, len(deliveries))
+	for i, delivery := range deliveries {
+		result[i] = newWebhookDeliveryResponse(delivery)
+		if log, ok := attemptLog[delivery.ID]; ok {
+			result[i].AttemptLog = log
+		}
+	}
+	setNextLink(writer, request, nextCursor)
+	respondWithJSON(writer, 200, response{
+		Deliveries: result,
+		NextCursor: nextCursor,
+	})
+}
+
+// RedeliverWebhookHandler puts a delivery back in the queue with a fresh
+// set of attempts, whatever state it ended up in.
+func (cfg *apiConfig) RedeliverWebhookHandler(writer http.ResponseWriter, request *http.Request) {
+	userID, err := cfg.authenticatedUserID(request)
+	if err != nil {
+		respondWithError(writer, 401, err.Error())
+		return
+	}
+	endpoint, ok := cfg.webhookEndpoint(writer, request, userID)
+	if !ok {
+		return
+	}
+	deliveryID, err := uuid.Parse(request.PathValue("deliveryID"))
+	if err != nil {
+		respondWithError(writer, 404, "delivery not found")
+		return
+	}
+	delivery, err := cfg.dbQueries.RedeliverWebhook(request.Context(), database.RedeliverWebhookParams{
+		ID: deliveryID,
+		EndpointID: endpoint.ID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(writer, 409, "delivery not found or already queued")
+		return
+	}
+	if err != nil {
+		respondWithError(writer, 500, err.Error())
+		return
+	}
+	respondWithJSON(writer, 202, newWebhookDeliveryResponse(delivery))
+}
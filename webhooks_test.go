@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+func TestValidateWebhookEndpoint(t *testing.T) {
+	cases := []struct {
+		url string
+		platform string
+		ok bool
+	}{
+		{"https://93.184.216.34/hook", "", true},
+		{"http://93.184.216.34/hook", "", false},
+		{"http://93.184.216.34/hook", "dev", true},
+		{"https://127.0.0.1/hook", "", false},
+		{"https://localhost:8443/hook", "", false},
+		{"https://[::1]/hook", "", false},
+		{"https://10.0.0.5/hook", "", false},
+		{"https://192.168.1.20/hook", "", false},
+		{"https://169.254.169.254/latest/meta-data", "", false},
+		{"https://[::ffff:169.254.169.254]/", "", false},
+		// Development relaxes the scheme, not the address.
+		{"http://127.0.0.1:8080/hook", "dev", false},
+		{"not a url", "", false},
+	}
+	for _, c := range cases {
+		cfg := &apiConfig{platform: c.platform}
+		err := cfg.validateWebhookEndpoint(context.Background(), c.url, []string{webhookChirpCreated})
+		if (err == nil) != c.ok {
+			t.Logf("%s (platform %q): expected ok=%v, got %v\n", c.url, c.platform, c.ok, err)
+			t.Fail()
+		}
+	}
+}